@@ -1,71 +1,154 @@
 package main
 
 import (
-	"bytes"
-	"crypto/rand"
-	"crypto/rsa"
+	"crypto"
 	"crypto/x509"
-	"crypto/x509/pkix"
-	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
-	"math/big"
-	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/vukit/gophkeeper/internal/server/pki"
 )
 
+const day = 24 * time.Hour
+
+const usage = `usage: certs <command> [flags]
+
+commands:
+  ca      create a certificate authority: ca.pem, ca-key.pem
+  server  issue a server certificate signed by the CA: public.pem, private.pem
+  client  issue a device certificate signed by the CA: clients/<name>.pem, clients/<name>-key.pem
+
+run "certs <command> -h" for command flags`
+
 func main() {
-	cert := &x509.Certificate{
-		SerialNumber: big.NewInt(1658),
-		Subject: pkix.Name{
-			Organization: []string{"Yandex.Praktikum"},
-			Country:      []string{"RU"},
-		},
-		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
-		NotBefore:    time.Now(),
-		NotAfter:     time.Now().AddDate(10, 0, 0),
-		SubjectKeyId: []byte{1, 2, 3, 4, 6},
-		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
-		KeyUsage:     x509.KeyUsageDigitalSignature,
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	var err error
+
+	switch os.Args[1] {
+	case "ca":
+		err = createCA(os.Args[2:])
+	case "server":
+		err = issueServer(os.Args[2:])
+	case "client":
+		err = issueClient(os.Args[2:])
+	default:
+		log.Fatal(usage)
 	}
 
-	privateKey, err := rsa.GenerateKey(rand.Reader, 4096)
 	if err != nil {
 		log.Fatal(err)
 	}
+}
+
+func createCA(args []string) error {
+	fs := flag.NewFlagSet("ca", flag.ExitOnError)
+	dir := fs.String("o", "configs/tls", "output directory")
+	name := fs.String("cn", "GophKeeper CA", "certificate authority name")
+	days := fs.Int("days", 3650, "validity in days")
+	force := fs.Bool("f", false, "overwrite existing certificate authority")
+	fs.Parse(args)
+
+	certFile, keyFile := filepath.Join(*dir, "ca.pem"), filepath.Join(*dir, "ca-key.pem")
+
+	if _, err := os.Stat(certFile); err == nil && !*force {
+		return fmt.Errorf("%s already exists, use -f to replace it and reissue all certificates", certFile)
+	}
 
-	certBytes, err := x509.CreateCertificate(rand.Reader, cert, cert, &privateKey.PublicKey, privateKey)
+	cert, key, err := pki.NewCA(*name, time.Duration(*days)*day)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	var certPEM bytes.Buffer
+	return write(cert, key, certFile, keyFile)
+}
+
+func issueServer(args []string) error {
+	fs := flag.NewFlagSet("server", flag.ExitOnError)
+	dir := fs.String("o", "configs/tls", "output directory with the certificate authority")
+	hosts := fs.String("hosts", "localhost,127.0.0.1,::1", "comma-separated server host names and IP addresses")
+	days := fs.Int("days", 825, "validity in days")
+	fs.Parse(args)
 
-	err = pem.Encode(&certPEM, &pem.Block{
-		Type:  "CERTIFICATE",
-		Bytes: certBytes,
-	})
+	ca, caKey, err := pki.LoadCA(filepath.Join(*dir, "ca.pem"), filepath.Join(*dir, "ca-key.pem"))
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	err = os.WriteFile("configs/tls/public.pem", certPEM.Bytes(), 0o600)
+	cert, key, err := pki.IssueServer(ca, caKey, splitList(*hosts), time.Duration(*days)*day)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	if err = write(cert, key, filepath.Join(*dir, "public.pem"), filepath.Join(*dir, "private.pem")); err != nil {
+		return err
 	}
 
-	var privateKeyPEM bytes.Buffer
+	fmt.Printf("server key pin: %s\n", pki.SPKIPin(cert))
+
+	return nil
+}
 
-	err = pem.Encode(&privateKeyPEM, &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
-	})
+func issueClient(args []string) error {
+	fs := flag.NewFlagSet("client", flag.ExitOnError)
+	dir := fs.String("o", "configs/tls", "output directory with the certificate authority")
+	name := fs.String("name", "", "device name")
+	username := fs.String("user", "", "bind the certificate to this user")
+	days := fs.Int("days", 365, "validity in days")
+	fs.Parse(args)
+
+	if *name == "" || strings.ContainsAny(*name, `/\`) {
+		return errors.New("device name is required and must not contain path separators")
+	}
+
+	ca, caKey, err := pki.LoadCA(filepath.Join(*dir, "ca.pem"), filepath.Join(*dir, "ca-key.pem"))
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	err = os.WriteFile("configs/tls/private.pem", privateKeyPEM.Bytes(), 0o600)
+	cert, key, err := pki.IssueClient(ca, caKey, *name, *username, time.Duration(*days)*day)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+
+	clients := filepath.Join(*dir, "clients")
+
+	if err = os.MkdirAll(clients, 0o700); err != nil {
+		return err
+	}
+
+	return write(cert, key, filepath.Join(clients, *name+".pem"), filepath.Join(clients, *name+"-key.pem"))
+}
+
+func write(cert *x509.Certificate, key crypto.Signer, certFile, keyFile string) error {
+	keyPEM, err := pki.EncodeKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(certFile, pki.EncodeCertificate(cert), 0o600); err != nil {
+		return err
+	}
+
+	return os.WriteFile(keyFile, keyPEM, 0o600)
+}
+
+func splitList(list string) []string {
+	items := make([]string, 0)
+
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
 }
@@ -19,9 +19,20 @@ func main() {
 	cfg := config.Config{}
 	flag.StringVar(&cfg.ServerAddress, "s", "localhost:8080", "server address")
 	flag.StringVar(&cfg.ServerProtocol, "p", "http", "server protocol (http|https)")
+	flag.StringVar(&cfg.TLSCA, "tlsca", "", "path to CA certificate for server certificate verification")
+	flag.StringVar(&cfg.TLSCertificate, "tlsc", "", "path to client TLS certificate")
+	flag.StringVar(&cfg.TLSPrivateKey, "tlspk", "", "path to client TLS private key")
+	flag.StringVar(&cfg.TLSPin, "tlspin", "", "comma-separated pinned server key hashes (sha256/<base64>)")
+	flag.StringVar(&cfg.KnownServers, "ks", "", "known servers file, remembers verified server keys and asks to trust unverified ones on first use")
+	flag.StringVar(&cfg.KnownAccounts, "ka", "known_accounts", "known accounts file, refuses key derivation downgrades of accounts signed in before")
 	flag.StringVar(&cfg.LogFile, "l", "client.log", "logging file")
 	flag.StringVar(&cfg.UserInterface, "u", "tui", "user interface (tui|gui)")
 	flag.StringVar(&cfg.DownloadFolder, "d", "gophkeeper", "folder for downloaded files")
+	flag.StringVar(&cfg.SSHAuthSock, "ssh", "", "ssh-agent socket path for vault SSH keys, use it as SSH_AUTH_SOCK")
+	flag.UintVar(&cfg.KDFTime, "kdft", 3, "argon2id iterations for new accounts")
+	flag.UintVar(&cfg.KDFMemory, "kdfm", 64*1024, "argon2id memory in KiB for new accounts")
+	flag.UintVar(&cfg.KDFThreads, "kdfp", 4, "argon2id parallelism for new accounts")
+	flag.StringVar(&cfg.DeviceName, "dev", hostname(), "device name shown in vault item history")
 	flag.Parse()
 
 	err := env.Parse(&cfg)
@@ -55,3 +66,13 @@ func main() {
 
 	app.Run(ctx, &cfg, mLogger)
 }
+
+// hostname возвращает имя хоста, которое по умолчанию используется как название устройства
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+
+	return name
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vukit/gophkeeper/internal/server/jwtkeys"
+)
+
+func main() {
+	alg := flag.String("alg", jwtkeys.EdDSA, "signature algorithm (HS256|EdDSA|ES256)")
+	kid := flag.String("kid", time.Now().Format("20060102150405"), "key id")
+	dir := flag.String("o", "configs/jwt", "keys directory")
+	flag.Parse()
+
+	key, err := jwtkeys.Generate(*alg, *kid)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	data, err := json.MarshalIndent(key, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(*dir, *kid+".json"), data, 0o600)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
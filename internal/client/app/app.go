@@ -2,14 +2,18 @@ package app
 
 import (
 	"context"
+	"time"
 
 	"github.com/vukit/gophkeeper/internal/client"
 	"github.com/vukit/gophkeeper/internal/client/config"
 	"github.com/vukit/gophkeeper/internal/client/logger"
 	"github.com/vukit/gophkeeper/internal/client/service"
+	"github.com/vukit/gophkeeper/internal/client/sshagent"
 	"github.com/vukit/gophkeeper/internal/client/tui"
 )
 
+const logoutTimeout = 5 * time.Second
+
 func Run(ctx context.Context, cfg *config.Config, mLogger *logger.Logger) {
 	var gkService client.GophKeeperService
 
@@ -35,12 +39,48 @@ func Run(ctx context.Context, cfg *config.Config, mLogger *logger.Logger) {
 
 		gkService.SetCryptoService(cryptoService)
 
-		err = tui.Manager(ctx, user, gkService, mLogger, cfg.DownloadFolder)
+		err = upgradeVault(ctx, gkService, cryptoService)
 		if err != nil {
 			mLogger.Info(err.Error())
 
 			return
 		}
+
+		sshAgent := sshagent.NewAgent(mLogger)
+
+		if cfg.SSHAuthSock != "" {
+			agentCtx, stopAgent := context.WithCancel(ctx)
+			agentDone := make(chan struct{})
+
+			go func() {
+				defer close(agentDone)
+
+				if errAgent := sshAgent.Serve(agentCtx, cfg.SSHAuthSock); errAgent != nil {
+					mLogger.Info(errAgent.Error())
+				}
+			}()
+
+			defer func() {
+				stopAgent()
+				<-agentDone
+			}()
+		}
+
+		err = tui.Manager(ctx, user, gkService, mLogger, cfg.DownloadFolder, sshAgent)
+		if err != nil {
+			mLogger.Info(err.Error())
+		}
+
+		// ключи SSH недоступны агенту после выхода из хранилища
+		sshAgent.SetKeys(nil)
+		sshAgent.SetConfirm(nil)
+
+		logoutCtx, cancel := context.WithTimeout(context.Background(), logoutTimeout)
+		defer cancel()
+
+		if err = gkService.Logout(logoutCtx); err != nil {
+			mLogger.Info(err.Error())
+		}
 	} else {
 		mLogger.Info("Graphical user interface not implemented yet")
 	}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/vukit/gophkeeper/internal/client"
+	"github.com/vukit/gophkeeper/internal/client/service"
+)
+
+// upgradeVault перешифровывает через методы сохранения сервиса записи пользователя без идентификатора,
+// сохранённые прежними версиями клиента: при сохранении записи назначается идентификатор, к которому
+// привязываются шифротексты её полей. Записи без идентификатора расшифровываются только на время
+// перешифровки, после неё сервис cs их не принимает
+func upgradeVault(ctx context.Context, gkService client.GophKeeperService, cs *service.CryptoService) (err error) {
+	cs.AcceptUnbound(true)
+	defer cs.AcceptUnbound(false)
+
+	logins, err := gkService.GetLogins(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range logins {
+		if logins[i].UID == "" {
+			if err = gkService.SaveLogin(ctx, &logins[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	cards, err := gkService.GetCards(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range cards {
+		if cards[i].UID == "" {
+			if err = gkService.SaveCard(ctx, &cards[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	notes, err := gkService.GetNotes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range notes {
+		if notes[i].UID == "" {
+			if err = gkService.SaveNote(ctx, &notes[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	otps, err := gkService.GetOTPs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range otps {
+		if otps[i].UID == "" {
+			if err = gkService.SaveOTP(ctx, &otps[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	sshKeys, err := gkService.GetSSHKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range sshKeys {
+		if sshKeys[i].UID == "" {
+			if err = gkService.SaveSSHKey(ctx, &sshKeys[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	items, err := gkService.GetItems(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		if items[i].UID == "" {
+			if err = gkService.SaveItem(ctx, &items[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	files, err := gkService.GetFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "gophkeeper_upgrade_*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for i := range files {
+		if files[i].UID != "" {
+			continue
+		}
+
+		if err = gkService.DownloadFile(ctx, &files[i], tmpDir); err != nil {
+			return err
+		}
+
+		files[i].Path = filepath.Join(tmpDir, files[i].Name)
+
+		if err = gkService.SaveFile(ctx, &files[i]); err != nil {
+			return err
+		}
+
+		if err = os.Remove(files[i].Path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
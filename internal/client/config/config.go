@@ -4,7 +4,18 @@ package config
 type Config struct {
 	ServerAddress  string `env:"SERVER_ADDRESS"`
 	ServerProtocol string `env:"SERVER_PROTOCOL"`
+	TLSCA          string `env:"CLIENT_TLS_CA"`
+	TLSCertificate string `env:"CLIENT_TLS_CERTIFICATE"`
+	TLSPrivateKey  string `env:"CLIENT_TLS_PRIVATE_KEY"`
+	TLSPin         string `env:"CLIENT_TLS_PIN"`
+	KnownServers   string `env:"CLIENT_KNOWN_SERVERS"`
+	KnownAccounts  string `env:"CLIENT_KNOWN_ACCOUNTS"`
 	LogFile        string `env:"CLIENT_LOG_FILE"`
 	UserInterface  string `env:"CLIENT_USER_INTERFACE"`
 	DownloadFolder string `env:"CLIENT_DOWNLOAD_FOLDER"`
+	SSHAuthSock    string `env:"CLIENT_SSH_AUTH_SOCK"`
+	DeviceName     string `env:"CLIENT_DEVICE_NAME"`
+	KDFTime        uint   `env:"CLIENT_KDF_TIME"`
+	KDFMemory      uint   `env:"CLIENT_KDF_MEMORY"`
+	KDFThreads     uint   `env:"CLIENT_KDF_THREADS"`
 }
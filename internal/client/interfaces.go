@@ -13,6 +13,23 @@ type GophKeeperService interface {
 
 	SignUp(context.Context, *model.User) error
 	SignIn(context.Context, *model.User) error
+	SignInTOTP(ctx context.Context, user *model.User, code string) error
+	UpgradeAccount(context.Context, *model.User) error
+	ChangePassword(ctx context.Context, user *model.User, password, newPassword string) error
+	Recover(ctx context.Context, user *model.User, code string) error
+	NewRecoveryCodes(ctx context.Context, user *model.User, password string) ([]string, error)
+	RotateVaultKey(context.Context, *model.User) ([]string, error)
+	Logout(context.Context) error
+	LogoutAll(context.Context) error
+	EnrollTOTP(context.Context) (model.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, code string) error
+	CreateAPIToken(context.Context, model.APIToken) (string, error)
+	GetAPITokens(context.Context) ([]model.APIToken, error)
+	RevokeAPIToken(context.Context, *model.APIToken) error
+	ExportAccount(ctx context.Context, user *model.User, folder string) (string, error)
+	ImportAccount(context.Context, model.AccountImport) error
+	DeleteAccount(ctx context.Context, user *model.User, password string) error
 
 	SaveLogin(context.Context, *model.Login) error
 	DeleteLogin(context.Context, *model.Login) error
@@ -22,6 +39,37 @@ type GophKeeperService interface {
 	DeleteCard(context.Context, *model.Card) error
 	GetCards(context.Context) ([]model.Card, error)
 
+	SaveNote(context.Context, *model.Note) error
+	DeleteNote(context.Context, *model.Note) error
+	GetNotes(context.Context) ([]model.Note, error)
+
+	SaveOTP(context.Context, *model.OTP) error
+	DeleteOTP(context.Context, *model.OTP) error
+	GetOTPs(context.Context) ([]model.OTP, error)
+
+	SaveSSHKey(context.Context, *model.SSHKey) error
+	DeleteSSHKey(context.Context, *model.SSHKey) error
+	GetSSHKeys(context.Context) ([]model.SSHKey, error)
+
+	SaveItem(context.Context, *model.Item) error
+	DeleteItem(context.Context, *model.Item) error
+	GetItems(context.Context) ([]model.Item, error)
+
+	GetRevisions(ctx context.Context, itemID int) ([]model.Revision, error)
+	RestoreRevision(context.Context, *model.Revision) error
+	GetTrash(ctx context.Context) ([]model.TrashItem, error)
+	RestoreTrash(context.Context, *model.TrashItem) error
+	Sync(context.Context) (int64, error)
+	Events(context.Context) <-chan struct{}
+
+	SaveFolder(context.Context, *model.Folder) error
+	DeleteFolder(context.Context, *model.Folder) error
+	GetFolders(context.Context) ([]model.Folder, error)
+
+	SaveTag(context.Context, *model.Tag) error
+	DeleteTag(context.Context, *model.Tag) error
+	GetTags(context.Context) ([]model.Tag, error)
+
 	SaveFile(context.Context, *model.File) error
 	DeleteFile(context.Context, *model.File) error
 	GetFiles(context.Context) ([]model.File, error)
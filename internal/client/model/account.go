@@ -0,0 +1,67 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Имена элементов архива выгрузки учётной записи
+const (
+	AccountArchiveManifest = "account.json"
+	AccountArchiveVersion  = 1
+)
+
+// Account модель выгрузки учётной записи: записи и содержимое файлов зашифрованы ключом хранилища,
+// который зашифрован ключом из мастер-пароля учётной записи. Path файла — имя элемента архива с его содержимым
+type Account struct {
+	Version    int       `json:"version"`
+	Username   string    `json:"username"`
+	ExportedAt time.Time `json:"exported_at"`
+	KDF        KDF       `json:"kdf"`
+	VaultKey   string    `json:"vault_key"`
+	Logins     []Login   `json:"logins"`
+	Cards      []Card    `json:"cards"`
+	Notes      []Note    `json:"notes"`
+	OTPs       []OTP     `json:"otps"`
+	SSHKeys    []SSHKey  `json:"ssh_keys"`
+	Items      []Item    `json:"items"`
+	Folders    []Folder  `json:"folders"`
+	Tags       []Tag     `json:"tags"`
+	Files      []File    `json:"files"`
+}
+
+// AccountImport модель загрузки архива выгрузки учётной записи: путь к архиву
+// и мастер-пароль учётной записи, из которой он выгружен
+type AccountImport struct {
+	Path     string
+	Password string
+}
+
+var (
+	ErrAccountImportEmpity   = errors.New("archive path and/or password empity")
+	ErrAccountPasswordEmpity = errors.New("password empity")
+)
+
+// Validate проверяет корректность модели загрузки архива выгрузки учётной записи
+func (r *AccountImport) Validate() error {
+	if strings.TrimSpace(r.Path) == "" || r.Password == "" {
+		return ErrAccountImportEmpity
+	}
+
+	return nil
+}
+
+// AccountDeletion модель удаления учётной записи: мастер-пароль для подтверждения
+type AccountDeletion struct {
+	Password string `json:"password"`
+}
+
+// Validate проверяет корректность модели удаления учётной записи
+func (r *AccountDeletion) Validate() error {
+	if r.Password == "" {
+		return ErrAccountPasswordEmpity
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+func TestAccountImport(t *testing.T) {
+	tests := []struct {
+		name       string
+		accountImp model.AccountImport
+		want       error
+	}{
+		{
+			name:       "case 1",
+			accountImp: model.AccountImport{Path: "gophkeeper-user.zip", Password: "password"},
+			want:       nil,
+		},
+		{
+			name:       "case 2",
+			accountImp: model.AccountImport{Path: " ", Password: "password"},
+			want:       model.ErrAccountImportEmpity,
+		},
+		{
+			name:       "case 3",
+			accountImp: model.AccountImport{Path: "gophkeeper-user.zip", Password: ""},
+			want:       model.ErrAccountImportEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.accountImp.Validate())
+		})
+	}
+}
+
+func TestAccountDeletion(t *testing.T) {
+	tests := []struct {
+		name     string
+		deletion model.AccountDeletion
+		want     error
+	}{
+		{
+			name:     "case 1",
+			deletion: model.AccountDeletion{Password: "password"},
+			want:     nil,
+		},
+		{
+			name:     "case 2",
+			deletion: model.AccountDeletion{Password: ""},
+			want:     model.ErrAccountPasswordEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.deletion.Validate())
+		})
+	}
+}
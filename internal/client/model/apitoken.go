@@ -0,0 +1,62 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIToken модель токена API пользователя для доступа к данным без мастер-пароля
+type APIToken struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// APITokenForm модель формы создания токена API: области доступа перечисляются через запятую,
+// срок действия задаётся в днях, пустой срок — бессрочный токен
+type APITokenForm struct {
+	Name      string
+	Scopes    string
+	ExpiresIn string
+}
+
+var (
+	ErrAPITokenNameEmpity    = errors.New("token name empity")
+	ErrAPITokenScopesEmpity  = errors.New("token scopes empity")
+	ErrAPITokenInvalidExpiry = errors.New("token expiry must be a positive number of days")
+)
+
+// APIToken проверяет форму и возвращает токен API для создания на сервере
+func (r *APITokenForm) APIToken() (token APIToken, err error) {
+	token.Name = strings.TrimSpace(r.Name)
+	if token.Name == "" {
+		return token, ErrAPITokenNameEmpity
+	}
+
+	for _, scope := range strings.Split(r.Scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			token.Scopes = append(token.Scopes, scope)
+		}
+	}
+
+	if len(token.Scopes) == 0 {
+		return token, ErrAPITokenScopesEmpity
+	}
+
+	if expiresIn := strings.TrimSpace(r.ExpiresIn); expiresIn != "" {
+		days, err := strconv.Atoi(expiresIn)
+		if err != nil || days <= 0 {
+			return token, ErrAPITokenInvalidExpiry
+		}
+
+		expiresAt := time.Now().AddDate(0, 0, days)
+		token.ExpiresAt = &expiresAt
+	}
+
+	return token, nil
+}
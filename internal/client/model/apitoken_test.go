@@ -0,0 +1,64 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+func TestAPITokenForm(t *testing.T) {
+	tests := []struct {
+		name       string
+		form       model.APITokenForm
+		wantScopes []string
+		wantExpiry bool
+		want       error
+	}{
+		{
+			name:       "case 1",
+			form:       model.APITokenForm{Name: "ci", Scopes: "logins:read, files:read"},
+			wantScopes: []string{"logins:read", "files:read"},
+			want:       nil,
+		},
+		{
+			name:       "case 2",
+			form:       model.APITokenForm{Name: "ci", Scopes: "files:write,", ExpiresIn: "30"},
+			wantScopes: []string{"files:write"},
+			wantExpiry: true,
+			want:       nil,
+		},
+		{
+			name: "case 3",
+			form: model.APITokenForm{Name: " ", Scopes: "logins:read"},
+			want: model.ErrAPITokenNameEmpity,
+		},
+		{
+			name: "case 4",
+			form: model.APITokenForm{Name: "ci", Scopes: " , "},
+			want: model.ErrAPITokenScopesEmpity,
+		},
+		{
+			name: "case 5",
+			form: model.APITokenForm{Name: "ci", Scopes: "logins:read", ExpiresIn: "0"},
+			want: model.ErrAPITokenInvalidExpiry,
+		},
+		{
+			name: "case 6",
+			form: model.APITokenForm{Name: "ci", Scopes: "logins:read", ExpiresIn: "week"},
+			want: model.ErrAPITokenInvalidExpiry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.form.APIToken()
+			assert.Equal(t, tt.want, err)
+
+			if tt.want == nil {
+				assert.Equal(t, tt.wantScopes, token.Scopes)
+				assert.Equal(t, tt.wantExpiry, token.ExpiresAt != nil)
+			}
+		})
+	}
+}
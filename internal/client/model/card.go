@@ -8,12 +8,15 @@ import (
 
 // Card модель банковской карты приложения
 type Card struct {
-	ID       int    `json:"id"`
-	Bank     string `json:"bank"`
-	Number   string `json:"number"`
-	Date     string `json:"date"`
-	CVV      string `json:"cvv"`
-	MetaInfo string `json:"metainfo"`
+	ID       int     `json:"id"`
+	UID      string  `json:"uid"`
+	Bank     string  `json:"bank"`
+	Number   string  `json:"number"`
+	Date     string  `json:"date"`
+	CVV      string  `json:"cvv"`
+	MetaInfo string  `json:"metainfo"`
+	Fields   []Field `json:"fields"`
+	Placement
 }
 
 const (
@@ -61,5 +64,16 @@ func (r *Card) Validate() error {
 		return ErrCardMetainfoEmpity
 	}
 
-	return nil
+	return ValidateFields(r.Fields)
+}
+
+// Content возвращает содержимое банковской карты для сравнения редакций
+func (r *Card) Content() []Field {
+	return append([]Field{
+		{Name: "Bank", Type: FieldTypeText, Value: r.Bank},
+		{Name: "Number", Type: FieldTypeText, Value: r.Number},
+		{Name: "Date", Type: FieldTypeText, Value: r.Date},
+		{Name: "CVV", Type: FieldTypeHidden, Value: r.CVV},
+		{Name: "Metainfo", Type: FieldTypeText, Value: r.MetaInfo},
+	}, r.Fields...)
 }
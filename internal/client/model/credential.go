@@ -0,0 +1,9 @@
+package model
+
+// Credential модель замены учётных данных пользователя приложения
+type Credential struct {
+	Password    string `json:"password"`
+	NewPassword string `json:"new_password"`
+	KDF         KDF    `json:"kdf"`
+	VaultKey    string `json:"vault_key,omitempty"`
+}
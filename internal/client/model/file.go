@@ -7,10 +7,13 @@ import (
 
 // File модель файла пользователя
 type File struct {
-	ID       int    `json:"id"`
-	Path     string `json:"path"`
-	Name     string `json:"name"`
-	MetaInfo string `json:"metainfo"`
+	ID       int     `json:"id"`
+	UID      string  `json:"uid"`
+	Path     string  `json:"path"`
+	Name     string  `json:"name"`
+	MetaInfo string  `json:"metainfo"`
+	Fields   []Field `json:"fields"`
+	Placement
 }
 
 var ErrFileMetainfoEmpity = errors.New("metainfo empity")
@@ -21,5 +24,13 @@ func (r *File) Validate() error {
 		return ErrFileMetainfoEmpity
 	}
 
-	return nil
+	return ValidateFields(r.Fields)
+}
+
+// Content возвращает содержимое файла без самого файла для просмотра в корзине
+func (r *File) Content() []Field {
+	return append([]Field{
+		{Name: "Name", Type: FieldTypeText, Value: r.Name},
+		{Name: "Metainfo", Type: FieldTypeText, Value: r.MetaInfo},
+	}, r.Fields...)
 }
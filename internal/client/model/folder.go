@@ -0,0 +1,65 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// Folder модель папки записей приложения
+type Folder struct {
+	ID   int    `json:"id"`
+	UID  string `json:"uid"`
+	Name string `json:"name"`
+}
+
+// Tag модель метки записей приложения
+type Tag struct {
+	ID   int    `json:"id"`
+	UID  string `json:"uid"`
+	Name string `json:"name"`
+}
+
+// Placement размещение записи: папка FolderID, ноль — запись вне папок, и метки TagIDs
+type Placement struct {
+	FolderID int   `json:"folder_id"`
+	TagIDs   []int `json:"tag_ids"`
+}
+
+var (
+	ErrFolderNameEmpity = errors.New("folder name empity")
+	ErrTagNameEmpity    = errors.New("tag name empity")
+	ErrTagNameInvalid   = errors.New("tag name must not contain commas")
+)
+
+// Validate проверяет корректность модели папки записей
+func (r *Folder) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrFolderNameEmpity
+	}
+
+	return nil
+}
+
+// Validate проверяет корректность модели метки записей, метки перечисляются через запятую
+func (r *Tag) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrTagNameEmpity
+	}
+
+	if strings.Contains(r.Name, ",") {
+		return ErrTagNameInvalid
+	}
+
+	return nil
+}
+
+// HasTag сообщает, что запись отмечена меткой tagID
+func (r *Placement) HasTag(tagID int) bool {
+	for _, id := range r.TagIDs {
+		if id == tagID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,70 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+func TestFolder(t *testing.T) {
+	tests := []struct {
+		name   string
+		folder model.Folder
+		want   error
+	}{
+		{
+			name:   "case 1",
+			folder: model.Folder{Name: "Work"},
+			want:   nil,
+		},
+		{
+			name:   "case 2",
+			folder: model.Folder{Name: " "},
+			want:   model.ErrFolderNameEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.folder.Validate())
+		})
+	}
+}
+
+func TestTag(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  model.Tag
+		want error
+	}{
+		{
+			name: "case 1",
+			tag:  model.Tag{Name: "2fa"},
+			want: nil,
+		},
+		{
+			name: "case 2",
+			tag:  model.Tag{Name: ""},
+			want: model.ErrTagNameEmpity,
+		},
+		{
+			name: "case 3",
+			tag:  model.Tag{Name: "bank, cards"},
+			want: model.ErrTagNameInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.tag.Validate())
+		})
+	}
+}
+
+func TestPlacementHasTag(t *testing.T) {
+	placement := model.Placement{FolderID: 1, TagIDs: []int{2, 3}}
+
+	assert.True(t, placement.HasTag(3))
+	assert.False(t, placement.HasTag(1))
+}
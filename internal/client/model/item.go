@@ -0,0 +1,119 @@
+package model
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ItemTypeCustom тип записей произвольной структуры, которые состоят из названия и дополнительных полей
+const ItemTypeCustom = "custom"
+
+// Типы дополнительных полей записей
+const (
+	FieldTypeText   = "text"
+	FieldTypeHidden = "hidden"
+	FieldTypeURL    = "url"
+	FieldTypeDate   = "date"
+)
+
+// FieldDateLayout формат значения поля с датой
+const FieldDateLayout = "2006-01-02"
+
+// FieldTypes типы дополнительных полей записей
+var FieldTypes = []string{FieldTypeText, FieldTypeHidden, FieldTypeURL, FieldTypeDate}
+
+// Field модель дополнительного поля записи приложения
+type Field struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Item модель записи произвольной структуры приложения: название и дополнительные поля
+// передаются на сервер зашифрованными целиком в Data
+type Item struct {
+	ID        int       `json:"id"`
+	UID       string    `json:"uid"`
+	Type      string    `json:"type"`
+	Name      string    `json:"-"`
+	Fields    []Field   `json:"-"`
+	Data      string    `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Placement
+}
+
+var (
+	ErrItemNameEmpity   = errors.New("name empity")
+	ErrFieldNameEmpity  = errors.New("field name empity")
+	ErrFieldValueEmpity = errors.New("field value empity")
+	ErrFieldTypeInvalid = errors.New("invalid field type")
+	ErrFieldInvalidURL  = errors.New("invalid field url")
+	ErrFieldInvalidDate = errors.New("invalid field date, expected YYYY-MM-DD")
+	ErrFieldNameExists  = errors.New("field name already exists")
+)
+
+// Validate проверяет корректность модели записи произвольной структуры приложения
+func (r *Item) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrItemNameEmpity
+	}
+
+	return ValidateFields(r.Fields)
+}
+
+// Validate проверяет корректность дополнительного поля записи
+func (r *Field) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrFieldNameEmpity
+	}
+
+	if strings.TrimSpace(r.Value) == "" {
+		return ErrFieldValueEmpity
+	}
+
+	switch r.Type {
+	case FieldTypeText, FieldTypeHidden:
+	case FieldTypeURL:
+		u, err := url.Parse(r.Value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return ErrFieldInvalidURL
+		}
+	case FieldTypeDate:
+		if _, err := time.Parse(FieldDateLayout, r.Value); err != nil {
+			return ErrFieldInvalidDate
+		}
+	default:
+		return ErrFieldTypeInvalid
+	}
+
+	return nil
+}
+
+// ValidateFields проверяет корректность дополнительных полей записи, названия полей не повторяются
+func ValidateFields(fields []Field) error {
+	names := make(map[string]bool, len(fields))
+
+	for i := range fields {
+		if err := fields[i].Validate(); err != nil {
+			return err
+		}
+
+		if names[fields[i].Name] {
+			return ErrFieldNameExists
+		}
+
+		names[fields[i].Name] = true
+	}
+
+	return nil
+}
+
+// Content возвращает содержимое записи произвольной структуры для сравнения редакций
+func (r *Item) Content() []Field {
+	return append([]Field{
+		{Name: "Name", Type: FieldTypeText, Value: r.Name},
+	}, r.Fields...)
+}
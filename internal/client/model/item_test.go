@@ -0,0 +1,101 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+func TestItem(t *testing.T) {
+	tests := []struct {
+		name string
+		item model.Item
+		want error
+	}{
+		{
+			name: "case 1",
+			item: model.Item{Name: "Wi-Fi", Fields: []model.Field{{Name: "SSID", Type: model.FieldTypeText, Value: "home"}}},
+			want: nil,
+		},
+		{
+			name: "case 2",
+			item: model.Item{Name: " "},
+			want: model.ErrItemNameEmpity,
+		},
+		{
+			name: "case 3",
+			item: model.Item{Name: "Wi-Fi", Fields: []model.Field{{Name: "SSID", Type: model.FieldTypeText, Value: ""}}},
+			want: model.ErrFieldValueEmpity,
+		},
+		{
+			name: "case 4",
+			item: model.Item{Name: "Wi-Fi", Fields: []model.Field{
+				{Name: "SSID", Type: model.FieldTypeText, Value: "home"},
+				{Name: "SSID", Type: model.FieldTypeHidden, Value: "guest"},
+			}},
+			want: model.ErrFieldNameExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.item.Validate())
+		})
+	}
+}
+
+func TestField(t *testing.T) {
+	tests := []struct {
+		name  string
+		field model.Field
+		want  error
+	}{
+		{
+			name:  "case 1",
+			field: model.Field{Name: "Recovery email", Type: model.FieldTypeText, Value: "user@example.com"},
+			want:  nil,
+		},
+		{
+			name:  "case 2",
+			field: model.Field{Name: "PIN", Type: model.FieldTypeHidden, Value: "1234"},
+			want:  nil,
+		},
+		{
+			name:  "case 3",
+			field: model.Field{Name: "Website", Type: model.FieldTypeURL, Value: "https://example.com/login"},
+			want:  nil,
+		},
+		{
+			name:  "case 4",
+			field: model.Field{Name: "Website", Type: model.FieldTypeURL, Value: "example.com"},
+			want:  model.ErrFieldInvalidURL,
+		},
+		{
+			name:  "case 5",
+			field: model.Field{Name: "Expires", Type: model.FieldTypeDate, Value: "2030-12-31"},
+			want:  nil,
+		},
+		{
+			name:  "case 6",
+			field: model.Field{Name: "Expires", Type: model.FieldTypeDate, Value: "31.12.2030"},
+			want:  model.ErrFieldInvalidDate,
+		},
+		{
+			name:  "case 7",
+			field: model.Field{Name: "", Type: model.FieldTypeText, Value: "value"},
+			want:  model.ErrFieldNameEmpity,
+		},
+		{
+			name:  "case 8",
+			field: model.Field{Name: "Secret", Type: "password", Value: "value"},
+			want:  model.ErrFieldTypeInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.field.Validate())
+		})
+	}
+}
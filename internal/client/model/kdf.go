@@ -0,0 +1,79 @@
+package model
+
+import (
+	"encoding/hex"
+	"errors"
+)
+
+// Алгоритмы получения ключа шифрования из мастер-пароля,
+// пустой алгоритм соответствует KDFSHA256 учётных записей, созданных до появления параметров
+const (
+	KDFSHA256   = "sha256"
+	KDFArgon2id = "argon2id"
+)
+
+// Версии схемы получения ключей из мастер-пароля:
+// KDFVersionLegacy — на сервер передаётся мастер-пароль, данные шифруются ключом KDF,
+// KDFVersionSplitAuth — на сервер передаётся только ключ аутентификации, данные шифруются ключом KDF,
+// KDFVersionSplitKeys — ключ аутентификации и ключ шифрования независимо получаются из ключа KDF
+const (
+	KDFVersionLegacy uint8 = iota
+	KDFVersionSplitAuth
+	KDFVersionSplitKeys
+)
+
+const minKDFSaltLength = 16
+
+// Наибольшие параметры Argon2id: параметры, полученные от сервера до аутентификации,
+// не должны исчерпывать память и время клиента
+const (
+	maxKDFTime    = 10
+	maxKDFMemory  = 1024 * 1024 // КиБ
+	maxKDFThreads = 16
+)
+
+// KDF модель параметров получения ключа шифрования пользователя приложения
+type KDF struct {
+	Version   uint8  `json:"version"`
+	Algorithm string `json:"algorithm"`
+	Salt      string `json:"salt"`
+	Time      uint32 `json:"time"`
+	Memory    uint32 `json:"memory"`
+	Threads   uint8  `json:"threads"`
+}
+
+var (
+	ErrKDFUnknownVersion   = errors.New("unknown kdf version")
+	ErrKDFUnknownAlgorithm = errors.New("unknown kdf algorithm")
+	ErrKDFShortSalt        = errors.New("kdf salt is too short")
+	ErrKDFInvalidParams    = errors.New("invalid kdf parameters")
+	ErrKDFParamsTooLarge   = errors.New("kdf parameters are too large")
+)
+
+// Validate проверяет корректность модели параметров получения ключа шифрования
+func (r *KDF) Validate() error {
+	if r.Version > KDFVersionSplitKeys {
+		return ErrKDFUnknownVersion
+	}
+
+	switch r.Algorithm {
+	case "", KDFSHA256:
+		return nil
+	case KDFArgon2id:
+		if salt, err := hex.DecodeString(r.Salt); err != nil || len(salt) < minKDFSaltLength {
+			return ErrKDFShortSalt
+		}
+
+		if r.Time == 0 || r.Threads == 0 || r.Memory < 8*uint32(r.Threads) {
+			return ErrKDFInvalidParams
+		}
+
+		if r.Time > maxKDFTime || r.Memory > maxKDFMemory || r.Threads > maxKDFThreads {
+			return ErrKDFParamsTooLarge
+		}
+
+		return nil
+	default:
+		return ErrKDFUnknownAlgorithm
+	}
+}
@@ -0,0 +1,64 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+func TestKDF(t *testing.T) {
+	tests := []struct {
+		name string
+		kdf  model.KDF
+		want error
+	}{
+		{
+			name: "case 1",
+			kdf:  model.KDF{Algorithm: model.KDFSHA256},
+			want: nil,
+		},
+		{
+			name: "case 2",
+			kdf:  model.KDF{Algorithm: model.KDFArgon2id, Salt: "00112233445566778899aabbccddeeff", Time: 3, Memory: 65536, Threads: 4},
+			want: nil,
+		},
+		{
+			name: "case 3",
+			kdf:  model.KDF{Algorithm: model.KDFArgon2id, Salt: "0011", Time: 3, Memory: 65536, Threads: 4},
+			want: model.ErrKDFShortSalt,
+		},
+		{
+			name: "case 4",
+			kdf:  model.KDF{Algorithm: model.KDFArgon2id, Salt: "00112233445566778899aabbccddeeff", Time: 3, Memory: 16, Threads: 4},
+			want: model.ErrKDFInvalidParams,
+		},
+		{
+			name: "case 5",
+			kdf:  model.KDF{Algorithm: "md5"},
+			want: model.ErrKDFUnknownAlgorithm,
+		},
+		{
+			name: "case 6",
+			kdf:  model.KDF{Version: 3, Algorithm: model.KDFSHA256},
+			want: model.ErrKDFUnknownVersion,
+		},
+		{
+			name: "case 7",
+			kdf:  model.KDF{Algorithm: model.KDFArgon2id, Salt: "00112233445566778899aabbccddeeff", Time: 3, Memory: 4 * 1024 * 1024, Threads: 4},
+			want: model.ErrKDFParamsTooLarge,
+		},
+		{
+			name: "case 8",
+			kdf:  model.KDF{Algorithm: model.KDFArgon2id, Salt: "00112233445566778899aabbccddeeff", Time: 1000, Memory: 65536, Threads: 4},
+			want: model.ErrKDFParamsTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.kdf.Validate())
+		})
+	}
+
+}
@@ -8,10 +8,13 @@ import (
 
 // Login модель логина приложения
 type Login struct {
-	ID       int    `json:"id"`
-	Username string `json:"username"`
-	Password string `json:"password"`
-	MetaInfo string `json:"metainfo"`
+	ID       int     `json:"id"`
+	UID      string  `json:"uid"`
+	Username string  `json:"username"`
+	Password string  `json:"password"`
+	MetaInfo string  `json:"metainfo"`
+	Fields   []Field `json:"fields"`
+	Placement
 }
 
 const maxLoginUsernameLegth = 64
@@ -41,5 +44,14 @@ func (r *Login) Validate() error {
 		return ErrLoginMetaInfoEmpity
 	}
 
-	return nil
+	return ValidateFields(r.Fields)
+}
+
+// Content возвращает содержимое логина для сравнения редакций
+func (r *Login) Content() []Field {
+	return append([]Field{
+		{Name: "Username", Type: FieldTypeText, Value: r.Username},
+		{Name: "Password", Type: FieldTypeHidden, Value: r.Password},
+		{Name: "Metainfo", Type: FieldTypeText, Value: r.MetaInfo},
+	}, r.Fields...)
 }
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// Note модель заметки приложения
+type Note struct {
+	ID     int     `json:"id"`
+	UID    string  `json:"uid"`
+	Title  string  `json:"title"`
+	Text   string  `json:"text"`
+	Fields []Field `json:"fields"`
+	Placement
+}
+
+var (
+	ErrNoteTitleEmpity = errors.New("title empity")
+	ErrNoteTextEmpity  = errors.New("text empity")
+)
+
+// Validate проверяет корректность модели заметки приложения
+func (r *Note) Validate() error {
+	if strings.TrimSpace(r.Title) == "" {
+		return ErrNoteTitleEmpity
+	}
+
+	if strings.TrimSpace(r.Text) == "" {
+		return ErrNoteTextEmpity
+	}
+
+	return ValidateFields(r.Fields)
+}
+
+// Content возвращает содержимое заметки для сравнения редакций
+func (r *Note) Content() []Field {
+	return append([]Field{
+		{Name: "Title", Type: FieldTypeText, Value: r.Title},
+		{Name: "Text", Type: FieldTypeText, Value: r.Text},
+	}, r.Fields...)
+}
@@ -0,0 +1,43 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+func TestNote(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		text  string
+		want  error
+	}{
+		{
+			name:  "case 1",
+			title: "Recovery phrase",
+			text:  "abandon ability able about",
+			want:  nil,
+		},
+		{
+			name:  "case 2",
+			title: "",
+			text:  "abandon ability able about",
+			want:  model.ErrNoteTitleEmpity,
+		},
+		{
+			name:  "case 3",
+			title: "Recovery phrase",
+			text:  " ",
+			want:  model.ErrNoteTextEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			note := model.Note{Title: tt.title, Text: tt.text}
+			assert.Equal(t, tt.want, note.Validate())
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/vukit/gophkeeper/internal/client/otp"
+)
+
+// OTP модель ключа одноразовых паролей приложения, ключ может быть привязан к логину LoginID
+type OTP struct {
+	ID        int     `json:"id"`
+	UID       string  `json:"uid"`
+	LoginID   int     `json:"login_id"`
+	Name      string  `json:"name"`
+	Secret    string  `json:"secret"`
+	Algorithm string  `json:"algorithm"`
+	Digits    int     `json:"digits"`
+	Period    int     `json:"period"`
+	Fields    []Field `json:"fields"`
+	Placement
+}
+
+var ErrOTPNameEmpity = errors.New("name empity")
+
+// Validate проверяет корректность модели ключа одноразовых паролей приложения
+func (r *OTP) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrOTPNameEmpity
+	}
+
+	if err := r.Key().Validate(); err != nil {
+		return err
+	}
+
+	return ValidateFields(r.Fields)
+}
+
+// Key возвращает параметры генерации одноразовых паролей
+func (r *OTP) Key() otp.Key {
+	return otp.Key{Secret: r.Secret, Algorithm: r.Algorithm, Digits: r.Digits, Period: r.Period}
+}
+
+// SetKey заполняет модель параметрами ключа key, импортированного из ссылки otpauth
+func (r *OTP) SetKey(key otp.Key) {
+	r.Name = key.Name()
+	r.Secret = key.Secret
+	r.Algorithm = key.Algorithm
+	r.Digits = key.Digits
+	r.Period = key.Period
+}
+
+// Content возвращает содержимое ключа одноразовых паролей для сравнения редакций
+func (r *OTP) Content() []Field {
+	return append([]Field{
+		{Name: "Name", Type: FieldTypeText, Value: r.Name},
+		{Name: "Secret", Type: FieldTypeHidden, Value: r.Secret},
+		{Name: "Algorithm", Type: FieldTypeText, Value: r.Algorithm},
+		{Name: "Digits", Type: FieldTypeText, Value: strconv.Itoa(r.Digits)},
+		{Name: "Period", Type: FieldTypeText, Value: strconv.Itoa(r.Period)},
+	}, r.Fields...)
+}
@@ -0,0 +1,54 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/client/model"
+	"github.com/vukit/gophkeeper/internal/client/otp"
+)
+
+func TestOTP(t *testing.T) {
+	tests := []struct {
+		name string
+		otp  model.OTP
+		want error
+	}{
+		{
+			name: "case 1",
+			otp:  model.OTP{Name: "Example", Secret: "JBSWY3DPEHPK3PXP", Algorithm: otp.SHA1, Digits: 6, Period: 30},
+			want: nil,
+		},
+		{
+			name: "case 2",
+			otp:  model.OTP{Name: " ", Secret: "JBSWY3DPEHPK3PXP", Algorithm: otp.SHA1, Digits: 6, Period: 30},
+			want: model.ErrOTPNameEmpity,
+		},
+		{
+			name: "case 3",
+			otp:  model.OTP{Name: "Example", Secret: "not base32!", Algorithm: otp.SHA1, Digits: 6, Period: 30},
+			want: otp.ErrOTPInvalidSecret,
+		},
+		{
+			name: "case 4",
+			otp:  model.OTP{Name: "Example", Secret: "JBSWY3DPEHPK3PXP", Algorithm: "MD5", Digits: 6, Period: 30},
+			want: otp.ErrOTPInvalidAlgorithm,
+		},
+		{
+			name: "case 5",
+			otp:  model.OTP{Name: "Example", Secret: "JBSWY3DPEHPK3PXP", Algorithm: otp.SHA256, Digits: 5, Period: 30},
+			want: otp.ErrOTPInvalidDigits,
+		},
+		{
+			name: "case 6",
+			otp:  model.OTP{Name: "Example", Secret: "JBSWY3DPEHPK3PXP", Algorithm: otp.SHA512, Digits: 8, Period: 0},
+			want: otp.ErrOTPInvalidPeriod,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.otp.Validate())
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// PasswordChange модель смены мастер-пароля пользователя приложения: новые учётные данные
+// и все данные пользователя, перешифрованные новым ключом хранилища
+type PasswordChange struct {
+	Credential
+	Logins  []Login  `json:"logins"`
+	Cards   []Card   `json:"cards"`
+	Files   []File   `json:"files"`
+	Notes   []Note   `json:"notes"`
+	OTPs    []OTP    `json:"otps"`
+	SSHKeys []SSHKey `json:"ssh_keys"`
+	Items   []Item   `json:"items"`
+	Folders []Folder `json:"folders"`
+	Tags    []Tag    `json:"tags"`
+
+	RecoveryKeys []RecoveryKey `json:"recovery_keys"`
+
+	Revision int64 `json:"revision"` // ревизия хранилища, на которой клиент прочитал данные
+}
+
+// MasterPassword модель формы смены мастер-пароля
+type MasterPassword struct {
+	Current string
+	New     string
+	Confirm string
+}
+
+var (
+	ErrMasterPasswordEmpity    = errors.New("current and/or new password empity")
+	ErrMasterPasswordMismatch  = errors.New("new password and confirmation do not match")
+	ErrMasterPasswordUnchanged = errors.New("new password is the same as current")
+)
+
+// Validate проверяет корректность модели формы смены мастер-пароля
+func (r *MasterPassword) Validate() error {
+	if strings.TrimSpace(r.Current) == "" || strings.TrimSpace(r.New) == "" {
+		return ErrMasterPasswordEmpity
+	}
+
+	if r.New != r.Confirm {
+		return ErrMasterPasswordMismatch
+	}
+
+	if r.New == r.Current {
+		return ErrMasterPasswordUnchanged
+	}
+
+	return nil
+}
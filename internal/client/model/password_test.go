@@ -0,0 +1,48 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+func TestMasterPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password model.MasterPassword
+		want     error
+	}{
+		{
+			name:     "case 1",
+			password: model.MasterPassword{Current: "secret", New: "new secret", Confirm: "new secret"},
+			want:     nil,
+		},
+		{
+			name:     "case 2",
+			password: model.MasterPassword{Current: "", New: "new secret", Confirm: "new secret"},
+			want:     model.ErrMasterPasswordEmpity,
+		},
+		{
+			name:     "case 3",
+			password: model.MasterPassword{Current: "secret", New: " ", Confirm: " "},
+			want:     model.ErrMasterPasswordEmpity,
+		},
+		{
+			name:     "case 4",
+			password: model.MasterPassword{Current: "secret", New: "new secret", Confirm: "new sercet"},
+			want:     model.ErrMasterPasswordMismatch,
+		},
+		{
+			name:     "case 5",
+			password: model.MasterPassword{Current: "secret", New: "secret", Confirm: "secret"},
+			want:     model.ErrMasterPasswordUnchanged,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.password.Validate())
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// RecoveryKey модель ключа восстановления: проверочное значение кода восстановления
+// и ключ шифрования хранилища, зашифрованный кодом восстановления
+type RecoveryKey struct {
+	Verifier string `json:"verifier"`
+	VaultKey string `json:"vault_key"`
+}
+
+// RecoveryKeysChange модель замены ключей восстановления: мастер-пароль для подтверждения
+// и новые ключи восстановления
+type RecoveryKeysChange struct {
+	Password     string        `json:"password"`
+	RecoveryKeys []RecoveryKey `json:"recovery_keys"`
+}
+
+// Recovery модель запроса восстановления доступа по коду восстановления
+type Recovery struct {
+	Username    string `json:"username"`
+	Verifier    string `json:"verifier"`
+	NewPassword string `json:"new_password,omitempty"`
+	VaultKey    string `json:"vault_key,omitempty"`
+	KDF         *KDF   `json:"kdf,omitempty"`
+}
+
+// RecoveryForm модель формы восстановления доступа по коду восстановления
+type RecoveryForm struct {
+	Username string
+	Code     string
+	Password string
+	Confirm  string
+}
+
+var (
+	ErrRecoveryFormEmpity     = errors.New("username, recovery code and/or new password empity")
+	ErrRecoveryPasswordEmpity = errors.New("password empity")
+)
+
+// Validate проверяет, что замена ключей восстановления подтверждена мастер-паролем
+func (r *RecoveryKeysChange) Validate() error {
+	if r.Password == "" {
+		return ErrRecoveryPasswordEmpity
+	}
+
+	return nil
+}
+
+// Validate проверяет корректность модели формы восстановления доступа
+func (r *RecoveryForm) Validate() error {
+	if strings.TrimSpace(r.Username) == "" || strings.TrimSpace(r.Code) == "" || strings.TrimSpace(r.Password) == "" {
+		return ErrRecoveryFormEmpity
+	}
+
+	if len(r.Username) > maxUserUsernameLegth {
+		return ErrUserLongUsername
+	}
+
+	if r.Password != r.Confirm {
+		return ErrMasterPasswordMismatch
+	}
+
+	return nil
+}
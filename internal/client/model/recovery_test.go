@@ -0,0 +1,78 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+func TestRecoveryForm(t *testing.T) {
+	tests := []struct {
+		name string
+		form model.RecoveryForm
+		want error
+	}{
+		{
+			name: "case 1",
+			form: model.RecoveryForm{Username: "mark", Code: "ABCD-EFGH", Password: "secret", Confirm: "secret"},
+			want: nil,
+		},
+		{
+			name: "case 2",
+			form: model.RecoveryForm{Username: "mark", Code: "", Password: "secret", Confirm: "secret"},
+			want: model.ErrRecoveryFormEmpity,
+		},
+		{
+			name: "case 3",
+			form: model.RecoveryForm{Username: "", Code: "ABCD-EFGH", Password: "secret", Confirm: "secret"},
+			want: model.ErrRecoveryFormEmpity,
+		},
+		{
+			name: "case 4",
+			form: model.RecoveryForm{Username: "mark", Code: "ABCD-EFGH", Password: "secret", Confirm: "sercet"},
+			want: model.ErrMasterPasswordMismatch,
+		},
+		{
+			name: "case 5",
+			form: model.RecoveryForm{
+				Username: "longusernamelongusernamelongusernamelongusernamelongusernamelongusername",
+				Code:     "ABCD-EFGH",
+				Password: "secret",
+				Confirm:  "secret",
+			},
+			want: model.ErrUserLongUsername,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.form.Validate())
+		})
+	}
+}
+
+func TestRecoveryKeysChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		change model.RecoveryKeysChange
+		want   error
+	}{
+		{
+			name:   "case 1",
+			change: model.RecoveryKeysChange{Password: "secret"},
+			want:   nil,
+		},
+		{
+			name:   "case 2",
+			change: model.RecoveryKeysChange{Password: ""},
+			want:   model.ErrRecoveryPasswordEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.change.Validate())
+		})
+	}
+}
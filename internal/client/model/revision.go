@@ -0,0 +1,85 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы записей хранилища
+const (
+	ItemTypeLogin  = "login"
+	ItemTypeCard   = "card"
+	ItemTypeFile   = "file"
+	ItemTypeNote   = "note"
+	ItemTypeOTP    = "otp"
+	ItemTypeSSHKey = "ssh_key"
+)
+
+// Признаки строк сравнения редакций записи
+const (
+	DiffSame    = "  "
+	DiffRemoved = "- "
+	DiffAdded   = "+ "
+)
+
+// hiddenValue значение скрытого поля в сравнении редакций записи
+const hiddenValue = "********"
+
+// Revision модель прежней редакции записи приложения: Data — данные редакции, зашифрованные
+// так же, как данные записи, Content — расшифрованное содержимое редакции
+type Revision struct {
+	ID        int             `json:"id"`
+	ItemID    int             `json:"item_id"`
+	UID       string          `json:"uid"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	Device    string          `json:"device"`
+	CreatedAt time.Time       `json:"created_at"`
+	Content   []Field         `json:"-"`
+	Placement
+}
+
+// DiffContent сравнивает содержимое записи from и to по названиям полей и возвращает строки
+// сравнения с признаками DiffSame, DiffRemoved и DiffAdded. Значения скрытых полей
+// показываются, только если reveal
+func DiffContent(from, to []Field, reveal bool) []string {
+	lines := make([]string, 0, len(from)+len(to))
+
+	previous := make(map[string]Field, len(from))
+	for _, field := range from {
+		previous[field.Name] = field
+	}
+
+	current := make(map[string]bool, len(to))
+
+	for _, field := range to {
+		current[field.Name] = true
+
+		old, ok := previous[field.Name]
+
+		switch {
+		case !ok:
+			lines = append(lines, DiffAdded+diffLine(field, reveal))
+		case old.Value == field.Value && old.Type == field.Type:
+			lines = append(lines, DiffSame+diffLine(field, reveal))
+		default:
+			lines = append(lines, DiffRemoved+diffLine(old, reveal), DiffAdded+diffLine(field, reveal))
+		}
+	}
+
+	for _, field := range from {
+		if !current[field.Name] {
+			lines = append(lines, DiffRemoved+diffLine(field, reveal))
+		}
+	}
+
+	return lines
+}
+
+func diffLine(field Field, reveal bool) string {
+	if field.Type == FieldTypeHidden && !reveal {
+		return field.Name + ": " + hiddenValue
+	}
+
+	return field.Name + ": " + field.Value
+}
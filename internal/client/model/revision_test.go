@@ -0,0 +1,50 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+func TestDiffContent(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   []model.Field
+		to     []model.Field
+		reveal bool
+		want   []string
+	}{
+		{
+			name: "case 1",
+			from: []model.Field{{Name: "Username", Type: model.FieldTypeText, Value: "user"}},
+			to:   []model.Field{{Name: "Username", Type: model.FieldTypeText, Value: "user"}},
+			want: []string{"  Username: user"},
+		},
+		{
+			name: "case 2",
+			from: []model.Field{{Name: "Password", Type: model.FieldTypeHidden, Value: "old"}},
+			to:   []model.Field{{Name: "Password", Type: model.FieldTypeHidden, Value: "new"}},
+			want: []string{"- Password: ********", "+ Password: ********"},
+		},
+		{
+			name:   "case 3",
+			from:   []model.Field{{Name: "Password", Type: model.FieldTypeHidden, Value: "old"}},
+			to:     []model.Field{{Name: "Password", Type: model.FieldTypeHidden, Value: "new"}},
+			reveal: true,
+			want:   []string{"- Password: old", "+ Password: new"},
+		},
+		{
+			name: "case 4",
+			from: []model.Field{{Name: "Pin", Type: model.FieldTypeText, Value: "1234"}},
+			to:   []model.Field{{Name: "Site", Type: model.FieldTypeURL, Value: "https://example.com"}},
+			want: []string{"+ Site: https://example.com", "- Pin: 1234"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, model.DiffContent(tt.from, tt.to, tt.reveal))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHKey модель ключа SSH приложения: закрытый ключ в формате PEM, открытый ключ в формате
+// authorized_keys без комментария и его отпечаток SHA256. Ключ с Confirm используется агентом SSH
+// только после подтверждения пользователя
+type SSHKey struct {
+	ID          int     `json:"id"`
+	UID         string  `json:"uid"`
+	PrivateKey  string  `json:"private_key"`
+	PublicKey   string  `json:"public_key"`
+	Comment     string  `json:"comment"`
+	Fingerprint string  `json:"fingerprint"`
+	Confirm     bool    `json:"confirm"`
+	Fields      []Field `json:"fields"`
+	Placement
+}
+
+var (
+	ErrSSHKeyCommentEmpity    = errors.New("comment empity")
+	ErrSSHKeyPrivateKeyEmpity = errors.New("private key empity")
+	ErrSSHKeyInvalid          = errors.New("invalid ssh private key")
+	ErrSSHKeyMismatch         = errors.New("public key or fingerprint does not match private key")
+)
+
+// Validate проверяет корректность модели ключа SSH приложения
+func (r *SSHKey) Validate() error {
+	if strings.TrimSpace(r.Comment) == "" {
+		return ErrSSHKeyCommentEmpity
+	}
+
+	if strings.TrimSpace(r.PrivateKey) == "" {
+		return ErrSSHKeyPrivateKeyEmpity
+	}
+
+	signer, err := r.Signer()
+	if err != nil {
+		return err
+	}
+
+	if r.PublicKey != authorizedKey(signer.PublicKey()) || r.Fingerprint != ssh.FingerprintSHA256(signer.PublicKey()) {
+		return ErrSSHKeyMismatch
+	}
+
+	return ValidateFields(r.Fields)
+}
+
+// Signer возвращает закрытый ключ для подписи, ключи, защищённые парольной фразой, не поддерживаются
+func (r *SSHKey) Signer() (ssh.Signer, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(r.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSSHKeyInvalid, err)
+	}
+
+	return signer, nil
+}
+
+// SetPrivateKey заполняет модель закрытым ключом privateKey, открытым ключом и отпечатком
+func (r *SSHKey) SetPrivateKey(privateKey string) error {
+	r.PrivateKey = strings.TrimSpace(privateKey) + "\n"
+
+	signer, err := r.Signer()
+	if err != nil {
+		return err
+	}
+
+	r.PublicKey = authorizedKey(signer.PublicKey())
+	r.Fingerprint = ssh.FingerprintSHA256(signer.PublicKey())
+
+	return nil
+}
+
+// AuthorizedKey возвращает строку открытого ключа с комментарием для файла authorized_keys
+func (r *SSHKey) AuthorizedKey() string {
+	return strings.TrimSpace(r.PublicKey + " " + r.Comment)
+}
+
+func authorizedKey(key ssh.PublicKey) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+}
+
+// Content возвращает содержимое ключа SSH для сравнения редакций
+func (r *SSHKey) Content() []Field {
+	return append([]Field{
+		{Name: "Comment", Type: FieldTypeText, Value: r.Comment},
+		{Name: "Fingerprint", Type: FieldTypeText, Value: r.Fingerprint},
+		{Name: "Public key", Type: FieldTypeText, Value: r.PublicKey},
+		{Name: "Private key", Type: FieldTypeHidden, Value: r.PrivateKey},
+		{Name: "Confirm", Type: FieldTypeText, Value: strconv.FormatBool(r.Confirm)},
+	}, r.Fields...)
+}
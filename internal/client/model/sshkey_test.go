@@ -0,0 +1,64 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vukit/gophkeeper/internal/client/model"
+	"github.com/vukit/gophkeeper/internal/client/sshagent"
+)
+
+func TestSSHKey(t *testing.T) {
+	privateKey, err := sshagent.GenerateKey("user@host")
+	require.NoError(t, err)
+
+	otherKey, err := sshagent.GenerateKey("user@host")
+	require.NoError(t, err)
+
+	key := model.SSHKey{Comment: "user@host"}
+	require.NoError(t, key.SetPrivateKey(privateKey))
+	assert.Contains(t, key.PublicKey, "ssh-ed25519 ")
+	assert.Contains(t, key.Fingerprint, "SHA256:")
+	assert.Equal(t, key.PublicKey+" user@host", key.AuthorizedKey())
+
+	tests := []struct {
+		name   string
+		modify func(key *model.SSHKey)
+		want   error
+	}{
+		{
+			name:   "case 1",
+			modify: func(key *model.SSHKey) {},
+			want:   nil,
+		},
+		{
+			name:   "case 2",
+			modify: func(key *model.SSHKey) { key.Comment = " " },
+			want:   model.ErrSSHKeyCommentEmpity,
+		},
+		{
+			name:   "case 3",
+			modify: func(key *model.SSHKey) { key.PrivateKey = "" },
+			want:   model.ErrSSHKeyPrivateKeyEmpity,
+		},
+		{
+			name:   "case 4",
+			modify: func(key *model.SSHKey) { key.PrivateKey = "not a key" },
+			want:   model.ErrSSHKeyInvalid,
+		},
+		{
+			name:   "case 5",
+			modify: func(key *model.SSHKey) { key.PrivateKey = otherKey },
+			want:   model.ErrSSHKeyMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := key
+			tt.modify(&current)
+			assert.ErrorIs(t, current.Validate(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package model
+
+import "encoding/json"
+
+// SyncItem модель записи приложения любого типа, изменённой после ревизии синхронизации:
+// Data — данные записи, зашифрованные так же, как данные записи. Удалённая запись Deleted
+// передаётся без данных
+type SyncItem struct {
+	ID      int             `json:"id"`
+	UID     string          `json:"uid"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+	Deleted bool            `json:"deleted"`
+	Placement
+}
+
+// Sync модель изменений хранилища пользователя после ревизии синхронизации: Revision — текущая
+// ревизия, если Reset, Items содержит все записи хранилища
+type Sync struct {
+	Revision int64      `json:"revision"`
+	Reset    bool       `json:"reset"`
+	Items    []SyncItem `json:"items"`
+}
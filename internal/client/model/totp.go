@@ -0,0 +1,35 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// TOTPEnrollment модель секрета подключаемой двухфакторной аутентификации
+// и ссылки otpauth для приложения-аутентификатора
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TOTPSignIn модель второго шага аутентификации пользователя
+type TOTPSignIn struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// TOTPCode модель одноразового пароля или резервного кода двухфакторной аутентификации
+type TOTPCode struct {
+	Code string `json:"code"`
+}
+
+var ErrTOTPCodeEmpity = errors.New("authentication code empity")
+
+// Validate проверяет корректность модели одноразового пароля
+func (r *TOTPCode) Validate() error {
+	if strings.TrimSpace(r.Code) == "" {
+		return ErrTOTPCodeEmpity
+	}
+
+	return nil
+}
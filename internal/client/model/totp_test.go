@@ -0,0 +1,33 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code model.TOTPCode
+		want error
+	}{
+		{
+			name: "case 1",
+			code: model.TOTPCode{Code: "123456"},
+			want: nil,
+		},
+		{
+			name: "case 2",
+			code: model.TOTPCode{Code: " "},
+			want: model.ErrTOTPCodeEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.code.Validate())
+		})
+	}
+}
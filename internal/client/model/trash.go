@@ -0,0 +1,18 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TrashItem модель записи приложения любого типа в корзине: Data — данные записи, зашифрованные
+// так же, как данные записи, Content — расшифрованное содержимое записи
+type TrashItem struct {
+	ID        int             `json:"id"`
+	UID       string          `json:"uid"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	DeletedAt time.Time       `json:"deleted_at"`
+	Content   []Field         `json:"-"`
+	Placement
+}
@@ -10,6 +10,11 @@ import (
 type User struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	KDF      KDF    `json:"kdf"`
+	VaultKey string `json:"vault_key,omitempty"`
+
+	RecoveryKeys  []RecoveryKey `json:"recovery_keys,omitempty"`
+	RecoveryCodes []string      `json:"-"`
 }
 
 const maxUserUsernameLegth = 64
@@ -0,0 +1,217 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Алгоритмы HMAC одноразовых паролей
+const (
+	SHA1   = "SHA1"
+	SHA256 = "SHA256"
+	SHA512 = "SHA512"
+)
+
+// Параметры одноразовых паролей по умолчанию и допустимые границы
+const (
+	DefaultDigits = 6
+	DefaultPeriod = 30
+	MinDigits     = 6
+	MaxDigits     = 8
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+var (
+	ErrOTPInvalidSecret    = errors.New("invalid otp secret")
+	ErrOTPInvalidAlgorithm = errors.New("invalid otp algorithm")
+	ErrOTPInvalidDigits    = fmt.Errorf("otp digits must be from %d to %d", MinDigits, MaxDigits)
+	ErrOTPInvalidPeriod    = errors.New("invalid otp period")
+	ErrOTPInvalidURI       = errors.New("invalid otpauth uri")
+	ErrOTPUnsupportedType  = errors.New("only totp otpauth uri is supported")
+)
+
+// Key параметры генерации одноразовых паролей RFC 6238
+type Key struct {
+	Issuer    string
+	Account   string
+	Secret    string
+	Algorithm string
+	Digits    int
+	Period    int
+}
+
+// Name возвращает название ключа для списка: издатель и учётная запись
+func (k Key) Name() string {
+	switch {
+	case k.Issuer == "":
+		return k.Account
+	case k.Account == "":
+		return k.Issuer
+	default:
+		return k.Issuer + ":" + k.Account
+	}
+}
+
+// HOTP возвращает одноразовый пароль RFC 4226 длиной digits для ключа key и счётчика counter
+func HOTP(key []byte, counter uint64, digits int, algorithm string) (string, error) {
+	newHash, err := hashFunc(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	if digits < MinDigits || digits > MaxDigits {
+		return "", ErrOTPInvalidDigits
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(newHash, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod), nil
+}
+
+// TOTP возвращает одноразовый пароль RFC 6238 для ключа key в момент t
+func TOTP(key []byte, t time.Time, period, digits int, algorithm string) (string, error) {
+	if period <= 0 {
+		return "", ErrOTPInvalidPeriod
+	}
+
+	return HOTP(key, uint64(t.Unix())/uint64(period), digits, algorithm)
+}
+
+// Code возвращает одноразовый пароль ключа k в момент t и время, оставшееся до смены пароля
+func (k Key) Code(t time.Time) (code string, remaining time.Duration, err error) {
+	secret, err := DecodeSecret(k.Secret)
+	if err != nil {
+		return "", 0, err
+	}
+
+	code, err = TOTP(secret, t, k.Period, k.Digits, k.Algorithm)
+	if err != nil {
+		return "", 0, err
+	}
+
+	period := time.Duration(k.Period) * time.Second
+
+	return code, period - time.Duration(t.UnixNano())%period, nil
+}
+
+// Validate проверяет параметры ключа
+func (k Key) Validate() error {
+	if _, err := DecodeSecret(k.Secret); err != nil {
+		return err
+	}
+
+	if _, err := hashFunc(k.Algorithm); err != nil {
+		return err
+	}
+
+	if k.Digits < MinDigits || k.Digits > MaxDigits {
+		return ErrOTPInvalidDigits
+	}
+
+	if k.Period <= 0 {
+		return ErrOTPInvalidPeriod
+	}
+
+	return nil
+}
+
+// ParseURI разбирает ссылку otpauth://totp/, отсутствующие параметры принимают значения по умолчанию
+func ParseURI(uri string) (Key, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil || u.Scheme != "otpauth" {
+		return Key{}, ErrOTPInvalidURI
+	}
+
+	if !strings.EqualFold(u.Host, "totp") {
+		return Key{}, ErrOTPUnsupportedType
+	}
+
+	query := u.Query()
+
+	key := Key{
+		Issuer:    query.Get("issuer"),
+		Secret:    strings.ToUpper(query.Get("secret")),
+		Algorithm: strings.ToUpper(query.Get("algorithm")),
+		Digits:    DefaultDigits,
+		Period:    DefaultPeriod,
+	}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		key.Account = strings.TrimSpace(account)
+
+		if key.Issuer == "" {
+			key.Issuer = issuer
+		}
+	} else {
+		key.Account = label
+	}
+
+	if key.Algorithm == "" {
+		key.Algorithm = SHA1
+	}
+
+	if digits := query.Get("digits"); digits != "" {
+		if key.Digits, err = strconv.Atoi(digits); err != nil {
+			return Key{}, ErrOTPInvalidDigits
+		}
+	}
+
+	if period := query.Get("period"); period != "" {
+		if key.Period, err = strconv.Atoi(period); err != nil {
+			return Key{}, ErrOTPInvalidPeriod
+		}
+	}
+
+	return key, key.Validate()
+}
+
+// DecodeSecret декодирует секрет в кодировке base32, пробелы и выравнивание не учитываются
+func DecodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+
+	key, err := encoding.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil || len(key) == 0 {
+		return nil, ErrOTPInvalidSecret
+	}
+
+	return key, nil
+}
+
+func hashFunc(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case SHA1:
+		return sha1.New, nil
+	case SHA256:
+		return sha256.New, nil
+	case SHA512:
+		return sha512.New, nil
+	default:
+		return nil, ErrOTPInvalidAlgorithm
+	}
+}
@@ -0,0 +1,157 @@
+package otp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vukit/gophkeeper/internal/client/otp"
+)
+
+// Ключи тестовых векторов RFC 4226 и RFC 6238
+var rfcKeys = map[string][]byte{
+	otp.SHA1:   []byte("12345678901234567890"),
+	otp.SHA256: []byte("12345678901234567890123456789012"),
+	otp.SHA512: []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+}
+
+func TestHOTP(t *testing.T) {
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, code := range want {
+		got, err := otp.HOTP(rfcKeys[otp.SHA1], uint64(counter), 6, otp.SHA1)
+		require.NoError(t, err)
+		assert.Equal(t, code, got)
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	tests := []struct {
+		name string
+		unix int64
+		want map[string]string
+	}{
+		{
+			name: "case 1",
+			unix: 59,
+			want: map[string]string{otp.SHA1: "94287082", otp.SHA256: "46119246", otp.SHA512: "90693936"},
+		},
+		{
+			name: "case 2",
+			unix: 1111111109,
+			want: map[string]string{otp.SHA1: "07081804", otp.SHA256: "68084774", otp.SHA512: "25091201"},
+		},
+		{
+			name: "case 3",
+			unix: 1111111111,
+			want: map[string]string{otp.SHA1: "14050471", otp.SHA256: "67062674", otp.SHA512: "99943326"},
+		},
+		{
+			name: "case 4",
+			unix: 1234567890,
+			want: map[string]string{otp.SHA1: "89005924", otp.SHA256: "91819424", otp.SHA512: "93441116"},
+		},
+		{
+			name: "case 5",
+			unix: 2000000000,
+			want: map[string]string{otp.SHA1: "69279037", otp.SHA256: "90698825", otp.SHA512: "38618901"},
+		},
+		{
+			name: "case 6",
+			unix: 20000000000,
+			want: map[string]string{otp.SHA1: "65353130", otp.SHA256: "77737706", otp.SHA512: "47863826"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for algorithm, want := range tt.want {
+				got, err := otp.TOTP(rfcKeys[algorithm], time.Unix(tt.unix, 0), 30, 8, algorithm)
+				require.NoError(t, err)
+				assert.Equal(t, want, got, algorithm)
+			}
+		})
+	}
+}
+
+func TestKeyCode(t *testing.T) {
+	// base32 ключа SHA1 тестовых векторов RFC 6238
+	key := otp.Key{Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", Algorithm: otp.SHA1, Digits: 8, Period: 30}
+
+	code, remaining, err := key.Code(time.Unix(1111111111, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "14050471", code)
+	assert.Equal(t, 29*time.Second, remaining)
+
+	_, _, err = otp.Key{Secret: "!", Algorithm: otp.SHA1, Digits: 6, Period: 30}.Code(time.Now())
+	assert.ErrorIs(t, err, otp.ErrOTPInvalidSecret)
+}
+
+func TestParseURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		want    otp.Key
+		wantErr error
+	}{
+		{
+			name: "case 1",
+			uri:  "otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example",
+			want: otp.Key{
+				Issuer: "Example", Account: "alice@example.com", Secret: "JBSWY3DPEHPK3PXP",
+				Algorithm: otp.SHA1, Digits: 6, Period: 30,
+			},
+		},
+		{
+			name: "case 2",
+			uri:  "otpauth://totp/ACME%20Co:john?secret=jbswy3dpehpk3pxp&algorithm=SHA256&digits=8&period=60",
+			want: otp.Key{
+				Issuer: "ACME Co", Account: "john", Secret: "JBSWY3DPEHPK3PXP",
+				Algorithm: otp.SHA256, Digits: 8, Period: 60,
+			},
+		},
+		{
+			name:    "case 3",
+			uri:     "otpauth://hotp/Example:alice?secret=JBSWY3DPEHPK3PXP&counter=1",
+			wantErr: otp.ErrOTPUnsupportedType,
+		},
+		{
+			name:    "case 4",
+			uri:     "https://example.com/?secret=JBSWY3DPEHPK3PXP",
+			wantErr: otp.ErrOTPInvalidURI,
+		},
+		{
+			name:    "case 5",
+			uri:     "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&algorithm=MD5",
+			wantErr: otp.ErrOTPInvalidAlgorithm,
+		},
+		{
+			name:    "case 6",
+			uri:     "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&digits=10",
+			wantErr: otp.ErrOTPInvalidDigits,
+		},
+		{
+			name:    "case 7",
+			uri:     "otpauth://totp/Example:alice",
+			wantErr: otp.ErrOTPInvalidSecret,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := otp.ParseURI(tt.uri)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, key)
+		})
+	}
+}
@@ -0,0 +1,461 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+var (
+	ErrAccountArchiveInvalid  = errors.New("invalid account archive")
+	ErrAccountArchiveVersion  = errors.New("unsupported account archive version")
+	ErrAccountInvalidPassword = errors.New("invalid master password of the exported account")
+)
+
+// ExportAccount метод выгрузки учётной записи: архив с зашифрованными данными сохраняется в каталог folder,
+// возвращается путь к архиву. Архив проверяется после загрузки, прерванная загрузка удаляется
+func (s *httpService) ExportAccount(ctx context.Context, user *model.User, folder string) (path string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/account/export", http.NoBody)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	err = checkStatusCode(resp.StatusCode, resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	name := "gophkeeper-" + user.Username + ".zip"
+
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		name = filepath.Base(params["filename"])
+	}
+
+	path = filepath.Join(folder, name)
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = io.Copy(dst, resp.Body)
+	if errClose := dst.Close(); err == nil {
+		err = errClose
+	}
+
+	if err == nil {
+		_, err = readAccountArchive(path)
+	}
+
+	if err != nil {
+		os.Remove(path)
+
+		return "", err
+	}
+
+	return path, nil
+}
+
+// ImportAccount метод загрузки архива выгрузки учётной записи в учётную запись пользователя: данные
+// расшифровываются мастер-паролем выгруженной учётной записи и сохраняются как новые записи, зашифрованные
+// ключом хранилища пользователя. Записи и описания файлов расшифровываются до начала сохранения,
+// поэтому неверный пароль не приводит к частичной загрузке
+func (s *httpService) ImportAccount(ctx context.Context, accountImport model.AccountImport) (err error) {
+	archive, err := zip.OpenReader(accountImport.Path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	account, err := decodeAccountManifest(&archive.Reader)
+	if err != nil {
+		return err
+	}
+
+	cs, err := NewCryptoService(&model.User{
+		Username: account.Username,
+		Password: accountImport.Password,
+		KDF:      account.KDF,
+		VaultKey: account.VaultKey,
+	})
+	if err != nil {
+		if errors.Is(err, ErrCryptoDecrypt) {
+			return ErrAccountInvalidPassword
+		}
+
+		return err
+	}
+
+	// выгрузки прежних версий клиента содержат записи без идентификатора,
+	// загруженные записи получают новые идентификаторы
+	cs.AcceptUnbound(true)
+
+	for i := range account.Logins {
+		if err = cs.decryptLogin(&account.Logins[i]); err != nil {
+			return fmt.Errorf("error decrypted login with id = %d: %w", account.Logins[i].ID, err)
+		}
+	}
+
+	for i := range account.Cards {
+		if err = cs.decryptCard(&account.Cards[i]); err != nil {
+			return fmt.Errorf("error decrypted card with id = %d: %w", account.Cards[i].ID, err)
+		}
+	}
+
+	for i := range account.Notes {
+		if err = cs.decryptNote(&account.Notes[i]); err != nil {
+			return fmt.Errorf("error decrypted note with id = %d: %w", account.Notes[i].ID, err)
+		}
+	}
+
+	for i := range account.OTPs {
+		if err = cs.decryptOTP(&account.OTPs[i]); err != nil {
+			return fmt.Errorf("error decrypted otp with id = %d: %w", account.OTPs[i].ID, err)
+		}
+	}
+
+	for i := range account.SSHKeys {
+		if err = cs.decryptSSHKey(&account.SSHKeys[i]); err != nil {
+			return fmt.Errorf("error decrypted ssh key with id = %d: %w", account.SSHKeys[i].ID, err)
+		}
+	}
+
+	for i := range account.Items {
+		if err = cs.decryptItem(&account.Items[i]); err != nil {
+			return fmt.Errorf("error decrypted item with id = %d: %w", account.Items[i].ID, err)
+		}
+	}
+
+	for i := range account.Folders {
+		if err = cs.decryptFolder(&account.Folders[i]); err != nil {
+			return fmt.Errorf("error decrypted folder with id = %d: %w", account.Folders[i].ID, err)
+		}
+	}
+
+	for i := range account.Tags {
+		if err = cs.decryptTag(&account.Tags[i]); err != nil {
+			return fmt.Errorf("error decrypted tag with id = %d: %w", account.Tags[i].ID, err)
+		}
+	}
+
+	blobs := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		blobs[f.Name] = f
+	}
+
+	for i := range account.Files {
+		if blobs[account.Files[i].Path] == nil {
+			return fmt.Errorf("file with id = %d: %w", account.Files[i].ID, ErrAccountArchiveInvalid)
+		}
+
+		if err = cs.decryptFileInfo(&account.Files[i]); err != nil {
+			return fmt.Errorf("error decrypted file with id = %d: %w", account.Files[i].ID, err)
+		}
+	}
+
+	place, err := s.importPlacement(ctx, account.Folders, account.Tags)
+	if err != nil {
+		return err
+	}
+
+	// логины получают новые идентификаторы, привязка ключей одноразовых паролей восстанавливается
+	// по идентификаторам записей, назначенным при загрузке
+	loginUIDs := make(map[int]string, len(account.Logins))
+
+	for _, login := range account.Logins {
+		var uid string
+
+		if uid, err = newItemUID(); err != nil {
+			return err
+		}
+
+		if err = s.SaveLogin(ctx, &model.Login{
+			UID: uid, Username: login.Username, Password: login.Password, MetaInfo: login.MetaInfo, Fields: login.Fields,
+			Placement: place(login.Placement),
+		}); err != nil {
+			return err
+		}
+
+		loginUIDs[login.ID] = uid
+	}
+
+	if err = s.importOTPs(ctx, account.OTPs, loginUIDs, place); err != nil {
+		return err
+	}
+
+	for _, card := range account.Cards {
+		if err = s.SaveCard(ctx, &model.Card{
+			Bank: card.Bank, Number: card.Number, Date: card.Date, CVV: card.CVV, MetaInfo: card.MetaInfo, Fields: card.Fields,
+			Placement: place(card.Placement),
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, note := range account.Notes {
+		if err = s.SaveNote(ctx, &model.Note{
+			Title: note.Title, Text: note.Text, Fields: note.Fields, Placement: place(note.Placement),
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range account.SSHKeys {
+		if err = s.SaveSSHKey(ctx, &model.SSHKey{
+			PrivateKey: key.PrivateKey, PublicKey: key.PublicKey, Comment: key.Comment, Fingerprint: key.Fingerprint, Confirm: key.Confirm, Fields: key.Fields,
+			Placement: place(key.Placement),
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range account.Items {
+		if err = s.SaveItem(ctx, &model.Item{
+			Type: item.Type, Name: item.Name, Fields: item.Fields, Placement: place(item.Placement),
+		}); err != nil {
+			return err
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "gophkeeper-import")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for i := range account.Files {
+		account.Files[i].Placement = place(account.Files[i].Placement)
+
+		if err = s.importFile(ctx, cs, blobs[account.Files[i].Path], &account.Files[i], filepath.Join(tmpDir, strconv.Itoa(i))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importPlacement создаёт папки folders и метки tags выгруженной учётной записи и возвращает функцию,
+// которая переводит размещение выгруженной записи на созданные папки и метки
+func (s *httpService) importPlacement(
+	ctx context.Context,
+	folders []model.Folder,
+	tags []model.Tag,
+) (place func(model.Placement) model.Placement, err error) {
+	folderUIDs := make(map[int]string, len(folders))
+
+	for _, folder := range folders {
+		imported := model.Folder{Name: folder.Name}
+
+		if imported.UID, err = newItemUID(); err != nil {
+			return nil, err
+		}
+
+		if err = s.SaveFolder(ctx, &imported); err != nil {
+			return nil, err
+		}
+
+		folderUIDs[folder.ID] = imported.UID
+	}
+
+	tagUIDs := make(map[int]string, len(tags))
+
+	for _, tag := range tags {
+		imported := model.Tag{Name: tag.Name}
+
+		if imported.UID, err = newItemUID(); err != nil {
+			return nil, err
+		}
+
+		if err = s.SaveTag(ctx, &imported); err != nil {
+			return nil, err
+		}
+
+		tagUIDs[tag.ID] = imported.UID
+	}
+
+	folderIDs := make(map[string]int, len(folders))
+	tagIDs := make(map[string]int, len(tags))
+
+	if len(folders) > 0 {
+		saved, err := s.GetFolders(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, folder := range saved {
+			folderIDs[folder.UID] = folder.ID
+		}
+	}
+
+	if len(tags) > 0 {
+		saved, err := s.GetTags(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tag := range saved {
+			tagIDs[tag.UID] = tag.ID
+		}
+	}
+
+	return func(placement model.Placement) model.Placement {
+		imported := model.Placement{FolderID: folderIDs[folderUIDs[placement.FolderID]]}
+
+		for _, id := range placement.TagIDs {
+			if tagID := tagIDs[tagUIDs[id]]; tagID != 0 {
+				imported.TagIDs = append(imported.TagIDs, tagID)
+			}
+		}
+
+		return imported
+	}, nil
+}
+
+// importFile расшифровывает содержимое файла file из элемента архива blob во временный каталог dir
+// и сохраняет файл как новый файл пользователя
+func (s *httpService) importFile(ctx context.Context, cs *CryptoService, blob *zip.File, file *model.File, dir string) (err error) {
+	src, err := blob.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	decrypted, err := cs.decryptFileContent(src, file)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Mkdir(dir, 0o700); err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Base(file.Name)
+	if name == "." || name == string(filepath.Separator) {
+		name = "file"
+	}
+
+	path := filepath.Join(dir, name)
+
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, decrypted)
+	if errClose := dst.Close(); err == nil {
+		err = errClose
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.SaveFile(ctx, &model.File{Path: path, MetaInfo: file.MetaInfo, Fields: file.Fields, Placement: file.Placement})
+}
+
+// DeleteAccount метод удаления учётной записи пользователя со всеми данными,
+// мастер-пароль password подтверждает удаление
+func (s *httpService) DeleteAccount(ctx context.Context, user *model.User, password string) (err error) {
+	current := *user
+	current.Password = password
+
+	keys, err := DeriveKeys(&current)
+	if err != nil {
+		return err
+	}
+
+	err = s.doJSON(ctx, http.MethodDelete, "/account", model.AccountDeletion{Password: keys.AuthPassword(&current)}, nil)
+	if err != nil {
+		return err
+	}
+
+	s.setSession(false)
+
+	return nil
+}
+
+// importOTPs сохраняет ключи одноразовых паролей выгрузки как новые записи и привязывает их
+// к загруженным логинам, loginUIDs сопоставляет идентификатор логина выгрузки с идентификатором новой записи
+func (s *httpService) importOTPs(
+	ctx context.Context,
+	otps []model.OTP,
+	loginUIDs map[int]string,
+	place func(model.Placement) model.Placement,
+) (err error) {
+	loginIDs := make(map[string]int)
+
+	for _, otp := range otps {
+		if otp.LoginID != 0 && len(loginIDs) == 0 {
+			var logins []model.Login
+
+			if logins, err = s.GetLogins(ctx); err != nil {
+				return err
+			}
+
+			for _, login := range logins {
+				loginIDs[login.UID] = login.ID
+			}
+		}
+
+		imported := otp
+		imported.ID = 0
+		imported.UID = ""
+		imported.LoginID = loginIDs[loginUIDs[otp.LoginID]]
+		imported.Placement = place(otp.Placement)
+
+		if err = s.SaveOTP(ctx, &imported); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readAccountArchive открывает архив выгрузки учётной записи path и возвращает описание учётной записи
+func readAccountArchive(path string) (account model.Account, err error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return account, err
+	}
+	defer archive.Close()
+
+	return decodeAccountManifest(&archive.Reader)
+}
+
+// decodeAccountManifest возвращает описание учётной записи из архива выгрузки
+func decodeAccountManifest(archive *zip.Reader) (account model.Account, err error) {
+	manifest, err := archive.Open(model.AccountArchiveManifest)
+	if err != nil {
+		return account, ErrAccountArchiveInvalid
+	}
+	defer manifest.Close()
+
+	decoder := json.NewDecoder(manifest)
+
+	if err = decoder.Decode(&account); err != nil {
+		return account, fmt.Errorf("%w: %s", ErrAccountArchiveInvalid, err)
+	}
+
+	if account.Version != model.AccountArchiveVersion {
+		return account, ErrAccountArchiveVersion
+	}
+
+	return account, nil
+}
@@ -1,28 +1,104 @@
 package service
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"io"
+	"sync/atomic"
 
 	"github.com/vukit/gophkeeper/internal/client/model"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Формат шифротекста (конверт):
+//
+//	| версия (1 байт) | алгоритм (1 байт) | nonce | шифротекст с тегом |
+//
+// Шифротексты версии 2 аутентифицируют связанные данные — владельца, запись и поле,
+// версии 1 зашифрованы без связанных данных. Шифротексты без конверта (legacy) зашифрованы
+// фиксированным nonce, полученным из хвоста ключа. Версия 1 и legacy поддерживаются
+// только для расшифровки данных, не привязанных к записи.
+const (
+	envelopeVersion1 byte = 1
+	envelopeVersion2 byte = 2
+	algAES256GCM     byte = 1
+	envelopeHeader        = 2
+)
+
+const (
+	keyLength     = 32
+	kdfSaltLength = 16
+)
+
+// Контексты HKDF для независимых ключей, получаемых из ключа KDF
+const (
+	hkdfInfoAuth  = "gophkeeper authentication key"
+	hkdfInfoVault = "gophkeeper vault encryption key"
+	hkdfInfoWrap  = "gophkeeper vault wrapping key"
+)
+
+// Keys ключи, получаемые из мастер-пароля пользователя: ключ аутентификации
+// передаётся серверу, ключ шифрования и ключ, которым зашифрован случайный
+// ключ шифрования хранилища, никогда не покидают клиент
+type Keys struct {
+	Auth  []byte
+	Vault []byte
+	Wrap  []byte
+}
+
+var (
+	ErrCryptoDecrypt        = errors.New("message authentication failed")
+	ErrCryptoTampered       = errors.New("ciphertext does not belong to this item or field, data was tampered with")
+	ErrCryptoInvalidKeySize = errors.New("invalid vault key size")
+	ErrCryptoUnbound        = errors.New("vault item is not bound to its identifier")
 )
 
 // CryptoService структура сервиса симметричного шифрования
 type CryptoService struct {
-	aesgcm cipher.AEAD
-	nonce  []byte
+	key         []byte
+	username    string
+	aesgcm      cipher.AEAD
+	legacyNonce []byte
+	legacyCount int64
+	unbound     int32
 }
 
-// NewCryptoService возвращает сервис симметричного шифрования для пользователя приложения
+// NewCryptoService возвращает сервис симметричного шифрования для пользователя приложения.
+// Если на сервере хранится зашифрованный ключ хранилища, он расшифровывается ключом из
+// мастер-пароля, иначе ключом хранилища служит ключ, полученный из мастер-пароля
 func NewCryptoService(user *model.User) (cs *CryptoService, err error) {
-	cs = &CryptoService{}
+	keys, err := DeriveKeys(user)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.Vault
+
+	if user.VaultKey != "" {
+		if key, err = UnwrapKey(keys.Wrap, user.VaultKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return newCryptoService(key, user.Username)
+}
+
+// newCryptoService возвращает сервис симметричного шифрования с ключом key для пользователя username
+func newCryptoService(key []byte, username string) (cs *CryptoService, err error) {
+	if len(key) != keyLength {
+		return nil, ErrCryptoInvalidKeySize
+	}
 
-	key := sha256.Sum256([]byte(user.Password))
+	cs = &CryptoService{key: key, username: username}
 
-	aesblock, err := aes.NewCipher(key[:])
+	aesblock, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
@@ -32,53 +108,271 @@ func NewCryptoService(user *model.User) (cs *CryptoService, err error) {
 		return nil, err
 	}
 
-	cs.nonce = key[len(key)-cs.aesgcm.NonceSize():]
+	cs.legacyNonce = key[len(key)-cs.aesgcm.NonceSize():]
 
 	return cs, nil
 }
 
-// Encrypt шифрует масссив src
-func (r *CryptoService) Encrypt(src []byte) []byte {
-	return r.aesgcm.Seal(nil, r.nonce, src, nil)
+// NewVaultKey возвращает случайный ключ шифрования хранилища
+func NewVaultKey() ([]byte, error) {
+	key := make([]byte, keyLength)
+
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
 }
 
-// Decrypt расшифровывает масссив src
-func (r *CryptoService) Decrypt(src []byte) ([]byte, error) {
-	dst, err := r.aesgcm.Open(nil, r.nonce, src, nil)
+// WrapKey шифрует ключ хранилища vaultKey ключом wrapKey для хранения на сервере
+func WrapKey(wrapKey, vaultKey []byte) (string, error) {
+	cs, err := newCryptoService(wrapKey, "")
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := cs.Encrypt(vaultKey, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(wrapped), nil
+}
+
+// UnwrapKey расшифровывает ключ хранилища, зашифрованный функцией WrapKey
+func UnwrapKey(wrapKey []byte, wrapped string) ([]byte, error) {
+	cs, err := newCryptoService(wrapKey, "")
 	if err != nil {
 		return nil, err
 	}
 
-	return dst, nil
+	data, err := hex.DecodeString(wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := cs.Decrypt(data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) != keyLength {
+		return nil, ErrCryptoInvalidKeySize
+	}
+
+	return key, nil
 }
 
-// EncryptFile шифрует файл src
-func (r *CryptoService) EncryptFile(src io.ReadCloser) (dst io.Reader, err error) {
-	inBytes, err := io.ReadAll(src)
+// NewKDF возвращает параметры Argon2id со случайной солью для нового пользователя
+func NewKDF(time, memory uint32, threads uint8) (kdf model.KDF, err error) {
+	salt := make([]byte, kdfSaltLength)
+
+	if _, err = io.ReadFull(rand.Reader, salt); err != nil {
+		return kdf, err
+	}
+
+	kdf = model.KDF{
+		Version:   model.KDFVersionSplitKeys,
+		Algorithm: model.KDFArgon2id,
+		Salt:      hex.EncodeToString(salt),
+		Time:      time,
+		Memory:    memory,
+		Threads:   threads,
+	}
+
+	return kdf, kdf.Validate()
+}
+
+// DeriveKeys получает ключи пользователя из мастер-пароля согласно версии схемы
+func DeriveKeys(user *model.User) (keys *Keys, err error) {
+	master, err := deriveKey(user)
 	if err != nil {
 		return nil, err
 	}
 
-	outBytes := r.Encrypt(inBytes)
+	keys = &Keys{}
+
+	if keys.Wrap, err = expandKey(master, hkdfInfoWrap); err != nil {
+		return nil, err
+	}
+
+	switch user.KDF.Version {
+	case model.KDFVersionLegacy:
+		keys.Vault = master
+	case model.KDFVersionSplitAuth:
+		if keys.Auth, err = expandKey(master, hkdfInfoAuth); err != nil {
+			return nil, err
+		}
+
+		keys.Vault = master
+	default:
+		if keys.Auth, err = expandKey(master, hkdfInfoAuth); err != nil {
+			return nil, err
+		}
+
+		if keys.Vault, err = expandKey(master, hkdfInfoVault); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+// AuthPassword возвращает значение, которое передаётся серверу вместо мастер-пароля,
+// для учётных записей KDFVersionLegacy это сам мастер-пароль
+func (k *Keys) AuthPassword(user *model.User) string {
+	if k.Auth == nil {
+		return user.Password
+	}
+
+	return hex.EncodeToString(k.Auth)
+}
+
+func expandKey(master []byte, info string) ([]byte, error) {
+	key := make([]byte, keyLength)
+
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, master, []byte(info)), key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// deriveKey получает ключ из мастер-пароля по параметрам KDF пользователя
+func deriveKey(user *model.User) ([]byte, error) {
+	if err := user.KDF.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch user.KDF.Algorithm {
+	case model.KDFArgon2id:
+		salt, err := hex.DecodeString(user.KDF.Salt)
+		if err != nil {
+			return nil, err
+		}
+
+		return argon2.IDKey([]byte(user.Password), salt, user.KDF.Time, user.KDF.Memory, user.KDF.Threads, keyLength), nil
+	default:
+		key := sha256.Sum256([]byte(user.Password))
+
+		return key[:], nil
+	}
+}
+
+// Encrypt шифрует масссив src случайным nonce со связанными данными ad и упаковывает результат в конверт
+func (r *CryptoService) Encrypt(src, ad []byte) ([]byte, error) {
+	nonceSize := r.aesgcm.NonceSize()
+
+	dst := make([]byte, envelopeHeader+nonceSize, envelopeHeader+nonceSize+len(src)+r.aesgcm.Overhead())
+	dst[0] = envelopeVersion2
+	dst[1] = algAES256GCM
+
+	nonce := dst[envelopeHeader:]
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return r.aesgcm.Seal(dst, nonce, src, ad), nil
+}
+
+// Decrypt расшифровывает масссив src в формате конверта со связанными данными ad.
+// Если ad не заданы, поддерживаются также конверт версии 1 и устаревший формат,
+// иначе шифротекст, не прошедший проверку, считается подменённым
+func (r *CryptoService) Decrypt(src, ad []byte) ([]byte, error) {
+	nonceSize := r.aesgcm.NonceSize()
+	enveloped := len(src) >= envelopeHeader+nonceSize+r.aesgcm.Overhead() && src[1] == algAES256GCM
+	nonce, sealed := src, src
 
-	dst = bytes.NewReader(outBytes)
+	if enveloped {
+		nonce, sealed = src[envelopeHeader:envelopeHeader+nonceSize], src[envelopeHeader+nonceSize:]
+	}
+
+	if enveloped && src[0] == envelopeVersion2 {
+		dst, err := r.aesgcm.Open(nil, nonce, sealed, ad)
+		if err == nil {
+			return dst, nil
+		}
+	}
+
+	if ad != nil {
+		return nil, ErrCryptoTampered
+	}
+
+	if enveloped && src[0] == envelopeVersion1 {
+		dst, err := r.aesgcm.Open(nil, nonce, sealed, nil)
+		if err == nil {
+			atomic.AddInt64(&r.legacyCount, 1)
+
+			return dst, nil
+		}
+	}
+
+	dst, err := r.aesgcm.Open(nil, r.legacyNonce, src, nil)
+	if err != nil {
+		return nil, ErrCryptoDecrypt
+	}
+
+	atomic.AddInt64(&r.legacyCount, 1)
 
 	return dst, nil
 }
 
-// DecryptFile шифрует файл src
-func (r *CryptoService) DecryptFile(src io.ReadCloser) (dst io.Reader, err error) {
-	inBytes, err := io.ReadAll(src)
+// LegacyCount возвращает количество расшифрованных шифротекстов в устаревшем формате
+func (r *CryptoService) LegacyCount() int64 {
+	return atomic.LoadInt64(&r.legacyCount)
+}
+
+// AcceptUnbound разрешает или запрещает расшифровку записей без идентификатора, сохранённых
+// прежними версиями клиента: такие записи расшифровываются только для перешифровки с идентификатором
+func (r *CryptoService) AcceptUnbound(accept bool) {
+	var unbound int32
+	if accept {
+		unbound = 1
+	}
+
+	atomic.StoreInt32(&r.unbound, unbound)
+}
+
+// acceptsUnbound сообщает, разрешена ли расшифровка записей без идентификатора
+func (r *CryptoService) acceptsUnbound() bool {
+	return atomic.LoadInt32(&r.unbound) == 1
+}
+
+// EncryptFile возвращает поток, который шифрует файл src по сегментам со связанными данными ad по мере чтения
+func (r *CryptoService) EncryptFile(src io.ReadCloser, ad []byte) (dst io.ReadCloser, err error) {
+	return newStreamEncryptReader(r.key, ad, src)
+}
+
+// DecryptFile возвращает поток, который расшифровывает файл src по сегментам по мере чтения.
+// Если связанные данные ad не заданы, поддерживаются также поток без связанных данных
+// и файлы в прежнем формате, которые расшифровываются целиком
+func (r *CryptoService) DecryptFile(src io.Reader, ad []byte) (dst io.Reader, err error) {
+	buffered := bufio.NewReaderSize(src, streamHeaderSize+streamChunkSize+r.aesgcm.Overhead()+1)
+
+	if r.isStream(buffered, streamVersionBound, ad) {
+		return newStreamDecryptReader(r.key, ad, buffered)
+	}
+
+	if ad != nil {
+		return nil, ErrCryptoTampered
+	}
+
+	if r.isStream(buffered, streamVersion, nil) {
+		atomic.AddInt64(&r.legacyCount, 1)
+
+		return newStreamDecryptReader(r.key, nil, buffered)
+	}
+
+	inBytes, err := io.ReadAll(buffered)
 	if err != nil {
 		return nil, err
 	}
 
-	outBytes, err := r.Decrypt(inBytes)
+	outBytes, err := r.Decrypt(inBytes, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	dst = bytes.NewReader(outBytes)
-
-	return dst, nil
+	return bytes.NewReader(outBytes), nil
 }
@@ -2,11 +2,15 @@ package service_test
 
 import (
 	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -32,8 +36,9 @@ func TestCryptoArray(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			cs, err := service.NewCryptoService(&tt.user)
 			require.Nil(t, err)
-			encrypted := cs.Encrypt([]byte(tt.message))
-			decrypted, err := cs.Decrypt(encrypted)
+			encrypted, err := cs.Encrypt([]byte(tt.message), nil)
+			require.Nil(t, err)
+			decrypted, err := cs.Decrypt(encrypted, nil)
 			require.Nil(t, err)
 			assert.Equal(t, tt.message, string(decrypted))
 		})
@@ -41,6 +46,104 @@ func TestCryptoArray(t *testing.T) {
 
 }
 
+func TestCryptoRandomNonce(t *testing.T) {
+	cs, err := service.NewCryptoService(&model.User{Username: "mark", Password: "superSecret"})
+	require.Nil(t, err)
+
+	first, err := cs.Encrypt([]byte("This is test message"), nil)
+	require.Nil(t, err)
+
+	second, err := cs.Encrypt([]byte("This is test message"), nil)
+	require.Nil(t, err)
+
+	assert.False(t, bytes.Equal(first, second))
+	assert.Equal(t, byte(2), first[0])
+	assert.Equal(t, int64(0), cs.LegacyCount())
+}
+
+func TestCryptoLegacy(t *testing.T) {
+	user := model.User{Username: "mark", Password: "superSecret"}
+	message := "This is test message"
+
+	key := sha256.Sum256([]byte(user.Password))
+	block, err := aes.NewCipher(key[:])
+	require.Nil(t, err)
+	aesgcm, err := cipher.NewGCM(block)
+	require.Nil(t, err)
+	legacy := aesgcm.Seal(nil, key[len(key)-aesgcm.NonceSize():], []byte(message), nil)
+
+	cs, err := service.NewCryptoService(&user)
+	require.Nil(t, err)
+
+	decrypted, err := cs.Decrypt(legacy, nil)
+	require.Nil(t, err)
+	assert.Equal(t, message, string(decrypted))
+	assert.Equal(t, int64(1), cs.LegacyCount())
+}
+
+func TestCryptoTampered(t *testing.T) {
+	cs, err := service.NewCryptoService(&model.User{Username: "mark", Password: "superSecret"})
+	require.Nil(t, err)
+
+	encrypted, err := cs.Encrypt([]byte("This is test message"), nil)
+	require.Nil(t, err)
+
+	encrypted[len(encrypted)-1] ^= 0xff
+
+	_, err = cs.Decrypt(encrypted, nil)
+	assert.ErrorIs(t, err, service.ErrCryptoDecrypt)
+}
+
+func TestCryptoArgon2id(t *testing.T) {
+	firstKDF, err := service.NewKDF(1, 64, 1)
+	require.Nil(t, err)
+
+	secondKDF, err := service.NewKDF(1, 64, 1)
+	require.Nil(t, err)
+	assert.NotEqual(t, firstKDF.Salt, secondKDF.Salt)
+
+	first, err := service.NewCryptoService(&model.User{Username: "mark", Password: "superSecret", KDF: firstKDF})
+	require.Nil(t, err)
+
+	second, err := service.NewCryptoService(&model.User{Username: "anna", Password: "superSecret", KDF: secondKDF})
+	require.Nil(t, err)
+
+	encrypted, err := first.Encrypt([]byte("This is test message"), nil)
+	require.Nil(t, err)
+
+	_, err = second.Decrypt(encrypted, nil)
+	assert.ErrorIs(t, err, service.ErrCryptoDecrypt)
+
+	decrypted, err := first.Decrypt(encrypted, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "This is test message", string(decrypted))
+}
+
+func TestDeriveKeys(t *testing.T) {
+	kdf, err := service.NewKDF(1, 64, 1)
+	require.Nil(t, err)
+	assert.Equal(t, model.KDFVersionSplitKeys, kdf.Version)
+
+	user := model.User{Username: "mark", Password: "superSecret", KDF: kdf}
+
+	keys, err := service.DeriveKeys(&user)
+	require.Nil(t, err)
+	assert.False(t, bytes.Equal(keys.Auth, keys.Vault))
+	assert.NotEqual(t, user.Password, keys.AuthPassword(&user))
+
+	user.KDF.Version = model.KDFVersionLegacy
+	legacyKeys, err := service.DeriveKeys(&user)
+	require.Nil(t, err)
+	assert.Equal(t, user.Password, legacyKeys.AuthPassword(&user))
+
+	user.KDF.Version = model.KDFVersionSplitAuth
+	splitAuthKeys, err := service.DeriveKeys(&user)
+	require.Nil(t, err)
+	assert.True(t, bytes.Equal(legacyKeys.Vault, splitAuthKeys.Vault))
+	assert.True(t, bytes.Equal(keys.Auth, splitAuthKeys.Auth))
+	assert.False(t, bytes.Equal(splitAuthKeys.Auth, splitAuthKeys.Vault))
+}
+
 func TestCryptoFile(t *testing.T) {
 	dir := filepath.Join(os.TempDir(), "gophkeeper_crypto_test")
 	os.RemoveAll(dir)
@@ -57,10 +160,10 @@ func TestCryptoFile(t *testing.T) {
 	cs, err := service.NewCryptoService(&model.User{Username: "mark", Password: "superSecret"})
 	assert.Nil(t, err)
 
-	encyptedDst, err := cs.EncryptFile(src)
+	encyptedDst, err := cs.EncryptFile(src, nil)
 	assert.Nil(t, err)
 
-	dst, err := cs.DecryptFile(io.NopCloser(encyptedDst))
+	dst, err := cs.DecryptFile(io.NopCloser(encyptedDst), nil)
 	assert.Nil(t, err)
 
 	srcBytes, err := ioutil.ReadAll(src)
@@ -71,3 +174,233 @@ func TestCryptoFile(t *testing.T) {
 
 	assert.True(t, bytes.Equal(srcBytes, dstBytes))
 }
+
+func TestCryptoStream(t *testing.T) {
+	cs, err := service.NewCryptoService(&model.User{Username: "mark", Password: "superSecret"})
+	require.Nil(t, err)
+
+	for _, size := range []int{0, 1, 64 * 1024, 64*1024 + 1, 3*64*1024 + 17} {
+		src := make([]byte, size)
+		_, err = rand.Read(src)
+		require.Nil(t, err)
+
+		encrypted, err := cs.EncryptFile(io.NopCloser(bytes.NewReader(src)), nil)
+		require.Nil(t, err)
+
+		encryptedBytes, err := io.ReadAll(encrypted)
+		require.Nil(t, err)
+
+		decrypted, err := cs.DecryptFile(bytes.NewReader(encryptedBytes), nil)
+		require.Nil(t, err)
+
+		decryptedBytes, err := io.ReadAll(decrypted)
+		require.Nil(t, err)
+		assert.True(t, bytes.Equal(src, decryptedBytes), "size %d", size)
+	}
+}
+
+func TestCryptoStreamTampered(t *testing.T) {
+	const (
+		headerSize = 18
+		chunkSize  = 64*1024 + 16
+	)
+
+	cs, err := service.NewCryptoService(&model.User{Username: "mark", Password: "superSecret"})
+	require.Nil(t, err)
+
+	src := make([]byte, 3*64*1024+17)
+	_, err = rand.Read(src)
+	require.Nil(t, err)
+
+	encrypted, err := cs.EncryptFile(io.NopCloser(bytes.NewReader(src)), nil)
+	require.Nil(t, err)
+
+	encryptedBytes, err := io.ReadAll(encrypted)
+	require.Nil(t, err)
+
+	decrypt := func(data []byte) error {
+		decrypted, err := cs.DecryptFile(bytes.NewReader(data), nil)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.ReadAll(decrypted)
+
+		return err
+	}
+
+	truncated := encryptedBytes[:headerSize+2*chunkSize]
+	assert.ErrorIs(t, decrypt(truncated), service.ErrCryptoStreamTruncated)
+
+	reordered := make([]byte, 0, len(encryptedBytes))
+	reordered = append(reordered, encryptedBytes[:headerSize]...)
+	reordered = append(reordered, encryptedBytes[headerSize:headerSize+chunkSize]...)
+	reordered = append(reordered, encryptedBytes[headerSize+2*chunkSize:headerSize+3*chunkSize]...)
+	reordered = append(reordered, encryptedBytes[headerSize+chunkSize:headerSize+2*chunkSize]...)
+	reordered = append(reordered, encryptedBytes[headerSize+3*chunkSize:]...)
+	assert.ErrorIs(t, decrypt(reordered), service.ErrCryptoDecrypt)
+}
+
+func TestCryptoFileEnvelope(t *testing.T) {
+	cs, err := service.NewCryptoService(&model.User{Username: "mark", Password: "superSecret"})
+	require.Nil(t, err)
+
+	encrypted, err := cs.Encrypt([]byte("This is test file"), nil)
+	require.Nil(t, err)
+
+	decrypted, err := cs.DecryptFile(bytes.NewReader(encrypted), nil)
+	require.Nil(t, err)
+
+	decryptedBytes, err := io.ReadAll(decrypted)
+	require.Nil(t, err)
+	assert.Equal(t, "This is test file", string(decryptedBytes))
+}
+
+func TestCryptoWrappedVaultKey(t *testing.T) {
+	kdf, err := service.NewKDF(1, 64, 1)
+	require.Nil(t, err)
+
+	user := model.User{Username: "mark", Password: "superSecret", KDF: kdf}
+
+	keys, err := service.DeriveKeys(&user)
+	require.Nil(t, err)
+
+	vaultKey, err := service.NewVaultKey()
+	require.Nil(t, err)
+
+	user.VaultKey, err = service.WrapKey(keys.Wrap, vaultKey)
+	require.Nil(t, err)
+
+	unwrapped, err := service.UnwrapKey(keys.Wrap, user.VaultKey)
+	require.Nil(t, err)
+	assert.True(t, bytes.Equal(vaultKey, unwrapped))
+
+	_, err = service.UnwrapKey(keys.Vault, user.VaultKey)
+	assert.ErrorIs(t, err, service.ErrCryptoDecrypt)
+
+	cs, err := service.NewCryptoService(&user)
+	require.Nil(t, err)
+
+	encrypted, err := cs.Encrypt([]byte("Hello, World!"), nil)
+	require.Nil(t, err)
+
+	changed := model.User{Username: "mark", Password: "newSecret", KDF: kdf}
+
+	changedKeys, err := service.DeriveKeys(&changed)
+	require.Nil(t, err)
+
+	changed.VaultKey, err = service.WrapKey(changedKeys.Wrap, vaultKey)
+	require.Nil(t, err)
+
+	changedCS, err := service.NewCryptoService(&changed)
+	require.Nil(t, err)
+
+	decrypted, err := changedCS.Decrypt(encrypted, nil)
+	require.Nil(t, err)
+	assert.Equal(t, []byte("Hello, World!"), decrypted)
+}
+
+func TestCryptoRecoveryKeys(t *testing.T) {
+	vaultKey, err := service.NewVaultKey()
+	require.Nil(t, err)
+
+	codes, keys, err := service.NewRecoveryKeys(vaultKey, 3)
+	require.Nil(t, err)
+	require.Len(t, codes, 3)
+	require.Len(t, keys, 3)
+	assert.NotEqual(t, codes[0], codes[1])
+
+	for i, code := range codes {
+		verifier, err := service.RecoveryVerifier(code)
+		require.Nil(t, err)
+		assert.Equal(t, keys[i].Verifier, verifier)
+
+		unwrapped, err := service.UnwrapRecoveryKey(code, keys[i].VaultKey)
+		require.Nil(t, err)
+		assert.True(t, bytes.Equal(vaultKey, unwrapped))
+	}
+
+	sloppy := strings.ToLower(strings.ReplaceAll(codes[0], "-", " "))
+
+	unwrapped, err := service.UnwrapRecoveryKey(sloppy, keys[0].VaultKey)
+	require.Nil(t, err)
+	assert.True(t, bytes.Equal(vaultKey, unwrapped))
+
+	_, err = service.UnwrapRecoveryKey(codes[1], keys[0].VaultKey)
+	assert.ErrorIs(t, err, service.ErrCryptoDecrypt)
+
+	_, err = service.RecoveryVerifier("ABCD-EFGH")
+	assert.ErrorIs(t, err, service.ErrCryptoInvalidRecoveryCode)
+}
+
+func TestCryptoAssociatedData(t *testing.T) {
+	user := model.User{Username: "mark", Password: "superSecret"}
+
+	cs, err := service.NewCryptoService(&user)
+	require.Nil(t, err)
+
+	password := []byte("gophkeeper\x00mark\x00login\x00a1b2\x00password")
+	metaInfo := []byte("gophkeeper\x00mark\x00login\x00a1b2\x00metainfo")
+
+	encrypted, err := cs.Encrypt([]byte("superSecret"), password)
+	require.Nil(t, err)
+
+	decrypted, err := cs.Decrypt(encrypted, password)
+	require.Nil(t, err)
+	assert.Equal(t, "superSecret", string(decrypted))
+
+	_, err = cs.Decrypt(encrypted, metaInfo)
+	assert.ErrorIs(t, err, service.ErrCryptoTampered)
+
+	_, err = cs.Decrypt(encrypted, nil)
+	assert.ErrorIs(t, err, service.ErrCryptoDecrypt)
+
+	key := sha256.Sum256([]byte(user.Password))
+	block, err := aes.NewCipher(key[:])
+	require.Nil(t, err)
+	aesgcm, err := cipher.NewGCM(block)
+	require.Nil(t, err)
+
+	nonce := make([]byte, aesgcm.NonceSize())
+	_, err = rand.Read(nonce)
+	require.Nil(t, err)
+
+	unbound := append([]byte{1, 1}, nonce...)
+	unbound = aesgcm.Seal(unbound, nonce, []byte("superSecret"), nil)
+
+	decrypted, err = cs.Decrypt(unbound, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "superSecret", string(decrypted))
+	assert.Equal(t, int64(1), cs.LegacyCount())
+
+	_, err = cs.Decrypt(unbound, password)
+	assert.ErrorIs(t, err, service.ErrCryptoTampered)
+}
+
+func TestCryptoStreamAssociatedData(t *testing.T) {
+	cs, err := service.NewCryptoService(&model.User{Username: "mark", Password: "superSecret"})
+	require.Nil(t, err)
+
+	first := []byte("gophkeeper\x00mark\x00file\x00a1b2\x00content")
+	second := []byte("gophkeeper\x00mark\x00file\x00c3d4\x00content")
+
+	src := make([]byte, 64*1024+17)
+	_, err = rand.Read(src)
+	require.Nil(t, err)
+
+	encrypted, err := cs.EncryptFile(io.NopCloser(bytes.NewReader(src)), first)
+	require.Nil(t, err)
+
+	encryptedBytes, err := io.ReadAll(encrypted)
+	require.Nil(t, err)
+
+	decrypted, err := cs.DecryptFile(bytes.NewReader(encryptedBytes), first)
+	require.Nil(t, err)
+
+	decryptedBytes, err := io.ReadAll(decrypted)
+	require.Nil(t, err)
+	assert.True(t, bytes.Equal(src, decryptedBytes))
+
+	_, err = cs.DecryptFile(bytes.NewReader(encryptedBytes), second)
+	assert.ErrorIs(t, err, service.ErrCryptoTampered)
+}
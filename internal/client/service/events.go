@@ -0,0 +1,105 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	eventsMinDelay    = time.Second
+	eventsMaxDelay    = 30 * time.Second
+	eventsIdleTimeout = 75 * time.Second // сервер отправляет ping каждые 30 секунд
+)
+
+// Events метод возвращает канал уведомлений об изменении хранилища пользователя на любом устройстве.
+// Уведомления приходят из потока /events, который переоткрывается после разрыва с нарастающей
+// задержкой. Уведомление отправляется и после каждого подключения, так как изменения могли быть
+// пропущены, непрочитанные уведомления объединяются в одно. Канал закрывается при завершении ctx
+func (s *httpService) Events(ctx context.Context) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+
+	go func() {
+		defer close(changes)
+
+		delay := eventsMinDelay
+
+		for {
+			connected, err := s.listenEvents(ctx, changes)
+			if ctx.Err() != nil {
+				return
+			}
+
+			if connected {
+				delay = eventsMinDelay
+			}
+
+			if err != nil {
+				s.mLogger.Warning(fmt.Sprintf("change notifications stream failed: %s", err))
+			}
+
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+
+			if delay *= 2; delay > eventsMaxDelay {
+				delay = eventsMaxDelay
+			}
+		}
+	}()
+
+	return changes
+}
+
+// listenEvents читает поток уведомлений до его закрытия сервером, сбоя соединения или завершения ctx.
+// Соединение разрывается, если сервер молчит дольше eventsIdleTimeout
+func (s *httpService) listenEvents(ctx context.Context, changes chan struct{}) (connected bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/events", http.NoBody)
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if err = checkStatusCode(resp.StatusCode, resp.Body); err != nil {
+		return false, err
+	}
+
+	idle := time.AfterFunc(eventsIdleTimeout, cancel)
+	defer idle.Stop()
+
+	notifyChange(changes)
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		idle.Reset(eventsIdleTimeout)
+
+		if strings.TrimSpace(scanner.Text()) == "event: change" {
+			notifyChange(changes)
+		}
+	}
+
+	return true, scanner.Err()
+}
+
+// notifyChange отправляет уведомление, если в канале нет непрочитанного
+func notifyChange(changes chan struct{}) {
+	select {
+	case changes <- struct{}{}:
+	default:
+	}
+}
@@ -3,7 +3,6 @@ package service
 import (
 	"bytes"
 	"context"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,9 +11,11 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 
 	"github.com/vukit/gophkeeper/internal/client/config"
 	"github.com/vukit/gophkeeper/internal/client/logger"
@@ -24,10 +25,34 @@ import (
 type httpService struct {
 	client  *http.Client
 	baseURL string
+	server  string
 	mLogger *logger.Logger
-	cs      *CryptoService
+	kdf     model.KDF
+	device  string
+	view    *vaultView
+
+	knownAccounts string
+
+	csMu sync.RWMutex
+	cs   *CryptoService
+
+	sessionMu sync.Mutex
+	session   bool
+	mfaToken  string
+}
+
+type signInResponse struct {
+	KDF      model.KDF `json:"kdf"`
+	VaultKey string    `json:"vault_key"`
+	MFAToken string    `json:"mfa_token"`
 }
 
+var (
+	ErrSessionExpired       = errors.New("session expired, please sign in again")
+	ErrSecondFactorRequired = errors.New("authentication code required")
+	ErrUpgradeRequired      = errors.New("account uses an old key derivation scheme and must be upgraded")
+)
+
 // NewHTTPService возваращает сервис с методам для обмена данными с сервером по HTTP протоколу
 func NewHTTPService(cfg *config.Config, mLogger *logger.Logger) *httpService {
 	service := &httpService{}
@@ -37,234 +62,1163 @@ func NewHTTPService(cfg *config.Config, mLogger *logger.Logger) *httpService {
 		log.Fatalf("Got error while creating cookie jar %s", err.Error())
 	}
 
-	service.client = &http.Client{Jar: jar}
+	tlsConfig, err := NewTLSConfig(cfg, mLogger)
+	if err != nil {
+		log.Fatalf("Got error while loading TLS configuration %s", err.Error())
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	service.client = &http.Client{Jar: jar, Transport: transport}
 
 	service.baseURL = fmt.Sprintf("%s://%s/api", cfg.ServerProtocol, cfg.ServerAddress)
 
+	service.server = cfg.ServerAddress
+
+	service.knownAccounts = cfg.KnownAccounts
+
 	service.mLogger = mLogger
 
+	service.device = cfg.DeviceName
+
+	service.view = newVaultView()
+
+	service.kdf = model.KDF{
+		Algorithm: model.KDFArgon2id,
+		Time:      uint32(cfg.KDFTime),
+		Memory:    uint32(cfg.KDFMemory),
+		Threads:   uint8(cfg.KDFThreads),
+	}
+
 	return service
 }
 
-// SetCryptoService устанавливает сервис симметричного шифрования
+// SetCryptoService устанавливает сервис симметричного шифрования, локальное представление
+// хранилища, расшифрованное прежним сервисом, очищается
 func (s *httpService) SetCryptoService(cs *CryptoService) {
+	s.view.mu.Lock()
+	defer s.view.mu.Unlock()
+
+	s.setCrypto(cs)
+	s.view.clear()
+}
+
+// crypto возвращает текущий сервис симметричного шифрования
+func (s *httpService) crypto() *CryptoService {
+	s.csMu.RLock()
+	defer s.csMu.RUnlock()
+
+	return s.cs
+}
+
+// setCrypto заменяет сервис симметричного шифрования. Вызывается под блокировкой view.mu,
+// чтобы синхронизация не расшифровала изменения сервисом, не соответствующим ключу хранилища
+func (s *httpService) setCrypto(cs *CryptoService) {
+	s.csMu.Lock()
+	defer s.csMu.Unlock()
+
 	s.cs = cs
 }
 
-// SignIn метод аутентификация пользователя
+// SignIn метод аутентификация пользователя: параметры получения ключей запрашиваются у сервера,
+// серверу передаётся только ключ аутентификации. Для учётной записи старше KDFVersionSplitKeys
+// после успешного входа возвращается ErrUpgradeRequired, и вход завершается методом UpgradeAccount.
+// Если у пользователя подключена двухфакторная аутентификация, возвращается ErrSecondFactorRequired,
+// и вход завершается методом SignInTOTP. Схема, которая старше запомненной для учётной записи,
+// отклоняется, см. KDFDowngradeError и LegacyAccountError
 func (s *httpService) SignIn(ctx context.Context, user *model.User) (err error) {
-	body := &bytes.Buffer{}
-	encoder := json.NewEncoder(body)
+	var preLogin struct {
+		KDF model.KDF `json:"kdf"`
+	}
 
-	err = encoder.Encode(user)
+	err = s.doJSON(ctx, http.MethodPost, "/prelogin", model.User{Username: user.Username}, &preLogin)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/signin", body)
+	user.KDF = preLogin.KDF
+
+	if err = s.checkAccountKDF(user); err != nil {
+		return err
+	}
+
+	keys, err := DeriveKeys(user)
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	answer := signInResponse{}
 
-	resp, err := s.client.Do(req)
+	err = s.doJSON(ctx, http.MethodPost, "/signin", model.User{Username: user.Username, Password: keys.AuthPassword(user)}, &answer)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	err = checkStatusCode(resp.StatusCode, resp.Body)
+	if answer.MFAToken != "" {
+		s.mfaToken = answer.MFAToken
+
+		return ErrSecondFactorRequired
+	}
+
+	return s.completeSignIn(ctx, user, answer)
+}
+
+// SignInTOTP метод второго шага аутентификации пользователя с подключённой двухфакторной
+// аутентификацией: серверу передаётся одноразовый пароль или резервный код code
+func (s *httpService) SignInTOTP(ctx context.Context, user *model.User, code string) (err error) {
+	answer := signInResponse{}
+
+	err = s.doJSON(ctx, http.MethodPost, "/signin/totp", model.TOTPSignIn{MFAToken: s.mfaToken, Code: code}, &answer)
 	if err != nil {
 		return err
 	}
 
+	s.mfaToken = ""
+
+	return s.completeSignIn(ctx, user, answer)
+}
+
+// completeSignIn завершает аутентификацию пользователя по ответу сервера answer
+func (s *httpService) completeSignIn(ctx context.Context, user *model.User, answer signInResponse) (err error) {
+	s.setSession(true)
+
+	user.KDF = answer.KDF
+	user.VaultKey = answer.VaultKey
+
+	if user.KDF.Version < model.KDFVersionSplitKeys || user.VaultKey == "" {
+		return ErrUpgradeRequired
+	}
+
+	s.rememberAccountKDF(user)
+
+	return nil
+}
+
+// UpgradeAccount метод перевода учётной записи, вход в которую завершился ErrUpgradeRequired,
+// на KDFVersionSplitKeys с новым ключом хранилища: прежние редакции записей и корзина зашифрованы
+// заменяемым ключом и удаляются сервером, коды восстановления возвращаются в user.RecoveryCodes
+func (s *httpService) UpgradeAccount(ctx context.Context, user *model.User) (err error) {
+	if err = s.upgradeCredential(ctx, user); err != nil {
+		return err
+	}
+
+	s.rememberAccountKDF(user)
+
 	return nil
 }
 
-// SignUp метод регистрации пользователя
+// SignUp метод регистрации пользователя: параметры получения ключей генерируются
+// со случайной солью, ключ хранилища и коды восстановления — случайные
 func (s *httpService) SignUp(ctx context.Context, user *model.User) (err error) {
-	body := &bytes.Buffer{}
-	encoder := json.NewEncoder(body)
+	user.KDF, err = NewKDF(s.kdf.Time, s.kdf.Memory, s.kdf.Threads)
+	if err != nil {
+		return err
+	}
 
-	err = encoder.Encode(user)
+	keys, err := DeriveKeys(user)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/signup", body)
+	vaultKey, err := NewVaultKey()
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	user.VaultKey, err = WrapKey(keys.Wrap, vaultKey)
+	if err != nil {
+		return err
+	}
 
-	resp, err := s.client.Do(req)
+	codes, recoveryKeys, err := NewRecoveryKeys(vaultKey, recoveryCodes)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	err = checkStatusCode(resp.StatusCode, resp.Body)
+	err = s.doJSON(ctx, http.MethodPost, "/signup", model.User{
+		Username:     user.Username,
+		Password:     keys.AuthPassword(user),
+		KDF:          user.KDF,
+		VaultKey:     user.VaultKey,
+		RecoveryKeys: recoveryKeys,
+	}, nil)
 	if err != nil {
 		return err
 	}
 
+	s.setSession(true)
+
+	s.rememberAccountKDF(user)
+
+	user.RecoveryCodes = codes
+
 	return nil
 }
 
-// SaveLogin метод сохранения данных логина пользователя
-func (s *httpService) SaveLogin(ctx context.Context, login *model.Login) (err error) {
-	login.Username = hex.EncodeToString((s.cs.Encrypt([]byte(login.Username))))
-	login.Password = hex.EncodeToString((s.cs.Encrypt([]byte(login.Password))))
-	login.MetaInfo = hex.EncodeToString((s.cs.Encrypt([]byte(login.MetaInfo))))
+// upgradeCredential переводит учётную запись со схемой старше KDFVersionSplitKeys на KDFVersionSplitKeys:
+// прежний ключ хранилища может оставаться в копиях базы данных, сделанных до перехода, поэтому все данные
+// перешифровываются новым случайным ключом хранилища, а пользователю выдаются коды восстановления
+func (s *httpService) upgradeCredential(ctx context.Context, user *model.User) (err error) {
+	cs, err := NewCryptoService(user)
+	if err != nil {
+		return err
+	}
 
-	body := &bytes.Buffer{}
-	encoder := json.NewEncoder(body)
+	cs.AcceptUnbound(true)
+	s.SetCryptoService(cs)
 
-	err = encoder.Encode(login)
+	upgraded := model.User{Username: user.Username, Password: user.Password}
+
+	upgraded.KDF, err = NewKDF(s.kdf.Time, s.kdf.Memory, s.kdf.Threads)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/logins", body)
+	user.RecoveryCodes, err = s.rekeyVault(ctx, user, &upgraded)
+
+	return err
+}
+
+// ChangePassword метод смены мастер-пароля: ключ хранилища шифруется ключом,
+// полученным из нового пароля, сами данные пользователя не перешифровываются
+func (s *httpService) ChangePassword(ctx context.Context, user *model.User, password, newPassword string) (err error) {
+	current := *user
+	current.Password = password
+
+	currentKeys, err := DeriveKeys(&current)
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	changed := model.User{Username: user.Username, Password: newPassword}
 
-	resp, err := s.client.Do(req)
+	changed.KDF, err = NewKDF(s.kdf.Time, s.kdf.Memory, s.kdf.Threads)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	err = checkStatusCode(resp.StatusCode, resp.Body)
-	if err != nil {
-		return err
+	keys, err := DeriveKeys(&changed)
+	if err != nil {
+		return err
+	}
+
+	changed.VaultKey, err = WrapKey(keys.Wrap, s.crypto().key)
+	if err != nil {
+		return err
+	}
+
+	credential := model.Credential{
+		Password:    currentKeys.AuthPassword(&current),
+		NewPassword: keys.AuthPassword(&changed),
+		KDF:         changed.KDF,
+		VaultKey:    changed.VaultKey,
+	}
+
+	if err = s.doJSON(ctx, http.MethodPost, "/credential", credential, nil); err != nil {
+		return err
+	}
+
+	user.Password = changed.Password
+	user.KDF = changed.KDF
+	user.VaultKey = changed.VaultKey
+
+	s.rememberAccountKDF(user)
+
+	return nil
+}
+
+// Recover метод восстановления доступа по коду восстановления: ключ хранилища, зашифрованный
+// кодом, расшифровывается на клиенте и шифруется ключом из нового мастер-пароля user.Password
+func (s *httpService) Recover(ctx context.Context, user *model.User, code string) (err error) {
+	verifier, err := RecoveryVerifier(code)
+	if err != nil {
+		return err
+	}
+
+	var answer struct {
+		VaultKey string `json:"vault_key"`
+	}
+
+	err = s.doJSON(ctx, http.MethodPost, "/recovery", model.Recovery{Username: user.Username, Verifier: verifier}, &answer)
+	if err != nil {
+		return err
+	}
+
+	vaultKey, err := UnwrapRecoveryKey(code, answer.VaultKey)
+	if err != nil {
+		return err
+	}
+
+	user.KDF, err = NewKDF(s.kdf.Time, s.kdf.Memory, s.kdf.Threads)
+	if err != nil {
+		return err
+	}
+
+	keys, err := DeriveKeys(user)
+	if err != nil {
+		return err
+	}
+
+	user.VaultKey, err = WrapKey(keys.Wrap, vaultKey)
+	if err != nil {
+		return err
+	}
+
+	err = s.doJSON(ctx, http.MethodPost, "/recovery/password", model.Recovery{
+		Username:    user.Username,
+		Verifier:    verifier,
+		NewPassword: keys.AuthPassword(user),
+		VaultKey:    user.VaultKey,
+		KDF:         &user.KDF,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	s.setSession(true)
+
+	s.rememberAccountKDF(user)
+
+	return nil
+}
+
+// Logout метод завершения текущего сеанса пользователя
+func (s *httpService) Logout(ctx context.Context) (err error) {
+	if !s.hasSession() {
+		return nil
+	}
+
+	if err = s.doJSON(ctx, http.MethodPost, "/logout", nil, nil); err != nil {
+		return err
+	}
+
+	s.setSession(false)
+
+	return nil
+}
+
+// LogoutAll метод завершения всех сеансов пользователя, включая текущий
+func (s *httpService) LogoutAll(ctx context.Context) (err error) {
+	if err = s.doJSON(ctx, http.MethodPost, "/logout/all", nil, nil); err != nil {
+		return err
+	}
+
+	s.setSession(false)
+
+	return nil
+}
+
+// EnrollTOTP метод начала подключения двухфакторной аутентификации, возвращает секрет
+// и ссылку otpauth для приложения-аутентификатора
+func (s *httpService) EnrollTOTP(ctx context.Context) (enrollment model.TOTPEnrollment, err error) {
+	err = s.doJSON(ctx, http.MethodPost, "/totp", nil, &enrollment)
+
+	return enrollment, err
+}
+
+// ConfirmTOTP метод подтверждения подключения двухфакторной аутентификации одноразовым паролем code,
+// возвращает резервные коды
+func (s *httpService) ConfirmTOTP(ctx context.Context, code string) (backupCodes []string, err error) {
+	var answer struct {
+		BackupCodes []string `json:"backup_codes"`
+	}
+
+	if err = s.doJSON(ctx, http.MethodPost, "/totp/confirm", model.TOTPCode{Code: code}, &answer); err != nil {
+		return nil, err
+	}
+
+	return answer.BackupCodes, nil
+}
+
+// DisableTOTP метод отключения двухфакторной аутентификации по одноразовому паролю или резервному коду code
+func (s *httpService) DisableTOTP(ctx context.Context, code string) (err error) {
+	return s.doJSON(ctx, http.MethodDelete, "/totp", model.TOTPCode{Code: code}, nil)
+}
+
+// CreateAPIToken метод создания токена API, возвращает секрет токена, который сервер больше не покажет
+func (s *httpService) CreateAPIToken(ctx context.Context, token model.APIToken) (secret string, err error) {
+	var answer struct {
+		Token string `json:"token"`
+	}
+
+	if err = s.doJSON(ctx, http.MethodPost, "/tokens", token, &answer); err != nil {
+		return "", err
+	}
+
+	return answer.Token, nil
+}
+
+// GetAPITokens метод возвращает токены API пользователя
+func (s *httpService) GetAPITokens(ctx context.Context) (tokens []model.APIToken, err error) {
+	tokens = make([]model.APIToken, 0)
+
+	err = s.doJSON(ctx, http.MethodGet, "/tokens", nil, &tokens)
+
+	return tokens, err
+}
+
+// RevokeAPIToken метод отзыва токена API
+func (s *httpService) RevokeAPIToken(ctx context.Context, token *model.APIToken) (err error) {
+	return s.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/tokens/%d", token.ID), nil, nil)
+}
+
+// NewRecoveryCodes метод заменяет коды восстановления пользователя новыми,
+// мастер-пароль password подтверждает замену
+func (s *httpService) NewRecoveryCodes(ctx context.Context, user *model.User, password string) (codes []string, err error) {
+	current := *user
+	current.Password = password
+
+	keys, err := DeriveKeys(&current)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, recoveryKeys, err := NewRecoveryKeys(s.crypto().key, recoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	change := model.RecoveryKeysChange{Password: keys.AuthPassword(&current), RecoveryKeys: recoveryKeys}
+
+	if err = s.doJSON(ctx, http.MethodPut, "/recovery/keys", change, nil); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// RotateVaultKey метод замены ключа хранилища: все данные пользователя перешифровываются
+// новым случайным ключом и заменяются на сервере одной операцией, прежние коды
+// восстановления заменяются новыми. Перешифрованное содержимое файлов загружается заранее,
+// файлам без идентификатора при этом назначается идентификатор. Прежние редакции записей
+// и корзина зашифрованы заменяемым ключом и удаляются сервером
+func (s *httpService) RotateVaultKey(ctx context.Context, user *model.User) (codes []string, err error) {
+	changed := *user
+
+	return s.rekeyVault(ctx, user, &changed)
+}
+
+// rekeyVault перешифровывает все данные пользователя user новым случайным ключом хранилища,
+// который шифруется ключом из учётных данных changed, и заменяет учётные данные на сервере
+func (s *httpService) rekeyVault(ctx context.Context, user, changed *model.User) (codes []string, err error) {
+	keys, err := DeriveKeys(user)
+	if err != nil {
+		return nil, err
+	}
+
+	changedKeys, err := DeriveKeys(changed)
+	if err != nil {
+		return nil, err
+	}
+
+	vaultKey, err := NewVaultKey()
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := newCryptoService(vaultKey, user.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := WrapKey(changedKeys.Wrap, vaultKey)
+	if err != nil {
+		return nil, err
+	}
+
+	change := model.PasswordChange{
+		Credential: model.Credential{
+			Password:    keys.AuthPassword(user),
+			NewPassword: changedKeys.AuthPassword(changed),
+			KDF:         changed.KDF,
+			VaultKey:    wrapped,
+		},
+	}
+
+	codes, change.RecoveryKeys, err = NewRecoveryKeys(vaultKey, recoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	if change.Revision, err = s.Sync(ctx); err != nil {
+		return nil, err
+	}
+
+	if change.Logins, err = s.GetLogins(ctx); err != nil {
+		return nil, err
+	}
+
+	if change.Cards, err = s.GetCards(ctx); err != nil {
+		return nil, err
+	}
+
+	if change.Notes, err = s.GetNotes(ctx); err != nil {
+		return nil, err
+	}
+
+	if change.OTPs, err = s.GetOTPs(ctx); err != nil {
+		return nil, err
+	}
+
+	if change.SSHKeys, err = s.GetSSHKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	if change.Files, err = s.GetFiles(ctx); err != nil {
+		return nil, err
+	}
+
+	if change.Items, err = s.GetItems(ctx); err != nil {
+		return nil, err
+	}
+
+	if change.Folders, err = s.GetFolders(ctx); err != nil {
+		return nil, err
+	}
+
+	if change.Tags, err = s.GetTags(ctx); err != nil {
+		return nil, err
+	}
+
+	if err = s.doJSON(ctx, http.MethodDelete, "/password/files", nil, nil); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			s.doJSON(ctx, http.MethodDelete, "/password/files", nil, nil)
+		}
+	}()
+
+	for i := range change.Logins {
+		if change.Logins[i], err = cs.encryptLogin(change.Logins[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.Cards {
+		if change.Cards[i], err = cs.encryptCard(change.Cards[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.Notes {
+		if change.Notes[i], err = cs.encryptNote(change.Notes[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.OTPs {
+		if change.OTPs[i], err = cs.encryptOTP(change.OTPs[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.SSHKeys {
+		if change.SSHKeys[i], err = cs.encryptSSHKey(change.SSHKeys[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.Items {
+		if change.Items[i], err = cs.encryptItem(change.Items[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.Folders {
+		if change.Folders[i], err = cs.encryptFolder(change.Folders[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.Tags {
+		if change.Tags[i], err = cs.encryptTag(change.Tags[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.Files {
+		bound := change.Files[i]
+
+		if bound.UID == "" {
+			if bound.UID, err = newItemUID(); err != nil {
+				return nil, err
+			}
+		}
+
+		if err = s.stageFile(ctx, &change.Files[i], cs, &bound); err != nil {
+			return nil, err
+		}
+
+		if change.Files[i], err = cs.encryptFileInfo(bound); err != nil {
+			return nil, err
+		}
+	}
+
+	s.view.mu.Lock()
+	defer s.view.mu.Unlock()
+
+	if err = s.doJSON(ctx, http.MethodPost, "/password", change, nil); err != nil {
+		return nil, err
+	}
+
+	s.setCrypto(cs)
+	user.KDF = changed.KDF
+	user.VaultKey = wrapped
+
+	return codes, nil
+}
+
+// stageFile загружает на сервер содержимое файла file, перешифрованное сервисом cs
+// как содержимое файла bound с назначенным идентификатором
+func (s *httpService) stageFile(ctx context.Context, file *model.File, cs *CryptoService, bound *model.File) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s%s%d", s.baseURL, "/files/", file.ID), &bytes.Buffer{})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = checkStatusCode(resp.StatusCode, resp.Body)
+	if err != nil {
+		return err
+	}
+
+	decryptedBody, err := s.crypto().decryptFileContent(resp.Body, file)
+	if err != nil {
+		return err
+	}
+
+	encryptedBody, err := cs.encryptFileContent(io.NopCloser(decryptedBody), bound)
+	if err != nil {
+		return err
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s%s%d", s.baseURL, "/password/files/", file.ID), encryptedBody)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	stageResp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer stageResp.Body.Close()
+
+	return checkStatusCode(stageResp.StatusCode, stageResp.Body)
+}
+
+// SaveLogin метод сохранения данных логина пользователя
+func (s *httpService) SaveLogin(ctx context.Context, login *model.Login) (err error) {
+	encrypted, err := s.crypto().encryptLogin(*login)
+	if err != nil {
+		return err
+	}
+
+	body := &bytes.Buffer{}
+	encoder := json.NewEncoder(body)
+
+	err = encoder.Encode(encrypted)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/logins", body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = checkStatusCode(resp.StatusCode, resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteLogin метод удаления данных логина пользователя
+func (s *httpService) DeleteLogin(ctx context.Context, login *model.Login) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s%s%d", s.baseURL, "/logins/", login.ID), &bytes.Buffer{})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = checkStatusCode(resp.StatusCode, resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetLogins метод возвращает данные логинов пользователя из локального представления хранилища,
+// синхронизированного с сервером
+func (s *httpService) GetLogins(ctx context.Context) (logins []model.Login, err error) {
+	values, err := s.syncedValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logins = make([]model.Login, 0)
+
+	for _, value := range values {
+		if login, ok := value.(model.Login); ok {
+			logins = append(logins, login)
+		}
+	}
+
+	return logins, nil
+}
+
+// SaveCard метод сохранения данных банковской карты пользователя
+func (s *httpService) SaveCard(ctx context.Context, card *model.Card) (err error) {
+	encrypted, err := s.crypto().encryptCard(*card)
+	if err != nil {
+		return err
+	}
+
+	body := &bytes.Buffer{}
+	encoder := json.NewEncoder(body)
+
+	err = encoder.Encode(encrypted)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/cards", body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = checkStatusCode(resp.StatusCode, resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteCard метод удаления данных банковской карты пользователя
+func (s *httpService) DeleteCard(ctx context.Context, card *model.Card) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s%s%d", s.baseURL, "/cards/", card.ID), &bytes.Buffer{})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = checkStatusCode(resp.StatusCode, resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetCards метод возвращает данные банковских карт пользователя из локального представления хранилища,
+// синхронизированного с сервером
+func (s *httpService) GetCards(ctx context.Context) (cards []model.Card, err error) {
+	values, err := s.syncedValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cards = make([]model.Card, 0)
+
+	for _, value := range values {
+		if card, ok := value.(model.Card); ok {
+			cards = append(cards, card)
+		}
+	}
+
+	return cards, nil
+}
+
+// SaveNote метод сохранения заметки пользователя
+func (s *httpService) SaveNote(ctx context.Context, note *model.Note) (err error) {
+	encrypted, err := s.crypto().encryptNote(*note)
+	if err != nil {
+		return err
+	}
+
+	return s.doJSON(ctx, http.MethodPost, "/notes", encrypted, nil)
+}
+
+// DeleteNote метод удаления заметки пользователя
+func (s *httpService) DeleteNote(ctx context.Context, note *model.Note) (err error) {
+	return s.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/notes/%d", note.ID), nil, nil)
+}
+
+// GetNotes метод возвращает заметки пользователя из локального представления хранилища,
+// синхронизированного с сервером
+func (s *httpService) GetNotes(ctx context.Context) (notes []model.Note, err error) {
+	values, err := s.syncedValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	notes = make([]model.Note, 0)
+
+	for _, value := range values {
+		if note, ok := value.(model.Note); ok {
+			notes = append(notes, note)
+		}
+	}
+
+	return notes, nil
+}
+
+// SaveOTP метод сохранения ключа одноразовых паролей пользователя
+func (s *httpService) SaveOTP(ctx context.Context, otp *model.OTP) (err error) {
+	encrypted, err := s.crypto().encryptOTP(*otp)
+	if err != nil {
+		return err
+	}
+
+	return s.doJSON(ctx, http.MethodPost, "/otps", encrypted, nil)
+}
+
+// DeleteOTP метод удаления ключа одноразовых паролей пользователя
+func (s *httpService) DeleteOTP(ctx context.Context, otp *model.OTP) (err error) {
+	return s.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/otps/%d", otp.ID), nil, nil)
+}
+
+// GetOTPs метод возвращает ключи одноразовых паролей пользователя из локального представления хранилища,
+// синхронизированного с сервером
+func (s *httpService) GetOTPs(ctx context.Context) (otps []model.OTP, err error) {
+	values, err := s.syncedValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	otps = make([]model.OTP, 0)
+
+	for _, value := range values {
+		if otp, ok := value.(model.OTP); ok {
+			otps = append(otps, otp)
+		}
+	}
+
+	return otps, nil
+}
+
+// SaveSSHKey метод сохранения ключа SSH пользователя
+func (s *httpService) SaveSSHKey(ctx context.Context, key *model.SSHKey) (err error) {
+	encrypted, err := s.crypto().encryptSSHKey(*key)
+	if err != nil {
+		return err
+	}
+
+	return s.doJSON(ctx, http.MethodPost, "/sshkeys", encrypted, nil)
+}
+
+// DeleteSSHKey метод удаления ключа SSH пользователя
+func (s *httpService) DeleteSSHKey(ctx context.Context, key *model.SSHKey) (err error) {
+	return s.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/sshkeys/%d", key.ID), nil, nil)
+}
+
+// GetSSHKeys метод возвращает ключи SSH пользователя из локального представления хранилища,
+// синхронизированного с сервером
+func (s *httpService) GetSSHKeys(ctx context.Context) (keys []model.SSHKey, err error) {
+	values, err := s.syncedValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys = make([]model.SSHKey, 0)
+
+	for _, value := range values {
+		if key, ok := value.(model.SSHKey); ok {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// SaveItem метод сохранения записи пользователя произвольной структуры
+func (s *httpService) SaveItem(ctx context.Context, item *model.Item) (err error) {
+	if item.Type == "" {
+		item.Type = model.ItemTypeCustom
+	}
+
+	encrypted, err := s.crypto().encryptItem(*item)
+	if err != nil {
+		return err
+	}
+
+	return s.doJSON(ctx, http.MethodPost, "/items", encrypted, nil)
+}
+
+// DeleteItem метод удаления записи пользователя произвольной структуры
+func (s *httpService) DeleteItem(ctx context.Context, item *model.Item) (err error) {
+	return s.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/items/%d", item.ID), nil, nil)
+}
+
+// GetItems метод возвращает записи пользователя произвольной структуры из локального представления хранилища,
+// синхронизированного с сервером
+func (s *httpService) GetItems(ctx context.Context) (items []model.Item, err error) {
+	values, err := s.syncedValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items = make([]model.Item, 0)
+
+	for _, value := range values {
+		if item, ok := value.(model.Item); ok {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// Sync метод получает изменения записей пользователя после последней синхронизации и применяет их
+// к локальному представлению хранилища, возвращает текущую ревизию синхронизации
+func (s *httpService) Sync(ctx context.Context) (revision int64, err error) {
+	s.view.mu.Lock()
+	defer s.view.mu.Unlock()
+
+	changes := model.Sync{}
+
+	if err = s.doJSON(ctx, http.MethodGet, fmt.Sprintf("/sync?since=%d", s.view.since()), nil, &changes); err != nil {
+		return 0, err
+	}
+
+	if err = s.view.apply(changes, s.crypto()); err != nil {
+		return 0, err
+	}
+
+	return changes.Revision, nil
+}
+
+// syncedValues возвращает записи локального представления хранилища после синхронизации
+func (s *httpService) syncedValues(ctx context.Context) (values []interface{}, err error) {
+	if _, err = s.Sync(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.view.values(), nil
+}
+
+// GetRevisions метод возвращает прежние редакции записи пользователя itemID, начиная с последней
+func (s *httpService) GetRevisions(ctx context.Context, itemID int) (revisions []model.Revision, err error) {
+	revisions = make([]model.Revision, 0)
+
+	if err = s.doJSON(ctx, http.MethodGet, fmt.Sprintf("/revisions/%d", itemID), nil, &revisions); err != nil {
+		return nil, err
+	}
+
+	decrypted := revisions[:0]
+
+	for i := range revisions {
+		err = s.crypto().decryptRevision(&revisions[i])
+		if errors.Is(err, ErrCryptoUnbound) {
+			s.mLogger.Warning(fmt.Sprintf("revision with id = %d is not bound to its identifier and skipped", revisions[i].ID))
+
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error decrypted revision with id = %d: %w", revisions[i].ID, err)
+		}
+
+		decrypted = append(decrypted, revisions[i])
+	}
+
+	return decrypted, nil
+}
+
+// RestoreRevision метод восстановления прежней редакции записи пользователя
+func (s *httpService) RestoreRevision(ctx context.Context, revision *model.Revision) (err error) {
+	return s.doJSON(ctx, http.MethodPost, fmt.Sprintf("/revisions/%d/%d", revision.ItemID, revision.ID), nil, nil)
+}
+
+// GetTrash метод возвращает записи пользователя в корзине, начиная с последней удалённой
+func (s *httpService) GetTrash(ctx context.Context) (items []model.TrashItem, err error) {
+	items = make([]model.TrashItem, 0)
+
+	if err = s.doJSON(ctx, http.MethodGet, "/trash", nil, &items); err != nil {
+		return nil, err
+	}
+
+	decrypted := items[:0]
+
+	for i := range items {
+		err = s.crypto().decryptTrashItem(&items[i])
+		if errors.Is(err, ErrCryptoUnbound) {
+			s.mLogger.Warning(fmt.Sprintf("trash item with id = %d is not bound to its identifier and skipped", items[i].ID))
+
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error decrypted trash item with id = %d: %w", items[i].ID, err)
+		}
+
+		decrypted = append(decrypted, items[i])
 	}
 
-	return nil
+	return decrypted, nil
 }
 
-// DeleteLogin метод удаления данных логина пользователя
-func (s *httpService) DeleteLogin(ctx context.Context, login *model.Login) (err error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s%s%d", s.baseURL, "/logins/", login.ID), &bytes.Buffer{})
-	if err != nil {
-		return err
-	}
+// RestoreTrash метод восстановления записи пользователя из корзины
+func (s *httpService) RestoreTrash(ctx context.Context, item *model.TrashItem) (err error) {
+	return s.doJSON(ctx, http.MethodPost, fmt.Sprintf("/trash/%d", item.ID), nil, nil)
+}
 
-	resp, err := s.client.Do(req)
+// SaveFolder метод сохранения папки записей пользователя
+func (s *httpService) SaveFolder(ctx context.Context, folder *model.Folder) (err error) {
+	encrypted, err := s.crypto().encryptFolder(*folder)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	err = checkStatusCode(resp.StatusCode, resp.Body)
-	if err != nil {
-		return err
-	}
+	return s.doJSON(ctx, http.MethodPost, "/folders", encrypted, nil)
+}
 
-	return nil
+// DeleteFolder метод удаления папки записей пользователя
+func (s *httpService) DeleteFolder(ctx context.Context, folder *model.Folder) (err error) {
+	return s.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/folders/%d", folder.ID), nil, nil)
 }
 
-// GetLogins метод возвращает данные логинов пользователя
-func (s *httpService) GetLogins(ctx context.Context) (logins []model.Login, err error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/logins", &bytes.Buffer{})
-	if err != nil {
+// GetFolders метод возвращает папки записей пользователя
+func (s *httpService) GetFolders(ctx context.Context) (folders []model.Folder, err error) {
+	folders = make([]model.Folder, 0)
+
+	if err = s.doJSON(ctx, http.MethodGet, "/folders", nil, &folders); err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, err
+	for i := range folders {
+		if err = s.crypto().decryptFolder(&folders[i]); err != nil {
+			return nil, fmt.Errorf("error decrypted folder with id = %d: %w", folders[i].ID, err)
+		}
 	}
-	defer resp.Body.Close()
 
-	err = checkStatusCode(resp.StatusCode, resp.Body)
+	return folders, nil
+}
+
+// SaveTag метод сохранения метки записей пользователя
+func (s *httpService) SaveTag(ctx context.Context, tag *model.Tag) (err error) {
+	encrypted, err := s.crypto().encryptTag(*tag)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	decoder := json.NewDecoder(resp.Body)
-	logins = make([]model.Login, 0)
+	return s.doJSON(ctx, http.MethodPost, "/tags", encrypted, nil)
+}
 
-	err = decoder.Decode(&logins)
-	if err != nil {
+// DeleteTag метод удаления метки записей пользователя
+func (s *httpService) DeleteTag(ctx context.Context, tag *model.Tag) (err error) {
+	return s.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/tags/%d", tag.ID), nil, nil)
+}
+
+// GetTags метод возвращает метки записей пользователя
+func (s *httpService) GetTags(ctx context.Context) (tags []model.Tag, err error) {
+	tags = make([]model.Tag, 0)
+
+	if err = s.doJSON(ctx, http.MethodGet, "/tags", nil, &tags); err != nil {
 		return nil, err
 	}
 
-	for i := 0; i < len(logins); i++ {
-		data, err := hex.DecodeString(logins[i].Username)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted login with id = %d: %w", logins[i].ID, err)
+	for i := range tags {
+		if err = s.crypto().decryptTag(&tags[i]); err != nil {
+			return nil, fmt.Errorf("error decrypted tag with id = %d: %w", tags[i].ID, err)
 		}
+	}
 
-		data, err = s.cs.Decrypt(data)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted login with id = %d: %w", logins[i].ID, err)
-		}
+	return tags, nil
+}
 
-		logins[i].Username = string(data)
+// SaveFile метод сохранения данных файла пользователя, содержимое файла шифруется
+// и отправляется на сервер потоком без загрузки в память целиком
+func (s *httpService) SaveFile(ctx context.Context, file *model.File) (err error) {
+	info := model.File{ID: file.ID, UID: file.UID, MetaInfo: file.MetaInfo, Fields: file.Fields, Placement: file.Placement}
 
-		data, err = hex.DecodeString(logins[i].Password)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted login with id = %d: %w", logins[i].ID, err)
-		}
+	var src *os.File
 
-		data, err = s.cs.Decrypt(data)
+	if _, err = os.Stat(file.Path); err == nil {
+		src, err = os.Open(file.Path)
 		if err != nil {
-			return nil, fmt.Errorf("error decrypted login with id = %d: %w", logins[i].ID, err)
+			return err
 		}
+		defer src.Close()
 
-		logins[i].Password = string(data)
-
-		data, err = hex.DecodeString(logins[i].MetaInfo)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted login with id = %d: %w", logins[i].ID, err)
-		}
+		info.Name = filepath.Base(src.Name())
 
-		data, err = s.cs.Decrypt(data)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted login with id = %d: %w", logins[i].ID, err)
+		if info.UID == "" {
+			if info.UID, err = newItemUID(); err != nil {
+				return err
+			}
 		}
+	}
 
-		logins[i].MetaInfo = string(data)
+	encrypted, err := s.crypto().encryptFileInfo(info)
+	if err != nil {
+		return err
 	}
 
-	return logins, nil
-}
+	if src == nil {
+		encrypted.Name = ""
+	}
 
-// SaveCard метод сохранения данных банковской карты пользователя
-func (s *httpService) SaveCard(ctx context.Context, card *model.Card) (err error) {
-	card.Bank = hex.EncodeToString((s.cs.Encrypt([]byte(card.Bank))))
-	card.Number = hex.EncodeToString((s.cs.Encrypt([]byte(card.Number))))
-	card.Date = hex.EncodeToString((s.cs.Encrypt([]byte(card.Date))))
-	card.CVV = hex.EncodeToString((s.cs.Encrypt([]byte(card.CVV))))
-	card.MetaInfo = hex.EncodeToString((s.cs.Encrypt([]byte(card.MetaInfo))))
+	body, pipeWriter := io.Pipe()
+	writer := multipart.NewWriter(pipeWriter)
 
-	body := &bytes.Buffer{}
-	encoder := json.NewEncoder(body)
+	go func() {
+		pipeWriter.CloseWithError(s.writeFileForm(writer, encrypted, src))
+	}()
 
-	err = encoder.Encode(card)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/files", body)
 	if err != nil {
-		return err
-	}
+		body.Close()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/cards", body)
-	if err != nil {
 		return err
 	}
 
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Add("Content-Type", writer.FormDataContentType())
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return err
 	}
+
 	defer resp.Body.Close()
 
 	err = checkStatusCode(resp.StatusCode, resp.Body)
@@ -275,142 +1229,51 @@ func (s *httpService) SaveCard(ctx context.Context, card *model.Card) (err error
 	return nil
 }
 
-// DeleteCard метод удаления данных банковской карты пользователя
-func (s *httpService) DeleteCard(ctx context.Context, card *model.Card) (err error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s%s%d", s.baseURL, "/cards/", card.ID), &bytes.Buffer{})
+// writeFileForm записывает поля формы сохранения файла с зашифрованными данными encrypted,
+// содержимое файла src, если оно передано, записывается последним и шифруется по мере отправки
+func (s *httpService) writeFileForm(writer *multipart.Writer, encrypted model.File, src *os.File) (err error) {
+	err = writer.WriteField("id", strconv.Itoa(encrypted.ID))
 	if err != nil {
 		return err
 	}
 
-	resp, err := s.client.Do(req)
+	err = writer.WriteField("metainfo", encrypted.MetaInfo)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	err = checkStatusCode(resp.StatusCode, resp.Body)
+	fields, err := json.Marshal(encrypted.Fields)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
-
-// GetCards метод возвращает данные банковских карт пользователя
-func (s *httpService) GetCards(ctx context.Context) (cards []model.Card, err error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/cards", &bytes.Buffer{})
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-
-	resp, err := s.client.Do(req)
+	err = writer.WriteField("fields", string(fields))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer resp.Body.Close()
 
-	err = checkStatusCode(resp.StatusCode, resp.Body)
+	placement, err := json.Marshal(encrypted.Placement)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	decoder := json.NewDecoder(resp.Body)
-	cards = make([]model.Card, 0)
-
-	err = decoder.Decode(&cards)
+	err = writer.WriteField("placement", string(placement))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	for i := 0; i < len(cards); i++ {
-		data, err := hex.DecodeString(cards[i].Bank)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted card with id = %d: %w", cards[i].ID, err)
-		}
-
-		data, err = s.cs.Decrypt(data)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted card with id = %d: %w", cards[i].ID, err)
-		}
-
-		cards[i].Bank = string(data)
-
-		data, err = hex.DecodeString(cards[i].Number)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted card with id = %d: %w", cards[i].ID, err)
-		}
-
-		data, err = s.cs.Decrypt(data)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted card with id = %d: %w", cards[i].ID, err)
-		}
-
-		cards[i].Number = string(data)
-
-		data, err = hex.DecodeString(cards[i].Date)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted card with id = %d: %w", cards[i].ID, err)
-		}
-
-		data, err = s.cs.Decrypt(data)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted card with id = %d: %w", cards[i].ID, err)
-		}
-
-		cards[i].Date = string(data)
-
-		data, err = hex.DecodeString(cards[i].CVV)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted card with id = %d: %w", cards[i].ID, err)
-		}
-
-		data, err = s.cs.Decrypt(data)
+	if src != nil {
+		err = writer.WriteField("uid", encrypted.UID)
 		if err != nil {
-			return nil, fmt.Errorf("error decrypted card with id = %d: %w", cards[i].ID, err)
-		}
-
-		cards[i].CVV = string(data)
-
-		data, err = hex.DecodeString(cards[i].MetaInfo)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted card with id = %d: %w", cards[i].ID, err)
-		}
-
-		data, err = s.cs.Decrypt(data)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted card with id = %d: %w", cards[i].ID, err)
+			return err
 		}
 
-		cards[i].MetaInfo = string(data)
-	}
-
-	return cards, nil
-}
-
-// SaveFile метод сохранения данных файла пользователя
-func (s *httpService) SaveFile(ctx context.Context, file *model.File) (err error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	var (
-		part          io.Writer
-		encryptedFile io.Reader
-	)
-
-	if _, err = os.Stat(file.Path); err == nil {
-		src, _ := os.Open(file.Path)
-		defer src.Close()
-
-		filename := hex.EncodeToString((s.cs.Encrypt([]byte(filepath.Base(src.Name())))))
-
-		part, err = writer.CreateFormFile("file", filename)
+		part, err := writer.CreateFormFile("file", encrypted.Name)
 		if err != nil {
 			return err
 		}
 
-		encryptedFile, err = s.cs.EncryptFile(src)
+		encryptedFile, err := s.crypto().encryptFileContent(src, &encrypted)
 		if err != nil {
 			return err
 		}
@@ -421,42 +1284,60 @@ func (s *httpService) SaveFile(ctx context.Context, file *model.File) (err error
 		}
 	}
 
-	part, err = writer.CreateFormField("id")
+	return writer.Close()
+}
+
+// DeleteFile метод удаления данных файла пользователя
+func (s *httpService) DeleteFile(ctx context.Context, file *model.File) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s%s%d", s.baseURL, "/files/", file.ID), &bytes.Buffer{})
 	if err != nil {
 		return err
 	}
 
-	_, err = part.Write([]byte(strconv.Itoa(file.ID)))
+	resp, err := s.do(req)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
-	part, err = writer.CreateFormField("metainfo")
+	err = checkStatusCode(resp.StatusCode, resp.Body)
 	if err != nil {
 		return err
 	}
 
-	metaInfo := hex.EncodeToString((s.cs.Encrypt([]byte(file.MetaInfo))))
+	return nil
+}
 
-	_, err = part.Write([]byte(metaInfo))
+// GetFiles метод возвращает данные файлов пользователя из локального представления хранилища,
+// синхронизированного с сервером
+func (s *httpService) GetFiles(ctx context.Context) (files []model.File, err error) {
+	values, err := s.syncedValues(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	writer.Close()
+	files = make([]model.File, 0)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/files", body)
-	if err != nil {
-		return err
+	for _, value := range values {
+		if file, ok := value.(model.File); ok {
+			files = append(files, file)
+		}
 	}
 
-	req.Header.Add("Content-Type", writer.FormDataContentType())
+	return files, nil
+}
 
-	resp, err := s.client.Do(req)
+// DownloadFile метод скачивания файла пользователя
+func (s *httpService) DownloadFile(ctx context.Context, file *model.File, downloadFolder string) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s%s%d", s.baseURL, "/files/", file.ID), &bytes.Buffer{})
 	if err != nil {
 		return err
 	}
 
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
 	defer resp.Body.Close()
 
 	err = checkStatusCode(resp.StatusCode, resp.Body)
@@ -464,90 +1345,124 @@ func (s *httpService) SaveFile(ctx context.Context, file *model.File) (err error
 		return err
 	}
 
-	return nil
-}
+	dstPath := filepath.Join(downloadFolder, file.Name)
 
-// DeleteFile метод удаления данных файла пользователя
-func (s *httpService) DeleteFile(ctx context.Context, file *model.File) (err error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s%s%d", s.baseURL, "/files/", file.ID), &bytes.Buffer{})
+	dst, err := os.Create(dstPath)
 	if err != nil {
 		return err
 	}
+	defer dst.Close()
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return err
+	decryptedBody, err := s.crypto().decryptFileContent(resp.Body, file)
+	if err == nil {
+		_, err = io.Copy(dst, decryptedBody)
 	}
-	defer resp.Body.Close()
 
-	err = checkStatusCode(resp.StatusCode, resp.Body)
 	if err != nil {
+		dst.Close()
+		os.Remove(dstPath)
+
 		return err
 	}
 
 	return nil
 }
 
-// GetFiles метод возвращает данные файлов пользователя
-func (s *httpService) GetFiles(ctx context.Context) (files []model.File, err error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/files", &bytes.Buffer{})
+// doJSON отправляет запрос с телом src в формате JSON и декодирует ответ в dst, если dst не nil
+func (s *httpService) doJSON(ctx context.Context, method, path string, src, dst interface{}) (err error) {
+	body := &bytes.Buffer{}
+
+	if src != nil {
+		encoder := json.NewEncoder(body)
+
+		err = encoder.Encode(src)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, body)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	err = checkStatusCode(resp.StatusCode, resp.Body)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	if dst == nil {
+		return nil
 	}
 
 	decoder := json.NewDecoder(resp.Body)
-	files = make([]model.File, 0)
 
-	err = decoder.Decode(&files)
-	if err != nil {
-		return nil, err
+	return decoder.Decode(dst)
+}
+
+// do отправляет запрос req. Токен доступа с истёкшим сроком действия предварительно обновляется,
+// запрос, отклонённый из-за недействительного токена, повторяется после обновления, если его тело
+// можно отправить повторно
+func (s *httpService) do(req *http.Request) (*http.Response, error) {
+	if s.device != "" {
+		req.Header.Set("X-Device", s.device)
 	}
 
-	for i := 0; i < len(files); i++ {
-		data, err := hex.DecodeString(files[i].MetaInfo)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted file with id = %d: %w", files[i].ID, err)
-		}
+	token := s.accessToken()
 
-		data, err = s.cs.Decrypt(data)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted file with id = %d: %w", files[i].ID, err)
+	if token == "" && s.hasSession() {
+		if err := s.refresh(req.Context(), token); err != nil {
+			return nil, err
 		}
 
-		files[i].MetaInfo = string(data)
+		token = s.accessToken()
+	}
 
-		data, err = hex.DecodeString(files[i].Name)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted file with id = %d: %w", files[i].ID, err)
-		}
+	resp, err := s.client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") == "" ||
+		req.GetBody == nil || !s.hasSession() {
+		return resp, err
+	}
 
-		data, err = s.cs.Decrypt(data)
-		if err != nil {
-			return nil, fmt.Errorf("error decrypted file with id = %d: %w", files[i].ID, err)
-		}
+	resp.Body.Close()
 
-		files[i].Name = string(data)
+	if err = s.refresh(req.Context(), token); err != nil {
+		return nil, err
 	}
 
-	return files, nil
+	retry := req.Clone(req.Context())
+	retry.Header.Del("Cookie")
+
+	retry.Body, err = req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.client.Do(retry)
 }
 
-// DownloadFile метод скачивания файла пользователя
-func (s *httpService) DownloadFile(ctx context.Context, file *model.File, downloadFolder string) (err error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s%s%d", s.baseURL, "/files/", file.ID), &bytes.Buffer{})
+// refresh обновляет токены сеанса, если токен доступа stale не был обновлён другим запросом
+func (s *httpService) refresh(ctx context.Context, stale string) (err error) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	if !s.session {
+		return ErrSessionExpired
+	}
+
+	if token := s.accessToken(); token != "" && token != stale {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/refresh", http.NoBody)
 	if err != nil {
 		return err
 	}
@@ -558,28 +1473,43 @@ func (s *httpService) DownloadFile(ctx context.Context, file *model.File, downlo
 	}
 	defer resp.Body.Close()
 
-	err = checkStatusCode(resp.StatusCode, resp.Body)
-	if err != nil {
-		return err
-	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		s.session = false
 
-	dst, err := os.Create(filepath.Join(downloadFolder, file.Name))
-	if err != nil {
-		return err
+		return ErrSessionExpired
 	}
-	defer dst.Close()
 
-	decryptedBody, err := s.cs.DecryptFile(resp.Body)
+	return checkStatusCode(resp.StatusCode, resp.Body)
+}
+
+// accessToken возвращает текущий токен доступа или пустую строку, если срок его действия истёк
+func (s *httpService) accessToken() string {
+	u, err := url.Parse(s.baseURL)
 	if err != nil {
-		return err
+		return ""
 	}
 
-	_, err = io.Copy(dst, decryptedBody)
-	if err != nil {
-		return err
+	for _, cookie := range s.client.Jar.Cookies(u) {
+		if cookie.Name == "jwt" {
+			return cookie.Value
+		}
 	}
 
-	return nil
+	return ""
+}
+
+func (s *httpService) hasSession() bool {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	return s.session
+}
+
+func (s *httpService) setSession(session bool) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	s.session = session
 }
 
 func checkStatusCode(statusCode int, body io.ReadCloser) error {
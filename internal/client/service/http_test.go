@@ -1,9 +1,400 @@
 package service_test
 
 import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vukit/gophkeeper/internal/client"
+	"github.com/vukit/gophkeeper/internal/client/config"
+	"github.com/vukit/gophkeeper/internal/client/logger"
+	"github.com/vukit/gophkeeper/internal/client/model"
+	"github.com/vukit/gophkeeper/internal/client/service"
 )
 
 func TestHTTPService(t *testing.T) {
 	t.Skip() // проверятся интеграционным тестом
 }
+
+// fakeVault хранилище логинов тестового сервера, passwords — пароли, полученные при входе
+type fakeVault struct {
+	mu        sync.Mutex
+	revision  int64
+	logins    []json.RawMessage
+	user      model.User
+	passwords []string
+}
+
+func (v *fakeVault) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/signup", func(w http.ResponseWriter, r *http.Request) {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		if err := json.NewDecoder(r.Body).Decode(&v.user); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: "jwt", Value: "token", Path: "/"})
+		fmt.Fprint(w, "{}")
+	})
+
+	mux.HandleFunc("/api/prelogin", func(w http.ResponseWriter, r *http.Request) {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"kdf": v.user.KDF})
+	})
+
+	mux.HandleFunc("/api/signin", func(w http.ResponseWriter, r *http.Request) {
+		user := model.User{}
+		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		v.passwords = append(v.passwords, user.Password)
+
+		if user.Password != v.user.Password {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: "jwt", Value: "token", Path: "/"})
+		json.NewEncoder(w).Encode(map[string]interface{}{"kdf": v.user.KDF, "vault_key": v.user.VaultKey})
+	})
+
+	mux.HandleFunc("/api/logins", func(w http.ResponseWriter, r *http.Request) {
+		login := json.RawMessage{}
+		if err := json.NewDecoder(r.Body).Decode(&login); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		saved := model.Login{}
+		if err := json.Unmarshal(login, &saved); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		if saved.ID > 0 && saved.ID <= len(v.logins) {
+			v.logins[saved.ID-1] = login
+		} else {
+			v.logins = append(v.logins, login)
+		}
+
+		v.revision++
+
+		fmt.Fprint(w, "{}")
+	})
+
+	mux.HandleFunc("/api/sync", func(w http.ResponseWriter, r *http.Request) {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		changes := model.Sync{Revision: v.revision, Reset: true}
+
+		for i, data := range v.logins {
+			login := model.Login{}
+			if err := json.Unmarshal(data, &login); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			changes.Items = append(changes.Items, model.SyncItem{ID: i + 1, UID: login.UID, Type: model.ItemTypeLogin, Data: data})
+		}
+
+		json.NewEncoder(w).Encode(changes)
+	})
+
+	mux.HandleFunc("/api/folders", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "[]") })
+	mux.HandleFunc("/api/tags", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "[]") })
+	mux.HandleFunc("/api/password/files", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "{}") })
+
+	mux.HandleFunc("/api/password", func(w http.ResponseWriter, r *http.Request) {
+		change := struct {
+			Logins   []json.RawMessage `json:"logins"`
+			Revision int64             `json:"revision"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		if change.Revision != v.revision || len(change.Logins) != len(v.logins) {
+			w.WriteHeader(http.StatusConflict)
+
+			return
+		}
+
+		v.logins = change.Logins
+		v.revision++
+
+		fmt.Fprint(w, "{}")
+	})
+
+	return mux
+}
+
+// newTestConfig запускает тестовый сервер vault и возвращает конфигурацию клиента для него
+func newTestConfig(t *testing.T, vault *fakeVault) *config.Config {
+	server := httptest.NewServer(vault.handler())
+	t.Cleanup(server.Close)
+
+	return &config.Config{
+		ServerProtocol: "http",
+		ServerAddress:  strings.TrimPrefix(server.URL, "http://"),
+		KnownAccounts:  filepath.Join(t.TempDir(), "known_accounts"),
+		KDFTime:        1,
+		KDFMemory:      64,
+		KDFThreads:     1,
+	}
+}
+
+// newTestService возвращает сервис зарегистрированного пользователя тестового сервера vault
+func newTestService(t *testing.T, vault *fakeVault) (gkService client.GophKeeperService, user *model.User, cs *service.CryptoService) {
+	gkService = service.NewHTTPService(newTestConfig(t, vault), logger.NewLogger(io.Discard))
+	user = &model.User{Username: "mark", Password: "secret"}
+
+	require.NoError(t, gkService.SignUp(context.Background(), user))
+
+	cs, err := service.NewCryptoService(user)
+	require.NoError(t, err)
+
+	gkService.SetCryptoService(cs)
+
+	return gkService, user, cs
+}
+
+func TestRotateVaultKeyConcurrent(t *testing.T) {
+	gkService, user, _ := newTestService(t, &fakeVault{})
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, gkService.SaveLogin(ctx, &model.Login{Username: fmt.Sprintf("user%d", i), Password: "secret"}))
+	}
+
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				logins, err := gkService.GetLogins(ctx)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				assert.Len(t, logins, 5)
+			}
+		}()
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := gkService.RotateVaultKey(ctx, user)
+		require.NoError(t, err)
+	}
+
+	close(done)
+	wg.Wait()
+
+	logins, err := gkService.GetLogins(ctx)
+	require.NoError(t, err)
+
+	usernames := make([]string, 0, len(logins))
+	for _, login := range logins {
+		usernames = append(usernames, login.Username)
+	}
+
+	assert.ElementsMatch(t, []string{"user0", "user1", "user2", "user3", "user4"}, usernames)
+}
+
+func TestUnboundItems(t *testing.T) {
+	vault := &fakeVault{}
+	gkService, _, cs := newTestService(t, vault)
+	ctx := context.Background()
+
+	encrypt := func(src string) string {
+		encrypted, err := cs.Encrypt([]byte(src), nil)
+		require.NoError(t, err)
+
+		return hex.EncodeToString(encrypted)
+	}
+
+	unbound, err := json.Marshal(model.Login{Username: encrypt("mark"), Password: encrypt("secret"), MetaInfo: encrypt("")})
+	require.NoError(t, err)
+
+	vault.logins = append(vault.logins, unbound)
+	vault.revision++
+
+	_, err = gkService.GetLogins(ctx)
+	assert.ErrorIs(t, err, service.ErrCryptoUnbound)
+
+	cs.AcceptUnbound(true)
+
+	logins, err := gkService.GetLogins(ctx)
+	require.NoError(t, err)
+	require.Len(t, logins, 1)
+	assert.Equal(t, "", logins[0].UID)
+	require.NoError(t, gkService.SaveLogin(ctx, &logins[0]))
+
+	cs.AcceptUnbound(false)
+
+	logins, err = gkService.GetLogins(ctx)
+	require.NoError(t, err)
+	require.Len(t, logins, 1)
+	assert.NotEqual(t, "", logins[0].UID)
+	assert.Equal(t, "mark", logins[0].Username)
+}
+
+func TestKnownAccounts(t *testing.T) {
+	vault := &fakeVault{}
+	cfg := newTestConfig(t, vault)
+	ctx := context.Background()
+
+	require.NoError(t, service.NewHTTPService(cfg, logger.NewLogger(io.Discard)).SignUp(ctx, &model.User{Username: "mark", Password: "secret"}))
+
+	data, err := os.ReadFile(cfg.KnownAccounts)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.ServerAddress+" mark 2\n", string(data))
+
+	tests := []struct {
+		name      string
+		username  string
+		version   uint8
+		trust     bool
+		downgrade bool
+		legacy    bool
+		passwords []string
+	}{
+		{
+			name:      "case 1",
+			username:  "mark",
+			version:   model.KDFVersionLegacy,
+			downgrade: true,
+			passwords: []string{},
+		},
+		{
+			name:      "case 2",
+			username:  "mark",
+			version:   model.KDFVersionSplitAuth,
+			downgrade: true,
+			passwords: []string{},
+		},
+		{
+			name:      "case 3",
+			username:  "john",
+			version:   model.KDFVersionLegacy,
+			legacy:    true,
+			passwords: []string{},
+		},
+		{
+			name:      "case 4",
+			username:  "john",
+			version:   model.KDFVersionLegacy,
+			trust:     true,
+			passwords: []string{"secret"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vault.mu.Lock()
+			vault.user.KDF.Version = tt.version
+			vault.user.Password = ""
+			vault.passwords = []string{}
+			vault.mu.Unlock()
+
+			gkService := service.NewHTTPService(cfg, logger.NewLogger(io.Discard))
+
+			if tt.trust {
+				require.NoError(t, service.TrustLegacyAccount(&service.LegacyAccountError{
+					Server:        cfg.ServerAddress,
+					Username:      tt.username,
+					KnownAccounts: cfg.KnownAccounts,
+				}))
+			}
+
+			err := gkService.SignIn(ctx, &model.User{Username: tt.username, Password: "secret"})
+			require.Error(t, err)
+
+			var downgradeErr *service.KDFDowngradeError
+			assert.Equal(t, tt.downgrade, errors.As(err, &downgradeErr))
+
+			var legacyErr *service.LegacyAccountError
+			assert.Equal(t, tt.legacy, errors.As(err, &legacyErr))
+
+			vault.mu.Lock()
+			defer vault.mu.Unlock()
+
+			assert.Equal(t, tt.passwords, vault.passwords)
+		})
+	}
+}
+
+func TestUpgradeAccount(t *testing.T) {
+	vault := &fakeVault{user: model.User{Username: "mark", Password: "secret"}}
+	cfg := newTestConfig(t, vault)
+	ctx := context.Background()
+
+	require.NoError(t, service.TrustLegacyAccount(&service.LegacyAccountError{
+		Server:        cfg.ServerAddress,
+		Username:      "mark",
+		KnownAccounts: cfg.KnownAccounts,
+	}))
+
+	gkService := service.NewHTTPService(cfg, logger.NewLogger(io.Discard))
+	user := &model.User{Username: "mark", Password: "secret"}
+
+	require.ErrorIs(t, gkService.SignIn(ctx, user), service.ErrUpgradeRequired)
+	assert.Equal(t, int64(0), vault.revision)
+
+	require.NoError(t, gkService.UpgradeAccount(ctx, user))
+	assert.Equal(t, int64(1), vault.revision)
+	assert.Equal(t, model.KDFVersionSplitKeys, user.KDF.Version)
+	assert.NotEmpty(t, user.RecoveryCodes)
+
+	data, err := os.ReadFile(cfg.KnownAccounts)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.ServerAddress+" mark 2\n", string(data))
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+var ErrInvalidKnownAccount = errors.New("invalid known accounts line")
+
+// KDFDowngradeError ошибка входа, при которой сервер до аутентификации сообщает схему получения
+// ключей старше запомненной для учётной записи: такой ответ может подменить злоумышленник,
+// чтобы получить мастер-пароль или ключ, от которого зависит ключ хранилища
+type KDFDowngradeError struct {
+	Server        string
+	Username      string
+	Known         uint8
+	Presented     uint8
+	KnownAccounts string
+}
+
+func (e *KDFDowngradeError) Error() string {
+	return fmt.Sprintf("server %s offers key derivation scheme %d for %s instead of %d used before: "+
+		"possible man-in-the-middle attack or compromised server, the master password was not sent, "+
+		"if the account was intentionally recreated remove it from %s",
+		e.Server, e.Presented, e.Username, e.Known, e.KnownAccounts)
+}
+
+// LegacyAccountError ошибка входа в учётную запись, для которой сервер требует передать мастер-пароль:
+// так работают только учётные записи прежних версий клиента, мастер-пароль передаётся только после
+// подтверждения, см. TrustLegacyAccount
+type LegacyAccountError struct {
+	Server        string
+	Username      string
+	KnownAccounts string
+}
+
+func (e *LegacyAccountError) Error() string {
+	return fmt.Sprintf("server %s asks to send the master password of %s itself, as only accounts created "+
+		"by old clients require: continue only if this account has never been signed in with a newer client, "+
+		"the account will be upgraded after sign in", e.Server, e.Username)
+}
+
+// TrustLegacyAccount запоминает в файле известных учётных записей подтверждение пользователя
+// передать мастер-пароль учётной записи прежней версии клиента
+func TrustLegacyAccount(legacy *LegacyAccountError) error {
+	return rememberAccount(legacy.KnownAccounts, legacy.Server, legacy.Username, model.KDFVersionLegacy)
+}
+
+// checkAccountKDF проверяет схему получения ключей, полученную от сервера до аутентификации,
+// по файлу известных учётных записей: схема не может быть старше запомненной, а схема
+// KDFVersionLegacy требует подтверждения пользователя
+func (s *httpService) checkAccountKDF(user *model.User) error {
+	if s.knownAccounts == "" {
+		return nil
+	}
+
+	known, err := readKnownAccounts(s.knownAccounts)
+	if err != nil {
+		return err
+	}
+
+	version, ok := known[accountKey(s.server, user.Username)]
+
+	switch {
+	case ok && user.KDF.Version < version:
+		return &KDFDowngradeError{
+			Server:        s.server,
+			Username:      user.Username,
+			Known:         version,
+			Presented:     user.KDF.Version,
+			KnownAccounts: s.knownAccounts,
+		}
+	case !ok && user.KDF.Version == model.KDFVersionLegacy:
+		return &LegacyAccountError{Server: s.server, Username: user.Username, KnownAccounts: s.knownAccounts}
+	default:
+		return nil
+	}
+}
+
+// rememberAccountKDF запоминает в файле известных учётных записей схему получения ключей
+// пользователя user, если она новее запомненной. Ошибка записи не прерывает вход и только
+// протоколируется
+func (s *httpService) rememberAccountKDF(user *model.User) {
+	if s.knownAccounts == "" {
+		return
+	}
+
+	known, err := readKnownAccounts(s.knownAccounts)
+	if err == nil {
+		if version, ok := known[accountKey(s.server, user.Username)]; ok && version >= user.KDF.Version {
+			return
+		}
+
+		err = rememberAccount(s.knownAccounts, s.server, user.Username, user.KDF.Version)
+	}
+
+	if err != nil {
+		s.mLogger.Warning(fmt.Sprintf("Failed to remember key derivation scheme of %s: %s", user.Username, err))
+	}
+}
+
+// rememberAccount запоминает в файле известных учётных записей path схему получения ключей version
+// пользователя username сервера server, остальные строки файла сохраняются
+func rememberAccount(path, server, username string, version uint8) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	key := accountKey(server, username)
+	lines := make([]string, 0)
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if line == "" || len(fields) > 1 && fields[0]+" "+fields[1] == key {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, key+" "+strconv.Itoa(int(version)), "")
+
+	tmp := path + ".tmp"
+
+	if err = os.WriteFile(tmp, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// readKnownAccounts читает файл известных учётных записей, каждая строка которого содержит адрес
+// сервера, имя пользователя и версию схемы получения ключей, отсутствующий файл считается пустым
+func readKnownAccounts(path string) (map[string]uint8, error) {
+	known := make(map[string]uint8)
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return known, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, ErrInvalidKnownAccount)
+		}
+
+		version, err := strconv.ParseUint(fields[2], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, ErrInvalidKnownAccount)
+		}
+
+		known[fields[0]+" "+fields[1]] = uint8(version)
+	}
+
+	return known, scanner.Err()
+}
+
+// accountKey возвращает адрес сервера и экранированное имя пользователя, разделённые пробелом
+func accountKey(server, username string) string {
+	return server + " " + url.QueryEscape(username)
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/vukit/gophkeeper/internal/client/model"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Код восстановления — 20 случайных байт в base32, разбитые на группы по 4 символа.
+// Из кода через HKDF получаются проверочное значение, которое хранит сервер,
+// и ключ, которым зашифрован ключ хранилища
+const (
+	recoveryCodeSize  = 20
+	recoveryCodeGroup = 4
+	recoveryCodes     = 8
+
+	hkdfInfoRecoveryVerifier = "gophkeeper recovery verifier"
+	hkdfInfoRecoveryWrap     = "gophkeeper recovery wrapping key"
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+var ErrCryptoInvalidRecoveryCode = errors.New("invalid recovery code")
+
+// NewRecoveryCode возвращает случайный код восстановления
+func NewRecoveryCode() (string, error) {
+	raw := make([]byte, recoveryCodeSize)
+
+	if _, err := io.ReadFull(rand.Reader, raw); err != nil {
+		return "", err
+	}
+
+	encoded := recoveryEncoding.EncodeToString(raw)
+	groups := make([]string, 0, len(encoded)/recoveryCodeGroup)
+
+	for i := 0; i < len(encoded); i += recoveryCodeGroup {
+		groups = append(groups, encoded[i:i+recoveryCodeGroup])
+	}
+
+	return strings.Join(groups, "-"), nil
+}
+
+// NewRecoveryKeys возвращает n кодов восстановления и ключи восстановления
+// с ключом хранилища vaultKey, зашифрованным каждым из кодов
+func NewRecoveryKeys(vaultKey []byte, n int) (codes []string, keys []model.RecoveryKey, err error) {
+	codes = make([]string, 0, n)
+	keys = make([]model.RecoveryKey, 0, n)
+
+	for i := 0; i < n; i++ {
+		code, err := NewRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		verifier, wrapKey, err := recoveryKeys(code)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		wrapped, err := WrapKey(wrapKey, vaultKey)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, code)
+		keys = append(keys, model.RecoveryKey{Verifier: verifier, VaultKey: wrapped})
+	}
+
+	return codes, keys, nil
+}
+
+// RecoveryVerifier возвращает проверочное значение кода восстановления
+func RecoveryVerifier(code string) (string, error) {
+	verifier, _, err := recoveryKeys(code)
+
+	return verifier, err
+}
+
+// UnwrapRecoveryKey расшифровывает кодом восстановления ключ хранилища, зашифрованный NewRecoveryKeys
+func UnwrapRecoveryKey(code, wrapped string) ([]byte, error) {
+	_, wrapKey, err := recoveryKeys(code)
+	if err != nil {
+		return nil, err
+	}
+
+	return UnwrapKey(wrapKey, wrapped)
+}
+
+// recoveryKeys возвращает проверочное значение и ключ шифрования, полученные из кода восстановления.
+// Регистр символов, пробелы и дефисы в коде не учитываются
+func recoveryKeys(code string) (verifier string, wrapKey []byte, err error) {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+
+		return r
+	}, strings.ToUpper(code))
+
+	raw, err := recoveryEncoding.DecodeString(normalized)
+	if err != nil || len(raw) != recoveryCodeSize {
+		return "", nil, ErrCryptoInvalidRecoveryCode
+	}
+
+	verifierKey := make([]byte, keyLength)
+
+	if _, err = io.ReadFull(hkdf.New(sha256.New, raw, nil, []byte(hkdfInfoRecoveryVerifier)), verifierKey); err != nil {
+		return "", nil, err
+	}
+
+	wrapKey = make([]byte, keyLength)
+
+	if _, err = io.ReadFull(hkdf.New(sha256.New, raw, nil, []byte(hkdfInfoRecoveryWrap)), wrapKey); err != nil {
+		return "", nil, err
+	}
+
+	return hex.EncodeToString(verifierKey), wrapKey, nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Потоковый формат файла в стиле STREAM/age:
+//
+//	| версия (1 байт) | алгоритм (1 байт) | соль (16 байт) | сегмент 0 | сегмент 1 | ... |
+//
+// Для каждого файла из ключа шифрования и случайной соли через HKDF получается отдельный ключ.
+// Каждый сегмент содержит до streamChunkSize байт открытого текста и тег AES-GCM.
+// Nonce сегмента — его порядковый номер и признак последнего сегмента, поэтому
+// перестановка, удаление или обрезка сегментов обнаруживаются при расшифровке.
+// Сегменты версии streamVersionBound аутентифицируют связанные данные файла.
+const (
+	streamVersion      byte = 2
+	streamVersionBound byte = 3
+	streamSaltSize          = 16
+	streamHeaderSize        = envelopeHeader + streamSaltSize
+	streamChunkSize         = 64 * 1024
+	hkdfInfoStream          = "gophkeeper file stream key"
+)
+
+var ErrCryptoStreamTruncated = errors.New("encrypted file is truncated")
+
+// newStreamAEAD возвращает AEAD для ключа файла, полученного из ключа шифрования и соли
+func newStreamAEAD(key, salt []byte) (cipher.AEAD, error) {
+	fileKey := make([]byte, keyLength)
+
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, salt, []byte(hkdfInfoStream)), fileKey); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// streamNonce возвращает nonce сегмента с номером counter
+func streamNonce(nonce []byte, counter uint64, last bool) []byte {
+	for i := range nonce {
+		nonce[i] = 0
+	}
+
+	binary.BigEndian.PutUint64(nonce[len(nonce)-9:len(nonce)-1], counter)
+
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+
+	return nonce
+}
+
+// streamEncryptReader шифрует данные источника по мере чтения
+type streamEncryptReader struct {
+	src     *bufio.Reader
+	closer  io.Closer
+	aead    cipher.AEAD
+	ad      []byte
+	nonce   []byte
+	counter uint64
+	chunk   []byte
+	out     []byte
+	done    bool
+}
+
+func newStreamEncryptReader(key, ad []byte, src io.ReadCloser) (*streamEncryptReader, error) {
+	header := make([]byte, streamHeaderSize)
+	header[0] = streamVersionBound
+	header[1] = algAES256GCM
+
+	if _, err := io.ReadFull(rand.Reader, header[envelopeHeader:]); err != nil {
+		return nil, err
+	}
+
+	aead, err := newStreamAEAD(key, header[envelopeHeader:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &streamEncryptReader{
+		src:    bufio.NewReaderSize(src, streamChunkSize),
+		closer: src,
+		aead:   aead,
+		ad:     ad,
+		nonce:  make([]byte, aead.NonceSize()),
+		chunk:  make([]byte, streamChunkSize, streamChunkSize+aead.Overhead()),
+		out:    header,
+	}, nil
+}
+
+// Read реализует io.Reader
+func (r *streamEncryptReader) Read(p []byte) (n int, err error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+
+		if err = r.sealNext(); err != nil {
+			return 0, err
+		}
+	}
+
+	n = copy(p, r.out)
+	r.out = r.out[n:]
+
+	return n, nil
+}
+
+// Close закрывает источник
+func (r *streamEncryptReader) Close() error {
+	return r.closer.Close()
+}
+
+func (r *streamEncryptReader) sealNext() error {
+	n, err := io.ReadFull(r.src, r.chunk[:streamChunkSize])
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+
+	last := n < streamChunkSize
+
+	if !last {
+		if _, err = r.src.Peek(1); errors.Is(err, io.EOF) {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	r.out = r.aead.Seal(r.chunk[:0], streamNonce(r.nonce, r.counter, last), r.chunk[:n], r.ad)
+	r.counter++
+	r.done = last
+
+	return nil
+}
+
+// streamDecryptReader расшифровывает данные источника по мере чтения
+type streamDecryptReader struct {
+	src     *bufio.Reader
+	aead    cipher.AEAD
+	ad      []byte
+	nonce   []byte
+	counter uint64
+	chunk   []byte
+	out     []byte
+	done    bool
+}
+
+func newStreamDecryptReader(key, ad []byte, src *bufio.Reader) (*streamDecryptReader, error) {
+	header := make([]byte, streamHeaderSize)
+
+	if _, err := io.ReadFull(src, header); err != nil {
+		return nil, ErrCryptoStreamTruncated
+	}
+
+	aead, err := newStreamAEAD(key, header[envelopeHeader:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &streamDecryptReader{
+		src:   src,
+		aead:  aead,
+		ad:    ad,
+		nonce: make([]byte, aead.NonceSize()),
+		chunk: make([]byte, streamChunkSize+aead.Overhead()),
+	}, nil
+}
+
+// Read реализует io.Reader
+func (r *streamDecryptReader) Read(p []byte) (n int, err error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+
+		if err = r.openNext(); err != nil {
+			return 0, err
+		}
+	}
+
+	n = copy(p, r.out)
+	r.out = r.out[n:]
+
+	return n, nil
+}
+
+func (r *streamDecryptReader) openNext() error {
+	n, err := io.ReadFull(r.src, r.chunk)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+
+	if n < r.aead.Overhead() {
+		return ErrCryptoStreamTruncated
+	}
+
+	last := n < len(r.chunk)
+
+	if !last {
+		if _, err = r.src.Peek(1); errors.Is(err, io.EOF) {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	out, err := r.aead.Open(r.chunk[:0], streamNonce(r.nonce, r.counter, last), r.chunk[:n], r.ad)
+	if err != nil {
+		if last {
+			return ErrCryptoStreamTruncated
+		}
+
+		return ErrCryptoDecrypt
+	}
+
+	r.out = out
+	r.counter++
+	r.done = last
+
+	return nil
+}
+
+// isStream проверяет, что src содержит файл в потоковом формате версии version: первый сегмент
+// должен расшифровываться со связанными данными ad, иначе файл считается зашифрованным в другом формате
+func (r *CryptoService) isStream(src *bufio.Reader, version byte, ad []byte) bool {
+	chunkSize := streamChunkSize + r.aesgcm.Overhead()
+
+	data, _ := src.Peek(streamHeaderSize + chunkSize + 1)
+	if len(data) < streamHeaderSize+r.aesgcm.Overhead() || !bytes.Equal(data[:envelopeHeader], []byte{version, algAES256GCM}) {
+		return false
+	}
+
+	aead, err := newStreamAEAD(r.key, data[envelopeHeader:streamHeaderSize])
+	if err != nil {
+		return false
+	}
+
+	chunk := data[streamHeaderSize:]
+	last := len(chunk) <= chunkSize
+
+	if !last {
+		chunk = chunk[:chunkSize]
+	}
+
+	_, err = aead.Open(nil, streamNonce(make([]byte, aead.NonceSize()), 0, last), chunk, ad)
+
+	return err == nil
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/vukit/gophkeeper/internal/client/config"
+	"github.com/vukit/gophkeeper/internal/client/logger"
+)
+
+const pinPrefix = "sha256/"
+
+var (
+	ErrTLSNoCertificates    = errors.New("no certificates found in tls ca file")
+	ErrTLSIncompleteKeyPair = errors.New("tls certificate and private key must be set together")
+	ErrTLSInvalidPin        = errors.New("invalid server key pin, expected sha256/<base64>")
+	ErrTLSInvalidKnownHost  = errors.New("invalid known servers line")
+)
+
+// CertificateChangedError ошибка соединения с сервером, ключ сертификата которого не совпадает
+// с закреплённым или запомненным при первом подключении
+type CertificateChangedError struct {
+	Server       string
+	Known        string
+	Presented    string
+	KnownServers string
+}
+
+func (e *CertificateChangedError) Error() string {
+	if e.KnownServers == "" {
+		return fmt.Sprintf("server %s presented certificate key %s that does not match pinned %s: "+
+			"possible man-in-the-middle attack, if the server key was replaced intentionally update the pin",
+			e.Server, e.Presented, e.Known)
+	}
+
+	return fmt.Sprintf("server %s presented certificate key %s instead of %s trusted on first use: "+
+		"possible man-in-the-middle attack, if the server key was replaced intentionally remove %s from %s",
+		e.Server, e.Presented, e.Known, e.Server, e.KnownServers)
+}
+
+// UnknownServerError ошибка первого подключения к серверу, сертификат которого не удалось проверить
+// удостоверяющим центром: ключ сертификата принимается только после подтверждения, см. TrustServer
+type UnknownServerError struct {
+	Server       string
+	Presented    string
+	KnownServers string
+}
+
+func (e *UnknownServerError) Error() string {
+	return fmt.Sprintf("server %s presented certificate key %s that is not verified by a certificate authority: "+
+		"trust it only if the key matches the one reported by the server administrator", e.Server, e.Presented)
+}
+
+// TrustServer запоминает в файле известных серверов ключ сертификата сервера, подтверждённый пользователем
+func TrustServer(unknown *UnknownServerError) error {
+	return rememberServer(unknown.KnownServers, unknown.Server, unknown.Presented)
+}
+
+// NewTLSConfig возвращает параметры TLS соединения с сервером: сертификат сервера проверяется
+// по сертификату удостоверяющего центра TLSCA, если он задан, иначе по системным сертификатам.
+// Сервер с закреплённым ключом TLSPin принимается только с этим ключом. Если задан файл KnownServers,
+// в нём запоминается ключ сервера с проверенным сертификатом, а сервер с сертификатом, который
+// не удалось проверить, принимается только с запомненным ключом, новый ключ требует подтверждения,
+// см. UnknownServerError. Клиентский сертификат предъявляется серверу, требующему взаимную аутентификацию
+func NewTLSConfig(cfg *config.Config, mLogger *logger.Logger) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.TLSCA != "" {
+		data, err := os.ReadFile(cfg.TLSCA)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, ErrTLSNoCertificates
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if (cfg.TLSCertificate == "") != (cfg.TLSPrivateKey == "") {
+		return nil, ErrTLSIncompleteKeyPair
+	}
+
+	if cfg.TLSCertificate != "" {
+		certificate, err := tls.LoadX509KeyPair(cfg.TLSCertificate, cfg.TLSPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	pins, err := parsePins(cfg.TLSPin)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pins) == 0 && cfg.KnownServers == "" {
+		return tlsConfig, nil
+	}
+
+	verifier := &serverVerifier{
+		server:       cfg.ServerAddress,
+		roots:        tlsConfig.RootCAs,
+		pins:         pins,
+		knownServers: cfg.KnownServers,
+		mLogger:      mLogger,
+	}
+
+	// проверка цепочки выполняется в VerifyConnection, чтобы принять сервер с закреплённым ключом
+	// или запомненный сервер с самоподписанным сертификатом
+	tlsConfig.InsecureSkipVerify = true //nolint:gosec
+	tlsConfig.VerifyConnection = verifier.verify
+
+	return tlsConfig, nil
+}
+
+// serverVerifier проверяет сертификат сервера по закреплённым ключам, удостоверяющему центру
+// и файлу известных серверов
+type serverVerifier struct {
+	server       string
+	roots        *x509.CertPool
+	pins         []string
+	knownServers string
+	mLogger      *logger.Logger
+	mu           sync.Mutex
+}
+
+func (v *serverVerifier) verify(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return ErrTLSNoCertificates
+	}
+
+	leaf := cs.PeerCertificates[0]
+	pin := spkiPin(leaf)
+
+	if len(v.pins) > 0 {
+		for _, p := range v.pins {
+			if p == pin {
+				return nil
+			}
+		}
+
+		return &CertificateChangedError{Server: v.server, Known: strings.Join(v.pins, ","), Presented: pin}
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, verifyErr := leaf.Verify(x509.VerifyOptions{Roots: v.roots, DNSName: cs.ServerName, Intermediates: intermediates})
+	if verifyErr != nil && v.knownServers == "" {
+		return verifyErr
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	known, err := readKnownServers(v.knownServers)
+	if err != nil {
+		return err
+	}
+
+	knownPin, ok := known[v.server]
+
+	switch {
+	case verifyErr == nil && knownPin == pin:
+		return nil
+	case verifyErr == nil:
+		// ключ сервера с проверенным сертификатом запоминается, чтобы сервер с непроверенным
+		// сертификатом не был принят как новый
+		v.mLogger.Info(fmt.Sprintf("Remembered server %s certificate key %s verified by certificate authority", v.server, pin))
+
+		return rememberServer(v.knownServers, v.server, pin)
+	case !ok:
+		return &UnknownServerError{Server: v.server, Presented: pin, KnownServers: v.knownServers}
+	case knownPin != pin:
+		return &CertificateChangedError{Server: v.server, Known: knownPin, Presented: pin, KnownServers: v.knownServers}
+	default:
+		return nil
+	}
+}
+
+// rememberServer запоминает в файле известных серверов path ключ pin сервера server,
+// прежний ключ сервера заменяется, остальные строки файла сохраняются
+func rememberServer(path, server, pin string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	lines := make([]string, 0)
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); line == "" || len(fields) > 0 && fields[0] == server {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, server+" "+pin, "")
+
+	tmp := path + ".tmp"
+
+	if err = os.WriteFile(tmp, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// readKnownServers читает файл известных серверов, каждая строка которого содержит адрес сервера
+// и отпечаток ключа его сертификата, отсутствующий файл считается пустым
+func readKnownServers(path string) (map[string]string, error) {
+	known := make(map[string]string)
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return known, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, ErrTLSInvalidKnownHost)
+		}
+
+		known[fields[0]] = fields[1]
+	}
+
+	return known, scanner.Err()
+}
+
+// parsePins разбирает список закреплённых ключей через запятую, несколько ключей позволяют
+// заранее закрепить ключ, на который сервер перейдёт при замене сертификата
+func parsePins(list string) ([]string, error) {
+	pins := make([]string, 0)
+
+	for _, pin := range strings.Split(list, ",") {
+		pin = strings.TrimSpace(pin)
+		if pin == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(pin, pinPrefix) {
+			pin = pinPrefix + pin
+		}
+
+		sum, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, pinPrefix))
+		if err != nil || len(sum) != sha256.Size {
+			return nil, ErrTLSInvalidPin
+		}
+
+		pins = append(pins, pin)
+	}
+
+	return pins, nil
+}
+
+func spkiPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	return pinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,170 @@
+package service_test
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vukit/gophkeeper/internal/client/config"
+	"github.com/vukit/gophkeeper/internal/client/logger"
+	"github.com/vukit/gophkeeper/internal/client/service"
+	"github.com/vukit/gophkeeper/internal/server/pki"
+)
+
+func TestTLSConfig(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	ca, caKey, err := pki.NewCA("test", time.Hour)
+	require.NoError(t, err)
+
+	otherCert, otherKey, err := pki.IssueServer(ca, caKey, []string{"127.0.0.1"}, time.Hour)
+	require.NoError(t, err)
+
+	other := httptest.NewUnstartedServer(handler)
+	other.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{otherCert.Raw}, PrivateKey: otherKey}}}
+	other.StartTLS()
+
+	defer other.Close()
+
+	dir := t.TempDir()
+
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pki.EncodeCertificate(server.Certificate()), 0o600))
+
+	pin := pki.SPKIPin(server.Certificate())
+	otherPin := pki.SPKIPin(otherCert)
+	knownServers := filepath.Join(dir, "known_servers")
+	verifiedServers := filepath.Join(dir, "verified_servers")
+
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		url     string
+		changed bool
+		unknown bool
+		trust   bool
+		wantErr bool
+	}{
+		{
+			name:    "case 1",
+			cfg:     config.Config{},
+			url:     server.URL,
+			wantErr: true,
+		},
+		{
+			name: "case 2",
+			cfg:  config.Config{TLSCA: caFile},
+			url:  server.URL,
+		},
+		{
+			name: "case 3",
+			cfg:  config.Config{TLSPin: otherPin + "," + pin},
+			url:  server.URL,
+		},
+		{
+			name:    "case 4",
+			cfg:     config.Config{TLSPin: otherPin},
+			url:     server.URL,
+			changed: true,
+			wantErr: true,
+		},
+		{
+			name:    "case 5",
+			cfg:     config.Config{KnownServers: knownServers},
+			url:     server.URL,
+			unknown: true,
+			trust:   true,
+			wantErr: true,
+		},
+		{
+			name: "case 6",
+			cfg:  config.Config{KnownServers: knownServers},
+			url:  server.URL,
+		},
+		{
+			name:    "case 7",
+			cfg:     config.Config{KnownServers: knownServers},
+			url:     other.URL,
+			changed: true,
+			wantErr: true,
+		},
+		{
+			name: "case 8",
+			cfg:  config.Config{TLSCA: caFile, KnownServers: verifiedServers},
+			url:  server.URL,
+		},
+		{
+			name:    "case 9",
+			cfg:     config.Config{KnownServers: verifiedServers},
+			url:     other.URL,
+			changed: true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.ServerAddress = "gophkeeper.test"
+
+			tlsConfig, err := service.NewTLSConfig(&tt.cfg, logger.NewLogger(io.Discard))
+			require.NoError(t, err)
+
+			transport := server.Client().Transport.(*http.Transport).Clone()
+			transport.TLSClientConfig = tlsConfig
+
+			resp, err := (&http.Client{Transport: transport}).Get(tt.url)
+			if err == nil {
+				resp.Body.Close()
+			}
+
+			assert.Equal(t, tt.wantErr, err != nil, err)
+
+			var changedErr *service.CertificateChangedError
+			assert.Equal(t, tt.changed, errors.As(err, &changedErr), err)
+
+			var unknownErr *service.UnknownServerError
+			assert.Equal(t, tt.unknown, errors.As(err, &unknownErr), err)
+
+			if tt.trust {
+				require.NoError(t, service.TrustServer(unknownErr))
+			}
+		})
+	}
+
+	data, err := os.ReadFile(knownServers)
+	require.NoError(t, err)
+	assert.Equal(t, "gophkeeper.test "+pin+"\n", string(data))
+
+	data, err = os.ReadFile(verifiedServers)
+	require.NoError(t, err)
+	assert.Equal(t, "gophkeeper.test "+pin+"\n", string(data))
+
+	_, err = service.NewTLSConfig(&config.Config{TLSPin: "sha256/invalid"}, logger.NewLogger(io.Discard))
+	assert.ErrorIs(t, err, service.ErrTLSInvalidPin)
+
+	_, err = service.NewTLSConfig(&config.Config{TLSCertificate: "client.pem"}, logger.NewLogger(io.Discard))
+	assert.ErrorIs(t, err, service.ErrTLSIncompleteKeyPair)
+
+	require.NoError(t, os.WriteFile(knownServers, []byte(strings.Repeat("# comment\n", 2)+"broken\n"), 0o600))
+
+	tlsConfig, err := service.NewTLSConfig(&config.Config{KnownServers: knownServers}, logger.NewLogger(io.Discard))
+	require.NoError(t, err)
+
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	_, err = (&http.Client{Transport: transport}).Get(server.URL)
+	assert.ErrorIs(t, err, service.ErrTLSInvalidKnownHost)
+}
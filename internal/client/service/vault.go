@@ -0,0 +1,682 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+// Типы записей хранилища в связанных данных шифротекстов
+const (
+	itemLogin  = "login"
+	itemCard   = "card"
+	itemFile   = "file"
+	itemNote   = "note"
+	itemOTP    = "otp"
+	itemSSHKey = "ssh_key"
+	itemFolder = "folder"
+	itemTag    = "tag"
+)
+
+const itemUIDLength = 16
+
+// newItemUID возвращает случайный идентификатор записи, к которому привязываются шифротексты её полей
+func newItemUID() (string, error) {
+	uid := make([]byte, itemUIDLength)
+
+	if _, err := io.ReadFull(rand.Reader, uid); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(uid), nil
+}
+
+// itemAD возвращает связанные данные поля field записи с идентификатором uid:
+// подмена поля другим полем, записью или данными другого пользователя обнаруживается
+// при расшифровке. Записи без идентификатора сохранены прежними версиями клиента,
+// связанных данных у них нет, см. AcceptUnbound
+func (r *CryptoService) itemAD(item, uid, field string) []byte {
+	if uid == "" {
+		return nil
+	}
+
+	return []byte(strings.Join([]string{"gophkeeper", r.username, item, uid, field}, "\x00"))
+}
+
+// encryptString шифрует строку src со связанными данными ad и возвращает шифротекст в шестнадцатеричном виде
+func (r *CryptoService) encryptString(src string, ad []byte) (string, error) {
+	if ad == nil {
+		return "", ErrCryptoUnbound
+	}
+
+	data, err := r.Encrypt([]byte(src), ad)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+// decryptString расшифровывает шифротекст src в шестнадцатеричном виде со связанными данными ad
+func (r *CryptoService) decryptString(src string, ad []byte) (string, error) {
+	if ad == nil && !r.acceptsUnbound() {
+		return "", ErrCryptoUnbound
+	}
+
+	data, err := hex.DecodeString(src)
+	if err != nil {
+		return "", err
+	}
+
+	data, err = r.Decrypt(data, ad)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// encryptLogin возвращает копию логина с зашифрованными полями,
+// логину без идентификатора назначается новый идентификатор
+func (r *CryptoService) encryptLogin(login model.Login) (encrypted model.Login, err error) {
+	encrypted.ID = login.ID
+	encrypted.UID = login.UID
+	encrypted.Placement = login.Placement
+
+	if encrypted.UID == "" {
+		if encrypted.UID, err = newItemUID(); err != nil {
+			return encrypted, err
+		}
+	}
+
+	if encrypted.Username, err = r.encryptString(login.Username, r.itemAD(itemLogin, encrypted.UID, "username")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Password, err = r.encryptString(login.Password, r.itemAD(itemLogin, encrypted.UID, "password")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.MetaInfo, err = r.encryptString(login.MetaInfo, r.itemAD(itemLogin, encrypted.UID, "metainfo")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Fields, err = r.encryptFields(login.Fields, itemLogin, encrypted.UID); err != nil {
+		return encrypted, err
+	}
+
+	return encrypted, nil
+}
+
+// decryptLogin расшифровывает поля логина
+func (r *CryptoService) decryptLogin(login *model.Login) (err error) {
+	if login.Username, err = r.decryptString(login.Username, r.itemAD(itemLogin, login.UID, "username")); err != nil {
+		return err
+	}
+
+	if login.Password, err = r.decryptString(login.Password, r.itemAD(itemLogin, login.UID, "password")); err != nil {
+		return err
+	}
+
+	if login.MetaInfo, err = r.decryptString(login.MetaInfo, r.itemAD(itemLogin, login.UID, "metainfo")); err != nil {
+		return err
+	}
+
+	if err = r.decryptFields(login.Fields, itemLogin, login.UID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// encryptCard возвращает копию банковской карты с зашифрованными полями,
+// карте без идентификатора назначается новый идентификатор
+func (r *CryptoService) encryptCard(card model.Card) (encrypted model.Card, err error) {
+	encrypted.ID = card.ID
+	encrypted.UID = card.UID
+	encrypted.Placement = card.Placement
+
+	if encrypted.UID == "" {
+		if encrypted.UID, err = newItemUID(); err != nil {
+			return encrypted, err
+		}
+	}
+
+	if encrypted.Bank, err = r.encryptString(card.Bank, r.itemAD(itemCard, encrypted.UID, "bank")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Number, err = r.encryptString(card.Number, r.itemAD(itemCard, encrypted.UID, "number")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Date, err = r.encryptString(card.Date, r.itemAD(itemCard, encrypted.UID, "date")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.CVV, err = r.encryptString(card.CVV, r.itemAD(itemCard, encrypted.UID, "cvv")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.MetaInfo, err = r.encryptString(card.MetaInfo, r.itemAD(itemCard, encrypted.UID, "metainfo")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Fields, err = r.encryptFields(card.Fields, itemCard, encrypted.UID); err != nil {
+		return encrypted, err
+	}
+
+	return encrypted, nil
+}
+
+// decryptCard расшифровывает поля банковской карты
+func (r *CryptoService) decryptCard(card *model.Card) (err error) {
+	if card.Bank, err = r.decryptString(card.Bank, r.itemAD(itemCard, card.UID, "bank")); err != nil {
+		return err
+	}
+
+	if card.Number, err = r.decryptString(card.Number, r.itemAD(itemCard, card.UID, "number")); err != nil {
+		return err
+	}
+
+	if card.Date, err = r.decryptString(card.Date, r.itemAD(itemCard, card.UID, "date")); err != nil {
+		return err
+	}
+
+	if card.CVV, err = r.decryptString(card.CVV, r.itemAD(itemCard, card.UID, "cvv")); err != nil {
+		return err
+	}
+
+	if card.MetaInfo, err = r.decryptString(card.MetaInfo, r.itemAD(itemCard, card.UID, "metainfo")); err != nil {
+		return err
+	}
+
+	if err = r.decryptFields(card.Fields, itemCard, card.UID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// encryptNote возвращает копию заметки с зашифрованными полями,
+// заметке без идентификатора назначается новый идентификатор
+func (r *CryptoService) encryptNote(note model.Note) (encrypted model.Note, err error) {
+	encrypted.ID = note.ID
+	encrypted.UID = note.UID
+	encrypted.Placement = note.Placement
+
+	if encrypted.UID == "" {
+		if encrypted.UID, err = newItemUID(); err != nil {
+			return encrypted, err
+		}
+	}
+
+	if encrypted.Title, err = r.encryptString(note.Title, r.itemAD(itemNote, encrypted.UID, "title")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Text, err = r.encryptString(note.Text, r.itemAD(itemNote, encrypted.UID, "text")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Fields, err = r.encryptFields(note.Fields, itemNote, encrypted.UID); err != nil {
+		return encrypted, err
+	}
+
+	return encrypted, nil
+}
+
+// decryptNote расшифровывает поля заметки
+func (r *CryptoService) decryptNote(note *model.Note) (err error) {
+	if note.Title, err = r.decryptString(note.Title, r.itemAD(itemNote, note.UID, "title")); err != nil {
+		return err
+	}
+
+	if note.Text, err = r.decryptString(note.Text, r.itemAD(itemNote, note.UID, "text")); err != nil {
+		return err
+	}
+
+	if err = r.decryptFields(note.Fields, itemNote, note.UID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// encryptOTP возвращает копию ключа одноразовых паролей с зашифрованными названием и секретом,
+// ключу без идентификатора назначается новый идентификатор
+func (r *CryptoService) encryptOTP(otp model.OTP) (encrypted model.OTP, err error) {
+	encrypted = otp
+
+	if encrypted.UID == "" {
+		if encrypted.UID, err = newItemUID(); err != nil {
+			return encrypted, err
+		}
+	}
+
+	if encrypted.Name, err = r.encryptString(otp.Name, r.itemAD(itemOTP, encrypted.UID, "name")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Secret, err = r.encryptString(otp.Secret, r.itemAD(itemOTP, encrypted.UID, "secret")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Fields, err = r.encryptFields(otp.Fields, itemOTP, encrypted.UID); err != nil {
+		return encrypted, err
+	}
+
+	return encrypted, nil
+}
+
+// decryptOTP расшифровывает название и секрет ключа одноразовых паролей
+func (r *CryptoService) decryptOTP(otp *model.OTP) (err error) {
+	if otp.Name, err = r.decryptString(otp.Name, r.itemAD(itemOTP, otp.UID, "name")); err != nil {
+		return err
+	}
+
+	if otp.Secret, err = r.decryptString(otp.Secret, r.itemAD(itemOTP, otp.UID, "secret")); err != nil {
+		return err
+	}
+
+	if err = r.decryptFields(otp.Fields, itemOTP, otp.UID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// encryptSSHKey возвращает копию ключа SSH с зашифрованными полями,
+// ключу без идентификатора назначается новый идентификатор
+func (r *CryptoService) encryptSSHKey(key model.SSHKey) (encrypted model.SSHKey, err error) {
+	encrypted = key
+
+	if encrypted.UID == "" {
+		if encrypted.UID, err = newItemUID(); err != nil {
+			return encrypted, err
+		}
+	}
+
+	if encrypted.PrivateKey, err = r.encryptString(key.PrivateKey, r.itemAD(itemSSHKey, encrypted.UID, "private_key")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.PublicKey, err = r.encryptString(key.PublicKey, r.itemAD(itemSSHKey, encrypted.UID, "public_key")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Comment, err = r.encryptString(key.Comment, r.itemAD(itemSSHKey, encrypted.UID, "comment")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Fingerprint, err = r.encryptString(key.Fingerprint, r.itemAD(itemSSHKey, encrypted.UID, "fingerprint")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Fields, err = r.encryptFields(key.Fields, itemSSHKey, encrypted.UID); err != nil {
+		return encrypted, err
+	}
+
+	return encrypted, nil
+}
+
+// decryptSSHKey расшифровывает поля ключа SSH
+func (r *CryptoService) decryptSSHKey(key *model.SSHKey) (err error) {
+	if key.PrivateKey, err = r.decryptString(key.PrivateKey, r.itemAD(itemSSHKey, key.UID, "private_key")); err != nil {
+		return err
+	}
+
+	if key.PublicKey, err = r.decryptString(key.PublicKey, r.itemAD(itemSSHKey, key.UID, "public_key")); err != nil {
+		return err
+	}
+
+	if key.Comment, err = r.decryptString(key.Comment, r.itemAD(itemSSHKey, key.UID, "comment")); err != nil {
+		return err
+	}
+
+	if key.Fingerprint, err = r.decryptString(key.Fingerprint, r.itemAD(itemSSHKey, key.UID, "fingerprint")); err != nil {
+		return err
+	}
+
+	if err = r.decryptFields(key.Fields, itemSSHKey, key.UID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// encryptFolder возвращает копию папки с зашифрованным названием, папке без идентификатора
+// назначается новый идентификатор
+func (r *CryptoService) encryptFolder(folder model.Folder) (encrypted model.Folder, err error) {
+	encrypted = folder
+
+	if encrypted.UID == "" {
+		if encrypted.UID, err = newItemUID(); err != nil {
+			return encrypted, err
+		}
+	}
+
+	if encrypted.Name, err = r.encryptString(folder.Name, r.itemAD(itemFolder, encrypted.UID, "name")); err != nil {
+		return encrypted, err
+	}
+
+	return encrypted, nil
+}
+
+// decryptFolder расшифровывает название папки
+func (r *CryptoService) decryptFolder(folder *model.Folder) (err error) {
+	folder.Name, err = r.decryptString(folder.Name, r.itemAD(itemFolder, folder.UID, "name"))
+
+	return err
+}
+
+// encryptTag возвращает копию метки с зашифрованным названием, метке без идентификатора
+// назначается новый идентификатор
+func (r *CryptoService) encryptTag(tag model.Tag) (encrypted model.Tag, err error) {
+	encrypted = tag
+
+	if encrypted.UID == "" {
+		if encrypted.UID, err = newItemUID(); err != nil {
+			return encrypted, err
+		}
+	}
+
+	if encrypted.Name, err = r.encryptString(tag.Name, r.itemAD(itemTag, encrypted.UID, "name")); err != nil {
+		return encrypted, err
+	}
+
+	return encrypted, nil
+}
+
+// decryptTag расшифровывает название метки
+func (r *CryptoService) decryptTag(tag *model.Tag) (err error) {
+	tag.Name, err = r.decryptString(tag.Name, r.itemAD(itemTag, tag.UID, "name"))
+
+	return err
+}
+
+// itemPayload содержимое записи произвольной структуры, которое шифруется целиком
+type itemPayload struct {
+	Name   string        `json:"name"`
+	Fields []model.Field `json:"fields"`
+}
+
+// encryptItem возвращает копию записи произвольной структуры с зашифрованными в Data названием
+// и дополнительными полями, записи без идентификатора назначается новый идентификатор
+func (r *CryptoService) encryptItem(item model.Item) (encrypted model.Item, err error) {
+	encrypted = model.Item{ID: item.ID, UID: item.UID, Type: item.Type, Placement: item.Placement}
+
+	if encrypted.UID == "" {
+		if encrypted.UID, err = newItemUID(); err != nil {
+			return encrypted, err
+		}
+	}
+
+	data, err := json.Marshal(itemPayload{Name: item.Name, Fields: item.Fields})
+	if err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Data, err = r.encryptString(string(data), r.itemAD(item.Type, encrypted.UID, "data")); err != nil {
+		return encrypted, err
+	}
+
+	return encrypted, nil
+}
+
+// decryptItem расшифровывает название и дополнительные поля записи произвольной структуры
+func (r *CryptoService) decryptItem(item *model.Item) (err error) {
+	data, err := r.decryptString(item.Data, r.itemAD(item.Type, item.UID, "data"))
+	if err != nil {
+		return err
+	}
+
+	payload := itemPayload{}
+	if err = json.Unmarshal([]byte(data), &payload); err != nil {
+		return err
+	}
+
+	item.Name = payload.Name
+	item.Fields = payload.Fields
+	item.Data = ""
+
+	return nil
+}
+
+// decryptRevision расшифровывает данные прежней редакции записи в её содержимое Content
+func (r *CryptoService) decryptRevision(revision *model.Revision) (err error) {
+	if revision.Content, err = r.decryptContent(revision.Type, revision.UID, revision.Data); err != nil {
+		return err
+	}
+
+	revision.Data = nil
+
+	return nil
+}
+
+// decryptTrashItem расшифровывает данные записи в корзине в её содержимое Content
+func (r *CryptoService) decryptTrashItem(item *model.TrashItem) (err error) {
+	if item.Content, err = r.decryptContent(item.Type, item.UID, item.Data); err != nil {
+		return err
+	}
+
+	item.Data = nil
+
+	return nil
+}
+
+// contentValue расшифрованная запись любого типа
+type contentValue interface {
+	Content() []model.Field
+}
+
+// decryptContent расшифровывает данные data записи типа itemType с идентификатором uid
+// и возвращает её содержимое
+func (r *CryptoService) decryptContent(itemType, uid string, data json.RawMessage) (content []model.Field, err error) {
+	value, err := r.decryptValue(itemType, uid, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.Content(), nil
+}
+
+// decryptValue расшифровывает данные data записи типа itemType с идентификатором uid
+// и возвращает указатель на запись модели этого типа
+func (r *CryptoService) decryptValue(itemType, uid string, data json.RawMessage) (value contentValue, err error) {
+	switch itemType {
+	case model.ItemTypeLogin:
+		login := model.Login{}
+		if err = json.Unmarshal(data, &login); err != nil {
+			return nil, err
+		}
+
+		login.UID = uid
+		if err = r.decryptLogin(&login); err != nil {
+			return nil, err
+		}
+
+		value = &login
+	case model.ItemTypeCard:
+		card := model.Card{}
+		if err = json.Unmarshal(data, &card); err != nil {
+			return nil, err
+		}
+
+		card.UID = uid
+		if err = r.decryptCard(&card); err != nil {
+			return nil, err
+		}
+
+		value = &card
+	case model.ItemTypeNote:
+		note := model.Note{}
+		if err = json.Unmarshal(data, &note); err != nil {
+			return nil, err
+		}
+
+		note.UID = uid
+		if err = r.decryptNote(&note); err != nil {
+			return nil, err
+		}
+
+		value = &note
+	case model.ItemTypeOTP:
+		otp := model.OTP{}
+		if err = json.Unmarshal(data, &otp); err != nil {
+			return nil, err
+		}
+
+		otp.UID = uid
+		if err = r.decryptOTP(&otp); err != nil {
+			return nil, err
+		}
+
+		value = &otp
+	case model.ItemTypeSSHKey:
+		key := model.SSHKey{}
+		if err = json.Unmarshal(data, &key); err != nil {
+			return nil, err
+		}
+
+		key.UID = uid
+		if err = r.decryptSSHKey(&key); err != nil {
+			return nil, err
+		}
+
+		value = &key
+	case model.ItemTypeFile:
+		file := model.File{}
+		if err = json.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+
+		file.UID = uid
+		if err = r.decryptFileInfo(&file); err != nil {
+			return nil, err
+		}
+
+		value = &file
+	default:
+		item := model.Item{UID: uid, Type: itemType}
+		if err = json.Unmarshal(data, &item.Data); err != nil {
+			return nil, err
+		}
+
+		if err = r.decryptItem(&item); err != nil {
+			return nil, err
+		}
+
+		value = &item
+	}
+
+	return value, nil
+}
+
+// encryptFields возвращает копию дополнительных полей записи с зашифрованными названиями и значениями,
+// тип поля входит в связанные данные значения, поэтому подмена типа обнаруживается при расшифровке
+func (r *CryptoService) encryptFields(fields []model.Field, item, uid string) (encrypted []model.Field, err error) {
+	encrypted = make([]model.Field, len(fields))
+
+	for i, field := range fields {
+		encrypted[i].Type = field.Type
+
+		if encrypted[i].Name, err = r.encryptString(field.Name, r.itemAD(item, uid, fieldAD(i, "name"))); err != nil {
+			return nil, err
+		}
+
+		if encrypted[i].Value, err = r.encryptString(field.Value, r.itemAD(item, uid, fieldAD(i, field.Type))); err != nil {
+			return nil, err
+		}
+	}
+
+	return encrypted, nil
+}
+
+// decryptFields расшифровывает названия и значения дополнительных полей записи
+func (r *CryptoService) decryptFields(fields []model.Field, item, uid string) (err error) {
+	for i := range fields {
+		if fields[i].Name, err = r.decryptString(fields[i].Name, r.itemAD(item, uid, fieldAD(i, "name"))); err != nil {
+			return err
+		}
+
+		if fields[i].Value, err = r.decryptString(fields[i].Value, r.itemAD(item, uid, fieldAD(i, fields[i].Type))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func fieldAD(index int, part string) string {
+	return "fields." + strconv.Itoa(index) + "." + part
+}
+
+// encryptFileInfo возвращает копию данных файла с зашифрованными именем и описанием.
+// Идентификатор файлу назначается только при загрузке содержимого, см. httpService.SaveFile
+func (r *CryptoService) encryptFileInfo(file model.File) (encrypted model.File, err error) {
+	encrypted.ID = file.ID
+	encrypted.UID = file.UID
+	encrypted.Placement = file.Placement
+
+	if encrypted.Name, err = r.encryptString(file.Name, r.itemAD(itemFile, file.UID, "name")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.MetaInfo, err = r.encryptString(file.MetaInfo, r.itemAD(itemFile, file.UID, "metainfo")); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.Fields, err = r.encryptFields(file.Fields, itemFile, encrypted.UID); err != nil {
+		return encrypted, err
+	}
+
+	return encrypted, nil
+}
+
+// decryptFileInfo расшифровывает имя и описание файла
+func (r *CryptoService) decryptFileInfo(file *model.File) (err error) {
+	if file.MetaInfo, err = r.decryptString(file.MetaInfo, r.itemAD(itemFile, file.UID, "metainfo")); err != nil {
+		return err
+	}
+
+	if file.Name, err = r.decryptString(file.Name, r.itemAD(itemFile, file.UID, "name")); err != nil {
+		return err
+	}
+
+	if err = r.decryptFields(file.Fields, itemFile, file.UID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// fileContentAD возвращает связанные данные содержимого файла
+func (r *CryptoService) fileContentAD(file *model.File) []byte {
+	return r.itemAD(itemFile, file.UID, "content")
+}
+
+// encryptFileContent возвращает поток, который шифрует содержимое src файла file
+func (r *CryptoService) encryptFileContent(src io.ReadCloser, file *model.File) (io.ReadCloser, error) {
+	ad := r.fileContentAD(file)
+	if ad == nil {
+		return nil, ErrCryptoUnbound
+	}
+
+	return r.EncryptFile(src, ad)
+}
+
+// decryptFileContent возвращает поток, который расшифровывает содержимое src файла file
+func (r *CryptoService) decryptFileContent(src io.Reader, file *model.File) (io.Reader, error) {
+	ad := r.fileContentAD(file)
+	if ad == nil && !r.acceptsUnbound() {
+		return nil, ErrCryptoUnbound
+	}
+
+	return r.DecryptFile(src, ad)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+// vaultView локальное представление записей хранилища пользователя, собранное из изменений
+// после ревизии синхронизации: расшифровываются только новые и изменённые записи
+type vaultView struct {
+	mu       sync.Mutex
+	synced   bool
+	revision int64
+	entries  map[int]viewEntry
+}
+
+// viewEntry расшифрованная запись представления и её размещение
+type viewEntry struct {
+	value contentValue
+	place model.Placement
+}
+
+func newVaultView() *vaultView {
+	return &vaultView{entries: make(map[int]viewEntry)}
+}
+
+// reset очищает представление, следующая синхронизация получает все записи хранилища
+func (v *vaultView) reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.clear()
+}
+
+// clear очищает представление. Вызывается под блокировкой mu
+func (v *vaultView) clear() {
+	v.synced, v.revision = false, 0
+	v.entries = make(map[int]viewEntry)
+}
+
+// since возвращает ревизию синхронизации, после которой запрашиваются изменения.
+// Вызывается под блокировкой mu
+func (v *vaultView) since() int64 {
+	if !v.synced {
+		return 0
+	}
+
+	return v.revision
+}
+
+// apply применяет изменения changes, расшифрованные сервисом cs. Ревизия запоминается,
+// только если расшифрованы все записи, поэтому после ошибки изменения запрашиваются повторно.
+// Вызывается под блокировкой mu
+func (v *vaultView) apply(changes model.Sync, cs *CryptoService) error {
+	if changes.Reset {
+		v.synced = false
+		v.entries = make(map[int]viewEntry)
+	}
+
+	for _, item := range changes.Items {
+		if item.Deleted {
+			delete(v.entries, item.ID)
+
+			continue
+		}
+
+		value, err := cs.decryptValue(item.Type, item.UID, item.Data)
+		if err != nil {
+			return fmt.Errorf("error decrypted %s with id = %d: %w", item.Type, item.ID, err)
+		}
+
+		v.entries[item.ID] = viewEntry{value: value, place: item.Placement}
+	}
+
+	v.synced, v.revision = true, changes.Revision
+
+	return nil
+}
+
+// values возвращает копии записей представления, начиная с последней созданной
+func (v *vaultView) values() []interface{} {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	ids := make([]int, 0, len(v.entries))
+	for id := range v.entries {
+		ids = append(ids, id)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(ids)))
+
+	values := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, placedValue(v.entries[id], id))
+	}
+
+	return values
+}
+
+// placedValue возвращает копию записи entry с идентификатором id и её размещением, дополнительные
+// поля и метки копируются, чтобы изменение полученной записи не меняло представление
+func placedValue(entry viewEntry, id int) interface{} {
+	place := model.Placement{FolderID: entry.place.FolderID, TagIDs: append([]int(nil), entry.place.TagIDs...)}
+
+	switch value := entry.value.(type) {
+	case *model.Login:
+		login := *value
+		login.ID, login.Placement, login.Fields = id, place, append([]model.Field(nil), value.Fields...)
+
+		return login
+	case *model.Card:
+		card := *value
+		card.ID, card.Placement, card.Fields = id, place, append([]model.Field(nil), value.Fields...)
+
+		return card
+	case *model.Note:
+		note := *value
+		note.ID, note.Placement, note.Fields = id, place, append([]model.Field(nil), value.Fields...)
+
+		return note
+	case *model.OTP:
+		otp := *value
+		otp.ID, otp.Placement, otp.Fields = id, place, append([]model.Field(nil), value.Fields...)
+
+		return otp
+	case *model.SSHKey:
+		key := *value
+		key.ID, key.Placement, key.Fields = id, place, append([]model.Field(nil), value.Fields...)
+
+		return key
+	case *model.File:
+		file := *value
+		file.ID, file.Placement, file.Fields = id, place, append([]model.Field(nil), value.Fields...)
+
+		return file
+	case *model.Item:
+		item := *value
+		item.ID, item.Placement, item.Fields = id, place, append([]model.Field(nil), value.Fields...)
+
+		return item
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,289 @@
+package sshagent
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/vukit/gophkeeper/internal/client/logger"
+	"github.com/vukit/gophkeeper/internal/client/model"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	ErrSSHAgentReadOnly    = errors.New("ssh agent keys are managed by gophkeeper")
+	ErrSSHAgentKeyNotFound = errors.New("ssh agent key not found")
+	ErrSSHAgentDenied      = errors.New("ssh agent key use denied")
+	ErrSSHAgentNotSocket   = errors.New("ssh agent socket path is occupied by a file that is not a socket")
+	ErrSSHAgentSocketInUse = errors.New("ssh agent socket path is in use by another agent")
+)
+
+// checkStaleSocket проверяет, что файл на месте socketPath отсутствует или является сокетом,
+// на котором никто не принимает соединения, и его можно заменить сокетом агента
+func checkStaleSocket(socketPath string) error {
+	info, err := os.Lstat(socketPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return ErrSSHAgentNotSocket
+	}
+
+	conn, err := net.Dial("unix", socketPath)
+	if err == nil {
+		conn.Close()
+
+		return ErrSSHAgentSocketInUse
+	}
+
+	return nil
+}
+
+// ConfirmFunc запрашивает у пользователя разрешение на использование ключа key
+type ConfirmFunc func(key model.SSHKey) bool
+
+// Agent агент SSH, предоставляющий ключи SSH разблокированного хранилища по протоколу ssh-agent.
+// Набор ключей агента определяется хранилищем, поэтому добавление и удаление ключей клиентами агента запрещено
+type Agent struct {
+	mu      sync.RWMutex
+	keys    []agentKey
+	confirm ConfirmFunc
+	mLogger *logger.Logger
+}
+
+type agentKey struct {
+	key    model.SSHKey
+	signer ssh.Signer
+}
+
+var _ agent.ExtendedAgent = (*Agent)(nil)
+
+// NewAgent возвращает агент SSH без ключей
+func NewAgent(mLogger *logger.Logger) *Agent {
+	return &Agent{mLogger: mLogger}
+}
+
+// SetKeys заменяет ключи агента ключами хранилища keys, ключи, которые не удалось разобрать, пропускаются
+func (a *Agent) SetKeys(keys []model.SSHKey) {
+	agentKeys := make([]agentKey, 0, len(keys))
+
+	for _, key := range keys {
+		signer, err := key.Signer()
+		if err != nil {
+			a.mLogger.Info(fmt.Sprintf("ssh agent skipped key %s: %s", key.Fingerprint, err))
+
+			continue
+		}
+
+		agentKeys = append(agentKeys, agentKey{key: key, signer: signer})
+	}
+
+	a.mu.Lock()
+	a.keys = agentKeys
+	a.mu.Unlock()
+}
+
+// SetConfirm устанавливает функцию подтверждения использования ключей с признаком Confirm,
+// без неё использование таких ключей запрещено
+func (a *Agent) SetConfirm(confirm ConfirmFunc) {
+	a.mu.Lock()
+	a.confirm = confirm
+	a.mu.Unlock()
+}
+
+// Serve принимает соединения клиентов агента на сокете socketPath, доступном только владельцу,
+// до отмены контекста ctx, после чего сокет удаляется, если он всё ещё принадлежит агенту.
+// Сокет создаётся в каталоге, доступном только владельцу, и переносится на место socketPath
+// после установки прав. Существующий файл на месте socketPath заменяется, только если он
+// является сокетом, на котором никто не принимает соединения
+func (a *Agent) Serve(ctx context.Context, socketPath string) error {
+	if err := checkStaleSocket(socketPath); err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp(filepath.Dir(socketPath), ".gophkeeper_agent_*")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(dir)
+
+	tmpPath := filepath.Join(dir, "agent.sock")
+
+	listener, err := net.Listen("unix", tmpPath)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Chmod(tmpPath, 0o600); err != nil {
+		listener.Close()
+
+		return err
+	}
+
+	socket, err := os.Lstat(tmpPath)
+	if err != nil {
+		listener.Close()
+
+		return err
+	}
+
+	if err = os.Rename(tmpPath, socketPath); err != nil {
+		listener.Close()
+
+		return err
+	}
+
+	defer func() {
+		if info, err := os.Lstat(socketPath); err == nil && os.SameFile(socket, info) {
+			os.Remove(socketPath)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	a.mLogger.Info("ssh agent listening on " + socketPath)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		go func() {
+			defer conn.Close()
+
+			agent.ServeAgent(a, conn)
+		}()
+	}
+}
+
+// List возвращает открытые ключи агента
+func (a *Agent) List() ([]*agent.Key, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	keys := make([]*agent.Key, 0, len(a.keys))
+
+	for _, k := range a.keys {
+		public := k.signer.PublicKey()
+		keys = append(keys, &agent.Key{Format: public.Type(), Blob: public.Marshal(), Comment: k.key.Comment})
+	}
+
+	return keys, nil
+}
+
+// Sign подписывает данные data ключом key
+func (a *Agent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+// SignWithFlags подписывает данные data ключом key, флаги выбирают алгоритм подписи ключом RSA.
+// Использование ключа с признаком Confirm требует подтверждения пользователя
+func (a *Agent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	a.mu.RLock()
+	k, ok := a.find(key)
+	confirm := a.confirm
+	a.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrSSHAgentKeyNotFound
+	}
+
+	if k.key.Confirm && (confirm == nil || !confirm(k.key)) {
+		a.mLogger.Info(fmt.Sprintf("ssh agent denied use of key %s", k.key.Fingerprint))
+
+		return nil, ErrSSHAgentDenied
+	}
+
+	a.mLogger.Info(fmt.Sprintf("ssh agent signed with key %s", k.key.Fingerprint))
+
+	var algorithm string
+
+	switch {
+	case flags&agent.SignatureFlagRsaSha256 != 0:
+		algorithm = ssh.KeyAlgoRSASHA256
+	case flags&agent.SignatureFlagRsaSha512 != 0:
+		algorithm = ssh.KeyAlgoRSASHA512
+	}
+
+	if algorithmSigner, ok := k.signer.(ssh.AlgorithmSigner); ok && algorithm != "" {
+		return algorithmSigner.SignWithAlgorithm(rand.Reader, data, algorithm)
+	}
+
+	return k.signer.Sign(rand.Reader, data)
+}
+
+// Signers возвращает закрытые ключи агента
+func (a *Agent) Signers() ([]ssh.Signer, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	signers := make([]ssh.Signer, 0, len(a.keys))
+	for _, k := range a.keys {
+		signers = append(signers, k.signer)
+	}
+
+	return signers, nil
+}
+
+// Add запрещён, ключи добавляются в хранилище
+func (a *Agent) Add(agent.AddedKey) error {
+	return ErrSSHAgentReadOnly
+}
+
+// Remove запрещён, ключи удаляются из хранилища
+func (a *Agent) Remove(ssh.PublicKey) error {
+	return ErrSSHAgentReadOnly
+}
+
+// RemoveAll запрещён, ключи удаляются из хранилища
+func (a *Agent) RemoveAll() error {
+	return ErrSSHAgentReadOnly
+}
+
+// Lock запрещён, ключи доступны, пока хранилище разблокировано
+func (a *Agent) Lock([]byte) error {
+	return ErrSSHAgentReadOnly
+}
+
+// Unlock запрещён, ключи доступны, пока хранилище разблокировано
+func (a *Agent) Unlock([]byte) error {
+	return ErrSSHAgentReadOnly
+}
+
+// Extension расширения протокола не поддерживаются
+func (a *Agent) Extension(string, []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
+
+func (a *Agent) find(key ssh.PublicKey) (agentKey, bool) {
+	blob := key.Marshal()
+
+	for _, k := range a.keys {
+		if bytes.Equal(k.signer.PublicKey().Marshal(), blob) {
+			return k, true
+		}
+	}
+
+	return agentKey{}, false
+}
@@ -0,0 +1,249 @@
+package sshagent_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vukit/gophkeeper/internal/client/logger"
+	"github.com/vukit/gophkeeper/internal/client/model"
+	"github.com/vukit/gophkeeper/internal/client/sshagent"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestGenerateKey(t *testing.T) {
+	privateKey, err := sshagent.GenerateKey("user@host")
+	require.NoError(t, err)
+
+	raw, err := ssh.ParseRawPrivateKey([]byte(privateKey))
+	require.NoError(t, err)
+
+	signer, err := ssh.NewSignerFromKey(raw)
+	require.NoError(t, err)
+	assert.Equal(t, ssh.KeyAlgoED25519, signer.PublicKey().Type())
+
+	other, err := sshagent.GenerateKey("user@host")
+	require.NoError(t, err)
+	assert.NotEqual(t, privateKey, other)
+}
+
+func TestAgent(t *testing.T) {
+	keys := make([]model.SSHKey, 2)
+
+	for i := range keys {
+		privateKey, err := sshagent.GenerateKey("key")
+		require.NoError(t, err)
+
+		keys[i] = model.SSHKey{Comment: "key " + string(rune('1'+i)), Confirm: i == 1}
+		require.NoError(t, keys[i].SetPrivateKey(privateKey))
+	}
+
+	allow := false
+	confirmed := make([]string, 0)
+
+	sshAgent := sshagent.NewAgent(logger.NewLogger(io.Discard))
+	sshAgent.SetKeys(append(keys, model.SSHKey{Comment: "broken", PrivateKey: "broken"}))
+	sshAgent.SetConfirm(func(key model.SSHKey) bool {
+		confirmed = append(confirmed, key.Comment)
+
+		return allow
+	})
+
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() { done <- sshAgent.Serve(ctx, socketPath) }()
+
+	var conn net.Conn
+
+	require.Eventually(t, func() bool {
+		var err error
+		conn, err = net.Dial("unix", socketPath)
+
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	info, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	client := agent.NewClient(conn)
+
+	list, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "key 1", list[0].Comment)
+	assert.Equal(t, keys[0].PublicKey, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(list[0]))))
+
+	data := []byte("session data")
+
+	tests := []struct {
+		name      string
+		key       ssh.PublicKey
+		allow     bool
+		confirmed []string
+		wantErr   bool
+	}{
+		{
+			name:      "case 1",
+			key:       list[0],
+			confirmed: []string{},
+		},
+		{
+			name:      "case 2",
+			key:       list[1],
+			allow:     false,
+			confirmed: []string{"key 2"},
+			wantErr:   true,
+		},
+		{
+			name:      "case 3",
+			key:       list[1],
+			allow:     true,
+			confirmed: []string{"key 2", "key 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allow = tt.allow
+
+			signature, err := client.Sign(tt.key, data)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			assert.Equal(t, tt.confirmed, confirmed)
+
+			if err == nil {
+				assert.NoError(t, tt.key.Verify(data, signature))
+			}
+		})
+	}
+
+	signers, err := client.Signers()
+	require.NoError(t, err)
+	assert.Len(t, signers, 2)
+
+	assert.Error(t, client.RemoveAll())
+	assert.Error(t, client.Lock([]byte("passphrase")))
+
+	list, err = client.List()
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	sshAgent.SetKeys(nil)
+
+	list, err = client.List()
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	conn.Close()
+	cancel()
+
+	require.NoError(t, <-done)
+
+	_, err = os.Stat(socketPath)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestAgentSocketPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, socketPath string)
+		want    error
+	}{
+		{
+			name:    "case 1",
+			prepare: func(t *testing.T, socketPath string) {},
+		},
+		{
+			name: "case 2",
+			prepare: func(t *testing.T, socketPath string) {
+				listener, err := net.Listen("unix", socketPath)
+				require.NoError(t, err)
+				listener.(*net.UnixListener).SetUnlinkOnClose(false)
+				listener.Close()
+			},
+		},
+		{
+			name: "case 3",
+			prepare: func(t *testing.T, socketPath string) {
+				require.NoError(t, os.WriteFile(socketPath, []byte("data"), 0o600))
+			},
+			want: sshagent.ErrSSHAgentNotSocket,
+		},
+		{
+			name: "case 4",
+			prepare: func(t *testing.T, socketPath string) {
+				listener, err := net.Listen("unix", socketPath)
+				require.NoError(t, err)
+				t.Cleanup(func() { listener.Close() })
+			},
+			want: sshagent.ErrSSHAgentSocketInUse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			socketPath := filepath.Join(t.TempDir(), "agent.sock")
+			tt.prepare(t, socketPath)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+
+			go func() { done <- sshagent.NewAgent(logger.NewLogger(io.Discard)).Serve(ctx, socketPath) }()
+
+			if tt.want != nil {
+				assert.ErrorIs(t, <-done, tt.want)
+				cancel()
+
+				info, err := os.Lstat(socketPath)
+				require.NoError(t, err)
+
+				if info.Mode()&os.ModeSocket != 0 {
+					conn, err := net.Dial("unix", socketPath)
+					require.NoError(t, err)
+					conn.Close()
+
+					return
+				}
+
+				data, err := os.ReadFile(socketPath)
+				require.NoError(t, err)
+				assert.Equal(t, "data", string(data))
+
+				return
+			}
+
+			require.Eventually(t, func() bool {
+				conn, err := net.Dial("unix", socketPath)
+				if err != nil {
+					return false
+				}
+
+				conn.Close()
+
+				return true
+			}, time.Second, 10*time.Millisecond)
+
+			info, err := os.Stat(socketPath)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+			cancel()
+			require.NoError(t, <-done)
+
+			entries, err := os.ReadDir(filepath.Dir(socketPath))
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package sshagent
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	opensshMagic     = "openssh-key-v1\x00"
+	opensshBlockSize = 8
+)
+
+// GenerateKey возвращает новый закрытый ключ Ed25519 с комментарием comment в формате OpenSSH
+func GenerateKey(comment string) (string, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		return "", err
+	}
+
+	var check [4]byte
+	if _, err = rand.Read(check[:]); err != nil {
+		return "", err
+	}
+
+	// формат закрытого ключа описан в PROTOCOL.key OpenSSH, ключ не шифруется,
+	// так как хранится в хранилище зашифрованным ключом хранилища
+	privateBlock := struct {
+		Check1  uint32
+		Check2  uint32
+		Keytype string
+		Public  []byte
+		Private []byte
+		Comment string
+		Pad     []byte `ssh:"rest"`
+	}{
+		Check1:  binary.BigEndian.Uint32(check[:]),
+		Check2:  binary.BigEndian.Uint32(check[:]),
+		Keytype: ssh.KeyAlgoED25519,
+		Public:  public,
+		Private: private,
+		Comment: comment,
+	}
+
+	for i := 1; len(ssh.Marshal(privateBlock))%opensshBlockSize != 0; i++ {
+		privateBlock.Pad = append(privateBlock.Pad, byte(i))
+	}
+
+	key := struct {
+		CipherName   string
+		KdfName      string
+		KdfOpts      string
+		NumKeys      uint32
+		PublicKey    []byte
+		PrivateBlock []byte
+	}{
+		CipherName:   "none",
+		KdfName:      "none",
+		NumKeys:      1,
+		PublicKey:    sshPublic.Marshal(),
+		PrivateBlock: ssh.Marshal(privateBlock),
+	}
+
+	block := &pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: append([]byte(opensshMagic), ssh.Marshal(key)...)}
+
+	return string(pem.EncodeToMemory(block)), nil
+}
@@ -0,0 +1,276 @@
+package tui
+
+import (
+	"context"
+
+	"github.com/rivo/tview"
+	"github.com/vukit/gophkeeper/internal/client"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+// Account компонент реализует текстовый интерфейс управления учётной записью пользователя
+func (r *TUI) Account(ctx context.Context, user *model.User, service client.GophKeeperService, downloadFolder string) *tview.Flex {
+	password := &model.MasterPassword{}
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow)
+
+	pages := tview.NewPages()
+
+	form := tview.NewForm()
+	setupAccountForm(ctx, form, user, password, r, service)
+
+	pages.AddPage("password", form, true, true)
+
+	showCodes := func(codes []string) {
+		pages.AddAndSwitchToPage("codes", RecoveryCodes(codes, func() {
+			pages.RemovePage("codes")
+			r.app.SetFocus(form)
+		}), true)
+		r.app.SetFocus(pages)
+	}
+
+	vault := tview.NewForm().
+		AddButton("New recovery codes", func() {
+			pages.AddAndSwitchToPage("renew", RecoveryCodesRenew(func(password string) {
+				if err := (&model.RecoveryKeysChange{Password: password}).Validate(); err != nil {
+					r.alertChannel <- err.Error()
+
+					return
+				}
+
+				codes, err := service.NewRecoveryCodes(ctx, user, password)
+				if err != nil {
+					r.alertChannel <- err.Error()
+
+					return
+				}
+
+				pages.RemovePage("renew")
+				showCodes(codes)
+			}, func() {
+				pages.RemovePage("renew")
+				r.app.SetFocus(form)
+			}), true)
+			r.app.SetFocus(pages)
+		}).
+		AddButton("Rotate vault key", func() {
+			pages.AddAndSwitchToPage("rotate", VaultKeyRotation(func() {
+				pages.RemovePage("rotate")
+				r.app.SetFocus(form)
+
+				r.alertChannel <- "re-encrypting vault, please wait..."
+
+				go func() {
+					codes, err := service.RotateVaultKey(ctx, user)
+					if err != nil {
+						r.alertChannel <- err.Error()
+
+						return
+					}
+
+					r.alertChannel <- "vault key was successfully rotated, history and trash were emptied"
+
+					r.app.QueueUpdateDraw(func() { showCodes(codes) })
+				}()
+			}, func() {
+				pages.RemovePage("rotate")
+				r.app.SetFocus(form)
+			}), true)
+			r.app.SetFocus(pages)
+		})
+	vault.SetBorder(true).SetTitle("[ Vault key ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	sessions := tview.NewForm().
+		AddButton("Log out all devices", func() {
+			if err := service.LogoutAll(ctx); err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			r.app.Stop()
+		})
+	sessions.SetBorder(true).SetTitle("[ Sessions ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	closePage := func(name string) {
+		pages.RemovePage(name)
+		r.app.SetFocus(form)
+	}
+
+	twoFactor := tview.NewForm().
+		AddButton("Enable", func() {
+			enrollment, err := service.EnrollTOTP(ctx)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			pages.AddAndSwitchToPage("totp", TOTPEnrollment(enrollment, func(code string) {
+				if err := (&model.TOTPCode{Code: code}).Validate(); err != nil {
+					r.alertChannel <- err.Error()
+
+					return
+				}
+
+				backupCodes, err := service.ConfirmTOTP(ctx, code)
+				if err != nil {
+					r.alertChannel <- err.Error()
+
+					return
+				}
+
+				r.alertChannel <- "two-factor authentication was successfully enabled"
+
+				pages.RemovePage("totp")
+				pages.AddAndSwitchToPage("backup", BackupCodes(backupCodes, func() { closePage("backup") }), true)
+				r.app.SetFocus(pages)
+			}, func() { closePage("totp") }), true)
+			r.app.SetFocus(pages)
+		}).
+		AddButton("Disable", func() {
+			pages.AddAndSwitchToPage("totp", TOTPDisable(func(code string) {
+				if err := (&model.TOTPCode{Code: code}).Validate(); err != nil {
+					r.alertChannel <- err.Error()
+
+					return
+				}
+
+				if err := service.DisableTOTP(ctx, code); err != nil {
+					r.alertChannel <- err.Error()
+
+					return
+				}
+
+				r.alertChannel <- "two-factor authentication was successfully disabled"
+
+				closePage("totp")
+			}, func() { closePage("totp") }), true)
+			r.app.SetFocus(pages)
+		})
+	twoFactor.SetBorder(true).SetTitle("[ Two-factor authentication ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	data := tview.NewForm().
+		AddButton("Export", func() {
+			r.alertChannel <- "exporting account data, please wait..."
+
+			go func() {
+				path, err := service.ExportAccount(ctx, user, downloadFolder)
+				if err != nil {
+					r.alertChannel <- err.Error()
+
+					return
+				}
+
+				r.alertChannel <- "account data was exported to " + path
+			}()
+		}).
+		AddButton("Import", func() {
+			pages.AddAndSwitchToPage("import", AccountImport(func(accountImport model.AccountImport) {
+				if err := accountImport.Validate(); err != nil {
+					r.alertChannel <- err.Error()
+
+					return
+				}
+
+				r.alertChannel <- "importing account data, please wait..."
+
+				closePage("import")
+
+				go func() {
+					if err := service.ImportAccount(ctx, accountImport); err != nil {
+						r.alertChannel <- err.Error()
+
+						return
+					}
+
+					r.alertChannel <- "account data was successfully imported"
+				}()
+			}, func() { closePage("import") }), true)
+			r.app.SetFocus(pages)
+		}).
+		AddButton("Delete account", func() {
+			pages.AddAndSwitchToPage("delete", AccountDelete(func(password string) {
+				if err := (&model.AccountDeletion{Password: password}).Validate(); err != nil {
+					r.alertChannel <- err.Error()
+
+					return
+				}
+
+				if err := service.DeleteAccount(ctx, user, password); err != nil {
+					r.alertChannel <- err.Error()
+
+					return
+				}
+
+				r.app.Stop()
+			}, func() { closePage("delete") }), true)
+			r.app.SetFocus(pages)
+		})
+	tokens := tview.NewForm().
+		AddButton("Manage tokens", func() {
+			pages.AddAndSwitchToPage("tokens", r.APITokens(ctx, service, func() { closePage("tokens") }), true)
+			r.app.SetFocus(pages)
+		})
+	tokens.SetBorder(true).SetTitle("[ API tokens ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	data.SetBorder(true).SetTitle("[ Account data ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	layout.AddItem(pages, 0, 1, true).
+		AddItem(vault, 5, 0, false).
+		AddItem(twoFactor, 5, 0, false).
+		AddItem(data, 5, 0, false).
+		AddItem(tokens, 5, 0, false).
+		AddItem(sessions, 5, 0, false)
+
+	return layout
+}
+
+func setupAccountForm(
+	ctx context.Context,
+	form *tview.Form,
+	user *model.User,
+	password *model.MasterPassword,
+	r *TUI,
+	service client.GophKeeperService,
+) {
+	form.Clear(true)
+	form.
+		AddPasswordField("Current password", password.Current, 30, '*', func(text string) { password.Current = text }).
+		AddPasswordField("New password", password.New, 30, '*', func(text string) { password.New = text }).
+		AddPasswordField("Confirm password", password.Confirm, 30, '*', func(text string) { password.Confirm = text }).
+		AddButton("Change password", func() {
+			err := password.Validate()
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			current, changed := password.Current, password.New
+
+			r.alertChannel <- "changing password, please wait..."
+
+			go func() {
+				errService := service.ChangePassword(ctx, user, current, changed)
+				if errService != nil {
+					r.alertChannel <- errService.Error()
+
+					return
+				}
+
+				r.alertChannel <- "password was successfully changed"
+			}()
+
+			*password = model.MasterPassword{}
+			setupAccountForm(ctx, form, user, password, r, service)
+		}).
+		AddButton("Cancel", func() {
+			*password = model.MasterPassword{}
+			setupAccountForm(ctx, form, user, password, r, service)
+		})
+
+	form.SetBorder(true).SetTitle("[ Change master password ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	r.app.SetFocus(form)
+}
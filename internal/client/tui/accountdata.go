@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"github.com/rivo/tview"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+// AccountImport компонент запрашивает путь к архиву выгрузки учётной записи и мастер-пароль
+// учётной записи, из которой он выгружен
+func AccountImport(accountImport func(model.AccountImport), cancel func()) *tview.Form {
+	data := model.AccountImport{}
+
+	form := tview.NewForm().
+		AddInputField("Archive", data.Path, 60, nil, func(text string) { data.Path = text }).
+		AddPasswordField("Master password", data.Password, 30, '*', func(text string) { data.Password = text }).
+		AddButton("Import", func() { accountImport(data) }).
+		AddButton("Cancel", cancel)
+	form.SetBorder(true).SetTitle("[ Import account data ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	return form
+}
+
+// AccountDelete компонент запрашивает мастер-пароль для подтверждения удаления учётной записи
+func AccountDelete(deleteAccount func(password string), cancel func()) *tview.Flex {
+	text := tview.NewTextView().SetWrap(true).
+		SetText("The account, all its data and files will be deleted from the server permanently.\n" +
+			"Export the account data first if you want to keep it.")
+	text.SetBorder(true).SetTitle("[ Delete account ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	password := ""
+
+	form := tview.NewForm().
+		AddPasswordField("Master password", password, 30, '*', func(text string) { password = text }).
+		AddButton("Delete account", func() { deleteAccount(password) }).
+		AddButton("Cancel", cancel)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(text, 5, 0, false).
+		AddItem(form, 5, 0, true)
+
+	return layout
+}
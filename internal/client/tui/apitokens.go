@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/vukit/gophkeeper/internal/client"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+const apiTokenTimeLayout = "2006-01-02 15:04"
+
+// APITokens компонент реализует текстовый интерфейс создания, просмотра и отзыва токенов API,
+// done вызывается при закрытии компонента
+func (r *TUI) APITokens(ctx context.Context, service client.GophKeeperService, done func()) *tview.Pages {
+	pages := tview.NewPages()
+
+	list := tview.NewList()
+	list.SetTitle("[ API tokens ]").SetBorder(true).SetBorderPadding(1, 1, 1, 1)
+
+	form := tview.NewForm()
+
+	var update func()
+
+	showSecret := func(secret string) {
+		text := tview.NewTextView().SetWrap(true).
+			SetText("Copy the token now, it will not be shown again.\n" +
+				"Pass it in the header \"Authorization: Bearer <token>\".\n\n" + secret)
+		text.SetBorder(true).SetTitle("[ New API token ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+		layout := tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(text, 8, 0, false).
+			AddItem(tview.NewForm().AddButton("Continue", func() {
+				pages.RemovePage("secret")
+				r.app.SetFocus(form)
+			}), 3, 0, true)
+
+		pages.AddAndSwitchToPage("secret", layout, true)
+		r.app.SetFocus(layout)
+	}
+
+	var setupForm func(token *model.APIToken)
+
+	setupForm = func(token *model.APIToken) {
+		form.Clear(true)
+
+		if token != nil {
+			expires := "never"
+			if token.ExpiresAt != nil {
+				expires = token.ExpiresAt.Local().Format(apiTokenTimeLayout)
+			}
+
+			lastUsed := "never"
+			if token.LastUsedAt != nil {
+				lastUsed = token.LastUsedAt.Local().Format(apiTokenTimeLayout)
+			}
+
+			form.
+				AddTextView("Name", token.Name, 60, 1, true, false).
+				AddTextView("Scopes", strings.Join(token.Scopes, ", "), 60, 1, true, false).
+				AddTextView("Expires", expires, 60, 1, true, false).
+				AddTextView("Last used", lastUsed, 60, 1, true, false).
+				AddButton("Revoke", func() {
+					if err := service.RevokeAPIToken(ctx, token); err != nil {
+						r.alertChannel <- err.Error()
+
+						return
+					}
+
+					r.alertChannel <- "token was successfully revoked"
+
+					setupForm(nil)
+					update()
+				}).
+				AddButton("Cancel", func() { setupForm(nil) })
+			form.SetTitle("[ API token ]")
+
+			r.app.SetFocus(form)
+
+			return
+		}
+
+		data := model.APITokenForm{Scopes: "logins:read, cards:read, files:read, notes:read, otps:read, sshkeys:read, items:read, folders:read, tags:read, history:read, trash:read, sync:read"}
+
+		form.
+			AddInputField("Name", data.Name, 30, nil, func(text string) { data.Name = text }).
+			AddInputField("Scopes", data.Scopes, 60, nil, func(text string) { data.Scopes = text }).
+			AddInputField("Expires in days", data.ExpiresIn, 10, nil, func(text string) { data.ExpiresIn = text }).
+			AddButton("Create", func() {
+				token, err := data.APIToken()
+				if err != nil {
+					r.alertChannel <- err.Error()
+
+					return
+				}
+
+				secret, err := service.CreateAPIToken(ctx, token)
+				if err != nil {
+					r.alertChannel <- err.Error()
+
+					return
+				}
+
+				setupForm(nil)
+				update()
+				showSecret(secret)
+			}).
+			AddButton("Close", done)
+		form.SetTitle("[ New API token ]")
+	}
+
+	form.SetBorder(true).SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	update = func() {
+		go func() {
+			tokens, err := service.GetAPITokens(ctx)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			r.app.QueueUpdateDraw(func() {
+				list.Clear()
+
+				for _, token := range tokens {
+					token := token
+					list.AddItem(token.Name, fmt.Sprintf("%s, created %s",
+						strings.Join(token.Scopes, ","), token.CreatedAt.Local().Format(apiTokenTimeLayout)),
+						rune(0), func() { setupForm(&token) })
+				}
+			})
+		}()
+	}
+
+	setupForm(nil)
+	update()
+
+	pages.AddPage("tokens", tview.NewFlex().AddItem(list, 45, 0, false).AddItem(form, 0, 1, true), true, true)
+
+	return pages
+}
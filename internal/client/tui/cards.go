@@ -2,7 +2,6 @@ package tui
 
 import (
 	"context"
-	"time"
 
 	"github.com/rivo/tview"
 	"github.com/vukit/gophkeeper/internal/client"
@@ -21,11 +20,14 @@ func (r *TUI) Cards(ctx context.Context, user *model.User, service client.GophKe
 
 	form := tview.NewForm()
 	card.ID = 0
+	card.UID = ""
 	card.Bank = ""
 	card.Number = ""
 	card.Date = ""
 	card.CVV = ""
 	card.MetaInfo = ""
+	card.Fields = nil
+	card.Placement = model.Placement{}
 	setupCardForm(ctx, form, card, r, service)
 
 	layout.AddItem(list, 35, 0, false).AddItem(form, 0, 1, true)
@@ -50,6 +52,8 @@ func setupCardForm(
 		AddInputField("CVV", card.CVV, 4, nil, func(text string) { card.CVV = text }).
 		AddInputField("Metainfo", card.MetaInfo, 30, nil, func(text string) { card.MetaInfo = text }).
 		AddButton("Save", func() {
+			card.Fields = compactFields(card.Fields)
+
 			err := card.Validate()
 			if err != nil {
 				r.alertChannel <- err.Error()
@@ -66,27 +70,43 @@ func setupCardForm(
 
 			r.alertChannel <- "card was successfully saved"
 			card.ID = 0
+			card.UID = ""
 			card.Bank = ""
 			card.Number = ""
 			card.Date = ""
 			card.CVV = ""
 			card.MetaInfo = ""
+			card.Fields = nil
+			card.Placement = model.Placement{}
 			setupCardForm(ctx, form, card, r, service)
 		}).
 		AddButton("Cancel", func() {
 			card.ID = 0
+			card.UID = ""
 			card.Bank = ""
 			card.Number = ""
 			card.Date = ""
 			card.CVV = ""
 			card.MetaInfo = ""
+			card.Fields = nil
+			card.Placement = model.Placement{}
 			setupCardForm(ctx, form, card, r, service)
 		})
 
+	r.addPlacement(ctx, form, service, &card.Placement)
+	r.addFields(form, &card.Fields)
+
 	if idx := form.GetButtonIndex("Delete"); idx != -1 {
 		form.RemoveButton(idx)
 	}
 
+	if card.ID != 0 {
+		r.addHistory(ctx, form, service, card.ID, card.Content(), func() {
+			*card = model.Card{}
+			setupCardForm(ctx, form, card, r, service)
+		})
+	}
+
 	form.SetBorder(true).SetTitle("[ New card ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
 
 	r.app.SetFocus(form)
@@ -100,11 +120,11 @@ func cardsUpdateList(
 	r *TUI,
 	service client.GophKeeperService,
 ) {
-	ticker := time.NewTicker(500 * time.Millisecond)
+	changes := r.subscribe()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-changes:
 			cards, err := service.GetCards(ctx)
 			if err != nil {
 				r.alertChannel <- err.Error()
@@ -118,6 +138,11 @@ func cardsUpdateList(
 
 			for _, currentcard := range cards {
 				currentCard := currentcard
+
+				if !r.filter.match(currentCard.Placement) {
+					continue
+				}
+
 				list.AddItem(currentCard.MetaInfo, "", rune(0), func() {
 					card = &currentCard
 					setupCardForm(ctx, form, card, r, service)
@@ -132,11 +157,14 @@ func cardsUpdateList(
 							}
 							r.alertChannel <- "card was successfully deleted"
 							card.ID = 0
+							card.UID = ""
 							card.Bank = ""
 							card.Number = ""
 							card.Date = ""
 							card.CVV = ""
 							card.MetaInfo = ""
+							card.Fields = nil
+							card.Placement = model.Placement{}
 							setupCardForm(ctx, form, card, r, service)
 						})
 					}
@@ -147,8 +175,6 @@ func cardsUpdateList(
 
 			r.app.Draw()
 		case <-ctx.Done():
-			ticker.Stop()
-
 			return
 		}
 	}
@@ -0,0 +1,35 @@
+package tui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	gkservice "github.com/vukit/gophkeeper/internal/client/service"
+)
+
+// CertificateChanged предупреждает, что ключ сертификата сервера изменился, и объясняет,
+// как принять новый ключ, если замена ожидаема
+func CertificateChanged(err *gkservice.CertificateChangedError, done func()) *tview.Modal {
+	return tview.NewModal().
+		SetText("WARNING: SERVER CERTIFICATE HAS CHANGED!\n\n" + err.Error()).
+		SetBackgroundColor(tcell.ColorDarkRed).
+		SetTextColor(tcell.ColorWhite).
+		AddButtons([]string{"Quit"}).
+		SetDoneFunc(func(int, string) { done() })
+}
+
+// UnknownServer просит подтвердить ключ сертификата сервера, который не удалось проверить
+// удостоверяющим центром, при первом подключении к серверу
+func UnknownServer(err *gkservice.UnknownServerError, trust, quit func()) *tview.Modal {
+	return tview.NewModal().
+		SetText("UNKNOWN SERVER CERTIFICATE\n\n" + err.Error()).
+		AddButtons([]string{"Trust", "Quit"}).
+		SetDoneFunc(func(_ int, label string) {
+			if label == "Trust" {
+				trust()
+
+				return
+			}
+
+			quit()
+		})
+}
@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+const fieldPage = "add-field"
+
+// addFields добавляет в форму form поля ввода дополнительных полей записи fields и кнопку добавления поля.
+// Поле, значение которого очищено, удаляется из записи при сохранении, см. compactFields
+func (r *TUI) addFields(form *tview.Form, fields *[]model.Field) {
+	for _, field := range *fields {
+		addFieldInput(form, fields, field)
+	}
+
+	form.AddButton("Add field", func() {
+		r.showFieldForm(form, fields)
+	})
+}
+
+// addFieldInput добавляет в форму form поле ввода дополнительного поля field, значение скрытого поля
+// не отображается. Поле записи находится по названию, поэтому ввод не зависит от удаления других полей
+func addFieldInput(form *tview.Form, fields *[]model.Field, field model.Field) {
+	changed := func(text string) {
+		field.Value = text
+		*fields = setField(*fields, field)
+	}
+
+	if field.Type == model.FieldTypeHidden {
+		form.AddPasswordField(field.Name, field.Value, 40, '*', changed)
+
+		return
+	}
+
+	form.AddInputField(field.Name, field.Value, 40, nil, changed)
+}
+
+// showFieldForm показывает поверх компонента форму добавления дополнительного поля записи fields,
+// добавленное поле появляется в форме form
+func (r *TUI) showFieldForm(form *tview.Form, fields *[]model.Field) {
+	field := model.Field{Type: model.FieldTypeText}
+
+	hide := func() {
+		r.pages.RemovePage(fieldPage)
+		r.app.SetFocus(form)
+	}
+
+	fieldForm := tview.NewForm()
+	fieldForm.
+		AddInputField("Name", "", 30, nil, func(text string) { field.Name = text }).
+		AddDropDown("Type", model.FieldTypes, 0, func(option string, _ int) { field.Type = option }).
+		AddInputField("Value", "", 30, nil, func(text string) { field.Value = text }).
+		AddButton("Add", func() {
+			if err := field.Validate(); err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			for _, current := range *fields {
+				if current.Name == field.Name {
+					r.alertChannel <- model.ErrFieldNameExists.Error()
+
+					return
+				}
+			}
+
+			*fields = append(*fields, field)
+			addFieldInput(form, fields, field)
+			hide()
+		}).
+		AddButton("Cancel", hide)
+
+	fieldForm.SetBorder(true).SetTitle("[ Add field ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	modal := tview.NewGrid().
+		SetColumns(0, 60, 0).
+		SetRows(0, 13, 0).
+		AddItem(fieldForm, 1, 1, 1, 1, 0, 0, true)
+
+	r.pages.AddPage(fieldPage, modal, true, true)
+	r.app.SetFocus(fieldForm)
+}
+
+// compactFields возвращает дополнительные поля записи без полей с очищенным значением
+func compactFields(fields []model.Field) []model.Field {
+	compacted := make([]model.Field, 0, len(fields))
+
+	for _, field := range fields {
+		if strings.TrimSpace(field.Value) != "" {
+			compacted = append(compacted, field)
+		}
+	}
+
+	return compacted
+}
+
+// setField заменяет дополнительное поле записи с названием field.Name или добавляет его
+func setField(fields []model.Field, field model.Field) []model.Field {
+	for i := range fields {
+		if fields[i].Name == field.Name {
+			fields[i] = field
+
+			return fields
+		}
+	}
+
+	return append(fields, field)
+}
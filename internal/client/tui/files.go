@@ -2,7 +2,6 @@ package tui
 
 import (
 	"context"
-	"time"
 
 	"github.com/rivo/tview"
 	"github.com/vukit/gophkeeper/internal/client"
@@ -25,8 +24,11 @@ func (r *TUI) Files(
 
 	form := tview.NewForm()
 	file.ID = 0
+	file.UID = ""
 	file.Path = ""
 	file.MetaInfo = ""
+	file.Fields = nil
+	file.Placement = model.Placement{}
 	setupFileForm(ctx, form, file, r, service, downloadFolder)
 
 	fileBrowser := r.FileBrowser(form, downloadFolder)
@@ -51,6 +53,8 @@ func setupFileForm(
 		AddInputField("Metainfo", file.MetaInfo, 60, nil, func(text string) { file.MetaInfo = text }).
 		AddInputField("File", "", 60, nil, func(text string) { file.Path = text }).
 		AddButton("Save", func() {
+			file.Fields = compactFields(file.Fields)
+
 			err := file.Validate()
 			if err != nil {
 				r.alertChannel <- err.Error()
@@ -68,17 +72,26 @@ func setupFileForm(
 
 			r.alertChannel <- "file was successfully saved"
 			file.ID = 0
+			file.UID = ""
 			file.Path = ""
 			file.MetaInfo = ""
+			file.Fields = nil
+			file.Placement = model.Placement{}
 			setupFileForm(ctx, form, file, r, service, downloadFolder)
 		}).
 		AddButton("Cancel", func() {
 			file.ID = 0
+			file.UID = ""
 			file.Path = ""
 			file.MetaInfo = ""
+			file.Fields = nil
+			file.Placement = model.Placement{}
 			setupFileForm(ctx, form, file, r, service, downloadFolder)
 		})
 
+	r.addPlacement(ctx, form, service, &file.Placement)
+	r.addFields(form, &file.Fields)
+
 	if idx := form.GetButtonIndex("Delete"); idx != -1 {
 		form.RemoveButton(idx)
 	}
@@ -97,11 +110,11 @@ func filesUpdateList(
 	service client.GophKeeperService,
 	downloadFolder string,
 ) {
-	ticker := time.NewTicker(500 * time.Millisecond)
+	changes := r.subscribe()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-changes:
 			files, err := service.GetFiles(ctx)
 			if err != nil {
 				r.alertChannel <- err.Error()
@@ -115,6 +128,11 @@ func filesUpdateList(
 
 			for _, currentFile := range files {
 				currentFile := currentFile
+
+				if !r.filter.match(currentFile.Placement) {
+					continue
+				}
+
 				list.AddItem(currentFile.MetaInfo, currentFile.Name, rune(0), func() {
 					file = &currentFile
 					setupFileForm(ctx, form, file, r, service, downloadFolder)
@@ -129,8 +147,11 @@ func filesUpdateList(
 							}
 							r.alertChannel <- "file was successfully downloaded"
 							file.ID = 0
+							file.UID = ""
 							file.Path = ""
 							file.MetaInfo = ""
+							file.Fields = nil
+							file.Placement = model.Placement{}
 							setupFileForm(ctx, form, file, r, service, downloadFolder)
 						})
 					}
@@ -144,8 +165,11 @@ func filesUpdateList(
 							}
 							r.alertChannel <- "file was successfully deleted"
 							file.ID = 0
+							file.UID = ""
 							file.Path = ""
 							file.MetaInfo = ""
+							file.Fields = nil
+							file.Placement = model.Placement{}
 							setupFileForm(ctx, form, file, r, service, downloadFolder)
 						})
 					}
@@ -156,8 +180,6 @@ func filesUpdateList(
 
 			r.app.Draw()
 		case <-ctx.Done():
-			ticker.Stop()
-
 			return
 		}
 	}
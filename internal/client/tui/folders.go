@@ -0,0 +1,190 @@
+package tui
+
+import (
+	"context"
+
+	"github.com/rivo/tview"
+	"github.com/vukit/gophkeeper/internal/client"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+var folderKinds = []string{"Folder", "Tag"}
+
+// folderEntry папка или метка, которая редактируется в компоненте Folders
+type folderEntry struct {
+	tag    bool
+	folder model.Folder
+	label  model.Tag
+}
+
+// Folders компонент реализует текстовый интерфейс CRUD для папок и меток записей
+func (r *TUI) Folders(ctx context.Context, user *model.User, service client.GophKeeperService) *tview.Flex {
+	entry := &folderEntry{}
+
+	layout := tview.NewFlex()
+
+	list := tview.NewList()
+	list.SetTitle("[ Folders and tags ]").SetBorder(true).SetBorderPadding(1, 1, 1, 1)
+	list.ShowSecondaryText(false)
+
+	form := tview.NewForm()
+	setupFolderForm(ctx, form, entry, r, service)
+
+	layout.AddItem(list, 35, 0, false).AddItem(form, 0, 1, true)
+
+	go foldersUpdateList(ctx, entry, list, form, r, service)
+
+	return layout
+}
+
+func setupFolderForm(
+	ctx context.Context,
+	form *tview.Form,
+	entry *folderEntry,
+	r *TUI,
+	service client.GophKeeperService,
+) {
+	isNew := entry.folder.ID == 0 && entry.label.ID == 0
+
+	form.Clear(true)
+
+	if isNew {
+		kind := 0
+		if entry.tag {
+			kind = 1
+		}
+
+		form.AddDropDown("Kind", folderKinds, kind, func(_ string, index int) { entry.tag = index == 1 })
+	}
+
+	name := entry.folder.Name
+	if entry.tag {
+		name = entry.label.Name
+	}
+
+	form.
+		AddInputField("Name", name, 40, nil, func(text string) {
+			entry.folder.Name = text
+			entry.label.Name = text
+		}).
+		AddButton("Save", func() {
+			var err error
+
+			if entry.tag {
+				if err = entry.label.Validate(); err == nil {
+					err = service.SaveTag(ctx, &entry.label)
+				}
+			} else {
+				if err = entry.folder.Validate(); err == nil {
+					err = service.SaveFolder(ctx, &entry.folder)
+				}
+			}
+
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			r.alertChannel <- folderKind(entry) + " was successfully saved"
+			*entry = folderEntry{}
+			setupFolderForm(ctx, form, entry, r, service)
+		}).
+		AddButton("Cancel", func() {
+			*entry = folderEntry{}
+			setupFolderForm(ctx, form, entry, r, service)
+		})
+
+	title := "[ New folder or tag ]"
+	if !isNew {
+		title = "[ Edit " + folderKind(entry) + " ]"
+
+		form.AddButton("Delete", func() {
+			var errService error
+
+			if entry.tag {
+				errService = service.DeleteTag(ctx, &entry.label)
+			} else {
+				errService = service.DeleteFolder(ctx, &entry.folder)
+			}
+
+			if errService != nil {
+				r.alertChannel <- errService.Error()
+
+				return
+			}
+
+			r.alertChannel <- folderKind(entry) + " was successfully deleted"
+			*entry = folderEntry{}
+			setupFolderForm(ctx, form, entry, r, service)
+		})
+	}
+
+	form.SetBorder(true).SetTitle(title).SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	r.app.SetFocus(form)
+}
+
+func folderKind(entry *folderEntry) string {
+	if entry.tag {
+		return "tag"
+	}
+
+	return "folder"
+}
+
+func foldersUpdateList(
+	ctx context.Context,
+	entry *folderEntry,
+	list *tview.List,
+	form *tview.Form,
+	r *TUI,
+	service client.GophKeeperService,
+) {
+	changes := r.subscribe()
+
+	for {
+		select {
+		case <-changes:
+			folders, err := service.GetFolders(ctx)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				continue
+			}
+
+			tags, err := service.GetTags(ctx)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				continue
+			}
+
+			currentItemIndex := list.GetCurrentItem()
+
+			list.Clear()
+
+			for _, currentfolder := range folders {
+				currentFolder := currentfolder
+				list.AddItem(currentFolder.Name, "", rune(0), func() {
+					*entry = folderEntry{folder: currentFolder}
+					setupFolderForm(ctx, form, entry, r, service)
+				})
+			}
+
+			for _, currenttag := range tags {
+				currentTag := currenttag
+				list.AddItem("#"+currentTag.Name, "", rune(0), func() {
+					*entry = folderEntry{tag: true, label: currentTag}
+					setupFolderForm(ctx, form, entry, r, service)
+				})
+			}
+
+			list.SetCurrentItem(currentItemIndex)
+
+			r.app.Draw()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/vukit/gophkeeper/internal/client"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+const historyPage = "history"
+
+// addHistory добавляет в форму form кнопку History, которая показывает прежние редакции записи itemID
+// и их отличия от текущего содержимого записи current, после восстановления редакции вызывается restored
+func (r *TUI) addHistory(
+	ctx context.Context,
+	form *tview.Form,
+	service client.GophKeeperService,
+	itemID int,
+	current []model.Field,
+	restored func(),
+) {
+	form.AddButton("History", func() {
+		revisions, err := service.GetRevisions(ctx, itemID)
+		if err != nil {
+			r.alertChannel <- err.Error()
+
+			return
+		}
+
+		if len(revisions) == 0 {
+			r.alertChannel <- "item has no previous revisions"
+
+			return
+		}
+
+		r.showHistory(ctx, form, service, revisions, current, restored)
+	})
+}
+
+// showHistory показывает список прежних редакций revisions записи и отличия выбранной редакции
+// от текущего содержимого записи current, значения скрытых полей показываются по кнопке Reveal
+func (r *TUI) showHistory(
+	ctx context.Context,
+	form *tview.Form,
+	service client.GophKeeperService,
+	revisions []model.Revision,
+	current []model.Field,
+	restored func(),
+) {
+	selected, reveal := 0, false
+
+	hide := func() {
+		r.pages.RemovePage(historyPage)
+		r.app.SetFocus(form)
+	}
+
+	diff := tview.NewTextView().SetDynamicColors(true).SetWrap(true)
+	diff.SetTitle("[ Changes since revision ]").SetBorder(true).SetBorderPadding(1, 1, 1, 1)
+
+	show := func() {
+		lines := model.DiffContent(revisions[selected].Content, current, reveal)
+
+		text := make([]string, 0, len(lines))
+
+		for _, line := range lines {
+			switch {
+			case strings.HasPrefix(line, model.DiffRemoved):
+				text = append(text, "[red]"+tview.Escape(line)+"[-]")
+			case strings.HasPrefix(line, model.DiffAdded):
+				text = append(text, "[green]"+tview.Escape(line)+"[-]")
+			default:
+				text = append(text, tview.Escape(line))
+			}
+		}
+
+		diff.SetText(strings.Join(text, "\n")).ScrollToBeginning()
+	}
+
+	list := tview.NewList()
+	list.SetTitle("[ History ]").SetBorder(true).SetBorderPadding(1, 1, 1, 1)
+
+	for _, revision := range revisions {
+		device := revision.Device
+		if device == "" {
+			device = "unknown device"
+		}
+
+		list.AddItem(revision.CreatedAt.Local().Format("2006-01-02 15:04:05"), device, rune(0), nil)
+	}
+
+	list.SetDoneFunc(hide)
+	list.SetChangedFunc(func(index int, _, _ string, _ rune) {
+		selected = index
+		show()
+	})
+
+	buttons := tview.NewForm().
+		AddButton("Restore", func() {
+			if err := service.RestoreRevision(ctx, &revisions[selected]); err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			r.alertChannel <- fmt.Sprintf("revision of %s was successfully restored",
+				revisions[selected].CreatedAt.Local().Format("2006-01-02 15:04:05"))
+			r.pages.RemovePage(historyPage)
+			restored()
+		}).
+		AddButton("Reveal", func() {
+			reveal = !reveal
+			show()
+		}).
+		AddButton("Close", hide)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tview.NewFlex().
+			AddItem(list, 30, 0, true).
+			AddItem(diff, 0, 1, false),
+			0, 1, true).
+		AddItem(buttons, 3, 0, false)
+
+	modal := tview.NewGrid().
+		SetColumns(0, 100, 0).
+		SetRows(0, 30, 0).
+		AddItem(layout, 1, 1, 1, 1, 0, 0, true)
+
+	show()
+
+	r.pages.AddPage(historyPage, modal, true, true)
+	r.app.SetFocus(list)
+}
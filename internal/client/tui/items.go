@@ -0,0 +1,149 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rivo/tview"
+	"github.com/vukit/gophkeeper/internal/client"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+// Items компонент реализует текстовый интерфейс CRUD для записей произвольной структуры,
+// которые состоят из названия и дополнительных полей
+func (r *TUI) Items(ctx context.Context, user *model.User, service client.GophKeeperService) *tview.Flex {
+	item := &model.Item{}
+
+	layout := tview.NewFlex()
+
+	list := tview.NewList()
+	list.SetTitle("[ Items ]").SetBorder(true).SetBorderPadding(1, 1, 1, 1)
+
+	form := tview.NewForm()
+	setupItemForm(ctx, form, item, r, service)
+
+	layout.AddItem(list, 35, 0, false).AddItem(form, 0, 1, true)
+
+	go itemsUpdateList(ctx, item, list, form, r, service)
+
+	return layout
+}
+
+func setupItemForm(
+	ctx context.Context,
+	form *tview.Form,
+	item *model.Item,
+	r *TUI,
+	service client.GophKeeperService,
+) {
+	form.Clear(true)
+	form.
+		AddInputField("Name", item.Name, 40, nil, func(text string) { item.Name = text }).
+		AddButton("Save", func() {
+			item.Fields = compactFields(item.Fields)
+
+			err := item.Validate()
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			err = service.SaveItem(ctx, item)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			r.alertChannel <- "item was successfully saved"
+			*item = model.Item{}
+			setupItemForm(ctx, form, item, r, service)
+		}).
+		AddButton("Cancel", func() {
+			*item = model.Item{}
+			setupItemForm(ctx, form, item, r, service)
+		})
+
+	r.addPlacement(ctx, form, service, &item.Placement)
+	r.addFields(form, &item.Fields)
+
+	if idx := form.GetButtonIndex("Delete"); idx != -1 {
+		form.RemoveButton(idx)
+	}
+
+	if item.ID != 0 {
+		r.addHistory(ctx, form, service, item.ID, item.Content(), func() {
+			*item = model.Item{}
+			setupItemForm(ctx, form, item, r, service)
+		})
+	}
+
+	title := "[ New item ]"
+	if item.ID != 0 {
+		title = "[ Edit item ]"
+
+		form.AddButton("Delete", func() {
+			errService := service.DeleteItem(ctx, item)
+			if errService != nil {
+				r.alertChannel <- errService.Error()
+
+				return
+			}
+
+			r.alertChannel <- "item was successfully deleted"
+			*item = model.Item{}
+			setupItemForm(ctx, form, item, r, service)
+		})
+	}
+
+	form.SetBorder(true).SetTitle(title).SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	r.app.SetFocus(form)
+}
+
+func itemsUpdateList(
+	ctx context.Context,
+	item *model.Item,
+	list *tview.List,
+	form *tview.Form,
+	r *TUI,
+	service client.GophKeeperService,
+) {
+	changes := r.subscribe()
+
+	for {
+		select {
+		case <-changes:
+			items, err := service.GetItems(ctx)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				continue
+			}
+
+			currentItemIndex := list.GetCurrentItem()
+
+			list.Clear()
+
+			for _, currentitem := range items {
+				currentItem := currentitem
+
+				if !r.filter.match(currentItem.Placement) {
+					continue
+				}
+
+				list.AddItem(currentItem.Name, fmt.Sprintf("%d fields", len(currentItem.Fields)), rune(0), func() {
+					*item = currentItem
+					setupItemForm(ctx, form, item, r, service)
+				})
+			}
+
+			list.SetCurrentItem(currentItemIndex)
+
+			r.app.Draw()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
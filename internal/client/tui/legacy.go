@@ -0,0 +1,35 @@
+package tui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	gkservice "github.com/vukit/gophkeeper/internal/client/service"
+)
+
+// KDFDowngrade предупреждает, что сервер предложил для учётной записи схему получения ключей
+// старше запомненной, и объясняет, что делать, если учётная запись пересоздана
+func KDFDowngrade(err *gkservice.KDFDowngradeError, done func()) *tview.Modal {
+	return tview.NewModal().
+		SetText("WARNING: KEY DERIVATION DOWNGRADE!\n\n" + err.Error()).
+		SetBackgroundColor(tcell.ColorDarkRed).
+		SetTextColor(tcell.ColorWhite).
+		AddButtons([]string{"Quit"}).
+		SetDoneFunc(func(int, string) { done() })
+}
+
+// LegacyAccount просит подтвердить передачу мастер-пароля серверу при первом входе
+// в учётную запись прежней версии клиента
+func LegacyAccount(err *gkservice.LegacyAccountError, trust, cancel func()) *tview.Modal {
+	return tview.NewModal().
+		SetText("LEGACY ACCOUNT\n\n" + err.Error()).
+		AddButtons([]string{"Send password", "Cancel"}).
+		SetDoneFunc(func(_ int, label string) {
+			if label == "Send password" {
+				trust()
+
+				return
+			}
+
+			cancel()
+		})
+}
@@ -8,9 +8,10 @@ import (
 	"github.com/vukit/gophkeeper/internal/client"
 	"github.com/vukit/gophkeeper/internal/client/logger"
 	"github.com/vukit/gophkeeper/internal/client/model"
+	gkservice "github.com/vukit/gophkeeper/internal/client/service"
 )
 
-// Login предоставляет форму аутентификации и регистрации пользователя,
+// Login предоставляет форму аутентификации, регистрации и восстановления доступа пользователя,
 // возвращает идентифицированного пользователя либо ошибку
 func Login(ctx context.Context, service client.GophKeeperService, mLogger *logger.Logger) (user *model.User, err error) {
 	user = &model.User{}
@@ -18,6 +19,94 @@ func Login(ctx context.Context, service client.GophKeeperService, mLogger *logge
 
 	alert, _ := Alert(ctx, 1, 0, 0, 0, tview.AlignCenter, 0)
 
+	var layout, recoveryLayout, totpLayout *tview.Flex
+
+	// signedIn завершает вход, коды восстановления, выданные при переводе учётной записи
+	// на новую схему ключей, предварительно показываются пользователю
+	signedIn := func() {
+		if len(user.RecoveryCodes) > 0 {
+			tvApp.SetRoot(RecoveryCodes(user.RecoveryCodes, tvApp.Stop), true)
+
+			return
+		}
+
+		tvApp.Stop()
+	}
+
+	// serviceError выводит ошибку обращения к серверу, смена ключа сертификата сервера и переход
+	// учётной записи на более старую схему получения ключей прерывают вход, ключ сертификата сервера,
+	// который не удалось проверить, и передача мастер-пароля принимаются только после подтверждения,
+	// вход в учётную запись прежней версии клиента завершается после подтверждения её перевода на новую схему
+	serviceError := func(serviceErr error) {
+		var changedErr *gkservice.CertificateChangedError
+		if errors.As(serviceErr, &changedErr) {
+			tvApp.SetRoot(CertificateChanged(changedErr, tvApp.Stop), true)
+
+			return
+		}
+
+		var unknownErr *gkservice.UnknownServerError
+		if errors.As(serviceErr, &unknownErr) {
+			tvApp.SetRoot(UnknownServer(unknownErr, func() {
+				if trustErr := gkservice.TrustServer(unknownErr); trustErr != nil {
+					alert.SetText(trustErr.Error())
+				} else {
+					alert.SetText("Server certificate key trusted, try again")
+				}
+
+				tvApp.SetRoot(layout, true)
+			}, tvApp.Stop), true)
+
+			return
+		}
+
+		var downgradeErr *gkservice.KDFDowngradeError
+		if errors.As(serviceErr, &downgradeErr) {
+			tvApp.SetRoot(KDFDowngrade(downgradeErr, tvApp.Stop), true)
+
+			return
+		}
+
+		var legacyErr *gkservice.LegacyAccountError
+		if errors.As(serviceErr, &legacyErr) {
+			tvApp.SetRoot(LegacyAccount(legacyErr, func() {
+				if trustErr := gkservice.TrustLegacyAccount(legacyErr); trustErr != nil {
+					alert.SetText(trustErr.Error())
+				} else {
+					alert.SetText("Legacy account confirmed, sign in again")
+				}
+
+				tvApp.SetRoot(layout, true)
+			}, func() { tvApp.SetRoot(layout, true) }), true)
+
+			return
+		}
+
+		if errors.Is(serviceErr, gkservice.ErrUpgradeRequired) {
+			tvApp.SetRoot(AccountUpgrade(func() {
+				if err = service.UpgradeAccount(ctx, user); err != nil {
+					alert.SetText(err.Error())
+					tvApp.SetRoot(layout, true)
+
+					return
+				}
+
+				signedIn()
+			}, func() {
+				if logoutErr := service.Logout(ctx); logoutErr != nil {
+					mLogger.Info(logoutErr.Error())
+				}
+
+				err = errors.New("canceled account upgrade")
+				tvApp.Stop()
+			}), true)
+
+			return
+		}
+
+		alert.SetText(serviceErr.Error())
+	}
+
 	form := tview.NewForm().
 		AddInputField("Username", user.Username, 23, nil, func(text string) { user.Username = text }).
 		AddPasswordField("Password", user.Password, 23, '*', func(text string) { user.Password = text }).
@@ -30,13 +119,20 @@ func Login(ctx context.Context, service client.GophKeeperService, mLogger *logge
 			}
 
 			err = service.SignIn(ctx, user)
+			if errors.Is(err, gkservice.ErrSecondFactorRequired) {
+				alert.SetText("")
+				tvApp.SetRoot(totpLayout, true)
+
+				return
+			}
+
 			if err != nil {
-				alert.SetText(err.Error())
+				serviceError(err)
 
 				return
 			}
 
-			tvApp.Stop()
+			signedIn()
 		}).
 		AddButton("Sign up", func() {
 			err = user.Validate()
@@ -48,32 +144,102 @@ func Login(ctx context.Context, service client.GophKeeperService, mLogger *logge
 
 			err = service.SignUp(ctx, user)
 			if err != nil {
-				alert.SetText(err.Error())
+				serviceError(err)
 
 				return
 			}
 
-			tvApp.Stop()
+			tvApp.SetRoot(RecoveryCodes(user.RecoveryCodes, tvApp.Stop), true)
+		}).
+		AddButton("Recover", func() {
+			alert.SetText("")
+			tvApp.SetRoot(recoveryLayout, true)
 		}).
 		AddButton("Quit", func() {
 			err = errors.New("canceled authentication")
 			tvApp.Stop()
 		})
-	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+
+	recovery := &model.RecoveryForm{}
+
+	recoveryForm := tview.NewForm().
+		AddInputField("Username", recovery.Username, 23, nil, func(text string) { recovery.Username = text }).
+		AddInputField("Recovery code", recovery.Code, 23, nil, func(text string) { recovery.Code = text }).
+		AddPasswordField("New password", recovery.Password, 23, '*', func(text string) { recovery.Password = text }).
+		AddPasswordField("Confirm password", recovery.Confirm, 23, '*', func(text string) { recovery.Confirm = text }).
+		AddButton("Recover", func() {
+			err = recovery.Validate()
+			if err != nil {
+				alert.SetText(err.Error())
+
+				return
+			}
+
+			user.Username = recovery.Username
+			user.Password = recovery.Password
+
+			err = service.Recover(ctx, user, recovery.Code)
+			if err != nil {
+				serviceError(err)
+
+				return
+			}
+
+			tvApp.Stop()
+		}).
+		AddButton("Back", func() {
+			alert.SetText("")
+			tvApp.SetRoot(layout, true)
+		})
+
+	totpCode := &model.TOTPCode{}
+
+	totpForm := tview.NewForm().
+		AddInputField("Authentication code", totpCode.Code, 20, nil, func(text string) { totpCode.Code = text }).
+		AddButton("Verify", func() {
+			err = totpCode.Validate()
+			if err != nil {
+				alert.SetText(err.Error())
+
+				return
+			}
+
+			err = service.SignInTOTP(ctx, user, totpCode.Code)
+			if err != nil {
+				serviceError(err)
+
+				return
+			}
+
+			signedIn()
+		}).
+		AddButton("Back", func() {
+			alert.SetText("")
+			tvApp.SetRoot(layout, true)
+		})
+
+	layout = loginLayout(alert, form, 8, 47)
+	totpLayout = loginLayout(alert, totpForm, 6, 47)
+	recoveryLayout = loginLayout(alert, recoveryForm, 12, 47)
+
+	if errTVApp := tvApp.SetRoot(layout, true).EnableMouse(true).Run(); errTVApp != nil {
+		return nil, errTVApp
+	}
+
+	return user, err
+}
+
+// loginLayout размещает форму по центру экрана под строкой сообщений
+func loginLayout(alert *tview.TextView, form *tview.Form, height, width int) *tview.Flex {
+	return tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(alert, 2, 0, false).
 		AddItem(tview.NewFlex().
 			AddItem(tview.NewBox(), 0, 1, false).
 			AddItem(tview.NewFlex().
 				SetDirection(tview.FlexRow).
-				AddItem(form, 8, 1, true).
+				AddItem(form, height, 1, true).
 				AddItem(Copyright(tview.AlignCenter), 1, 0, false),
-				36, 0, true).
+				width, 0, true).
 			AddItem(tview.NewBox(), 0, 1, false),
 			0, 1, true)
-
-	if errTVApp := tvApp.SetRoot(layout, true).EnableMouse(true).Run(); errTVApp != nil {
-		return nil, errTVApp
-	}
-
-	return user, err
 }
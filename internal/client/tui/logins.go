@@ -2,7 +2,6 @@ package tui
 
 import (
 	"context"
-	"time"
 
 	"github.com/rivo/tview"
 	"github.com/vukit/gophkeeper/internal/client"
@@ -21,9 +20,12 @@ func (r *TUI) Logins(ctx context.Context, user *model.User, service client.GophK
 
 	form := tview.NewForm()
 	login.ID = 0
+	login.UID = ""
 	login.Username = ""
 	login.Password = ""
 	login.MetaInfo = ""
+	login.Fields = nil
+	login.Placement = model.Placement{}
 	setupLoginForm(ctx, form, login, r, service)
 
 	layout.AddItem(list, 35, 0, false).AddItem(form, 0, 1, true)
@@ -46,6 +48,8 @@ func setupLoginForm(
 		AddPasswordField("Password", login.Password, 30, '*', func(text string) { login.Password = text }).
 		AddInputField("Metainfo", login.MetaInfo, 30, nil, func(text string) { login.MetaInfo = text }).
 		AddButton("Save", func() {
+			login.Fields = compactFields(login.Fields)
+
 			err := login.Validate()
 			if err != nil {
 				r.alertChannel <- err.Error()
@@ -62,23 +66,39 @@ func setupLoginForm(
 
 			r.alertChannel <- "login was successfully saved"
 			login.ID = 0
+			login.UID = ""
 			login.Username = ""
 			login.Password = ""
 			login.MetaInfo = ""
+			login.Fields = nil
+			login.Placement = model.Placement{}
 			setupLoginForm(ctx, form, login, r, service)
 		}).
 		AddButton("Cancel", func() {
 			login.ID = 0
+			login.UID = ""
 			login.Username = ""
 			login.Password = ""
 			login.MetaInfo = ""
+			login.Fields = nil
+			login.Placement = model.Placement{}
 			setupLoginForm(ctx, form, login, r, service)
 		})
 
+	r.addPlacement(ctx, form, service, &login.Placement)
+	r.addFields(form, &login.Fields)
+
 	if idx := form.GetButtonIndex("Delete"); idx != -1 {
 		form.RemoveButton(idx)
 	}
 
+	if login.ID != 0 {
+		r.addHistory(ctx, form, service, login.ID, login.Content(), func() {
+			*login = model.Login{}
+			setupLoginForm(ctx, form, login, r, service)
+		})
+	}
+
 	form.SetBorder(true).SetTitle("[ New login ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
 
 	r.app.SetFocus(form)
@@ -92,11 +112,11 @@ func loginsUpdateList(
 	r *TUI,
 	service client.GophKeeperService,
 ) {
-	ticker := time.NewTicker(500 * time.Millisecond)
+	changes := r.subscribe()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-changes:
 			logins, err := service.GetLogins(ctx)
 			if err != nil {
 				r.alertChannel <- err.Error()
@@ -110,6 +130,11 @@ func loginsUpdateList(
 
 			for _, currentLogin := range logins {
 				currentLogin := currentLogin
+
+				if !r.filter.match(currentLogin.Placement) {
+					continue
+				}
+
 				list.AddItem(currentLogin.MetaInfo, "", rune(0), func() {
 					login = &currentLogin
 					setupLoginForm(ctx, form, login, r, service)
@@ -124,9 +149,12 @@ func loginsUpdateList(
 							}
 							r.alertChannel <- "login was successfully deleted"
 							login.ID = 0
+							login.UID = ""
 							login.Username = ""
 							login.Password = ""
 							login.MetaInfo = ""
+							login.Fields = nil
+							login.Placement = model.Placement{}
 							setupLoginForm(ctx, form, login, r, service)
 						})
 					}
@@ -137,8 +165,6 @@ func loginsUpdateList(
 
 			r.app.Draw()
 		case <-ctx.Done():
-			ticker.Stop()
-
 			return
 		}
 	}
@@ -2,18 +2,25 @@ package tui
 
 import (
 	"context"
+	"sync"
 
 	"github.com/rivo/tview"
 	"github.com/vukit/gophkeeper/internal/client"
 	"github.com/vukit/gophkeeper/internal/client/logger"
 	"github.com/vukit/gophkeeper/internal/client/model"
+	"github.com/vukit/gophkeeper/internal/client/sshagent"
 )
 
 // TUI структура данных приложения текстового интерфейса
 type TUI struct {
 	app          *tview.Application
+	pages        *tview.Pages
 	alertChannel chan string
 	mLogger      *logger.Logger
+	confirmMu    sync.Mutex
+	filter       listFilter
+	watchMu      sync.Mutex
+	watchers     []chan struct{}
 }
 
 // Component структура данных компонента текстового интерфейса
@@ -29,19 +36,27 @@ func Manager(
 	service client.GophKeeperService,
 	mLogger *logger.Logger,
 	downloadFolder string,
+	sshAgent *sshagent.Agent,
 ) (err error) {
 	alertBox, alertChannel := Alert(ctx, 0, 0, 0, 0, tview.AlignLeft, 3)
 
-	tui := TUI{app: tview.NewApplication(), alertChannel: alertChannel, mLogger: mLogger}
+	pages := tview.NewPages()
+
+	tui := &TUI{app: tview.NewApplication(), pages: pages, alertChannel: alertChannel, mLogger: mLogger}
 
 	components := []Component{
 		{"Logins", tui.Logins(ctx, user, service)},
 		{"Cards", tui.Cards(ctx, user, service)},
+		{"Notes", tui.Notes(ctx, user, service)},
+		{"OTP", tui.OTPs(ctx, user, service)},
+		{"SSH keys", tui.SSHKeys(ctx, user, service, sshAgent)},
+		{"Items", tui.Items(ctx, user, service)},
+		{"Folders", tui.Folders(ctx, user, service)},
 		{"Files", tui.Files(ctx, user, service, downloadFolder)},
+		{"Trash", tui.Trash(ctx, user, service)},
+		{"Account", tui.Account(ctx, user, service, downloadFolder)},
 	}
 
-	pages := tview.NewPages()
-
 	for _, component := range components {
 		func(component Component) {
 			pages.AddPage(component.name,
@@ -51,30 +66,40 @@ func Manager(
 		}(component)
 	}
 
+	columns := make([]int, 0, len(components)+2)
+	for range components {
+		columns = append(columns, 10)
+	}
+
+	buttons := tview.NewGrid().SetColumns(append(columns, -1, 10)...).SetGap(0, 1)
+
+	for i, component := range components {
+		func(component Component) {
+			buttons.AddItem(tview.NewButton(component.name).SetSelectedFunc(func() {
+				pages.SwitchToPage(component.name)
+				tui.app.SetFocus(component.layout)
+			}), 0, i, 1, 1, 0, 0, false)
+		}(component)
+	}
+
+	buttons.
+		AddItem(tview.NewBox(), 0, len(components), 1, 1, 0, 0, false).
+		AddItem(tview.NewButton("Quit").SetSelectedFunc(func() {
+			tui.app.Stop()
+		}), 0, len(components)+1, 1, 1, 0, 0, false)
+
 	layout := tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(tview.NewGrid().SetColumns(-1, 35).
 			AddItem(alertBox, 0, 0, 1, 1, 0, 0, false).
 			AddItem(Copyright(tview.AlignRight), 0, 1, 1, 1, 0, 0, false),
 			1, 0, false).
-		AddItem(pages, 0, 1, true).
-		AddItem(tview.NewGrid().SetColumns(10, 10, 10, 10, -1, 10).SetGap(0, 1).
-			AddItem(tview.NewButton(components[0].name).SetSelectedFunc(func() {
-				pages.SwitchToPage(components[0].name)
-				tui.app.SetFocus(components[0].layout)
-			}), 0, 0, 1, 1, 0, 0, false).
-			AddItem(tview.NewButton(components[1].name).SetSelectedFunc(func() {
-				pages.SwitchToPage(components[1].name)
-				tui.app.SetFocus(components[1].layout)
-			}), 0, 1, 1, 1, 0, 0, false).
-			AddItem(tview.NewButton(components[2].name).SetSelectedFunc(func() {
-				pages.SwitchToPage(components[2].name)
-				tui.app.SetFocus(components[2].layout)
-			}), 0, 2, 1, 1, 0, 0, false).
-			AddItem(tview.NewBox(), 0, 4, 1, 1, 0, 0, false).
-			AddItem(tview.NewButton("Quit").SetSelectedFunc(func() {
-				tui.app.Stop()
-			}), 0, 5, 1, 1, 0, 0, false),
-			1, 0, false)
+		AddItem(tview.NewFlex().
+			AddItem(tui.Sidebar(ctx, service), 28, 0, false).
+			AddItem(pages, 0, 1, true),
+			0, 1, true).
+		AddItem(buttons, 1, 0, false)
+
+	go tui.watchVault(ctx, service)
 
 	if errTVApp := tui.app.SetRoot(layout, true).EnableMouse(true).Run(); errTVApp != nil {
 		return errTVApp
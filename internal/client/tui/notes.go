@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"context"
+
+	"github.com/rivo/tview"
+	"github.com/vukit/gophkeeper/internal/client"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+// Notes компонент реализует текстовый интерфейс CRUD для заметок
+func (r *TUI) Notes(ctx context.Context, user *model.User, service client.GophKeeperService) *tview.Flex {
+	note := &model.Note{}
+
+	layout := tview.NewFlex()
+
+	list := tview.NewList()
+	list.SetTitle("[ Notes ]").SetBorder(true).SetBorderPadding(1, 1, 1, 1)
+	list.ShowSecondaryText(false)
+
+	form := tview.NewForm()
+	note.ID = 0
+	note.UID = ""
+	note.Title = ""
+	note.Text = ""
+	note.Fields = nil
+	note.Placement = model.Placement{}
+	setupNoteForm(ctx, form, note, r, service)
+
+	layout.AddItem(list, 35, 0, false).AddItem(form, 0, 1, true)
+
+	go notesUpdateList(ctx, note, list, form, r, service)
+
+	return layout
+}
+
+func setupNoteForm(
+	ctx context.Context,
+	form *tview.Form,
+	note *model.Note,
+	r *TUI,
+	service client.GophKeeperService,
+) {
+	form.Clear(true)
+	form.
+		AddInputField("Title", note.Title, 40, nil, func(text string) { note.Title = text }).
+		AddTextArea("Text", note.Text, 0, 12, 0, func(text string) { note.Text = text }).
+		AddButton("Save", func() {
+			note.Fields = compactFields(note.Fields)
+
+			err := note.Validate()
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			err = service.SaveNote(ctx, note)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			r.alertChannel <- "note was successfully saved"
+			note.ID = 0
+			note.UID = ""
+			note.Title = ""
+			note.Text = ""
+			note.Fields = nil
+			note.Placement = model.Placement{}
+			setupNoteForm(ctx, form, note, r, service)
+		}).
+		AddButton("Cancel", func() {
+			note.ID = 0
+			note.UID = ""
+			note.Title = ""
+			note.Text = ""
+			note.Fields = nil
+			note.Placement = model.Placement{}
+			setupNoteForm(ctx, form, note, r, service)
+		})
+
+	r.addPlacement(ctx, form, service, &note.Placement)
+	r.addFields(form, &note.Fields)
+
+	if idx := form.GetButtonIndex("Delete"); idx != -1 {
+		form.RemoveButton(idx)
+	}
+
+	if note.ID != 0 {
+		r.addHistory(ctx, form, service, note.ID, note.Content(), func() {
+			*note = model.Note{}
+			setupNoteForm(ctx, form, note, r, service)
+		})
+	}
+
+	form.SetBorder(true).SetTitle("[ New note ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	r.app.SetFocus(form)
+}
+
+func notesUpdateList(
+	ctx context.Context,
+	note *model.Note,
+	list *tview.List,
+	form *tview.Form,
+	r *TUI,
+	service client.GophKeeperService,
+) {
+	changes := r.subscribe()
+
+	for {
+		select {
+		case <-changes:
+			notes, err := service.GetNotes(ctx)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				continue
+			}
+
+			currentItemIndex := list.GetCurrentItem()
+
+			list.Clear()
+
+			for _, currentnote := range notes {
+				currentNote := currentnote
+
+				if !r.filter.match(currentNote.Placement) {
+					continue
+				}
+
+				list.AddItem(currentNote.Title, "", rune(0), func() {
+					note = &currentNote
+					setupNoteForm(ctx, form, note, r, service)
+					form.SetTitle("[ Edit note ]")
+					if idx := form.GetButtonIndex("Delete"); idx == -1 {
+						form.AddButton("Delete", func() {
+							errService := service.DeleteNote(ctx, note)
+							if errService != nil {
+								r.alertChannel <- errService.Error()
+
+								return
+							}
+							r.alertChannel <- "note was successfully deleted"
+							note.ID = 0
+							note.UID = ""
+							note.Title = ""
+							note.Text = ""
+							note.Fields = nil
+							note.Placement = model.Placement{}
+							setupNoteForm(ctx, form, note, r, service)
+						})
+					}
+				})
+			}
+
+			list.SetCurrentItem(currentItemIndex)
+
+			r.app.Draw()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rivo/tview"
+	"github.com/vukit/gophkeeper/internal/client"
+	"github.com/vukit/gophkeeper/internal/client/model"
+	"github.com/vukit/gophkeeper/internal/client/otp"
+)
+
+var (
+	otpAlgorithms = []string{otp.SHA1, otp.SHA256, otp.SHA512}
+	otpDigits     = []string{"6", "7", "8"}
+)
+
+// OTPs компонент реализует текстовый интерфейс CRUD для ключей одноразовых паролей,
+// текущие одноразовые пароли и время до их смены обновляются в списке
+func (r *TUI) OTPs(ctx context.Context, user *model.User, service client.GophKeeperService) *tview.Flex {
+	item := &model.OTP{}
+
+	layout := tview.NewFlex()
+
+	list := tview.NewList()
+	list.SetTitle("[ OTP ]").SetBorder(true).SetBorderPadding(1, 1, 1, 1)
+
+	form := tview.NewForm()
+	resetOTP(item)
+	setupOTPForm(ctx, form, item, r, service)
+
+	layout.AddItem(list, 35, 0, false).AddItem(form, 0, 1, true)
+
+	go otpsUpdateList(ctx, item, list, form, r, service)
+
+	return layout
+}
+
+func resetOTP(item *model.OTP) {
+	*item = model.OTP{Algorithm: otp.SHA1, Digits: otp.DefaultDigits, Period: otp.DefaultPeriod}
+}
+
+func setupOTPForm(
+	ctx context.Context,
+	form *tview.Form,
+	item *model.OTP,
+	r *TUI,
+	service client.GophKeeperService,
+) {
+	loginOptions := []string{"-"}
+	loginIDs := []int{0}
+	currentLogin := 0
+
+	logins, err := service.GetLogins(ctx)
+	if err != nil {
+		r.alertChannel <- err.Error()
+	}
+
+	for _, login := range logins {
+		if login.ID == item.LoginID {
+			currentLogin = len(loginIDs)
+		}
+
+		loginOptions = append(loginOptions, login.MetaInfo)
+		loginIDs = append(loginIDs, login.ID)
+	}
+
+	uri := ""
+
+	form.Clear(true)
+	form.
+		AddInputField("otpauth URI", uri, 40, nil, func(text string) { uri = text }).
+		AddInputField("Name", item.Name, 40, nil, func(text string) { item.Name = text }).
+		AddPasswordField("Secret", item.Secret, 40, '*', func(text string) { item.Secret = text }).
+		AddDropDown("Algorithm", otpAlgorithms, indexOf(otpAlgorithms, item.Algorithm), func(option string, _ int) {
+			item.Algorithm = option
+		}).
+		AddDropDown("Digits", otpDigits, indexOf(otpDigits, strconv.Itoa(item.Digits)), func(option string, _ int) {
+			item.Digits, _ = strconv.Atoi(option)
+		}).
+		AddInputField("Period", strconv.Itoa(item.Period), 6, tview.InputFieldInteger, func(text string) {
+			item.Period, _ = strconv.Atoi(text)
+		}).
+		AddDropDown("Login", loginOptions, currentLogin, func(_ string, index int) {
+			if index >= 0 {
+				item.LoginID = loginIDs[index]
+			}
+		}).
+		AddButton("Import", func() {
+			key, err := otp.ParseURI(uri)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			item.SetKey(key)
+			setupOTPForm(ctx, form, item, r, service)
+		}).
+		AddButton("Save", func() {
+			item.Fields = compactFields(item.Fields)
+
+			err := item.Validate()
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			err = service.SaveOTP(ctx, item)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			r.alertChannel <- "otp was successfully saved"
+			resetOTP(item)
+			setupOTPForm(ctx, form, item, r, service)
+		}).
+		AddButton("Cancel", func() {
+			resetOTP(item)
+			setupOTPForm(ctx, form, item, r, service)
+		})
+
+	r.addPlacement(ctx, form, service, &item.Placement)
+	r.addFields(form, &item.Fields)
+
+	if idx := form.GetButtonIndex("Delete"); idx != -1 {
+		form.RemoveButton(idx)
+	}
+
+	if item.ID != 0 {
+		r.addHistory(ctx, form, service, item.ID, item.Content(), func() {
+			*item = model.OTP{}
+			setupOTPForm(ctx, form, item, r, service)
+		})
+	}
+
+	title := "[ New OTP ]"
+	if item.ID != 0 {
+		title = "[ Edit OTP ]"
+	}
+
+	form.SetBorder(true).SetTitle(title).SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	r.app.SetFocus(form)
+}
+
+func otpsUpdateList(
+	ctx context.Context,
+	item *model.OTP,
+	list *tview.List,
+	form *tview.Form,
+	r *TUI,
+	service client.GophKeeperService,
+) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	changes := r.subscribe()
+
+	otps := make([]model.OTP, 0)
+
+	for {
+		now := time.Now()
+
+		select {
+		case <-changes:
+			fetched, err := service.GetOTPs(ctx)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				continue
+			}
+
+			otps = fetched
+		case now = <-ticker.C:
+		case <-ctx.Done():
+			ticker.Stop()
+
+			return
+		}
+
+		currentItemIndex := list.GetCurrentItem()
+
+		list.Clear()
+
+		for _, currentotp := range otps {
+			currentOTP := currentotp
+
+			if !r.filter.match(currentOTP.Placement) {
+				continue
+			}
+
+			list.AddItem(currentOTP.Name, otpCode(currentOTP, now), rune(0), func() {
+				item = &currentOTP
+				setupOTPForm(ctx, form, item, r, service)
+				if idx := form.GetButtonIndex("Delete"); idx == -1 {
+					form.AddButton("Delete", func() {
+						errService := service.DeleteOTP(ctx, item)
+						if errService != nil {
+							r.alertChannel <- errService.Error()
+
+							return
+						}
+						r.alertChannel <- "otp was successfully deleted"
+						resetOTP(item)
+						setupOTPForm(ctx, form, item, r, service)
+					})
+				}
+			})
+		}
+
+		list.SetCurrentItem(currentItemIndex)
+
+		r.app.Draw()
+	}
+}
+
+// otpCode возвращает текущий одноразовый пароль ключа item и число секунд до его смены
+func otpCode(item model.OTP, now time.Time) string {
+	code, remaining, err := item.Key().Code(now)
+	if err != nil {
+		return err.Error()
+	}
+
+	return fmt.Sprintf("%s  %2ds", code, int(remaining.Seconds()+0.5))
+}
+
+func indexOf(options []string, option string) int {
+	for i := range options {
+		if options[i] == option {
+			return i
+		}
+	}
+
+	return 0
+}
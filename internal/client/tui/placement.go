@@ -0,0 +1,241 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/vukit/gophkeeper/internal/client"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+// Виды отбора записей списков компонентов
+const (
+	filterAll = iota
+	filterNoFolder
+	filterFolder
+	filterTag
+)
+
+// listFilter отбор записей списков компонентов по папке или метке, выбранной на боковой панели
+type listFilter struct {
+	mu   sync.RWMutex
+	kind int
+	id   int
+}
+
+// filterNode значение узла боковой панели: вид отбора и идентификатор папки или метки
+type filterNode struct {
+	kind int
+	id   int
+}
+
+func (f *listFilter) set(node filterNode) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.kind, f.id = node.kind, node.id
+}
+
+// match сообщает, что запись с размещением place проходит отбор
+func (f *listFilter) match(place model.Placement) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	switch f.kind {
+	case filterNoFolder:
+		return place.FolderID == 0
+	case filterFolder:
+		return place.FolderID == f.id
+	case filterTag:
+		return place.HasTag(f.id)
+	default:
+		return true
+	}
+}
+
+// Sidebar компонент боковой панели с деревом папок и меток, выбор узла дерева отбирает записи
+// в списках всех компонентов
+func (r *TUI) Sidebar(ctx context.Context, service client.GophKeeperService) *tview.TreeView {
+	root := tview.NewTreeNode("All items").SetReference(filterNode{kind: filterAll})
+
+	tree := tview.NewTreeView().SetRoot(root).SetCurrentNode(root)
+	tree.SetTitle("[ Folders ]").SetBorder(true).SetBorderPadding(1, 1, 1, 1)
+	tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		if value, ok := node.GetReference().(filterNode); ok {
+			r.filter.set(value)
+			r.notify()
+		}
+	})
+
+	go sidebarUpdateTree(ctx, tree, r, service)
+
+	return tree
+}
+
+func sidebarUpdateTree(
+	ctx context.Context,
+	tree *tview.TreeView,
+	r *TUI,
+	service client.GophKeeperService,
+) {
+	changes := r.subscribe()
+
+	for {
+		select {
+		case <-changes:
+			folders, err := service.GetFolders(ctx)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				continue
+			}
+
+			tags, err := service.GetTags(ctx)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				continue
+			}
+
+			current, _ := tree.GetCurrentNode().GetReference().(filterNode)
+
+			root := tview.NewTreeNode("All items").SetReference(filterNode{kind: filterAll})
+
+			folderNodes := tview.NewTreeNode("Folders").SetSelectable(false)
+			folderNodes.AddChild(tview.NewTreeNode("(no folder)").SetReference(filterNode{kind: filterNoFolder}))
+
+			for _, folder := range folders {
+				folderNodes.AddChild(tview.NewTreeNode(folder.Name).SetReference(filterNode{kind: filterFolder, id: folder.ID}))
+			}
+
+			tagNodes := tview.NewTreeNode("Tags").SetSelectable(false)
+
+			for _, tag := range tags {
+				tagNodes.AddChild(tview.NewTreeNode("#" + tag.Name).SetReference(filterNode{kind: filterTag, id: tag.ID}))
+			}
+
+			root.AddChild(folderNodes).AddChild(tagNodes)
+			tree.SetRoot(root).SetCurrentNode(root)
+
+			root.Walk(func(node, _ *tview.TreeNode) bool {
+				if value, ok := node.GetReference().(filterNode); ok && value == current {
+					tree.SetCurrentNode(node)
+				}
+
+				return true
+			})
+
+			r.app.Draw()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// addPlacement добавляет в форму form выбор папки и меток записи с размещением place,
+// метки перечисляются названиями через запятую
+func (r *TUI) addPlacement(ctx context.Context, form *tview.Form, service client.GophKeeperService, place *model.Placement) {
+	folderOptions := []string{"-"}
+	folderIDs := []int{0}
+	currentFolder := 0
+
+	folders, err := service.GetFolders(ctx)
+	if err != nil {
+		r.alertChannel <- err.Error()
+	}
+
+	for _, folder := range folders {
+		if folder.ID == place.FolderID {
+			currentFolder = len(folderIDs)
+		}
+
+		folderOptions = append(folderOptions, folder.Name)
+		folderIDs = append(folderIDs, folder.ID)
+	}
+
+	tags, err := service.GetTags(ctx)
+	if err != nil {
+		r.alertChannel <- err.Error()
+	}
+
+	tagIDs := make(map[string]int, len(tags))
+	tagNames := make([]string, 0, len(place.TagIDs))
+
+	for _, tag := range tags {
+		tagIDs[tag.Name] = tag.ID
+
+		if place.HasTag(tag.ID) {
+			tagNames = append(tagNames, tag.Name)
+		}
+	}
+
+	tagsField := tview.NewInputField().
+		SetLabel("Tags").
+		SetText(strings.Join(tagNames, ", ")).
+		SetFieldWidth(40)
+
+	tagsField.SetChangedFunc(func(text string) {
+		place.TagIDs, _ = parseTags(text, tagIDs)
+	})
+
+	tagsField.SetAutocompleteFunc(func(text string) []string {
+		prefix, last := "", strings.TrimSpace(text)
+		if i := strings.LastIndex(text, ","); i != -1 {
+			prefix, last = text[:i+1]+" ", strings.TrimSpace(text[i+1:])
+		}
+
+		if last == "" {
+			return nil
+		}
+
+		entries := make([]string, 0)
+
+		for _, tag := range tags {
+			if strings.HasPrefix(strings.ToLower(tag.Name), strings.ToLower(last)) {
+				entries = append(entries, prefix+tag.Name)
+			}
+		}
+
+		return entries
+	})
+
+	tagsField.SetDoneFunc(func(_ tcell.Key) {
+		if _, unknown := parseTags(tagsField.GetText(), tagIDs); len(unknown) > 0 {
+			r.alertChannel <- fmt.Sprintf("unknown tags: %s", strings.Join(unknown, ", "))
+		}
+	})
+
+	form.
+		AddDropDown("Folder", folderOptions, currentFolder, func(_ string, index int) {
+			if index >= 0 {
+				place.FolderID = folderIDs[index]
+			}
+		}).
+		AddFormItem(tagsField)
+}
+
+// parseTags возвращает идентификаторы меток, перечисленных названиями через запятую в text,
+// и названия неизвестных меток
+func parseTags(text string, tagIDs map[string]int) (ids []int, unknown []string) {
+	for _, name := range strings.Split(text, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		id, ok := tagIDs[name]
+		if !ok {
+			unknown = append(unknown, name)
+
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, unknown
+}
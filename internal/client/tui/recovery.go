@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// RecoveryCodes компонент выводит коды восстановления пользователя,
+// done вызывается после того, как пользователь сохранил коды
+func RecoveryCodes(codes []string, done func()) *tview.Flex {
+	text := tview.NewTextView().
+		SetText("Write down these recovery codes and keep them in a safe place.\n" +
+			"Each code restores access to the vault once if the master password is lost.\n\n" +
+			strings.Join(codes, "\n"))
+	text.SetBorder(true).SetTitle("[ Recovery codes ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	form := tview.NewForm().AddButton("Continue", done)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(text, len(codes)+6, 0, false).
+		AddItem(form, 3, 0, true)
+
+	return layout
+}
+
+// RecoveryCodesRenew компонент запрашивает мастер-пароль для замены кодов восстановления пользователя
+func RecoveryCodesRenew(renew func(password string), cancel func()) *tview.Flex {
+	text := tview.NewTextView().SetWrap(true).
+		SetText("New recovery codes will replace the current ones, the current codes will stop working.\n" +
+			"Enter the master password to confirm.")
+	text.SetBorder(true).SetTitle("[ New recovery codes ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	password := ""
+
+	form := tview.NewForm().
+		AddPasswordField("Master password", password, 30, '*', func(text string) { password = text }).
+		AddButton("Replace codes", func() { renew(password) }).
+		AddButton("Cancel", cancel)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(text, 5, 0, false).
+		AddItem(form, 5, 0, true)
+
+	return layout
+}
@@ -0,0 +1,254 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rivo/tview"
+	"github.com/vukit/gophkeeper/internal/client"
+	"github.com/vukit/gophkeeper/internal/client/model"
+	"github.com/vukit/gophkeeper/internal/client/sshagent"
+)
+
+const (
+	sshConfirmPage    = "ssh-confirm"
+	sshConfirmTimeout = 30 * time.Second
+)
+
+// SSHKeys компонент реализует текстовый интерфейс CRUD для ключей SSH, ключи хранилища
+// передаются агенту SSH при каждом обновлении списка
+func (r *TUI) SSHKeys(ctx context.Context, user *model.User, service client.GophKeeperService, sshAgent *sshagent.Agent) *tview.Flex {
+	key := &model.SSHKey{}
+
+	layout := tview.NewFlex()
+
+	list := tview.NewList()
+	list.SetTitle("[ SSH keys ]").SetBorder(true).SetBorderPadding(1, 1, 1, 1)
+
+	form := tview.NewForm()
+	setupSSHKeyForm(ctx, form, key, false, r, service)
+
+	layout.AddItem(list, 35, 0, false).AddItem(form, 0, 1, true)
+
+	sshAgent.SetConfirm(r.confirmSSHKey)
+
+	go sshKeysUpdateList(ctx, key, list, form, r, service, sshAgent)
+
+	return layout
+}
+
+func setupSSHKeyForm(
+	ctx context.Context,
+	form *tview.Form,
+	key *model.SSHKey,
+	reveal bool,
+	r *TUI,
+	service client.GophKeeperService,
+) {
+	form.Clear(true)
+	form.AddInputField("Comment", key.Comment, 40, nil, func(text string) { key.Comment = text })
+
+	if key.ID == 0 || reveal {
+		form.AddTextArea("Private key", key.PrivateKey, 0, 8, 0, func(text string) { key.PrivateKey = text })
+	}
+
+	if key.PublicKey != "" {
+		form.
+			AddTextView("Public key", key.AuthorizedKey(), 0, 3, false, false).
+			AddTextView("Fingerprint", key.Fingerprint, 0, 1, false, false)
+	}
+
+	form.
+		AddCheckbox("Confirm use", key.Confirm, func(checked bool) { key.Confirm = checked }).
+		AddButton("Save", func() {
+			if key.ID == 0 {
+				if err := key.SetPrivateKey(key.PrivateKey); err != nil {
+					r.alertChannel <- err.Error()
+
+					return
+				}
+			}
+
+			key.Fields = compactFields(key.Fields)
+
+			err := key.Validate()
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			err = service.SaveSSHKey(ctx, key)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			r.alertChannel <- "ssh key was successfully saved"
+			*key = model.SSHKey{}
+			setupSSHKeyForm(ctx, form, key, false, r, service)
+		}).
+		AddButton("Cancel", func() {
+			*key = model.SSHKey{}
+			setupSSHKeyForm(ctx, form, key, false, r, service)
+		})
+
+	if key.ID == 0 {
+		form.AddButton("Generate", func() {
+			comment := key.Comment
+			if comment == "" {
+				comment = "gophkeeper"
+			}
+
+			privateKey, err := sshagent.GenerateKey(comment)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			if err = key.SetPrivateKey(privateKey); err != nil {
+				r.alertChannel <- err.Error()
+
+				return
+			}
+
+			key.Comment = comment
+			setupSSHKeyForm(ctx, form, key, false, r, service)
+		})
+	} else if !reveal {
+		form.AddButton("Reveal", func() {
+			setupSSHKeyForm(ctx, form, key, true, r, service)
+		})
+	}
+
+	r.addPlacement(ctx, form, service, &key.Placement)
+	r.addFields(form, &key.Fields)
+
+	if idx := form.GetButtonIndex("Delete"); idx != -1 {
+		form.RemoveButton(idx)
+	}
+
+	if key.ID != 0 {
+		r.addHistory(ctx, form, service, key.ID, key.Content(), func() {
+			*key = model.SSHKey{}
+			setupSSHKeyForm(ctx, form, key, false, r, service)
+		})
+	}
+
+	title := "[ New SSH key ]"
+	if key.ID != 0 {
+		title = "[ Edit SSH key ]"
+
+		form.AddButton("Delete", func() {
+			errService := service.DeleteSSHKey(ctx, key)
+			if errService != nil {
+				r.alertChannel <- errService.Error()
+
+				return
+			}
+
+			r.alertChannel <- "ssh key was successfully deleted"
+			*key = model.SSHKey{}
+			setupSSHKeyForm(ctx, form, key, false, r, service)
+		})
+	}
+
+	form.SetBorder(true).SetTitle(title).SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	r.app.SetFocus(form)
+}
+
+func sshKeysUpdateList(
+	ctx context.Context,
+	key *model.SSHKey,
+	list *tview.List,
+	form *tview.Form,
+	r *TUI,
+	service client.GophKeeperService,
+	sshAgent *sshagent.Agent,
+) {
+	changes := r.subscribe()
+
+	for {
+		select {
+		case <-changes:
+			keys, err := service.GetSSHKeys(ctx)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				continue
+			}
+
+			sshAgent.SetKeys(keys)
+
+			currentItemIndex := list.GetCurrentItem()
+
+			list.Clear()
+
+			for _, currentkey := range keys {
+				currentKey := currentkey
+
+				if !r.filter.match(currentKey.Placement) {
+					continue
+				}
+
+				list.AddItem(currentKey.Comment, currentKey.Fingerprint, rune(0), func() {
+					*key = currentKey
+					setupSSHKeyForm(ctx, form, key, false, r, service)
+				})
+			}
+
+			list.SetCurrentItem(currentItemIndex)
+
+			r.app.Draw()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// confirmSSHKey запрашивает у пользователя разрешение на использование ключа key агентом SSH,
+// запрос без ответа отклоняется через sshConfirmTimeout
+func (r *TUI) confirmSSHKey(key model.SSHKey) bool {
+	r.confirmMu.Lock()
+	defer r.confirmMu.Unlock()
+
+	answer := make(chan bool, 1)
+
+	var focus tview.Primitive
+
+	hide := func() {
+		r.pages.RemovePage(sshConfirmPage)
+		r.app.SetFocus(focus)
+	}
+
+	r.app.QueueUpdateDraw(func() {
+		focus = r.app.GetFocus()
+
+		modal := tview.NewModal().
+			SetText(fmt.Sprintf("Allow SSH agent to use key\n%s\n%s?", key.Comment, key.Fingerprint)).
+			AddButtons([]string{"Allow", "Deny"}).
+			SetDoneFunc(func(_ int, label string) {
+				answer <- label == "Allow"
+				hide()
+			})
+
+		r.pages.AddPage(sshConfirmPage, modal, true, true)
+		r.app.SetFocus(modal)
+	})
+
+	timer := time.NewTimer(sshConfirmTimeout)
+	defer timer.Stop()
+
+	select {
+	case allowed := <-answer:
+		return allowed
+	case <-timer.C:
+		r.app.QueueUpdateDraw(hide)
+
+		return false
+	}
+}
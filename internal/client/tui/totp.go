@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+// TOTPEnrollment компонент выводит секрет подключаемой двухфакторной аутентификации и ссылку otpauth
+// для приложения-аутентификатора, confirm вызывается с одноразовым паролем из приложения
+func TOTPEnrollment(enrollment model.TOTPEnrollment, confirm func(code string), cancel func()) *tview.Flex {
+	text := tview.NewTextView().SetWrap(true).
+		SetText("Add this account to an authenticator app by the link or enter the secret manually,\n" +
+			"then type the code shown by the app to confirm.\n\n" +
+			enrollment.URI + "\n\n" +
+			"Secret: " + enrollment.Secret)
+	text.SetBorder(true).SetTitle("[ Enable two-factor authentication ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	code := ""
+
+	form := tview.NewForm().
+		AddInputField("Authentication code", code, 20, nil, func(text string) { code = text }).
+		AddButton("Confirm", func() { confirm(code) }).
+		AddButton("Cancel", cancel)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(text, 10, 0, false).
+		AddItem(form, 5, 0, true)
+
+	return layout
+}
+
+// TOTPDisable компонент запрашивает одноразовый пароль или резервный код для отключения
+// двухфакторной аутентификации
+func TOTPDisable(disable func(code string), cancel func()) *tview.Form {
+	code := ""
+
+	form := tview.NewForm().
+		AddInputField("Code or backup code", code, 20, nil, func(text string) { code = text }).
+		AddButton("Disable", func() { disable(code) }).
+		AddButton("Cancel", cancel)
+	form.SetBorder(true).SetTitle("[ Disable two-factor authentication ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	return form
+}
+
+// BackupCodes компонент выводит резервные коды двухфакторной аутентификации,
+// done вызывается после того, как пользователь сохранил коды
+func BackupCodes(codes []string, done func()) *tview.Flex {
+	text := tview.NewTextView().
+		SetText("Write down these backup codes and keep them in a safe place.\n" +
+			"Each code can be used once instead of an authentication code.\n\n" +
+			strings.Join(codes, "\n"))
+	text.SetBorder(true).SetTitle("[ Backup codes ]").SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	form := tview.NewForm().AddButton("Continue", done)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(text, len(codes)+6, 0, false).
+		AddItem(form, 3, 0, true)
+
+	return layout
+}
@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/vukit/gophkeeper/internal/client"
+	"github.com/vukit/gophkeeper/internal/client/model"
+)
+
+// Trash компонент реализует текстовый интерфейс просмотра и восстановления удалённых записей всех типов
+func (r *TUI) Trash(ctx context.Context, user *model.User, service client.GophKeeperService) *tview.Flex {
+	item := &model.TrashItem{}
+
+	layout := tview.NewFlex()
+
+	list := tview.NewList()
+	list.SetTitle("[ Trash ]").SetBorder(true).SetBorderPadding(1, 1, 1, 1)
+
+	form := tview.NewForm()
+	setupTrashForm(ctx, form, item, r, service)
+
+	layout.AddItem(list, 35, 0, false).AddItem(form, 0, 1, true)
+
+	go trashUpdateList(ctx, item, list, form, r, service)
+
+	return layout
+}
+
+func setupTrashForm(
+	ctx context.Context,
+	form *tview.Form,
+	item *model.TrashItem,
+	r *TUI,
+	service client.GophKeeperService,
+) {
+	form.Clear(true)
+
+	title := "[ Select deleted item ]"
+
+	if item.ID != 0 {
+		title = "[ Deleted " + item.Type + " ]"
+
+		lines := make([]string, 0, len(item.Content))
+		for _, line := range model.DiffContent(item.Content, item.Content, false) {
+			lines = append(lines, strings.TrimPrefix(line, model.DiffSame))
+		}
+
+		form.
+			AddTextView("Deleted", item.DeletedAt.Local().Format("2006-01-02 15:04:05"), 40, 1, true, false).
+			AddTextView("Content", strings.Join(lines, "\n"), 60, len(lines)+1, true, true).
+			AddButton("Restore", func() {
+				errService := service.RestoreTrash(ctx, item)
+				if errService != nil {
+					r.alertChannel <- errService.Error()
+
+					return
+				}
+
+				r.alertChannel <- item.Type + " was successfully restored"
+				*item = model.TrashItem{}
+				setupTrashForm(ctx, form, item, r, service)
+			}).
+			AddButton("Cancel", func() {
+				*item = model.TrashItem{}
+				setupTrashForm(ctx, form, item, r, service)
+			})
+	}
+
+	form.SetBorder(true).SetTitle(title).SetTitleAlign(tview.AlignLeft).SetBorderPadding(1, 0, 2, 0)
+
+	r.app.SetFocus(form)
+}
+
+// trashItemName возвращает название записи в корзине — значение первого поля её содержимого
+func trashItemName(item model.TrashItem) string {
+	if len(item.Content) == 0 || item.Content[0].Type == model.FieldTypeHidden {
+		return item.Type
+	}
+
+	return item.Content[0].Value
+}
+
+func trashUpdateList(
+	ctx context.Context,
+	item *model.TrashItem,
+	list *tview.List,
+	form *tview.Form,
+	r *TUI,
+	service client.GophKeeperService,
+) {
+	changes := r.subscribe()
+
+	for {
+		select {
+		case <-changes:
+			items, err := service.GetTrash(ctx)
+			if err != nil {
+				r.alertChannel <- err.Error()
+
+				continue
+			}
+
+			currentItemIndex := list.GetCurrentItem()
+
+			list.Clear()
+
+			for _, currentitem := range items {
+				currentItem := currentitem
+
+				if !r.filter.match(currentItem.Placement) {
+					continue
+				}
+
+				secondary := currentItem.Type + ", " + currentItem.DeletedAt.Local().Format("2006-01-02 15:04")
+
+				list.AddItem(trashItemName(currentItem), secondary, rune(0), func() {
+					*item = currentItem
+					setupTrashForm(ctx, form, item, r, service)
+				})
+			}
+
+			list.SetCurrentItem(currentItemIndex)
+
+			r.app.Draw()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
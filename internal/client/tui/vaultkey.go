@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"github.com/rivo/tview"
+)
+
+// vaultKeyHistoryWarning предупреждает, что прежние редакции записей и корзина не переносятся на новый ключ хранилища
+const vaultKeyHistoryWarning = "Item history and trash are encrypted with the current vault key " +
+	"and will be permanently deleted, restore the items you need before continuing."
+
+// AccountUpgrade просит подтвердить перевод учётной записи прежней версии клиента на новую схему ключей,
+// без которого вход не завершается
+func AccountUpgrade(upgrade, quit func()) *tview.Modal {
+	return tview.NewModal().
+		SetText("ACCOUNT UPGRADE\n\n" +
+			"This account uses an old key derivation scheme. To continue, all vault data will be re-encrypted " +
+			"with a new vault key and new recovery codes will be issued.\n\n" + vaultKeyHistoryWarning).
+		AddButtons([]string{"Upgrade", "Quit"}).
+		SetDoneFunc(func(_ int, label string) {
+			if label == "Upgrade" {
+				upgrade()
+
+				return
+			}
+
+			quit()
+		})
+}
+
+// VaultKeyRotation просит подтвердить замену ключа хранилища
+func VaultKeyRotation(rotate, cancel func()) *tview.Modal {
+	return tview.NewModal().
+		SetText("ROTATE VAULT KEY\n\n" +
+			"All vault data will be re-encrypted with a new vault key and new recovery codes will be issued, " +
+			"the current recovery codes will stop working.\n\n" + vaultKeyHistoryWarning).
+		AddButtons([]string{"Rotate", "Cancel"}).
+		SetDoneFunc(func(_ int, label string) {
+			if label == "Rotate" {
+				rotate()
+
+				return
+			}
+
+			cancel()
+		})
+}
@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"context"
+
+	"github.com/vukit/gophkeeper/internal/client"
+)
+
+// subscribe возвращает канал уведомлений компонента об изменении хранилища или отбора записей,
+// первое уведомление уже находится в канале, чтобы компонент заполнил список сразу
+func (r *TUI) subscribe() <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	changes <- struct{}{}
+
+	r.watchMu.Lock()
+	defer r.watchMu.Unlock()
+
+	r.watchers = append(r.watchers, changes)
+
+	return changes
+}
+
+// notify уведомляет компоненты об изменении, уведомление не ставится в очередь повторно,
+// если компонент ещё не обработал предыдущее
+func (r *TUI) notify() {
+	r.watchMu.Lock()
+	defer r.watchMu.Unlock()
+
+	for _, changes := range r.watchers {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// watchVault синхронизирует локальное представление хранилища по уведомлениям сервера об изменениях
+// и уведомляет компоненты, только когда ревизия синхронизации изменилась
+func (r *TUI) watchVault(ctx context.Context, service client.GophKeeperService) {
+	revision := int64(-1)
+
+	for range service.Events(ctx) {
+		current, err := service.Sync(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.alertChannel <- err.Error()
+			}
+
+			continue
+		}
+
+		if current != revision {
+			revision = current
+			r.notify()
+		}
+	}
+}
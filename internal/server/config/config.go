@@ -1,11 +1,30 @@
 package config
 
+import "time"
+
 // Config структура конфигурации сервера
 type Config struct {
 	Address        string `env:"ADDRESS"`
 	Protocol       string `env:"PROTOL"`
 	TLSCertificate string `env:"TLS_CERTIFICATE"`
 	TLSPrivateKey  string `env:"TLS_PRIVATE_KEY"`
+	TLSClientCA    string `env:"TLS_CLIENT_CA"`
 	DataBaseURI    string `env:"DATABASE_URI"`
 	FileStorage    string `env:"FILE_STORAGE"`
+	JWTKeys        string `env:"JWT_KEYS"`
+	JWTSigningKey  string `env:"JWT_SIGNING_KEY"`
+
+	PasswordHash        string `env:"PASSWORD_HASH"`
+	PasswordHashCost    int    `env:"PASSWORD_HASH_COST"`
+	PasswordHashTime    uint   `env:"PASSWORD_HASH_TIME"`
+	PasswordHashMemory  uint   `env:"PASSWORD_HASH_MEMORY"`
+	PasswordHashThreads uint   `env:"PASSWORD_HASH_THREADS"`
+
+	AuthAccountAttempts int           `env:"AUTH_ACCOUNT_ATTEMPTS"`
+	AuthIPAttempts      int           `env:"AUTH_IP_ATTEMPTS"`
+	AuthMaxLockout      time.Duration `env:"AUTH_MAX_LOCKOUT"`
+
+	ItemRevisions      int           `env:"ITEM_REVISIONS"`
+	TrashRetention     time.Duration `env:"TRASH_RETENTION"`
+	TrashPurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL"`
 }
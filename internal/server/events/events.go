@@ -0,0 +1,164 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vukit/gophkeeper/internal/server/logger"
+)
+
+// Bus шина уведомлений об изменении хранилищ пользователей, общая для всех экземпляров сервера
+type Bus interface {
+	NotifyChange(ctx context.Context, userID int) (err error)
+	ListenChanges(ctx context.Context, changed func(userID int)) (err error)
+}
+
+// maxSubscribers наибольшее число потоков уведомлений одного пользователя на экземпляре сервера
+const maxSubscribers = 16
+
+var (
+	ErrBrokerClosed       = errors.New("event stream is closed")
+	ErrTooManySubscribers = errors.New("too many event streams")
+)
+
+// Broker доставляет уведомления об изменении хранилища пользователя подписчикам этого экземпляра
+// сервера: уведомления публикуются в шину и приходят из шины всем экземплярам, включая этот
+type Broker struct {
+	bus     Bus
+	retry   time.Duration
+	mLogger *logger.Logger
+
+	mu          sync.Mutex
+	closed      bool
+	subscribers map[int]map[chan struct{}]struct{}
+}
+
+// NewBroker возвращает брокер уведомлений, который получает уведомления из шины bus,
+// после сбоя шины получение возобновляется через retry
+func NewBroker(bus Bus, retry time.Duration, mLogger *logger.Logger) *Broker {
+	return &Broker{
+		bus:         bus,
+		retry:       retry,
+		mLogger:     mLogger,
+		subscribers: make(map[int]map[chan struct{}]struct{}),
+	}
+}
+
+// Subscribe подписывает на уведомления об изменении хранилища пользователя userID. Уведомления,
+// которые подписчик ещё не прочитал, объединяются в одно. Канал закрывается при закрытии брокера,
+// cancel отменяет подписку
+func (b *Broker) Subscribe(userID int) (changes <-chan struct{}, cancel func(), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, nil, ErrBrokerClosed
+	}
+
+	if len(b.subscribers[userID]) >= maxSubscribers {
+		return nil, nil, ErrTooManySubscribers
+	}
+
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan struct{}]struct{})
+	}
+
+	subscriber := make(chan struct{}, 1)
+	b.subscribers[userID][subscriber] = struct{}{}
+
+	cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[userID][subscriber]; !ok {
+			return
+		}
+
+		delete(b.subscribers[userID], subscriber)
+
+		if len(b.subscribers[userID]) == 0 {
+			delete(b.subscribers, userID)
+		}
+
+		close(subscriber)
+	}
+
+	return subscriber, cancel, nil
+}
+
+// Publish публикует уведомление об изменении хранилища пользователя userID. Если шина недоступна,
+// уведомление получают только подписчики этого экземпляра сервера
+func (b *Broker) Publish(ctx context.Context, userID int) {
+	if err := b.bus.NotifyChange(ctx, userID); err != nil {
+		b.mLogger.Warning(fmt.Sprintf("change notification failed: %s", err))
+		b.deliver(userID)
+	}
+}
+
+// Run получает уведомления из шины до завершения контекста ctx. После сбоя шины получение
+// возобновляется, а все подписчики уведомляются, так как могли пропустить изменения
+func (b *Broker) Run(ctx context.Context) error {
+	for {
+		err := b.bus.ListenChanges(ctx, b.deliver)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		b.mLogger.Warning(fmt.Sprintf("change notifications listener failed: %s", err))
+
+		select {
+		case <-time.After(b.retry):
+		case <-ctx.Done():
+			return nil
+		}
+
+		b.deliverAll()
+	}
+}
+
+// Close закрывает каналы всех подписчиков, чтобы потоки уведомлений завершились при остановке сервера
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for userID, subscribers := range b.subscribers {
+		for subscriber := range subscribers {
+			close(subscriber)
+		}
+
+		delete(b.subscribers, userID)
+	}
+}
+
+func (b *Broker) deliver(userID int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for subscriber := range b.subscribers[userID] {
+		notify(subscriber)
+	}
+}
+
+func (b *Broker) deliverAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subscribers := range b.subscribers {
+		for subscriber := range subscribers {
+			notify(subscriber)
+		}
+	}
+}
+
+// notify отправляет уведомление подписчику, если у него нет непрочитанного уведомления
+func notify(subscriber chan struct{}) {
+	select {
+	case subscriber <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,201 @@
+package events_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vukit/gophkeeper/internal/server/events"
+	"github.com/vukit/gophkeeper/internal/server/logger"
+)
+
+// memoryBus шина в памяти: уведомления передаются слушателю, если он есть, broken — шина недоступна
+type memoryBus struct {
+	mu        sync.Mutex
+	broken    bool
+	listener  func(userID int)
+	listening chan struct{}
+}
+
+func newMemoryBus() *memoryBus {
+	return &memoryBus{listening: make(chan struct{}, 1)}
+}
+
+func (b *memoryBus) NotifyChange(ctx context.Context, userID int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.broken {
+		return errors.New("bus is unavailable")
+	}
+
+	if b.listener != nil {
+		b.listener(userID)
+	}
+
+	return nil
+}
+
+func (b *memoryBus) ListenChanges(ctx context.Context, changed func(userID int)) error {
+	b.mu.Lock()
+
+	if b.broken {
+		b.mu.Unlock()
+
+		return errors.New("bus is unavailable")
+	}
+
+	b.listener = changed
+	b.mu.Unlock()
+
+	b.listening <- struct{}{}
+
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
+func received(changes <-chan struct{}) bool {
+	select {
+	case _, ok := <-changes:
+		return ok
+	case <-time.After(100 * time.Millisecond):
+		return false
+	}
+}
+
+func TestPublish(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := newMemoryBus()
+	broker := events.NewBroker(bus, time.Millisecond, logger.NewLogger(&bytes.Buffer{}))
+
+	go broker.Run(ctx) //nolint:errcheck
+	<-bus.listening
+
+	first, cancelFirst, err := broker.Subscribe(1)
+	require.NoError(t, err)
+
+	defer cancelFirst()
+
+	second, cancelSecond, err := broker.Subscribe(1)
+	require.NoError(t, err)
+
+	other, cancelOther, err := broker.Subscribe(2)
+	require.NoError(t, err)
+
+	defer cancelOther()
+
+	tests := []struct {
+		name   string
+		userID int
+		want   []bool
+	}{
+		{
+			name:   "case 1",
+			userID: 1,
+			want:   []bool{true, true, false},
+		},
+		{
+			name:   "case 2",
+			userID: 2,
+			want:   []bool{false, false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker.Publish(ctx, tt.userID)
+			broker.Publish(ctx, tt.userID)
+
+			assert.Equal(t, tt.want, []bool{received(first), received(second), received(other)})
+			assert.False(t, received(first), "notifications are coalesced")
+		})
+	}
+
+	cancelSecond()
+	cancelSecond()
+
+	broker.Publish(ctx, 1)
+
+	assert.True(t, received(first))
+	assert.False(t, received(second))
+}
+
+func TestPublishBusUnavailable(t *testing.T) {
+	log := &bytes.Buffer{}
+
+	bus := newMemoryBus()
+	bus.broken = true
+
+	broker := events.NewBroker(bus, time.Millisecond, logger.NewLogger(log))
+
+	changes, cancel, err := broker.Subscribe(1)
+	require.NoError(t, err)
+
+	defer cancel()
+
+	broker.Publish(context.Background(), 1)
+
+	assert.True(t, received(changes))
+	assert.Contains(t, log.String(), "bus is unavailable")
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	bus := newMemoryBus()
+	bus.broken = true
+
+	broker := events.NewBroker(bus, time.Millisecond, logger.NewLogger(&bytes.Buffer{}))
+
+	changes, cancelChanges, err := broker.Subscribe(1)
+	require.NoError(t, err)
+
+	defer cancelChanges()
+
+	done := make(chan error)
+
+	go func() { done <- broker.Run(ctx) }()
+
+	assert.True(t, received(changes), "subscribers are notified after listener failure")
+
+	bus.mu.Lock()
+	bus.broken = false
+	bus.mu.Unlock()
+
+	<-bus.listening
+
+	cancel()
+
+	require.NoError(t, <-done)
+}
+
+func TestSubscribe(t *testing.T) {
+	broker := events.NewBroker(newMemoryBus(), time.Millisecond, logger.NewLogger(&bytes.Buffer{}))
+
+	for i := 0; i < 16; i++ {
+		_, _, err := broker.Subscribe(1)
+		require.NoError(t, err)
+	}
+
+	_, _, err := broker.Subscribe(1)
+	assert.ErrorIs(t, err, events.ErrTooManySubscribers)
+
+	changes, _, err := broker.Subscribe(2)
+	require.NoError(t, err)
+
+	broker.Close()
+
+	_, ok := <-changes
+	assert.False(t, ok)
+
+	_, _, err = broker.Subscribe(2)
+	assert.ErrorIs(t, err, events.ErrBrokerClosed)
+}
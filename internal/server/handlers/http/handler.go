@@ -1,41 +1,104 @@
 package http
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"mime"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/jwtauth"
+	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/vukit/gophkeeper/internal/server/events"
 	"github.com/vukit/gophkeeper/internal/server/handlers"
+	"github.com/vukit/gophkeeper/internal/server/jwtkeys"
+	"github.com/vukit/gophkeeper/internal/server/limiter"
 	"github.com/vukit/gophkeeper/internal/server/logger"
 	"github.com/vukit/gophkeeper/internal/server/model"
+	"github.com/vukit/gophkeeper/internal/server/pki"
 	"github.com/vukit/gophkeeper/internal/server/repositories/postgresql"
+	"github.com/vukit/gophkeeper/internal/server/totp"
 )
 
 // handler структура обработчика HTTP запросов
 type handler struct {
-	tokenAuth *jwtauth.JWTAuth
+	tokenAuth *jwtkeys.KeySet
 	repoDB    handlers.RepoDB
 	repoFile  handlers.RepoFile
+	limiter   *limiter.Limiter
+	events    *events.Broker
 	mLogger   *logger.Logger
 }
 
-var ErrNotFindUserID = errors.New("not find user id")
+const (
+	maxFormValueLength = 1 << 20
+	accessTokenTTL     = 15 * time.Minute
+	refreshTokenTTL    = 30 * 24 * time.Hour
+	refreshTokenLength = 32
+	eventsHeartbeat    = 30 * time.Second
+	accessTokenCookie  = "jwt"
+	refreshTokenCookie = "refresh"
+	refreshTokenPath   = "/api/refresh"
+	mfaTokenTTL        = 5 * time.Minute
+	mfaTokenAttempts   = 5
+	mfaTOTP            = "totp"
+	totpIssuer         = "GophKeeper"
+	totpBackupCodes    = 10
+	backupCodeLength   = 5
+)
+
+type ctxKey string
+
+// apiTokenCtxKey ключ контекста запроса, аутентифицированного токеном API
+const apiTokenCtxKey ctxKey = "api_token"
+
+var (
+	ErrNotFindUserID        = errors.New("not find user id")
+	ErrNotFindSessionID     = errors.New("not find session id")
+	ErrNoRefreshToken       = errors.New("no refresh token")
+	ErrInvalidMFAToken      = errors.New("invalid or expired mfa token, sign in again")
+	ErrTOTPEnabled          = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrTooManyAttempts      = errors.New("too many failed attempts, try again later")
+	ErrInsufficientScope    = errors.New("api token has no required scope")
+	ErrCertificateUser      = errors.New("client certificate is issued for another user")
+	ErrDuplicateFilePart    = errors.New("duplicate file part")
+	ErrFormValueTooLong     = errors.New("form value is too long")
+	ErrStreamingUnsupported = errors.New("streaming is not supported")
+)
 
 // NewHandler возвращает обработчик HTTP запросов
-func NewHandler(tokenAuth *jwtauth.JWTAuth, repoDB handlers.RepoDB, repoFile handlers.RepoFile, mLogger *logger.Logger) handler {
+func NewHandler(
+	tokenAuth *jwtkeys.KeySet,
+	repoDB handlers.RepoDB,
+	repoFile handlers.RepoFile,
+	authLimiter *limiter.Limiter,
+	broker *events.Broker,
+	mLogger *logger.Logger,
+) handler {
 	return handler{
 		tokenAuth: tokenAuth,
 		repoDB:    repoDB,
 		repoFile:  repoFile,
+		limiter:   authLimiter,
+		events:    broker,
 		mLogger:   mLogger,
 	}
 }
@@ -87,6 +150,7 @@ func (h *handler) Index(w http.ResponseWriter, r *http.Request) {
 // @Produce     json
 // @Success     200 {object} object
 // @Failure     400 {object} model.ErrorResponse
+// @Failure     403 {object} model.ErrorResponse
 // @Failure     406 {object} model.ErrorResponse
 // @Router /signup [post]
 func (h *handler) SignUp(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
@@ -108,6 +172,28 @@ func (h *handler) SignUp(ctx context.Context) func(w http.ResponseWriter, r *htt
 			return
 		}
 
+		if certificateMismatch(w, r, user.Username) {
+			return
+		}
+
+		if user.KDF.Algorithm == "" {
+			user.KDF = model.KDF{Algorithm: model.KDFSHA256}
+		}
+
+		if err = user.KDF.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = user.KDF.ValidateVaultKey(user.VaultKey); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
 		userID, err := h.repoDB.SaveUser(ctx, user)
 		if err != nil {
 			switch {
@@ -122,7 +208,7 @@ func (h *handler) SignUp(ctx context.Context) func(w http.ResponseWriter, r *htt
 			return
 		}
 
-		if err = h.setJWToken(w, userID); err != nil {
+		if err = h.startSession(ctx, w, r, userID); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
@@ -133,16 +219,19 @@ func (h *handler) SignUp(ctx context.Context) func(w http.ResponseWriter, r *htt
 	}
 }
 
-// SignIn endpoint аутентификации пользователя
+// SignIn endpoint аутентификации пользователя, если у пользователя подключена двухфакторная
+// аутентификация, сеанс не создаётся, а возвращается токен для второго шага SignInTOTP
 //
 // @Tags        User
 // @Summary     Аутентификация пользователя
 // @Param       value body model.User true "данные пользователя"
 // @Accept      json
 // @Produce     json
-// @Success     200 {object} object
+// @Success     200 {object} model.SignInResponse
 // @Failure     400 {object} model.ErrorResponse
+// @Failure     403 {object} model.ErrorResponse
 // @Failure     406 {object} model.ErrorResponse
+// @Failure     429 {object} model.ErrorResponse
 // @Router /signin [post]
 func (h *handler) SignIn(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -163,41 +252,99 @@ func (h *handler) SignIn(ctx context.Context) func(w http.ResponseWriter, r *htt
 			return
 		}
 
+		if certificateMismatch(w, r, user.Username) {
+			return
+		}
+
+		if h.throttled(ctx, w, r, user.Username) {
+			return
+		}
+
 		userID, err := h.repoDB.FindUser(ctx, user)
 		if err != nil {
+			if errors.Is(err, postgresql.ErrDBInvalidUsernamePasswordPair) {
+				h.authFailed(ctx, r, user.Username)
+			}
+
 			w.WriteHeader(http.StatusUnauthorized)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
 		}
 
-		if err = h.setJWToken(w, userID); err != nil {
+		totpState, err := h.repoDB.FindTOTP(ctx, userID)
+		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
 		}
 
-		fmt.Fprintf(w, "{}")
+		if totpState.Enabled() {
+			if err = h.limiter.Release(ctx, user.Username, clientIP(r)); err != nil {
+				h.mLogger.Warning(err.Error())
+			}
+
+			tokenID, err := newRefreshToken()
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+				return
+			}
+
+			expiresAt := time.Now().Add(mfaTokenTTL)
+
+			if err = h.repoDB.SaveMFAToken(ctx, userID, tokenID, expiresAt); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+				return
+			}
+
+			claims := map[string]interface{}{"user_id": strconv.Itoa(userID), "mfa": mfaTOTP, "jti": tokenID}
+			jwtauth.SetExpiry(claims, expiresAt)
+
+			_, mfaToken, err := h.tokenAuth.Encode(claims)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+				return
+			}
+
+			writeJSON(w, model.SignInResponse{MFAToken: mfaToken})
+
+			return
+		}
+
+		h.signIn(ctx, w, r, userID, totpState.Username)
 	}
 }
 
-// SaveLogin endpoint сохранения данных логина пользователя
+// SignInTOTP endpoint второго шага аутентификации пользователя с подключённой двухфакторной
+// аутентификацией: проверяется одноразовый пароль или резервный код
 //
-// @Tags        Logins
-// @Summary     Cохраняет данные логина пользователя
-// @Param       value body model.Login true "данные логина"
+// @Tags        User
+// @Summary     Второй шаг аутентификации пользователя
+// @Param       value body model.TOTPSignIn true "токен второго шага и одноразовый пароль или резервный код"
 // @Accept      json
 // @Produce     json
-// @Success     200 {object} object
+// @Success     200 {object} model.SignInResponse
 // @Failure     400 {object} model.ErrorResponse
-// @Failure     406 {object} model.ErrorResponse
-// @Router /logins [post]
-func (h *handler) SaveLogin(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+// @Failure     401 {object} model.ErrorResponse
+// @Failure     403 {object} model.ErrorResponse
+// @Failure     429 {object} model.ErrorResponse
+// @Router /signin/totp [post]
+func (h *handler) SignInTOTP(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
-		userID, err := getUserID(r)
+		signIn := model.TOTPSignIn{}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err := decoder.Decode(&signIn)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
@@ -205,49 +352,176 @@ func (h *handler) SaveLogin(ctx context.Context) func(w http.ResponseWriter, r *
 			return
 		}
 
-		login := model.Login{UserID: userID}
+		if err = signIn.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
-		decoder := json.NewDecoder(r.Body)
+			return
+		}
 
-		err = decoder.Decode(&login)
+		userID, tokenID, err := h.mfaUserID(signIn.MFAToken)
 		if err != nil {
-			w.WriteHeader(http.StatusNotAcceptable)
+			w.WriteHeader(http.StatusUnauthorized)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
 		}
 
-		if err = login.Validate(); err != nil {
+		totpState, err := h.repoDB.FindTOTP(ctx, userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if certificateMismatch(w, r, totpState.Username) {
+			return
+		}
+
+		if h.throttled(ctx, w, r, totpState.Username) {
+			return
+		}
+
+		// попытка учитывается до проверки кода, чтобы параллельные запросы с одним токеном
+		// не получили больше mfaTokenAttempts попыток
+		if err = h.repoDB.CountMFAAttempt(ctx, userID, tokenID, mfaTokenAttempts); err != nil {
+			writeMFATokenError(w, err)
+
+			return
+		}
+
+		if err = h.checkTOTP(ctx, userID, totpState, signIn.Code); err != nil {
+			if errors.Is(err, postgresql.ErrDBInvalidTOTPCode) || errors.Is(err, postgresql.ErrDBTOTPCodeUsed) {
+				h.authFailed(ctx, r, totpState.Username)
+			}
+
+			writeTOTPError(w, err)
+
+			return
+		}
+
+		if err = h.repoDB.DeleteMFAToken(ctx, userID, tokenID); err != nil {
+			writeMFATokenError(w, err)
+
+			return
+		}
+
+		h.signIn(ctx, w, r, userID, totpState.Username)
+	}
+}
+
+// signIn создаёт сеанс аутентифицированного пользователя и возвращает параметры получения ключей
+// и ключ шифрования хранилища
+func (h *handler) signIn(ctx context.Context, w http.ResponseWriter, r *http.Request, userID int, username string) {
+	kdf, err := h.repoDB.FindKDF(ctx, username)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	vaultKey, err := h.repoDB.FindVaultKey(ctx, userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	if err = h.startSession(ctx, w, r, userID); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	if err = h.limiter.Succeed(ctx, username, clientIP(r)); err != nil {
+		h.mLogger.Warning(err.Error())
+	}
+
+	writeJSON(w, model.SignInResponse{KDF: kdf, VaultKey: vaultKey})
+}
+
+// PreLogin endpoint возвращает параметры получения ключей пользователя до аутентификации,
+// для неизвестного пользователя возвращаются правдоподобные параметры
+//
+// @Tags        User
+// @Summary     Параметры получения ключей пользователя
+// @Param       value body model.User true "имя пользователя"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} model.PreLoginResponse
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     500 {object} model.ErrorResponse
+// @Router /prelogin [post]
+func (h *handler) PreLogin(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		user, err := getUserFromBody(r)
+		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
 		}
 
-		err = h.repoDB.SaveLogin(ctx, &login)
+		if strings.TrimSpace(user.Username) == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: model.ErrUserUsernamePasswordEmpity.Error()})
+
+			return
+		}
+
+		kdf, err := h.repoDB.FindKDF(ctx, user.Username)
 		if err != nil {
-			w.WriteHeader(http.StatusNotAcceptable)
+			if !errors.Is(err, postgresql.ErrDBInvalidUsernamePasswordPair) {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+				return
+			}
+
+			kdf = fakeKDF(user.Username)
+		}
+
+		body := &bytes.Buffer{}
+		encoder := json.NewEncoder(body)
+
+		err = encoder.Encode(model.PreLoginResponse{KDF: kdf})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
 		}
 
-		fmt.Fprintf(w, "{}")
+		_, err = w.Write(body.Bytes())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
 	}
 }
 
-// DeleteLogin endpoint удаления данных логина пользователя
+// UpdateCredential endpoint замены пароля и параметров получения ключей пользователя,
+// остальные сеансы и токены API пользователя завершаются, сеансу выдаются новые токены
 //
-// @Tags        Logins
-// @Summary     Удаляет данные логина пользователя
-// @Param       id path integer true "id логина"
+// @Tags        User
+// @Summary     Заменяет учётные данные пользователя
+// @Param       value body model.Credential true "текущий и новый пароль, параметры получения ключей"
 // @Accept      json
 // @Produce     json
 // @Success     200 {object} object
 // @Failure     400 {object} model.ErrorResponse
-// @Failure     406 {object} model.ErrorResponse
-// @Router /logins/{id} [delete]
-func (h *handler) DeleteLogin(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+// @Failure     401 {object} model.ErrorResponse
+// @Failure     429 {object} model.ErrorResponse
+// @Router /credential [post]
+func (h *handler) UpdateCredential(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
@@ -259,19 +533,80 @@ func (h *handler) DeleteLogin(ctx context.Context) func(w http.ResponseWriter, r
 			return
 		}
 
-		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		sessionID, err := getSessionID(r)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprint(w, model.ErrorResponse{Error: "invalid login id = " + chi.URLParam(r, "id")})
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
 		}
 
-		login := model.Login{ID: id, UserID: userID}
+		credential := model.Credential{}
 
-		err = h.repoDB.DeleteLogin(ctx, &login)
+		decoder := json.NewDecoder(r.Body)
+
+		err = decoder.Decode(&credential)
 		if err != nil {
-			w.WriteHeader(http.StatusNotAcceptable)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = credential.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		username, err := h.repoDB.FindUsername(ctx, userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if h.throttled(ctx, w, r, username) {
+			return
+		}
+
+		refreshToken, err := newRefreshToken()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		expiresAt := time.Now().Add(refreshTokenTTL)
+
+		err = h.repoDB.UpdateCredential(ctx, userID, sessionID, credential, refreshToken, expiresAt)
+		if err != nil {
+			switch {
+			case errors.Is(err, postgresql.ErrDBInvalidUsernamePasswordPair):
+				h.authFailed(ctx, r, username)
+				w.WriteHeader(http.StatusUnauthorized)
+			case errors.Is(err, postgresql.ErrDBSessionNotFound):
+				w.WriteHeader(http.StatusUnauthorized)
+			case errors.Is(err, model.ErrKDFDowngrade):
+				w.WriteHeader(http.StatusBadRequest)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = h.limiter.Succeed(ctx, username, clientIP(r)); err != nil {
+			h.mLogger.Warning(err.Error())
+		}
+
+		if err = h.setTokens(w, r, userID, sessionID, refreshToken, expiresAt); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
@@ -281,16 +616,21 @@ func (h *handler) DeleteLogin(ctx context.Context) func(w http.ResponseWriter, r
 	}
 }
 
-// FindLogins endpoint возвращает данные логинов пользователя
+// SaveRecoveryKeys endpoint замены ключей восстановления пользователя, замена подтверждается
+// текущим паролем пользователя
 //
-// @Tags        Logins
-// @Summary     Возвращает данные логинов пользователя
+// @Tags        User
+// @Summary     Заменяет ключи восстановления пользователя
+// @Param       value body model.RecoveryKeysChange true "текущий пароль и ключи восстановления"
 // @Accept      json
 // @Produce     json
-// @Success     200 {array}  model.Login
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     401 {object} model.ErrorResponse
+// @Failure     429 {object} model.ErrorResponse
 // @Failure     500 {object} model.ErrorResponse
-// @Router /logins [get]
-func (h *handler) FindLogins(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+// @Router /recovery/keys [put]
+func (h *handler) SaveRecoveryKeys(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
@@ -302,7 +642,26 @@ func (h *handler) FindLogins(ctx context.Context) func(w http.ResponseWriter, r
 			return
 		}
 
-		logins, err := h.repoDB.FindLogins(ctx, model.User{ID: userID})
+		change := model.RecoveryKeysChange{}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err = decoder.Decode(&change)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = change.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		username, err := h.repoDB.FindUsername(ctx, userID)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
@@ -310,39 +669,2584 @@ func (h *handler) FindLogins(ctx context.Context) func(w http.ResponseWriter, r
 			return
 		}
 
-		body := &bytes.Buffer{}
-		encoder := json.NewEncoder(body)
+		if h.throttled(ctx, w, r, username) {
+			return
+		}
+
+		err = h.repoDB.SaveRecoveryKeys(ctx, userID, change.Password, change.RecoveryKeys)
+		if err != nil {
+			switch {
+			case errors.Is(err, postgresql.ErrDBInvalidUsernamePasswordPair):
+				h.authFailed(ctx, r, username)
+				w.WriteHeader(http.StatusUnauthorized)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = h.limiter.Succeed(ctx, username, clientIP(r)); err != nil {
+			h.mLogger.Warning(err.Error())
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// FindRecoveryKey endpoint возвращает ключ шифрования хранилища пользователя,
+// зашифрованный кодом восстановления
+//
+// @Tags        User
+// @Summary     Ключ шифрования хранилища для кода восстановления
+// @Param       value body model.Recovery true "имя пользователя и проверочное значение кода восстановления"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} model.RecoveryResponse
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     401 {object} model.ErrorResponse
+// @Failure     403 {object} model.ErrorResponse
+// @Failure     429 {object} model.ErrorResponse
+// @Router /recovery [post]
+func (h *handler) FindRecoveryKey(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		recovery := model.Recovery{}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err := decoder.Decode(&recovery)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = recovery.ValidateRequest(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if certificateMismatch(w, r, recovery.Username) {
+			return
+		}
+
+		if h.throttled(ctx, w, r, recovery.Username) {
+			return
+		}
+
+		vaultKey, err := h.repoDB.FindRecoveryKey(ctx, recovery)
+		if err != nil {
+			switch {
+			case errors.Is(err, postgresql.ErrDBInvalidRecoveryCode):
+				h.authFailed(ctx, r, recovery.Username)
+				w.WriteHeader(http.StatusUnauthorized)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = h.limiter.Release(ctx, recovery.Username, clientIP(r)); err != nil {
+			h.mLogger.Warning(err.Error())
+		}
+
+		body := &bytes.Buffer{}
+		encoder := json.NewEncoder(body)
+
+		err = encoder.Encode(model.RecoveryResponse{VaultKey: vaultKey})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		_, err = w.Write(body.Bytes())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+	}
+}
+
+// Recover endpoint восстановления доступа по коду восстановления: пользователь задаёт новый
+// мастер-пароль, ключ шифрования хранилища при этом не меняется, код восстановления удаляется,
+// все сеансы пользователя завершаются
+//
+// @Tags        User
+// @Summary     Восстановление доступа по коду восстановления
+// @Param       value body model.Recovery true "код восстановления и новые учётные данные"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     401 {object} model.ErrorResponse
+// @Failure     403 {object} model.ErrorResponse
+// @Failure     429 {object} model.ErrorResponse
+// @Router /recovery/password [post]
+func (h *handler) Recover(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		recovery := model.Recovery{}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err := decoder.Decode(&recovery)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = recovery.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if certificateMismatch(w, r, recovery.Username) {
+			return
+		}
+
+		if h.throttled(ctx, w, r, recovery.Username) {
+			return
+		}
+
+		userID, err := h.repoDB.Recover(ctx, recovery)
+		if err != nil {
+			switch {
+			case errors.Is(err, postgresql.ErrDBInvalidRecoveryCode):
+				h.authFailed(ctx, r, recovery.Username)
+				w.WriteHeader(http.StatusUnauthorized)
+			case errors.Is(err, model.ErrKDFDowngrade):
+				w.WriteHeader(http.StatusBadRequest)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = h.limiter.Release(ctx, recovery.Username, clientIP(r)); err != nil {
+			h.mLogger.Warning(err.Error())
+		}
+
+		if err = h.repoDB.DeleteSessions(ctx, userID); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = h.startSession(ctx, w, r, userID); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// EnrollTOTP endpoint начала подключения двухфакторной аутентификации: создаётся секрет,
+// который вступает в силу после подтверждения одноразовым паролем в ConfirmTOTP
+//
+// @Tags        User
+// @Summary     Начало подключения двухфакторной аутентификации
+// @Produce     json
+// @Success     200 {object} model.TOTPEnrollment
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     409 {object} model.ErrorResponse
+// @Router /totp [post]
+func (h *handler) EnrollTOTP(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		totpState, err := h.repoDB.FindTOTP(ctx, userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if totpState.Enabled() {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, model.ErrorResponse{Error: ErrTOTPEnabled.Error()})
+
+			return
+		}
+
+		secret, err := totp.NewSecret()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = h.repoDB.SaveTOTPPending(ctx, userID, secret); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		writeJSON(w, model.TOTPEnrollment{Secret: secret, URI: totp.URI(totpIssuer, totpState.Username, secret)})
+	}
+}
+
+// ConfirmTOTP endpoint подтверждения подключения двухфакторной аутентификации одноразовым паролем,
+// возвращает резервные коды
+//
+// @Tags        User
+// @Summary     Подтверждение подключения двухфакторной аутентификации
+// @Param       value body model.TOTPCode true "одноразовый пароль"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} model.TOTPBackupCodes
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     401 {object} model.ErrorResponse
+// @Router /totp/confirm [post]
+func (h *handler) ConfirmTOTP(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		code, err := getTOTPCodeFromBody(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		totpState, err := h.repoDB.FindTOTP(ctx, userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if totpState.Pending == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: postgresql.ErrDBTOTPNotPending.Error()})
+
+			return
+		}
+
+		step, ok := totp.Validate(totpState.Pending, code.Code, time.Now())
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, model.ErrorResponse{Error: postgresql.ErrDBInvalidTOTPCode.Error()})
+
+			return
+		}
+
+		backupCodes, err := newBackupCodes(totpBackupCodes)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		normalized := make([]string, 0, len(backupCodes))
+		for _, backupCode := range backupCodes {
+			normalized = append(normalized, model.NormalizeBackupCode(backupCode))
+		}
+
+		err = h.repoDB.EnableTOTP(ctx, userID, totpState.Pending, step, normalized)
+		if err != nil {
+			switch {
+			case errors.Is(err, postgresql.ErrDBTOTPNotPending):
+				w.WriteHeader(http.StatusBadRequest)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		writeJSON(w, model.TOTPBackupCodes{BackupCodes: backupCodes})
+	}
+}
+
+// DisableTOTP endpoint отключения двухфакторной аутентификации, требует одноразовый пароль или резервный код
+//
+// @Tags        User
+// @Summary     Отключение двухфакторной аутентификации
+// @Param       value body model.TOTPCode true "одноразовый пароль или резервный код"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     401 {object} model.ErrorResponse
+// @Failure     429 {object} model.ErrorResponse
+// @Router /totp [delete]
+func (h *handler) DisableTOTP(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		code, err := getTOTPCodeFromBody(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		totpState, err := h.repoDB.FindTOTP(ctx, userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if !totpState.Enabled() {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: ErrTOTPNotEnabled.Error()})
+
+			return
+		}
+
+		if h.throttled(ctx, w, r, totpState.Username) {
+			return
+		}
+
+		if err = h.checkTOTP(ctx, userID, totpState, code.Code); err != nil {
+			if errors.Is(err, postgresql.ErrDBInvalidTOTPCode) || errors.Is(err, postgresql.ErrDBTOTPCodeUsed) {
+				h.authFailed(ctx, r, totpState.Username)
+			}
+
+			writeTOTPError(w, err)
+
+			return
+		}
+
+		if err = h.repoDB.DisableTOTP(ctx, userID); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = h.limiter.Succeed(ctx, totpState.Username, clientIP(r)); err != nil {
+			h.mLogger.Warning(err.Error())
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// Refresh endpoint обновления токенов сеанса: токен обновления из cookie заменяется новым,
+// выдаётся новый токен доступа
+//
+// @Tags        User
+// @Summary     Обновление токенов сеанса
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     401 {object} model.ErrorResponse
+// @Router /refresh [post]
+func (h *handler) Refresh(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		cookie, err := r.Cookie(refreshTokenCookie)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, model.ErrorResponse{Error: ErrNoRefreshToken.Error()})
+
+			return
+		}
+
+		refreshToken, err := newRefreshToken()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		expiresAt := time.Now().Add(refreshTokenTTL)
+
+		sessionID, userID, err := h.repoDB.RotateSession(ctx, cookie.Value, refreshToken, expiresAt)
+		if err != nil {
+			switch {
+			case errors.Is(err, postgresql.ErrDBInvalidRefreshToken):
+				clearTokens(w, r)
+				w.WriteHeader(http.StatusUnauthorized)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = h.setTokens(w, r, userID, sessionID, refreshToken, expiresAt); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// Logout endpoint завершения текущего сеанса пользователя
+//
+// @Tags        User
+// @Summary     Завершение сеанса
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Router /logout [post]
+func (h *handler) Logout(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		sessionID, err := getSessionID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = h.repoDB.DeleteSession(ctx, userID, sessionID); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		clearTokens(w, r)
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// LogoutAll endpoint завершения всех сеансов пользователя на всех устройствах
+//
+// @Tags        User
+// @Summary     Завершение всех сеансов
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Router /logout/all [post]
+func (h *handler) LogoutAll(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = h.repoDB.DeleteSessions(ctx, userID); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		clearTokens(w, r)
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// DeleteAccount endpoint удаления учётной записи пользователя со всеми данными и содержимым файлов,
+// требует повторного ввода пароля
+//
+// @Tags        User
+// @Summary     Удаляет учётную запись пользователя
+// @Param       value body model.AccountDeletion true "пароль пользователя"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     401 {object} model.ErrorResponse
+// @Failure     429 {object} model.ErrorResponse
+// @Router /account [delete]
+func (h *handler) DeleteAccount(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		deletion := model.AccountDeletion{}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err = decoder.Decode(&deletion)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = deletion.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		username, err := h.repoDB.FindUsername(ctx, userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if h.throttled(ctx, w, r, username) {
+			return
+		}
+
+		paths, err := h.repoDB.DeleteUser(ctx, userID, deletion.Password)
+		if err != nil {
+			switch {
+			case errors.Is(err, postgresql.ErrDBInvalidUsernamePasswordPair):
+				h.authFailed(ctx, r, username)
+				w.WriteHeader(http.StatusUnauthorized)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		for _, filePath := range paths {
+			h.deleteBlob(ctx, filePath)
+		}
+
+		if err = h.limiter.Succeed(ctx, username, clientIP(r)); err != nil {
+			h.mLogger.Warning(err.Error())
+		}
+
+		h.mLogger.Info(fmt.Sprintf("account deleted: username=%q files=%d", username, len(paths)))
+
+		clearTokens(w, r)
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// ExportAccount endpoint выгрузки учётной записи пользователя: zip архив содержит описание учётной записи
+// с зашифрованными записями и зашифрованное содержимое файлов, данные на сервере не расшифровываются
+//
+// @Tags        User
+// @Summary     Выгружает учётную запись пользователя
+// @Produce     application/zip
+// @Produce     json
+// @Success     200 {file} schema
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     500 {object} model.ErrorResponse
+// @Router /account/export [get]
+func (h *handler) ExportAccount(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		account, err := h.repoDB.FindAccount(ctx, userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		account.Version = model.AccountArchiveVersion
+		account.ExportedAt = time.Now().UTC()
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment",
+			map[string]string{"filename": "gophkeeper-" + account.Username + ".zip"}))
+
+		// после начала передачи архива код ответа изменить нельзя, поэтому при ошибке передача
+		// прекращается, и клиент получает архив без оглавления, который не откроется
+		if err = h.writeAccountArchive(r.Context(), w, account); err != nil {
+			h.mLogger.Warning(fmt.Sprintf("account export failed: username=%q: %s", account.Username, err))
+		}
+	}
+}
+
+// writeAccountArchive записывает в w zip архив выгрузки учётной записи account: сначала описание
+// учётной записи, затем содержимое файлов, которое передаётся из файлового репозитория потоком
+func (h *handler) writeAccountArchive(ctx context.Context, w io.Writer, account model.Account) (err error) {
+	archive := zip.NewWriter(w)
+
+	blobs := make([]string, len(account.Files))
+
+	for i := range account.Files {
+		blobs[i] = account.Files[i].Path
+		account.Files[i].Path = model.AccountArchiveFiles + strconv.Itoa(account.Files[i].ID)
+	}
+
+	manifest, err := archive.Create(model.AccountArchiveManifest)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(manifest)
+	encoder.SetIndent("", "  ")
+
+	if err = encoder.Encode(account); err != nil {
+		return err
+	}
+
+	for i, file := range account.Files {
+		// содержимое файлов зашифровано и не сжимается
+		dst, err := archive.CreateHeader(&zip.FileHeader{Name: file.Path, Method: zip.Store, Modified: account.ExportedAt})
+		if err != nil {
+			return err
+		}
+
+		src, err := h.repoFile.GetFile(ctx, blobs[i])
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(dst, src)
+		src.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// CreateAPIToken endpoint создания токена API пользователя с областями доступа scopes и необязательным
+// сроком действия expires_at, секрет токена возвращается только в ответе на этот запрос
+//
+// @Tags        User
+// @Summary     Создаёт токен API
+// @Param       value body model.APIToken true "название, области доступа и срок действия токена"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} model.APITokenCreated
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     500 {object} model.ErrorResponse
+// @Router /tokens [post]
+func (h *handler) CreateAPIToken(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		token := model.APIToken{}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err = decoder.Decode(&token)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = token.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		secret, err := newRefreshToken()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		token.UserID = userID
+		token.LastUsedAt = nil
+		tokenString := model.APITokenPrefix + secret
+
+		if err = h.repoDB.SaveAPIToken(ctx, &token, tokenString); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		writeJSON(w, model.APITokenCreated{APIToken: token, Token: tokenString})
+	}
+}
+
+// FindAPITokens endpoint возвращает токены API пользователя без секретов
+//
+// @Tags        User
+// @Summary     Возвращает токены API
+// @Produce     json
+// @Success     200 {array}  model.APIToken
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     500 {object} model.ErrorResponse
+// @Router /tokens [get]
+func (h *handler) FindAPITokens(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		tokens, err := h.repoDB.FindAPITokens(ctx, userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		writeJSON(w, tokens)
+	}
+}
+
+// DeleteAPIToken endpoint отзыва токена API пользователя
+//
+// @Tags        User
+// @Summary     Отзывает токен API
+// @Param       id path integer true "id токена"
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     404 {object} model.ErrorResponse
+// @Router /tokens/{id} [delete]
+func (h *handler) DeleteAPIToken(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid token id = " + chi.URLParam(r, "id")})
+
+			return
+		}
+
+		err = h.repoDB.DeleteAPIToken(ctx, userID, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, postgresql.ErrDBAPITokenNotFound):
+				w.WriteHeader(http.StatusNotFound)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// APIToken middleware аутентификации по токену API из заголовка Authorization: Bearer. Запрос
+// с токеном API получает в контексте утверждения владельца токена, как запрос с токеном доступа сеанса,
+// запросы без токена API передаются дальше без изменений
+func (h *handler) APIToken(ctx context.Context) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString := jwtauth.TokenFromHeader(r)
+			if !strings.HasPrefix(tokenString, model.APITokenPrefix) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			apiToken, err := h.repoDB.FindAPIToken(ctx, tokenString)
+			if err != nil {
+				switch {
+				case errors.Is(err, postgresql.ErrDBAPITokenNotFound):
+					jwtkeys.Unauthorized(w)
+				default:
+					w.Header().Set("Content-Type", "application/json; charset=utf-8")
+					w.WriteHeader(http.StatusInternalServerError)
+					fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+				}
+
+				return
+			}
+
+			token := jwt.New()
+			if err = token.Set("user_id", strconv.Itoa(apiToken.UserID)); err != nil {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+				return
+			}
+
+			rctx := jwtauth.NewContext(r.Context(), token, nil)
+			rctx = context.WithValue(rctx, apiTokenCtxKey, apiToken)
+
+			next.ServeHTTP(w, r.WithContext(rctx))
+		})
+	}
+}
+
+// Scope middleware отклоняет запросы с токеном API, которому не разрешена область доступа scope,
+// запросы с токеном доступа сеанса не ограничиваются
+func (h *handler) Scope(scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiToken, ok := getAPIToken(r); ok && !apiToken.HasScope(scope) {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, model.ErrorResponse{Error: fmt.Sprintf("%s: %s", ErrInsufficientScope, scope)})
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Notify middleware уведомляет устройства пользователя об изменении хранилища после успешного
+// выполнения запроса, изменяющего записи, папки или метки
+func (h *handler) Notify(ctx context.Context) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			if status := ww.Status(); status != 0 && (status < 200 || status >= 300) {
+				return
+			}
+
+			// утверждения запроса проверены Authenticator, поэтому ошибки здесь нет
+			if userID, err := getUserID(r); err == nil {
+				h.events.Publish(ctx, userID)
+			}
+		})
+	}
+}
+
+// Session middleware отклоняет запросы с токеном доступа завершённого сеанса,
+// запросы с токеном API уже проверены middleware APIToken
+func (h *handler) Session(ctx context.Context) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := getAPIToken(r); ok {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			userID, err := getUserID(r)
+			if err != nil {
+				jwtkeys.Unauthorized(w)
+
+				return
+			}
+
+			sessionID, err := getSessionID(r)
+			if err != nil {
+				jwtkeys.Unauthorized(w)
+
+				return
+			}
+
+			err = h.repoDB.FindSession(ctx, userID, sessionID)
+			if err != nil {
+				switch {
+				case errors.Is(err, postgresql.ErrDBSessionNotFound):
+					jwtkeys.Unauthorized(w)
+				default:
+					w.Header().Set("Content-Type", "application/json; charset=utf-8")
+					w.WriteHeader(http.StatusInternalServerError)
+					fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+				}
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientCertificate middleware отклоняет запросы, клиентский сертификат которых выдан другому пользователю,
+// запросы с сертификатом без привязки к пользователю и без сертификата передаются дальше
+func (h *handler) ClientCertificate(ctx context.Context) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := certificateUser(r); !ok {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			userID, err := getUserID(r)
+			if err != nil {
+				jwtkeys.Unauthorized(w)
+
+				return
+			}
+
+			username, err := h.repoDB.FindUsername(ctx, userID)
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+			if certificateMismatch(w, r, username) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// StageFile endpoint загрузки перешифрованного содержимого файла перед сменой мастер-пароля,
+// загруженное содержимое подменяет прежнее только при успешной смене мастер-пароля
+//
+// @Tags        User
+// @Summary     Загружает перешифрованное содержимое файла
+// @Param       id path integer true "id файла"
+// @Accept      octet-stream
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /password/files/{id} [put]
+func (h *handler) StageFile(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid file id = " + chi.URLParam(r, "id")})
+
+			return
+		}
+
+		filePath, err := h.repoFile.SaveFile(ctx, r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		oldPath, err := h.repoDB.SaveRekeyFile(ctx, userID, id, filePath)
+		if err != nil {
+			h.deleteBlob(ctx, filePath)
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		h.deleteBlob(ctx, oldPath)
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// DiscardStagedFiles endpoint удаления перешифрованного содержимого файлов,
+// загруженного для незавершённой смены мастер-пароля
+//
+// @Tags        User
+// @Summary     Удаляет загруженное перешифрованное содержимое файлов
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     500 {object} model.ErrorResponse
+// @Router /password/files [delete]
+func (h *handler) DiscardStagedFiles(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		paths, err := h.repoDB.DeleteRekeyFiles(ctx, userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		for _, filePath := range paths {
+			h.deleteBlob(ctx, filePath)
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// ChangePassword endpoint смены мастер-пароля: учётные данные и все перешифрованные данные
+// пользователя заменяются атомарно, при ошибке данные пользователя не изменяются. Остальные
+// сеансы и токены API пользователя завершаются, сеансу выдаются новые токены
+//
+// @Tags        User
+// @Summary     Меняет мастер-пароль и перешифровывает данные пользователя
+// @Param       value body model.PasswordChange true "учётные данные и перешифрованные данные"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     401 {object} model.ErrorResponse
+// @Failure     409 {object} model.ErrorResponse
+// @Failure     429 {object} model.ErrorResponse
+// @Router /password [post]
+func (h *handler) ChangePassword(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		sessionID, err := getSessionID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		change := model.PasswordChange{}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err = decoder.Decode(&change)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = change.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		username, err := h.repoDB.FindUsername(ctx, userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if h.throttled(ctx, w, r, username) {
+			return
+		}
+
+		refreshToken, err := newRefreshToken()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		expiresAt := time.Now().Add(refreshTokenTTL)
+
+		oldPaths, err := h.repoDB.ChangePassword(ctx, userID, sessionID, change, refreshToken, expiresAt)
+		if err != nil {
+			switch {
+			case errors.Is(err, postgresql.ErrDBInvalidUsernamePasswordPair):
+				h.authFailed(ctx, r, username)
+				w.WriteHeader(http.StatusUnauthorized)
+			case errors.Is(err, postgresql.ErrDBSessionNotFound):
+				w.WriteHeader(http.StatusUnauthorized)
+			case errors.Is(err, postgresql.ErrDBVaultChanged), errors.Is(err, postgresql.ErrDBFileNotStaged):
+				w.WriteHeader(http.StatusConflict)
+			case errors.Is(err, model.ErrKDFDowngrade):
+				w.WriteHeader(http.StatusBadRequest)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = h.limiter.Succeed(ctx, username, clientIP(r)); err != nil {
+			h.mLogger.Warning(err.Error())
+		}
+
+		for _, filePath := range oldPaths {
+			h.deleteBlob(ctx, filePath)
+		}
+
+		if err = h.setTokens(w, r, userID, sessionID, refreshToken, expiresAt); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// SaveLogin endpoint сохранения данных логина пользователя
+//
+// @Tags        Logins
+// @Summary     Cохраняет данные логина пользователя
+// @Param       value body model.Login true "данные логина"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /logins [post]
+func (h *handler) SaveLogin(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		login := model.Login{UserID: userID}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err = decoder.Decode(&login)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = login.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		err = h.repoDB.SaveLogin(postgresql.WithDevice(ctx, deviceName(r)), &login)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// DeleteLogin endpoint перемещения данных логина пользователя в корзину
+//
+// @Tags        Logins
+// @Summary     Перемещает данные логина пользователя в корзину
+// @Param       id path integer true "id логина"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /logins/{id} [delete]
+func (h *handler) DeleteLogin(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid login id = " + chi.URLParam(r, "id")})
+
+			return
+		}
+
+		login := model.Login{ID: id, UserID: userID}
+
+		err = h.repoDB.DeleteLogin(ctx, &login)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// FindLogins endpoint возвращает данные логинов пользователя
+//
+// @Tags        Logins
+// @Summary     Возвращает данные логинов пользователя
+// @Accept      json
+// @Produce     json
+// @Success     200 {array}  model.Login
+// @Failure     500 {object} model.ErrorResponse
+// @Router /logins [get]
+func (h *handler) FindLogins(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		logins, err := h.repoDB.FindLogins(ctx, model.User{ID: userID})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		body := &bytes.Buffer{}
+		encoder := json.NewEncoder(body)
+
+		err = encoder.Encode(logins)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		_, err = w.Write(body.Bytes())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+	}
+}
+
+// SaveCard endpoint сохраняет данные банковской карты пользователя
+//
+// @Tags        Cards
+// @Summary     Cохраняет данные банковской карты пользователя
+// @Param       value body model.Card true "данные банковской карты"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /cards [post]
+func (h *handler) SaveCard(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		card := model.Card{UserID: userID}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err = decoder.Decode(&card)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = card.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		err = h.repoDB.SaveCard(postgresql.WithDevice(ctx, deviceName(r)), &card)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// DeleteCard endpoint перемещает данные банковской карты пользователя в корзину
+//
+// @Tags        Cards
+// @Summary     Перемещает данные банковской карты пользователя в корзину
+// @Param       id path integer true "id банковской карты"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /cards/{id} [delete]
+func (h *handler) DeleteCard(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid login id = " + chi.URLParam(r, "id")})
+
+			return
+		}
+
+		card := model.Card{ID: id, UserID: userID}
+
+		err = h.repoDB.DeleteCard(ctx, &card)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// FindCards endpoint возвращает данные банковских карт пользователя
+//
+// @Tags        Cards
+// @Summary     Возвращает данные банковских карт пользователя
+// @Accept      json
+// @Produce     json
+// @Success     200 {array}  model.Card
+// @Failure     500 {object} model.ErrorResponse
+// @Router /cards [get]
+func (h *handler) FindCards(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		logins, err := h.repoDB.FindCards(ctx, model.User{ID: userID})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		body := &bytes.Buffer{}
+		encoder := json.NewEncoder(body)
+
+		err = encoder.Encode(logins)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		_, err = w.Write(body.Bytes())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+	}
+}
+
+// SaveNote endpoint сохраняет заметку пользователя
+//
+// @Tags        Notes
+// @Summary     Cохраняет заметку пользователя
+// @Param       value body model.Note true "заметка"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /notes [post]
+func (h *handler) SaveNote(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		note := model.Note{UserID: userID}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err = decoder.Decode(&note)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = note.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		err = h.repoDB.SaveNote(postgresql.WithDevice(ctx, deviceName(r)), &note)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// DeleteNote endpoint перемещает заметку пользователя в корзину
+//
+// @Tags        Notes
+// @Summary     Перемещает заметку пользователя в корзину
+// @Param       id path integer true "id заметки"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /notes/{id} [delete]
+func (h *handler) DeleteNote(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid note id = " + chi.URLParam(r, "id")})
+
+			return
+		}
+
+		note := model.Note{ID: id, UserID: userID}
+
+		err = h.repoDB.DeleteNote(ctx, &note)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// FindNotes endpoint возвращает заметки пользователя
+//
+// @Tags        Notes
+// @Summary     Возвращает заметки пользователя
+// @Accept      json
+// @Produce     json
+// @Success     200 {array}  model.Note
+// @Failure     500 {object} model.ErrorResponse
+// @Router /notes [get]
+func (h *handler) FindNotes(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		notes, err := h.repoDB.FindNotes(ctx, model.User{ID: userID})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		writeJSON(w, notes)
+	}
+}
+
+// SaveOTP endpoint сохраняет ключ одноразовых паролей пользователя
+//
+// @Tags        OTPs
+// @Summary     Cохраняет ключ одноразовых паролей пользователя
+// @Param       value body model.OTP true "ключ одноразовых паролей"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     404 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /otps [post]
+func (h *handler) SaveOTP(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		otp := model.OTP{UserID: userID}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err = decoder.Decode(&otp)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = otp.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		err = h.repoDB.SaveOTP(postgresql.WithDevice(ctx, deviceName(r)), &otp)
+		if err != nil {
+			switch {
+			case errors.Is(err, postgresql.ErrDBLoginNotFound):
+				w.WriteHeader(http.StatusNotFound)
+			default:
+				w.WriteHeader(http.StatusNotAcceptable)
+			}
+
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// DeleteOTP endpoint перемещает ключ одноразовых паролей пользователя в корзину
+//
+// @Tags        OTPs
+// @Summary     Перемещает ключ одноразовых паролей пользователя в корзину
+// @Param       id path integer true "id ключа одноразовых паролей"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /otps/{id} [delete]
+func (h *handler) DeleteOTP(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid otp id = " + chi.URLParam(r, "id")})
+
+			return
+		}
+
+		otp := model.OTP{ID: id, UserID: userID}
+
+		err = h.repoDB.DeleteOTP(ctx, &otp)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// FindOTPs endpoint возвращает ключи одноразовых паролей пользователя
+//
+// @Tags        OTPs
+// @Summary     Возвращает ключи одноразовых паролей пользователя
+// @Accept      json
+// @Produce     json
+// @Success     200 {array}  model.OTP
+// @Failure     500 {object} model.ErrorResponse
+// @Router /otps [get]
+func (h *handler) FindOTPs(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		otps, err := h.repoDB.FindOTPs(ctx, model.User{ID: userID})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		writeJSON(w, otps)
+	}
+}
+
+// SaveSSHKey endpoint сохраняет ключ SSH пользователя
+//
+// @Tags        SSHKeys
+// @Summary     Cохраняет ключ SSH пользователя
+// @Param       value body model.SSHKey true "ключ SSH"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /sshkeys [post]
+func (h *handler) SaveSSHKey(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		key := model.SSHKey{UserID: userID}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err = decoder.Decode(&key)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		if err = key.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		err = h.repoDB.SaveSSHKey(postgresql.WithDevice(ctx, deviceName(r)), &key)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// DeleteSSHKey endpoint перемещает ключ SSH пользователя в корзину
+//
+// @Tags        SSHKeys
+// @Summary     Перемещает ключ SSH пользователя в корзину
+// @Param       id path integer true "id ключа SSH"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /sshkeys/{id} [delete]
+func (h *handler) DeleteSSHKey(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid ssh key id = " + chi.URLParam(r, "id")})
+
+			return
+		}
+
+		key := model.SSHKey{ID: id, UserID: userID}
+
+		err = h.repoDB.DeleteSSHKey(ctx, &key)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// FindSSHKeys endpoint возвращает ключи SSH пользователя
+//
+// @Tags        SSHKeys
+// @Summary     Возвращает ключи SSH пользователя
+// @Accept      json
+// @Produce     json
+// @Success     200 {array}  model.SSHKey
+// @Failure     500 {object} model.ErrorResponse
+// @Router /sshkeys [get]
+func (h *handler) FindSSHKeys(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		keys, err := h.repoDB.FindSSHKeys(ctx, model.User{ID: userID})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		writeJSON(w, keys)
+	}
+}
+
+// SaveItem endpoint сохраняет запись произвольного типа пользователя
+//
+// @Tags        Items
+// @Summary     Cохраняет запись произвольного типа пользователя
+// @Param       value body model.Item true "запись"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     404 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /items [post]
+func (h *handler) SaveItem(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		item := model.Item{}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err = decoder.Decode(&item)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		item.UserID = userID
+
+		if err = item.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		err = h.repoDB.SaveItem(postgresql.WithDevice(ctx, deviceName(r)), &item)
+		if err != nil {
+			status := http.StatusNotAcceptable
+			if errors.Is(err, postgresql.ErrDBItemNotFound) {
+				status = http.StatusNotFound
+			}
+
+			w.WriteHeader(status)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// DeleteItem endpoint перемещает запись произвольного типа пользователя в корзину
+//
+// @Tags        Items
+// @Summary     Перемещает запись произвольного типа пользователя в корзину
+// @Param       id path integer true "id записи"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     404 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /items/{id} [delete]
+func (h *handler) DeleteItem(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid item id = " + chi.URLParam(r, "id")})
+
+			return
+		}
+
+		item := model.Item{ID: id, UserID: userID}
+
+		err = h.repoDB.DeleteItem(ctx, &item)
+		if err != nil {
+			status := http.StatusNotAcceptable
+			if errors.Is(err, postgresql.ErrDBItemNotFound) {
+				status = http.StatusNotFound
+			}
+
+			w.WriteHeader(status)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// FindItems endpoint возвращает записи произвольного типа пользователя
+//
+// @Tags        Items
+// @Summary     Возвращает записи произвольного типа пользователя
+// @Accept      json
+// @Produce     json
+// @Success     200 {array}  model.Item
+// @Failure     500 {object} model.ErrorResponse
+// @Router /items [get]
+func (h *handler) FindItems(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		items, err := h.repoDB.FindItems(ctx, model.User{ID: userID})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		writeJSON(w, items)
+	}
+}
+
+// FindRevisions endpoint возвращает прежние редакции записи пользователя любого типа, начиная с последней,
+// данные редакций зашифрованы клиентом так же, как данные записи
+//
+// @Tags        History
+// @Summary     Возвращает прежние редакции записи пользователя
+// @Param       id path integer true "id записи"
+// @Accept      json
+// @Produce     json
+// @Success     200 {array}  model.Revision
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     404 {object} model.ErrorResponse
+// @Failure     500 {object} model.ErrorResponse
+// @Router /revisions/{id} [get]
+func (h *handler) FindRevisions(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid item id = " + chi.URLParam(r, "id")})
+
+			return
+		}
+
+		revisions, err := h.repoDB.FindRevisions(ctx, userID, id)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, postgresql.ErrDBItemNotFound) {
+				status = http.StatusNotFound
+			}
+
+			w.WriteHeader(status)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		writeJSON(w, revisions)
+	}
+}
+
+// RestoreRevision endpoint восстанавливает прежнюю редакцию записи пользователя,
+// текущая редакция записи сохраняется в истории
+//
+// @Tags        History
+// @Summary     Восстанавливает прежнюю редакцию записи пользователя
+// @Param       id path integer true "id записи"
+// @Param       revision path integer true "id редакции"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     404 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /revisions/{id}/{revision} [post]
+func (h *handler) RestoreRevision(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid item id = " + chi.URLParam(r, "id")})
+
+			return
+		}
+
+		revisionID, err := strconv.Atoi(chi.URLParam(r, "revision"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid revision id = " + chi.URLParam(r, "revision")})
+
+			return
+		}
+
+		err = h.repoDB.RestoreRevision(postgresql.WithDevice(ctx, deviceName(r)), userID, id, revisionID)
+		if err != nil {
+			status := http.StatusNotAcceptable
+			if errors.Is(err, postgresql.ErrDBItemNotFound) || errors.Is(err, postgresql.ErrDBRevisionNotFound) {
+				status = http.StatusNotFound
+			}
+
+			w.WriteHeader(status)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// Sync endpoint возвращает записи пользователя всех типов, изменённые после ревизии синхронизации since.
+// Ревизия синхронизации увеличивается при каждом изменении записей, папок и меток пользователя
+//
+// @Tags        Sync
+// @Summary     Возвращает изменения записей пользователя после ревизии синхронизации
+// @Param       since query integer false "ревизия синхронизации, 0 — все записи"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} model.Sync
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     500 {object} model.ErrorResponse
+// @Router /sync [get]
+func (h *handler) Sync(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		var since int64
+
+		if value := r.URL.Query().Get("since"); value != "" {
+			since, err = strconv.ParseInt(value, 10, 64)
+			if err != nil || since < 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, model.ErrorResponse{Error: "invalid sync revision = " + value})
+
+				return
+			}
+		}
+
+		sync, err := h.repoDB.FindSync(ctx, userID, since)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		writeJSON(w, sync)
+	}
+}
+
+// Events endpoint передаёт поток уведомлений об изменении хранилища пользователя в формате
+// server-sent events: событие change отправляется после каждого сохранения, удаления
+// и восстановления записей, папок и меток на любом устройстве, после чего клиент запрашивает
+// изменения у /sync. Поток закрывается, когда истекает токен доступа, но не позже accessTokenTTL,
+// чтобы клиент переподключился с действующим сеансом
+//
+// @Tags        Sync
+// @Summary     Поток уведомлений об изменении хранилища пользователя
+// @Produce     text/event-stream
+// @Success     200 {string} string "event: change"
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     429 {object} model.ErrorResponse
+// @Failure     500 {object} model.ErrorResponse
+// @Router /events [get]
+func (h *handler) Events(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserID(r)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: ErrStreamingUnsupported.Error()})
+
+			return
+		}
+
+		changes, cancel, err := h.events.Subscribe(userID)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, events.ErrTooManySubscribers) {
+				status = http.StatusTooManyRequests
+			}
+
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(status)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ": connected\n\n")
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(eventsHeartbeat)
+		defer heartbeat.Stop()
+
+		expired := time.NewTimer(time.Until(streamDeadline(r)))
+		defer expired.Stop()
+
+		for {
+			select {
+			case _, ok := <-changes:
+				if !ok {
+					return
+				}
+
+				fmt.Fprint(w, "event: change\ndata: {}\n\n")
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			case <-expired.C:
+				return
+			case <-r.Context().Done():
+				return
+			case <-ctx.Done():
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// FindTrash endpoint возвращает записи пользователя всех типов в корзине, начиная с последней удалённой
+//
+// @Tags        Trash
+// @Summary     Возвращает записи пользователя в корзине
+// @Accept      json
+// @Produce     json
+// @Success     200 {array}  model.TrashItem
+// @Failure     500 {object} model.ErrorResponse
+// @Router /trash [get]
+func (h *handler) FindTrash(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		items, err := h.repoDB.FindTrash(ctx, model.User{ID: userID})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		writeJSON(w, items)
+	}
+}
+
+// RestoreTrash endpoint возвращает запись пользователя из корзины
+//
+// @Tags        Trash
+// @Summary     Возвращает запись пользователя из корзины
+// @Param       id path integer true "id записи"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     404 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /trash/{id} [post]
+func (h *handler) RestoreTrash(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid item id = " + chi.URLParam(r, "id")})
+
+			return
+		}
+
+		err = h.repoDB.RestoreTrash(ctx, userID, id)
+		if err != nil {
+			status := http.StatusNotAcceptable
+			if errors.Is(err, postgresql.ErrDBItemNotFound) {
+				status = http.StatusNotFound
+			}
+
+			w.WriteHeader(status)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// SaveFolder endpoint сохраняет папку записей пользователя, название зашифровано клиентом
+//
+// @Tags        Folders
+// @Summary     Cохраняет папку записей пользователя
+// @Param       value body model.Folder true "папка"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     404 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /folders [post]
+func (h *handler) SaveFolder(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		folder := model.Folder{}
+
+		decoder := json.NewDecoder(r.Body)
+
+		err = decoder.Decode(&folder)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		folder.UserID = userID
+
+		if err = folder.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		err = h.repoDB.SaveFolder(ctx, &folder)
+		if err != nil {
+			status := http.StatusNotAcceptable
+			if errors.Is(err, postgresql.ErrDBFolderNotFound) {
+				status = http.StatusNotFound
+			}
+
+			w.WriteHeader(status)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// DeleteFolder endpoint удаляет папку записей пользователя
+//
+// @Tags        Folders
+// @Summary     Удаляет папку записей пользователя
+// @Param       id path integer true "id папки"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} object
+// @Failure     400 {object} model.ErrorResponse
+// @Failure     404 {object} model.ErrorResponse
+// @Failure     406 {object} model.ErrorResponse
+// @Router /folders/{id} [delete]
+func (h *handler) DeleteFolder(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		userID, err := getUserID(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid folder id = " + chi.URLParam(r, "id")})
+
+			return
+		}
+
+		folder := model.Folder{ID: id, UserID: userID}
+
+		err = h.repoDB.DeleteFolder(ctx, &folder)
+		if err != nil {
+			status := http.StatusNotAcceptable
+			if errors.Is(err, postgresql.ErrDBFolderNotFound) {
+				status = http.StatusNotFound
+			}
+
+			w.WriteHeader(status)
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+
+		fmt.Fprintf(w, "{}")
+	}
+}
+
+// FindFolders endpoint возвращает папки записей пользователя
+//
+// @Tags        Folders
+// @Summary     Возвращает папки записей пользователя
+// @Accept      json
+// @Produce     json
+// @Success     200 {array}  model.Folder
+// @Failure     500 {object} model.ErrorResponse
+// @Router /folders [get]
+func (h *handler) FindFolders(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
-		err = encoder.Encode(logins)
+		userID, err := getUserID(r)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			w.WriteHeader(http.StatusBadRequest)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
 		}
 
-		_, err = w.Write(body.Bytes())
+		folders, err := h.repoDB.FindFolders(ctx, model.User{ID: userID})
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
 		}
+
+		writeJSON(w, folders)
 	}
 }
 
-// SaveCard endpoint сохраняет данные банковской карты пользователя
+// SaveTag endpoint сохраняет метку записей пользователя, название зашифровано клиентом
 //
-// @Tags        Cards
-// @Summary     Cохраняет данные банковской карты пользователя
-// @Param       value body model.Card true "данные банковской карты"
+// @Tags        Tags
+// @Summary     Cохраняет метку записей пользователя
+// @Param       value body model.Tag true "метка"
 // @Accept      json
 // @Produce     json
 // @Success     200 {object} object
 // @Failure     400 {object} model.ErrorResponse
+// @Failure     404 {object} model.ErrorResponse
 // @Failure     406 {object} model.ErrorResponse
-// @Router /cards [post]
-func (h *handler) SaveCard(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+// @Router /tags [post]
+func (h *handler) SaveTag(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
@@ -354,11 +3258,11 @@ func (h *handler) SaveCard(ctx context.Context) func(w http.ResponseWriter, r *h
 			return
 		}
 
-		card := model.Card{UserID: userID}
+		tag := model.Tag{}
 
 		decoder := json.NewDecoder(r.Body)
 
-		err = decoder.Decode(&card)
+		err = decoder.Decode(&tag)
 		if err != nil {
 			w.WriteHeader(http.StatusNotAcceptable)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
@@ -366,16 +3270,23 @@ func (h *handler) SaveCard(ctx context.Context) func(w http.ResponseWriter, r *h
 			return
 		}
 
-		if err = card.Validate(); err != nil {
+		tag.UserID = userID
+
+		if err = tag.Validate(); err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
 		}
 
-		err = h.repoDB.SaveCard(ctx, &card)
+		err = h.repoDB.SaveTag(ctx, &tag)
 		if err != nil {
-			w.WriteHeader(http.StatusNotAcceptable)
+			status := http.StatusNotAcceptable
+			if errors.Is(err, postgresql.ErrDBTagNotFound) {
+				status = http.StatusNotFound
+			}
+
+			w.WriteHeader(status)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
@@ -385,18 +3296,19 @@ func (h *handler) SaveCard(ctx context.Context) func(w http.ResponseWriter, r *h
 	}
 }
 
-// DeleteCard endpoint удаляет данные банковской карты пользователя
+// DeleteTag endpoint удаляет метку записей пользователя
 //
-// @Tags        Cards
-// @Summary     Удаляет данные банковской карты пользователя
-// @Param       id path integer true "id банковской карты"
+// @Tags        Tags
+// @Summary     Удаляет метку записей пользователя
+// @Param       id path integer true "id метки"
 // @Accept      json
 // @Produce     json
 // @Success     200 {object} object
 // @Failure     400 {object} model.ErrorResponse
+// @Failure     404 {object} model.ErrorResponse
 // @Failure     406 {object} model.ErrorResponse
-// @Router /cards/{id} [delete]
-func (h *handler) DeleteCard(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+// @Router /tags/{id} [delete]
+func (h *handler) DeleteTag(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
@@ -411,16 +3323,21 @@ func (h *handler) DeleteCard(ctx context.Context) func(w http.ResponseWriter, r
 		id, err := strconv.Atoi(chi.URLParam(r, "id"))
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprint(w, model.ErrorResponse{Error: "invalid login id = " + chi.URLParam(r, "id")})
+			fmt.Fprint(w, model.ErrorResponse{Error: "invalid tag id = " + chi.URLParam(r, "id")})
 
 			return
 		}
 
-		card := model.Card{ID: id, UserID: userID}
+		tag := model.Tag{ID: id, UserID: userID}
 
-		err = h.repoDB.DeleteCard(ctx, &card)
+		err = h.repoDB.DeleteTag(ctx, &tag)
 		if err != nil {
-			w.WriteHeader(http.StatusNotAcceptable)
+			status := http.StatusNotAcceptable
+			if errors.Is(err, postgresql.ErrDBTagNotFound) {
+				status = http.StatusNotFound
+			}
+
+			w.WriteHeader(status)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
@@ -430,16 +3347,16 @@ func (h *handler) DeleteCard(ctx context.Context) func(w http.ResponseWriter, r
 	}
 }
 
-// FindCards endpoint возвращает данные банковских карт пользователя
+// FindTags endpoint возвращает метки записей пользователя
 //
-// @Tags        Cards
-// @Summary     Возвращает данные банковских карт пользователя
+// @Tags        Tags
+// @Summary     Возвращает метки записей пользователя
 // @Accept      json
 // @Produce     json
-// @Success     200 {array}  model.Card
+// @Success     200 {array}  model.Tag
 // @Failure     500 {object} model.ErrorResponse
-// @Router /cards [get]
-func (h *handler) FindCards(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+// @Router /tags [get]
+func (h *handler) FindTags(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
@@ -451,18 +3368,7 @@ func (h *handler) FindCards(ctx context.Context) func(w http.ResponseWriter, r *
 			return
 		}
 
-		logins, err := h.repoDB.FindCards(ctx, model.User{ID: userID})
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
-
-			return
-		}
-
-		body := &bytes.Buffer{}
-		encoder := json.NewEncoder(body)
-
-		err = encoder.Encode(logins)
+		tags, err := h.repoDB.FindTags(ctx, model.User{ID: userID})
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
@@ -470,13 +3376,7 @@ func (h *handler) FindCards(ctx context.Context) func(w http.ResponseWriter, r *
 			return
 		}
 
-		_, err = w.Write(body.Bytes())
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
-
-			return
-		}
+		writeJSON(w, tags)
 	}
 }
 
@@ -486,6 +3386,9 @@ func (h *handler) FindCards(ctx context.Context) func(w http.ResponseWriter, r *
 // @Summary     Cохраняет данные файла пользователя
 // @Param   	id formData integer true  "id файла"
 // @Param   	metainfo formData string true  "metainfo файла"
+// @Param   	uid formData string false  "идентификатор записи, передаётся вместе с содержимым файла"
+// @Param   	fields formData string false  "дополнительные поля файла в JSON"
+// @Param   	placement formData string false  "папка и метки файла в JSON: {\"folder_id\": 0, \"tag_ids\": []}"
 // @Param   	file formData file true  "содержимое файла"
 // @Accept      multipart/form-data
 // @Produce     json
@@ -505,81 +3408,183 @@ func (h *handler) SaveFile(ctx context.Context) func(w http.ResponseWriter, r *h
 			return
 		}
 
-		fileID, err := strconv.Atoi(r.FormValue("id"))
+		form, err := h.readFileForm(ctx, r)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
-			fmt.Fprint(w, model.ErrorResponse{Error: "invalid file id = " + r.FormValue("id")})
+			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
 		}
 
-		file := &model.File{ID: fileID, UserID: userID, MetaInfo: r.FormValue("metainfo")}
+		file := &model.File{ID: form.id, UserID: userID, MetaInfo: form.metaInfo, Fields: form.fields, Placement: form.placement}
 
 		oldFile, err := h.repoDB.FindFile(ctx, file.ID, file.UserID)
 		if err != nil {
+			h.deleteBlob(ctx, form.path)
 			w.WriteHeader(http.StatusNotAcceptable)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
 		}
 
-		if oldFile.Path == "" && fileID != 0 {
+		if oldFile.Path == "" && file.ID != 0 {
+			h.deleteBlob(ctx, form.path)
 			w.WriteHeader(http.StatusNotAcceptable)
 			fmt.Fprint(w, model.ErrorResponse{Error: "file not exist"})
 
 			return
 		}
 
+		file.UID = oldFile.UID
 		file.Path = oldFile.Path
 		file.Name = oldFile.Name
 
-		mpFile, mpFH, err := r.FormFile("file")
-		if err == nil {
-			defer mpFile.Close()
-
-			errUpdateFile := h.repoFile.DeleteFile(ctx, oldFile.Path)
-			if errUpdateFile != nil {
-				w.WriteHeader(http.StatusNotAcceptable)
-				fmt.Fprint(w, model.ErrorResponse{Error: errUpdateFile.Error()})
-
-				return
-			}
-
-			newFilePath, errUpdateFile := h.repoFile.SaveFile(ctx, mpFile)
-			if errUpdateFile != nil {
-				w.WriteHeader(http.StatusNotAcceptable)
-				fmt.Fprint(w, model.ErrorResponse{Error: errUpdateFile.Error()})
-
-				return
-			}
-
-			file.Path = newFilePath
-			file.Name = mpFH.Filename
+		if form.path != "" {
+			file.UID = form.uid
+			file.Path = form.path
+			file.Name = form.name
 		}
 
 		if err = file.Validate(); err != nil {
+			h.deleteBlob(ctx, form.path)
 			w.WriteHeader(http.StatusBadRequest)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
 		}
 
-		err = h.repoDB.SaveFile(ctx, file)
+		err = h.repoDB.SaveFile(postgresql.WithDevice(ctx, deviceName(r)), file)
 		if err != nil {
+			h.deleteBlob(ctx, form.path)
 			w.WriteHeader(http.StatusNotAcceptable)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
 
 			return
 		}
 
+		if form.path != "" {
+			h.deleteBlob(ctx, oldFile.Path)
+		}
+
 		fmt.Fprintf(w, "{}")
 	}
 }
 
-// DeleteFile endpoint удаления данных файла пользователя
+// fileForm поля формы сохранения файла пользователя
+type fileForm struct {
+	id        int
+	uid       string
+	metaInfo  string
+	fields    []model.Field
+	placement model.Placement
+	path      string
+	name      string
+}
+
+// readFileForm читает форму сохранения файла потоком: содержимое файла сразу записывается
+// в файловый репозиторий без буферизации запроса в памяти или во временных файлах
+func (h *handler) readFileForm(ctx context.Context, r *http.Request) (form fileForm, err error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return form, err
+	}
+
+	defer func() {
+		if err != nil {
+			h.deleteBlob(ctx, form.path)
+			form.path = ""
+		}
+	}()
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return form, nil
+		}
+
+		if err != nil {
+			return form, err
+		}
+
+		switch part.FormName() {
+		case "id":
+			value, err := readFormValue(part)
+			if err != nil {
+				return form, err
+			}
+
+			form.id, err = strconv.Atoi(value)
+			if err != nil {
+				return form, fmt.Errorf("invalid file id = %s", value)
+			}
+		case "uid":
+			form.uid, err = readFormValue(part)
+			if err != nil {
+				return form, err
+			}
+		case "metainfo":
+			form.metaInfo, err = readFormValue(part)
+			if err != nil {
+				return form, err
+			}
+		case "fields":
+			value, err := readFormValue(part)
+			if err != nil {
+				return form, err
+			}
+
+			if err = json.Unmarshal([]byte(value), &form.fields); err != nil {
+				return form, fmt.Errorf("invalid file fields: %w", err)
+			}
+		case "placement":
+			value, err := readFormValue(part)
+			if err != nil {
+				return form, err
+			}
+
+			if err = json.Unmarshal([]byte(value), &form.placement); err != nil {
+				return form, fmt.Errorf("invalid file placement: %w", err)
+			}
+		case "file":
+			if form.path != "" {
+				return form, ErrDuplicateFilePart
+			}
+
+			form.path, err = h.repoFile.SaveFile(ctx, part)
+			if err != nil {
+				return form, err
+			}
+
+			form.name = part.FileName()
+		}
+	}
+}
+
+// readFormValue читает значение текстового поля формы ограниченной длины
+func readFormValue(part *multipart.Part) (string, error) {
+	value, err := io.ReadAll(io.LimitReader(part, maxFormValueLength+1))
+	if err != nil {
+		return "", err
+	}
+
+	if len(value) > maxFormValueLength {
+		return "", ErrFormValueTooLong
+	}
+
+	return string(value), nil
+}
+
+// deleteBlob удаляет файл из файлового репозитория, ошибка удаления записывается в журнал
+func (h *handler) deleteBlob(ctx context.Context, filePath string) {
+	if err := h.repoFile.DeleteFile(ctx, filePath); err != nil {
+		h.mLogger.Warning(err.Error())
+	}
+}
+
+// DeleteFile endpoint перемещения данных файла пользователя в корзину
 //
 // @Tags        Files
-// @Summary     Удаляет данные файла пользователя
+// @Summary     Перемещает данные файла пользователя в корзину
 // @Param       id path integer true "id файла"
 // @Accept      json
 // @Produce     json
@@ -607,23 +3612,9 @@ func (h *handler) DeleteFile(ctx context.Context) func(w http.ResponseWriter, r
 			return
 		}
 
-		file, err := h.repoDB.FindFile(ctx, id, userID)
-		if err != nil {
-			w.WriteHeader(http.StatusNotAcceptable)
-			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
-
-			return
-		}
-
-		err = h.repoFile.DeleteFile(ctx, file.Path)
-		if err != nil {
-			w.WriteHeader(http.StatusNotAcceptable)
-			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
-
-			return
-		}
+		file := model.File{ID: id, UserID: userID}
 
-		err = h.repoDB.DeleteFile(ctx, file)
+		err = h.repoDB.DeleteFile(ctx, &file)
 		if err != nil {
 			w.WriteHeader(http.StatusNotAcceptable)
 			fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
@@ -752,16 +3743,252 @@ func getUserFromBody(r *http.Request) (user model.User, err error) {
 	return
 }
 
-func (h *handler) setJWToken(w http.ResponseWriter, userID int) (err error) {
-	claims := map[string]interface{}{"user_id": strconv.Itoa(userID)}
-	jwtauth.SetExpiry(claims, time.Now().Add(time.Hour))
+func getTOTPCodeFromBody(r *http.Request) (code model.TOTPCode, err error) {
+	decoder := json.NewDecoder(r.Body)
+
+	if err = decoder.Decode(&code); err != nil {
+		return code, err
+	}
+
+	return code, code.Validate()
+}
+
+// fakeKDF возвращает детерминированные параметры получения ключей для неизвестного пользователя,
+// чтобы ответ PreLogin не отличался от ответа для существующей учётной записи
+func fakeKDF(username string) model.KDF {
+	salt := sha256.Sum256([]byte("gophkeeper prelogin " + username))
+
+	return model.KDF{
+		Version:   model.KDFVersionSplitKeys,
+		Algorithm: model.KDFArgon2id,
+		Salt:      hex.EncodeToString(salt[:16]),
+		Time:      3,
+		Memory:    64 * 1024,
+		Threads:   4,
+	}
+}
+
+// throttled учитывает попытку аутентификации пользователя username с адреса клиента как неудачную
+// и отвечает 429, если попытки временно заблокированы после неудачных попыток. Попытка отменяется
+// только после успешной проверки учётных данных
+func (h *handler) throttled(ctx context.Context, w http.ResponseWriter, r *http.Request, username string) bool {
+	retryAfter, err := h.limiter.Attempt(ctx, username, clientIP(r))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+		return true
+	}
+
+	if retryAfter <= 0 {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+	fmt.Fprint(w, model.ErrorResponse{Error: ErrTooManyAttempts.Error()})
+
+	return true
+}
+
+// authFailed протоколирует неудачную попытку аутентификации пользователя username
+func (h *handler) authFailed(ctx context.Context, r *http.Request, username string) {
+	if err := h.limiter.Fail(ctx, username, clientIP(r)); err != nil {
+		h.mLogger.Warning(err.Error())
+	}
+}
+
+// clientIP возвращает IP адрес клиента из адреса соединения, заголовки прокси не учитываются
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// certificateUser возвращает пользователя, которому выдан проверенный клиентский сертификат соединения
+func certificateUser(r *http.Request) (username string, ok bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+
+	return pki.CertificateUser(r.TLS.VerifiedChains[0][0])
+}
+
+// certificateMismatch отвечает 403, если клиентский сертификат соединения выдан не пользователю username
+func certificateMismatch(w http.ResponseWriter, r *http.Request, username string) bool {
+	certUsername, ok := certificateUser(r)
+	if !ok || certUsername == username {
+		return false
+	}
+
+	w.WriteHeader(http.StatusForbidden)
+	fmt.Fprint(w, model.ErrorResponse{Error: ErrCertificateUser.Error()})
+
+	return true
+}
+
+// mfaUserID возвращает идентификатор пользователя и идентификатор токена второго шага аутентификации
+func (h *handler) mfaUserID(mfaToken string) (id int, tokenID string, err error) {
+	token, err := h.tokenAuth.Verify(mfaToken)
+	if err != nil {
+		return 0, "", ErrInvalidMFAToken
+	}
+
+	if mfa, _ := token.Get("mfa"); mfa != mfaTOTP || token.JwtID() == "" {
+		return 0, "", ErrInvalidMFAToken
+	}
+
+	userID, _ := token.Get("user_id")
+
+	id, err = strconv.Atoi(fmt.Sprint(userID))
+	if err != nil {
+		return 0, "", ErrInvalidMFAToken
+	}
+
+	return id, token.JwtID(), nil
+}
+
+// writeMFATokenError отвечает 401 на использованный, исчерпавший попытки или неизвестный токен
+// второго шага аутентификации
+func writeMFATokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, postgresql.ErrDBMFATokenNotFound):
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, model.ErrorResponse{Error: ErrInvalidMFAToken.Error()})
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+	}
+}
+
+// checkTOTP проверяет одноразовый пароль или резервный код пользователя, одноразовый пароль
+// принимается однократно, использованный резервный код удаляется
+func (h *handler) checkTOTP(ctx context.Context, userID int, totpState model.TOTP, code string) error {
+	if step, ok := totp.Validate(totpState.Secret, code, time.Now()); ok {
+		return h.repoDB.UseTOTPStep(ctx, userID, step)
+	}
+
+	return h.repoDB.UseTOTPBackupCode(ctx, userID, model.NormalizeBackupCode(code))
+}
+
+func writeTOTPError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, postgresql.ErrDBInvalidTOTPCode), errors.Is(err, postgresql.ErrDBTOTPCodeUsed):
+		w.WriteHeader(http.StatusUnauthorized)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+}
+
+func newBackupCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+
+	for i := 0; i < n; i++ {
+		b := make([]byte, backupCodeLength)
+
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes = append(codes, code[:4]+"-"+code[4:])
+	}
+
+	return codes, nil
+}
+
+// writeJSON записывает в ответ значение v в формате JSON
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	body := &bytes.Buffer{}
+	encoder := json.NewEncoder(body)
+
+	err := encoder.Encode(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	_, err = w.Write(body.Bytes())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, model.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+}
+
+// startSession создаёт сеанс пользователя и выдаёт токены доступа и обновления
+func (h *handler) startSession(ctx context.Context, w http.ResponseWriter, r *http.Request, userID int) (err error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(refreshTokenTTL)
+
+	sessionID, err := h.repoDB.SaveSession(ctx, userID, refreshToken, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	return h.setTokens(w, r, userID, sessionID, refreshToken, expiresAt)
+}
+
+// setTokens выдаёт короткоживущий токен доступа сеанса sessionID и токен обновления refreshToken
+func (h *handler) setTokens(w http.ResponseWriter, r *http.Request, userID, sessionID int, refreshToken string, expiresAt time.Time) (err error) {
+	accessExpiresAt := time.Now().Add(accessTokenTTL)
+
+	claims := map[string]interface{}{"user_id": strconv.Itoa(userID), "sid": strconv.Itoa(sessionID)}
+	jwtauth.SetExpiry(claims, accessExpiresAt)
 
 	_, tokenString, err := h.tokenAuth.Encode(claims)
-	if err == nil {
-		http.SetCookie(w, &http.Cookie{Name: "jwt", Value: tokenString, Path: "/"})
+	if err != nil {
+		return err
 	}
 
-	return
+	http.SetCookie(w, tokenCookie(r, accessTokenCookie, "/", tokenString, accessExpiresAt))
+	http.SetCookie(w, tokenCookie(r, refreshTokenCookie, refreshTokenPath, refreshToken, expiresAt))
+
+	return nil
+}
+
+func clearTokens(w http.ResponseWriter, r *http.Request) {
+	for _, cookie := range []*http.Cookie{
+		tokenCookie(r, accessTokenCookie, "/", "", time.Unix(0, 0)),
+		tokenCookie(r, refreshTokenCookie, refreshTokenPath, "", time.Unix(0, 0)),
+	} {
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+	}
+}
+
+func tokenCookie(r *http.Request, name, path, value string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, refreshTokenLength)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
 func getUserID(r *http.Request) (id int, err error) {
@@ -782,3 +4009,57 @@ func getUserID(r *http.Request) (id int, err error) {
 
 	return
 }
+
+func getSessionID(r *http.Request) (id int, err error) {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		return 0, err
+	}
+
+	sessionID, ok := claims["sid"].(string)
+	if !ok {
+		return 0, ErrNotFindSessionID
+	}
+
+	id, err = strconv.Atoi(sessionID)
+	if err != nil {
+		return 0, ErrNotFindSessionID
+	}
+
+	return
+}
+
+// getAPIToken возвращает токен API, которым аутентифицирован запрос
+func getAPIToken(r *http.Request) (token model.APIToken, ok bool) {
+	token, ok = r.Context().Value(apiTokenCtxKey).(model.APIToken)
+
+	return token, ok
+}
+
+// streamDeadline возвращает время закрытия потока уведомлений запроса r: истечение токена доступа,
+// но не позже accessTokenTTL. У токенов API нет срока действия в утверждениях
+func streamDeadline(r *http.Request) time.Time {
+	deadline := time.Now().Add(accessTokenTTL)
+
+	if token, _, err := jwtauth.FromContext(r.Context()); err == nil && token != nil {
+		if expiration := token.Expiration(); !expiration.IsZero() && expiration.Before(deadline) {
+			deadline = expiration
+		}
+	}
+
+	return deadline
+}
+
+// deviceName возвращает название устройства, отправившего запрос r: заголовок X-Device клиента,
+// название токена API или User-Agent
+func deviceName(r *http.Request) string {
+	if device := r.Header.Get("X-Device"); device != "" {
+		return device
+	}
+
+	if apiToken, ok := getAPIToken(r); ok {
+		return "api token " + apiToken.Name
+	}
+
+	return r.UserAgent()
+}
@@ -1,9 +1,316 @@
 package http_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vukit/gophkeeper/internal/server/handlers"
+	handler "github.com/vukit/gophkeeper/internal/server/handlers/http"
+	"github.com/vukit/gophkeeper/internal/server/jwtkeys"
+	"github.com/vukit/gophkeeper/internal/server/limiter"
+	"github.com/vukit/gophkeeper/internal/server/logger"
+	"github.com/vukit/gophkeeper/internal/server/model"
+	"github.com/vukit/gophkeeper/internal/server/repositories/postgresql"
 )
 
 func TestHTTPHandler(t *testing.T) {
 	t.Skip() // проверятся интеграционным тестом
 }
+
+// fakeRepo репозиторий пользователя mark с паролем secret и резервным кодом backup двухфакторной
+// аутентификации, методы, которые не используются в тестах, не реализованы
+type fakeRepo struct {
+	handlers.RepoDB
+	recoveryKeys []model.RecoveryKey
+	mfaTokens    map[string]int
+}
+
+func (r *fakeRepo) FindUser(ctx context.Context, user model.User) (int, error) {
+	if user.Username != "mark" || user.Password != "secret" {
+		return 0, postgresql.ErrDBInvalidUsernamePasswordPair
+	}
+
+	return 1, nil
+}
+
+func (r *fakeRepo) FindUsername(ctx context.Context, userID int) (string, error) {
+	return "mark", nil
+}
+
+func (r *fakeRepo) FindKDF(ctx context.Context, username string) (model.KDF, error) {
+	return model.KDF{}, nil
+}
+
+func (r *fakeRepo) FindVaultKey(ctx context.Context, userID int) (string, error) {
+	return "", nil
+}
+
+func (r *fakeRepo) SaveSession(ctx context.Context, userID int, refreshToken string, expiresAt time.Time) (int, error) {
+	return 1, nil
+}
+
+func (r *fakeRepo) FindTOTP(ctx context.Context, userID int) (model.TOTP, error) {
+	return model.TOTP{Username: "mark", Secret: "JBSWY3DPEHPK3PXP"}, nil
+}
+
+func (r *fakeRepo) UseTOTPStep(ctx context.Context, userID int, step uint64) error {
+	return postgresql.ErrDBTOTPCodeUsed
+}
+
+func (r *fakeRepo) UseTOTPBackupCode(ctx context.Context, userID int, code string) error {
+	if code != "backup" {
+		return postgresql.ErrDBInvalidTOTPCode
+	}
+
+	return nil
+}
+
+func (r *fakeRepo) SaveMFAToken(ctx context.Context, userID int, tokenID string, expiresAt time.Time) error {
+	r.mfaTokens[tokenID] = 0
+
+	return nil
+}
+
+func (r *fakeRepo) CountMFAAttempt(ctx context.Context, userID int, tokenID string, maxAttempts int) error {
+	attempts, ok := r.mfaTokens[tokenID]
+	if !ok || attempts >= maxAttempts {
+		return postgresql.ErrDBMFATokenNotFound
+	}
+
+	r.mfaTokens[tokenID]++
+
+	return nil
+}
+
+func (r *fakeRepo) DeleteMFAToken(ctx context.Context, userID int, tokenID string) error {
+	if _, ok := r.mfaTokens[tokenID]; !ok {
+		return postgresql.ErrDBMFATokenNotFound
+	}
+
+	delete(r.mfaTokens, tokenID)
+
+	return nil
+}
+
+func (r *fakeRepo) SaveRecoveryKeys(ctx context.Context, userID int, password string, keys []model.RecoveryKey) error {
+	if password != "secret" {
+		return postgresql.ErrDBInvalidUsernamePasswordPair
+	}
+
+	r.recoveryKeys = keys
+
+	return nil
+}
+
+// memoryStore хранилище счётчиков неудачных попыток в памяти, время блокировки не истекает
+type memoryStore struct {
+	failures map[string]int
+	lockouts map[string]time.Duration
+}
+
+func (s *memoryStore) FindAuthLockout(ctx context.Context, key string) (time.Duration, error) {
+	return s.lockouts[key], nil
+}
+
+func (s *memoryStore) SaveAuthAttempt(ctx context.Context, key string, policy limiter.Policy) (int, time.Duration, error) {
+	if s.lockouts[key] > 0 {
+		return 0, s.lockouts[key], nil
+	}
+
+	s.failures[key]++
+	s.lockouts[key] = policy.Delay(s.failures[key])
+
+	return s.failures[key], 0, nil
+}
+
+func (s *memoryStore) DeleteAuthAttempt(ctx context.Context, key string, policy limiter.Policy) error {
+	if s.failures[key] > 0 {
+		s.failures[key]--
+	}
+
+	s.lockouts[key] = policy.Delay(s.failures[key])
+
+	return nil
+}
+
+func (s *memoryStore) DeleteAuthFailures(ctx context.Context, key string) error {
+	delete(s.failures, key)
+	delete(s.lockouts, key)
+
+	return nil
+}
+
+func TestSaveRecoveryKeys(t *testing.T) {
+	ctx := context.Background()
+	mLogger := logger.NewLogger(io.Discard)
+
+	tokenAuth, err := jwtkeys.Ephemeral()
+	require.NoError(t, err)
+
+	_, token, err := tokenAuth.Encode(map[string]interface{}{"user_id": "1", "sid": "1"})
+	require.NoError(t, err)
+
+	repoDB := &fakeRepo{}
+	store := &memoryStore{failures: map[string]int{}, lockouts: map[string]time.Duration{}}
+	policy := limiter.Policy{Threshold: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour}
+
+	h := handler.NewHandler(tokenAuth, repoDB, nil, limiter.NewLimiter(store, policy, policy, mLogger), nil, mLogger)
+	endpoint := jwtkeys.Verifier(tokenAuth)(http.HandlerFunc(h.SaveRecoveryKeys(ctx)))
+
+	tests := []struct {
+		name     string
+		body     string
+		want     int
+		wantKeys []model.RecoveryKey
+	}{
+		{
+			name:     "case 1",
+			body:     `{"password":"secret","recovery_keys":[{"verifier":"verifier 1","vault_key":"key 1"}]}`,
+			want:     http.StatusOK,
+			wantKeys: []model.RecoveryKey{{Verifier: "verifier 1", VaultKey: "key 1"}},
+		},
+		{
+			name:     "case 2",
+			body:     `[{"verifier":"verifier 2","vault_key":"key 2"}]`,
+			want:     http.StatusBadRequest,
+			wantKeys: []model.RecoveryKey{{Verifier: "verifier 1", VaultKey: "key 1"}},
+		},
+		{
+			name:     "case 3",
+			body:     `{"password":"wrong","recovery_keys":[{"verifier":"verifier 2","vault_key":"key 2"}]}`,
+			want:     http.StatusUnauthorized,
+			wantKeys: []model.RecoveryKey{{Verifier: "verifier 1", VaultKey: "key 1"}},
+		},
+		{
+			name:     "case 4",
+			body:     `{"password":"wrong","recovery_keys":[{"verifier":"verifier 2","vault_key":"key 2"}]}`,
+			want:     http.StatusUnauthorized,
+			wantKeys: []model.RecoveryKey{{Verifier: "verifier 1", VaultKey: "key 1"}},
+		},
+		{
+			name:     "case 5",
+			body:     `{"password":"secret","recovery_keys":[{"verifier":"verifier 2","vault_key":"key 2"}]}`,
+			want:     http.StatusTooManyRequests,
+			wantKeys: []model.RecoveryKey{{Verifier: "verifier 1", VaultKey: "key 1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPut, "/api/recovery/keys", strings.NewReader(tt.body))
+			request.Header.Set("Authorization", "Bearer "+token)
+
+			recorder := httptest.NewRecorder()
+			endpoint.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.want, recorder.Code, recorder.Body.String())
+			assert.Equal(t, tt.wantKeys, repoDB.recoveryKeys)
+		})
+	}
+}
+
+func TestSignInTOTP(t *testing.T) {
+	ctx := context.Background()
+	mLogger := logger.NewLogger(io.Discard)
+
+	tokenAuth, err := jwtkeys.Ephemeral()
+	require.NoError(t, err)
+
+	repoDB := &fakeRepo{mfaTokens: map[string]int{}}
+	store := &memoryStore{failures: map[string]int{}, lockouts: map[string]time.Duration{}}
+	policy := limiter.Policy{Threshold: 100, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour}
+
+	h := handler.NewHandler(tokenAuth, repoDB, nil, limiter.NewLimiter(store, policy, policy, mLogger), nil, mLogger)
+
+	signIn := func() string {
+		recorder := httptest.NewRecorder()
+		h.SignIn(ctx)(recorder, httptest.NewRequest(http.MethodPost, "/api/signin",
+			strings.NewReader(`{"username":"mark","password":"secret"}`)))
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+		answer := model.SignInResponse{}
+		require.NoError(t, json.NewDecoder(recorder.Body).Decode(&answer))
+		require.NotEmpty(t, answer.MFAToken)
+
+		return answer.MFAToken
+	}
+
+	mfaTokens := []string{signIn(), signIn()}
+
+	tests := []struct {
+		name  string
+		token int
+		code  string
+		want  int
+	}{
+		{
+			name:  "case 1",
+			token: 0,
+			code:  "wrong",
+			want:  http.StatusUnauthorized,
+		},
+		{
+			name:  "case 2",
+			token: 0,
+			code:  "wrong",
+			want:  http.StatusUnauthorized,
+		},
+		{
+			name:  "case 3",
+			token: 0,
+			code:  "wrong",
+			want:  http.StatusUnauthorized,
+		},
+		{
+			name:  "case 4",
+			token: 0,
+			code:  "wrong",
+			want:  http.StatusUnauthorized,
+		},
+		{
+			name:  "case 5",
+			token: 0,
+			code:  "wrong",
+			want:  http.StatusUnauthorized,
+		},
+		{
+			name:  "case 6",
+			token: 0,
+			code:  "backup",
+			want:  http.StatusUnauthorized,
+		},
+		{
+			name:  "case 7",
+			token: 1,
+			code:  "backup",
+			want:  http.StatusOK,
+		},
+		{
+			name:  "case 8",
+			token: 1,
+			code:  "backup",
+			want:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(model.TOTPSignIn{MFAToken: mfaTokens[tt.token], Code: tt.code})
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			h.SignInTOTP(ctx)(recorder, httptest.NewRequest(http.MethodPost, "/api/signin/totp", bytes.NewReader(body)))
+
+			assert.Equal(t, tt.want, recorder.Code, recorder.Body.String())
+		})
+	}
+}
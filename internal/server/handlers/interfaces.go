@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/vukit/gophkeeper/internal/server/model"
 )
@@ -11,6 +12,45 @@ import (
 type RepoDB interface {
 	SaveUser(ctx context.Context, user model.User) (id int, err error)
 	FindUser(ctx context.Context, user model.User) (id int, err error)
+	FindKDF(ctx context.Context, username string) (kdf model.KDF, err error)
+	UpdateCredential(
+		ctx context.Context, userID, sessionID int, credential model.Credential, refreshToken string, expiresAt time.Time,
+	) (err error)
+	FindVaultKey(ctx context.Context, userID int) (vaultKey string, err error)
+	FindUsername(ctx context.Context, userID int) (username string, err error)
+	FindAccount(ctx context.Context, userID int) (account model.Account, err error)
+	DeleteUser(ctx context.Context, userID int, password string) (paths []string, err error)
+
+	SaveRecoveryKeys(ctx context.Context, userID int, password string, keys []model.RecoveryKey) (err error)
+	FindRecoveryKey(ctx context.Context, recovery model.Recovery) (vaultKey string, err error)
+	Recover(ctx context.Context, recovery model.Recovery) (userID int, err error)
+
+	SaveSession(ctx context.Context, userID int, refreshToken string, expiresAt time.Time) (sessionID int, err error)
+	RotateSession(ctx context.Context, refreshToken, newRefreshToken string, expiresAt time.Time) (sessionID, userID int, err error)
+	FindSession(ctx context.Context, userID, sessionID int) (err error)
+	DeleteSession(ctx context.Context, userID, sessionID int) (err error)
+	DeleteSessions(ctx context.Context, userID int) (err error)
+
+	SaveAPIToken(ctx context.Context, token *model.APIToken, tokenString string) (err error)
+	FindAPIToken(ctx context.Context, tokenString string) (token model.APIToken, err error)
+	FindAPITokens(ctx context.Context, userID int) (tokens []model.APIToken, err error)
+	DeleteAPIToken(ctx context.Context, userID, tokenID int) (err error)
+
+	FindTOTP(ctx context.Context, userID int) (totp model.TOTP, err error)
+	SaveTOTPPending(ctx context.Context, userID int, secret string) (err error)
+	EnableTOTP(ctx context.Context, userID int, secret string, step uint64, backupCodes []string) (err error)
+	DisableTOTP(ctx context.Context, userID int) (err error)
+	UseTOTPStep(ctx context.Context, userID int, step uint64) (err error)
+	UseTOTPBackupCode(ctx context.Context, userID int, code string) (err error)
+	SaveMFAToken(ctx context.Context, userID int, tokenID string, expiresAt time.Time) (err error)
+	CountMFAAttempt(ctx context.Context, userID int, tokenID string, maxAttempts int) (err error)
+	DeleteMFAToken(ctx context.Context, userID int, tokenID string) (err error)
+
+	SaveRekeyFile(ctx context.Context, userID, fileID int, filePath string) (oldPath string, err error)
+	DeleteRekeyFiles(ctx context.Context, userID int) (paths []string, err error)
+	ChangePassword(
+		ctx context.Context, userID, sessionID int, change model.PasswordChange, refreshToken string, expiresAt time.Time,
+	) (oldPaths []string, err error)
 
 	SaveLogin(ctx context.Context, login *model.Login) (err error)
 	DeleteLogin(ctx context.Context, login *model.Login) (err error)
@@ -20,6 +60,35 @@ type RepoDB interface {
 	DeleteCard(ctx context.Context, card *model.Card) (err error)
 	FindCards(ctx context.Context, user model.User) (cards []model.Card, err error)
 
+	SaveNote(ctx context.Context, note *model.Note) (err error)
+	DeleteNote(ctx context.Context, note *model.Note) (err error)
+	FindNotes(ctx context.Context, user model.User) (notes []model.Note, err error)
+
+	SaveOTP(ctx context.Context, otp *model.OTP) (err error)
+	DeleteOTP(ctx context.Context, otp *model.OTP) (err error)
+	FindOTPs(ctx context.Context, user model.User) (otps []model.OTP, err error)
+
+	SaveSSHKey(ctx context.Context, key *model.SSHKey) (err error)
+	DeleteSSHKey(ctx context.Context, key *model.SSHKey) (err error)
+	FindSSHKeys(ctx context.Context, user model.User) (keys []model.SSHKey, err error)
+
+	SaveItem(ctx context.Context, item *model.Item) (err error)
+	DeleteItem(ctx context.Context, item *model.Item) (err error)
+	FindItems(ctx context.Context, user model.User) (items []model.Item, err error)
+	FindRevisions(ctx context.Context, userID, itemID int) (revisions []model.Revision, err error)
+	RestoreRevision(ctx context.Context, userID, itemID, revisionID int) (err error)
+	FindTrash(ctx context.Context, user model.User) (items []model.TrashItem, err error)
+	RestoreTrash(ctx context.Context, userID, itemID int) (err error)
+	FindSync(ctx context.Context, userID int, since int64) (sync model.Sync, err error)
+
+	SaveFolder(ctx context.Context, folder *model.Folder) (err error)
+	DeleteFolder(ctx context.Context, folder *model.Folder) (err error)
+	FindFolders(ctx context.Context, user model.User) (folders []model.Folder, err error)
+
+	SaveTag(ctx context.Context, tag *model.Tag) (err error)
+	DeleteTag(ctx context.Context, tag *model.Tag) (err error)
+	FindTags(ctx context.Context, user model.User) (tags []model.Tag, err error)
+
 	SaveFile(ctx context.Context, file *model.File) (err error)
 	DeleteFile(ctx context.Context, file *model.File) (err error)
 	FindFiles(ctx context.Context, user model.User) (files []model.File, err error)
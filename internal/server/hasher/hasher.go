@@ -0,0 +1,190 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Алгоритмы хеширования паролей пользователей
+const (
+	Bcrypt   = "bcrypt"
+	Argon2id = "argon2id"
+)
+
+const (
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+	legacyHashLength   = 2 * sha256.Size
+)
+
+var (
+	ErrHasherUnknownAlgorithm = errors.New("unknown password hash algorithm")
+	ErrHasherInvalidParams    = errors.New("invalid password hash parameters")
+	ErrHasherInvalidHash      = errors.New("invalid password hash")
+)
+
+// Config параметры хеширования паролей
+type Config struct {
+	Algorithm string
+	Cost      int
+	Time      uint32
+	Memory    uint32
+	Threads   uint8
+}
+
+// Hasher хеширует пароли пользователей в строку формата PHC (для bcrypt — в его собственном формате)
+// и проверяет пароли по хешам, в том числе по устаревшим хешам sha256
+type Hasher struct {
+	config Config
+	dummy  string
+}
+
+// NewHasher возвращает Hasher с параметрами config
+func NewHasher(config Config) (*Hasher, error) {
+	switch config.Algorithm {
+	case Bcrypt:
+		if config.Cost < bcrypt.MinCost || config.Cost > bcrypt.MaxCost {
+			return nil, ErrHasherInvalidParams
+		}
+	case Argon2id:
+		if config.Time == 0 || config.Threads == 0 || config.Memory < 8*uint32(config.Threads) {
+			return nil, ErrHasherInvalidParams
+		}
+	default:
+		return nil, ErrHasherUnknownAlgorithm
+	}
+
+	h := &Hasher{config: config}
+
+	dummy, err := h.Hash("gophkeeper dummy password")
+	if err != nil {
+		return nil, err
+	}
+
+	h.dummy = dummy
+
+	return h, nil
+}
+
+// Hash возвращает хеш пароля password
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.config.Algorithm == Bcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.config.Cost)
+
+		return string(hash), err
+	}
+
+	salt := make([]byte, argon2idSaltLength)
+
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.config.Time, h.config.Memory, h.config.Threads, argon2idKeyLength)
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		Argon2id,
+		argon2.Version,
+		h.config.Memory,
+		h.config.Time,
+		h.config.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify проверяет пароль password по хешу hash за постоянное время. Если пароль верен,
+// а хеш получен устаревшим алгоритмом или с другими параметрами, rehash равен true
+func (h *Hasher) Verify(password, hash string) (ok, rehash bool, err error) {
+	switch {
+	case strings.HasPrefix(hash, "$"+Argon2id+"$"):
+		config, salt, key, err := parseArgon2id(hash)
+		if err != nil {
+			return false, false, err
+		}
+
+		computed := argon2.IDKey([]byte(password), salt, config.Time, config.Memory, config.Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(computed, key) != 1 {
+			return false, false, nil
+		}
+
+		return true, h.config.Algorithm != Argon2id ||
+			config.Time != h.config.Time || config.Memory != h.config.Memory || config.Threads != h.config.Threads, nil
+	case strings.HasPrefix(hash, "$2"):
+		err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+
+		if err != nil {
+			return false, false, err
+		}
+
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return false, false, err
+		}
+
+		return true, h.config.Algorithm != Bcrypt || cost != h.config.Cost, nil
+	case len(hash) == legacyHashLength:
+		legacy, err := hex.DecodeString(hash)
+		if err != nil {
+			return false, false, ErrHasherInvalidHash
+		}
+
+		computed := sha256.Sum256([]byte(password))
+
+		return subtle.ConstantTimeCompare(computed[:], legacy) == 1, true, nil
+	default:
+		return false, false, ErrHasherInvalidHash
+	}
+}
+
+// VerifyDummy тратит на проверку пароля столько же времени, сколько Verify,
+// используется, когда пользователь не найден, чтобы время ответа не выдавало его отсутствие
+func (h *Hasher) VerifyDummy(password string) {
+	h.Verify(password, h.dummy)
+}
+
+// parseArgon2id разбирает хеш Argon2id в формате PHC
+func parseArgon2id(hash string) (config Config, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return config, nil, nil, ErrHasherInvalidHash
+	}
+
+	var version int
+
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return config, nil, nil, ErrHasherInvalidHash
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &config.Memory, &config.Time, &config.Threads); err != nil {
+		return config, nil, nil, ErrHasherInvalidHash
+	}
+
+	if config.Time == 0 || config.Threads == 0 || config.Memory < 8*uint32(config.Threads) {
+		return config, nil, nil, ErrHasherInvalidHash
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return config, nil, nil, ErrHasherInvalidHash
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return config, nil, nil, ErrHasherInvalidHash
+	}
+
+	config.Algorithm = Argon2id
+
+	return config, salt, key, nil
+}
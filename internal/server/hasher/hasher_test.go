@@ -0,0 +1,171 @@
+package hasher_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vukit/gophkeeper/internal/server/hasher"
+)
+
+var (
+	argon2idConfig = hasher.Config{Algorithm: hasher.Argon2id, Time: 1, Memory: 64, Threads: 1}
+	bcryptConfig   = hasher.Config{Algorithm: hasher.Bcrypt, Cost: 4}
+)
+
+func TestNewHasher(t *testing.T) {
+	tests := []struct {
+		name   string
+		config hasher.Config
+		want   error
+	}{
+		{
+			name:   "case 1",
+			config: argon2idConfig,
+			want:   nil,
+		},
+		{
+			name:   "case 2",
+			config: bcryptConfig,
+			want:   nil,
+		},
+		{
+			name:   "case 3",
+			config: hasher.Config{Algorithm: hasher.Bcrypt, Cost: 64},
+			want:   hasher.ErrHasherInvalidParams,
+		},
+		{
+			name:   "case 4",
+			config: hasher.Config{Algorithm: hasher.Argon2id, Time: 1, Memory: 4, Threads: 1},
+			want:   hasher.ErrHasherInvalidParams,
+		},
+		{
+			name:   "case 5",
+			config: hasher.Config{Algorithm: "md5"},
+			want:   hasher.ErrHasherUnknownAlgorithm,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := hasher.NewHasher(tt.config)
+			assert.Equal(t, tt.want, err)
+		})
+	}
+}
+
+func TestHasher(t *testing.T) {
+	legacy := sha256.Sum256([]byte("secret"))
+
+	argon2idHasher, err := hasher.NewHasher(argon2idConfig)
+	require.Nil(t, err)
+
+	bcryptHasher, err := hasher.NewHasher(bcryptConfig)
+	require.Nil(t, err)
+
+	argon2idHash, err := argon2idHasher.Hash("secret")
+	require.Nil(t, err)
+	assert.True(t, strings.HasPrefix(argon2idHash, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+	bcryptHash, err := bcryptHasher.Hash("secret")
+	require.Nil(t, err)
+
+	tests := []struct {
+		name     string
+		hasher   *hasher.Hasher
+		password string
+		hash     string
+		ok       bool
+		rehash   bool
+	}{
+		{
+			name:     "case 1",
+			hasher:   argon2idHasher,
+			password: "secret",
+			hash:     argon2idHash,
+			ok:       true,
+			rehash:   false,
+		},
+		{
+			name:     "case 2",
+			hasher:   argon2idHasher,
+			password: "sercet",
+			hash:     argon2idHash,
+			ok:       false,
+			rehash:   false,
+		},
+		{
+			name:     "case 3",
+			hasher:   argon2idHasher,
+			password: "secret",
+			hash:     hex.EncodeToString(legacy[:]),
+			ok:       true,
+			rehash:   true,
+		},
+		{
+			name:     "case 4",
+			hasher:   argon2idHasher,
+			password: "sercet",
+			hash:     hex.EncodeToString(legacy[:]),
+			ok:       false,
+			rehash:   true,
+		},
+		{
+			name:     "case 5",
+			hasher:   argon2idHasher,
+			password: "secret",
+			hash:     bcryptHash,
+			ok:       true,
+			rehash:   true,
+		},
+		{
+			name:     "case 6",
+			hasher:   bcryptHasher,
+			password: "secret",
+			hash:     bcryptHash,
+			ok:       true,
+			rehash:   false,
+		},
+		{
+			name:     "case 7",
+			hasher:   bcryptHasher,
+			password: "sercet",
+			hash:     bcryptHash,
+			ok:       false,
+			rehash:   false,
+		},
+		{
+			name:     "case 8",
+			hasher:   bcryptHasher,
+			password: "secret",
+			hash:     argon2idHash,
+			ok:       true,
+			rehash:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash, err := tt.hasher.Verify(tt.password, tt.hash)
+			require.Nil(t, err)
+			assert.Equal(t, tt.ok, ok)
+
+			if ok {
+				assert.Equal(t, tt.rehash, rehash)
+			}
+		})
+	}
+}
+
+func TestHasherInvalidHash(t *testing.T) {
+	h, err := hasher.NewHasher(argon2idConfig)
+	require.Nil(t, err)
+
+	for _, hash := range []string{"", "plain", "$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5", "$argon2id$v=18$m=64,t=1,p=1$c2FsdA$a2V5"} {
+		_, _, err = h.Verify("secret", hash)
+		assert.ErrorIs(t, err, hasher.ErrHasherInvalidHash)
+	}
+}
@@ -0,0 +1,409 @@
+package jwtkeys
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws"
+	"github.com/lestrrat-go/jwx/jwt"
+)
+
+// Поддерживаемые алгоритмы подписи токенов
+const (
+	HS256 = string(jwa.HS256)
+	EdDSA = string(jwa.EdDSA)
+	ES256 = string(jwa.ES256)
+)
+
+const minSecretLength = 32
+
+var (
+	ErrJWTKeysEmpity            = errors.New("no jwt keys found")
+	ErrJWTKeysUnknownAlgorithm  = errors.New("unknown jwt key algorithm")
+	ErrJWTKeysInvalidKey        = errors.New("invalid jwt key")
+	ErrJWTKeysMissingKeyID      = errors.New("jwt key has no kid")
+	ErrJWTKeysDuplicateKeyID    = errors.New("duplicate jwt key kid")
+	ErrJWTKeysSigningKeyUnknown = errors.New("jwt signing key is not found")
+	ErrJWTKeysSigningKeyPublic  = errors.New("jwt signing key has no private part")
+	ErrJWTKeysSigningKeyNotSet  = errors.New("jwt signing key kid is not set")
+)
+
+// KeySet набор ключей JWT: одним ключом подписываются новые токены,
+// по всем ключам набора проверяются предъявленные токены, что позволяет менять ключи без простоя
+type KeySet struct {
+	signKey   jwk.Key
+	verifySet jwk.Set
+}
+
+// Load загружает ключи из файла или каталога path и выбирает ключ подписи с идентификатором signingKeyID,
+// если идентификатор не задан, ключ подписи должен быть единственным закрытым ключом набора.
+// Поддерживаются файлы JWK или JWK Set (*.json, *.jwk) и PEM (*.pem), для PEM идентификатор ключа
+// по умолчанию — имя файла без расширения
+func Load(path, signingKeyID string) (*KeySet, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		files = files[:0]
+
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+
+			switch filepath.Ext(entry.Name()) {
+			case ".json", ".jwk", ".pem":
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+
+		sort.Strings(files)
+	}
+
+	keys := make([]jwk.Key, 0, len(files))
+
+	for _, file := range files {
+		fileKeys, err := readKeys(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		keys = append(keys, fileKeys...)
+	}
+
+	return NewKeySet(keys, signingKeyID)
+}
+
+// NewKeySet возвращает набор ключей keys с ключом подписи signingKeyID
+func NewKeySet(keys []jwk.Key, signingKeyID string) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, ErrJWTKeysEmpity
+	}
+
+	ks := &KeySet{verifySet: jwk.NewSet()}
+
+	var privateKeys []jwk.Key
+
+	for _, key := range keys {
+		if err := checkKey(key); err != nil {
+			return nil, fmt.Errorf("%s: %w", key.KeyID(), err)
+		}
+
+		if _, ok := ks.verifySet.LookupKeyID(key.KeyID()); ok {
+			return nil, fmt.Errorf("%s: %w", key.KeyID(), ErrJWTKeysDuplicateKeyID)
+		}
+
+		verifyKey := key
+		if key.KeyType() != jwa.OctetSeq {
+			publicKey, err := jwk.PublicKeyOf(key)
+			if err != nil {
+				return nil, err
+			}
+
+			verifyKey = publicKey
+		}
+
+		ks.verifySet.Add(verifyKey)
+
+		if isPrivate(key) {
+			privateKeys = append(privateKeys, key)
+		}
+	}
+
+	switch {
+	case signingKeyID != "":
+		for _, key := range keys {
+			if key.KeyID() == signingKeyID {
+				ks.signKey = key
+			}
+		}
+
+		if ks.signKey == nil {
+			return nil, fmt.Errorf("%s: %w", signingKeyID, ErrJWTKeysSigningKeyUnknown)
+		}
+
+		if !isPrivate(ks.signKey) {
+			return nil, fmt.Errorf("%s: %w", signingKeyID, ErrJWTKeysSigningKeyPublic)
+		}
+	case len(privateKeys) == 1:
+		ks.signKey = privateKeys[0]
+	default:
+		return nil, ErrJWTKeysSigningKeyNotSet
+	}
+
+	return ks, nil
+}
+
+// Generate возвращает новый случайный ключ для алгоритма alg с идентификатором kid
+func Generate(alg, kid string) (jwk.Key, error) {
+	var raw interface{}
+
+	switch alg {
+	case HS256:
+		secret := make([]byte, minSecretLength)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+
+		raw = secret
+	case EdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		raw = privateKey
+	case ES256:
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		raw = privateKey
+	default:
+		return nil, ErrJWTKeysUnknownAlgorithm
+	}
+
+	key, err := jwk.New(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = key.Set(jwk.KeyIDKey, kid); err != nil {
+		return nil, err
+	}
+
+	if err = key.Set(jwk.AlgorithmKey, alg); err != nil {
+		return nil, err
+	}
+
+	if err = key.Set(jwk.KeyUsageKey, jwk.ForSignature); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Ephemeral возвращает набор из одного случайного ключа HS256, который действует до перезапуска сервера
+func Ephemeral() (*KeySet, error) {
+	key, err := Generate(HS256, "ephemeral")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKeySet([]jwk.Key{key}, "")
+}
+
+// SigningKeyID возвращает идентификатор ключа подписи
+func (ks *KeySet) SigningKeyID() string {
+	return ks.signKey.KeyID()
+}
+
+// Encode возвращает токен с утверждениями claims, подписанный ключом подписи,
+// идентификатор ключа записывается в заголовок kid
+func (ks *KeySet) Encode(claims map[string]interface{}) (t jwt.Token, tokenString string, err error) {
+	t = jwt.New()
+
+	for k, v := range claims {
+		if err = t.Set(k, v); err != nil {
+			return nil, "", err
+		}
+	}
+
+	headers := jws.NewHeaders()
+	if err = headers.Set(jws.KeyIDKey, ks.signKey.KeyID()); err != nil {
+		return nil, "", err
+	}
+
+	payload, err := jwt.Sign(t, jwa.SignatureAlgorithm(ks.signKey.Algorithm()), ks.signKey, jwt.WithHeaders(headers))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return t, string(payload), nil
+}
+
+// Decode проверяет подпись токена tokenString ключом набора, указанным в заголовке kid,
+// алгоритм проверки определяется ключом, а не заголовком токена
+func (ks *KeySet) Decode(tokenString string) (jwt.Token, error) {
+	return jwt.Parse([]byte(tokenString), jwt.WithKeySet(ks.verifySet))
+}
+
+// Verify проверяет подпись и срок действия токена tokenString
+func (ks *KeySet) Verify(tokenString string) (jwt.Token, error) {
+	token, err := ks.Decode(tokenString)
+	if err != nil {
+		return token, jwtauth.ErrorReason(err)
+	}
+
+	if err = jwt.Validate(token); err != nil {
+		return token, jwtauth.ErrorReason(err)
+	}
+
+	return token, nil
+}
+
+// Verifier middleware проверки токена из заголовка Authorization или cookie jwt,
+// результат проверки помещается в контекст запроса для Authenticator и jwtauth.FromContext.
+// Запрос, уже аутентифицированный предыдущим middleware, передаётся дальше без проверки
+func Verifier(ks *KeySet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token, _, err := jwtauth.FromContext(r.Context()); err == nil && token != nil {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			tokenString := jwtauth.TokenFromHeader(r)
+			if tokenString == "" {
+				tokenString = jwtauth.TokenFromCookie(r)
+			}
+
+			var (
+				token jwt.Token
+				err   = jwtauth.ErrNoTokenFound
+			)
+
+			if tokenString != "" {
+				token, err = ks.Verify(tokenString)
+			}
+
+			next.ServeHTTP(w, r.WithContext(jwtauth.NewContext(r.Context(), token, err)))
+		})
+	}
+}
+
+// Authenticator middleware отклоняет запросы без действительного токена,
+// заголовок WWW-Authenticate ответа сообщает клиенту, что токен нужно обновить
+func Authenticator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _, err := jwtauth.FromContext(r.Context())
+		if err != nil || token == nil || jwt.Validate(token) != nil {
+			Unauthorized(w)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Unauthorized отвечает на запрос с недействительным токеном
+func Unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+func readKeys(file string) ([]jwk.Key, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	isPEM := filepath.Ext(file) == ".pem"
+
+	set, err := jwk.Parse(data, jwk.WithPEM(isPEM))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]jwk.Key, 0, set.Len())
+
+	for i := 0; i < set.Len(); i++ {
+		key, _ := set.Get(i)
+
+		if key.KeyID() == "" && isPEM && set.Len() == 1 {
+			if err = key.Set(jwk.KeyIDKey, strings.TrimSuffix(filepath.Base(file), ".pem")); err != nil {
+				return nil, err
+			}
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// checkKey проверяет идентификатор и алгоритм ключа, если алгоритм не указан, он определяется по типу ключа
+func checkKey(key jwk.Key) error {
+	if key.KeyID() == "" {
+		return ErrJWTKeysMissingKeyID
+	}
+
+	var alg string
+
+	switch k := key.(type) {
+	case jwk.SymmetricKey:
+		if len(k.Octets()) < minSecretLength {
+			return ErrJWTKeysInvalidKey
+		}
+
+		alg = HS256
+	case jwk.OKPPrivateKey:
+		if k.Crv() != jwa.Ed25519 {
+			return ErrJWTKeysUnknownAlgorithm
+		}
+
+		alg = EdDSA
+	case jwk.OKPPublicKey:
+		if k.Crv() != jwa.Ed25519 {
+			return ErrJWTKeysUnknownAlgorithm
+		}
+
+		alg = EdDSA
+	case jwk.ECDSAPrivateKey:
+		if k.Crv() != jwa.P256 {
+			return ErrJWTKeysUnknownAlgorithm
+		}
+
+		alg = ES256
+	case jwk.ECDSAPublicKey:
+		if k.Crv() != jwa.P256 {
+			return ErrJWTKeysUnknownAlgorithm
+		}
+
+		alg = ES256
+	default:
+		return ErrJWTKeysUnknownAlgorithm
+	}
+
+	switch key.Algorithm() {
+	case "":
+		return key.Set(jwk.AlgorithmKey, alg)
+	case alg:
+		return nil
+	default:
+		return ErrJWTKeysUnknownAlgorithm
+	}
+}
+
+func isPrivate(key jwk.Key) bool {
+	switch key.(type) {
+	case jwk.SymmetricKey, jwk.OKPPrivateKey, jwk.ECDSAPrivateKey:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,328 @@
+package jwtkeys_test
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws"
+	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vukit/gophkeeper/internal/server/jwtkeys"
+)
+
+func TestKeySet(t *testing.T) {
+	tests := []struct {
+		name string
+		alg  string
+	}{
+		{
+			name: "case 1",
+			alg:  jwtkeys.HS256,
+		},
+		{
+			name: "case 2",
+			alg:  jwtkeys.EdDSA,
+		},
+		{
+			name: "case 3",
+			alg:  jwtkeys.ES256,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := jwtkeys.Generate(tt.alg, "key-1")
+			require.NoError(t, err)
+
+			ks, err := jwtkeys.NewKeySet([]jwk.Key{key}, "")
+			require.NoError(t, err)
+
+			_, tokenString, err := ks.Encode(map[string]interface{}{"user_id": "1"})
+			require.NoError(t, err)
+
+			msg, err := jws.Parse([]byte(tokenString))
+			require.NoError(t, err)
+			assert.Equal(t, "key-1", msg.Signatures()[0].ProtectedHeaders().KeyID())
+			assert.Equal(t, tt.alg, string(msg.Signatures()[0].ProtectedHeaders().Algorithm()))
+
+			token, err := ks.Verify(tokenString)
+			require.NoError(t, err)
+
+			userID, ok := token.Get("user_id")
+			assert.True(t, ok)
+			assert.Equal(t, "1", userID)
+
+			other, err := jwtkeys.Generate(tt.alg, "key-1")
+			require.NoError(t, err)
+
+			otherKS, err := jwtkeys.NewKeySet([]jwk.Key{other}, "")
+			require.NoError(t, err)
+
+			_, err = otherKS.Verify(tokenString)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	oldKey, err := jwtkeys.Generate(jwtkeys.HS256, "old")
+	require.NoError(t, err)
+
+	newKey, err := jwtkeys.Generate(jwtkeys.EdDSA, "new")
+	require.NoError(t, err)
+
+	oldKS, err := jwtkeys.NewKeySet([]jwk.Key{oldKey}, "")
+	require.NoError(t, err)
+
+	_, oldToken, err := oldKS.Encode(map[string]interface{}{"user_id": "1"})
+	require.NoError(t, err)
+
+	rotatedKS, err := jwtkeys.NewKeySet([]jwk.Key{oldKey, newKey}, "new")
+	require.NoError(t, err)
+	assert.Equal(t, "new", rotatedKS.SigningKeyID())
+
+	_, err = rotatedKS.Verify(oldToken)
+	assert.NoError(t, err)
+
+	_, newToken, err := rotatedKS.Encode(map[string]interface{}{"user_id": "1"})
+	require.NoError(t, err)
+
+	_, err = oldKS.Verify(newToken)
+	assert.Error(t, err)
+
+	newKS, err := jwtkeys.NewKeySet([]jwk.Key{newKey}, "")
+	require.NoError(t, err)
+
+	_, err = newKS.Verify(newToken)
+	assert.NoError(t, err)
+
+	_, err = newKS.Verify(oldToken)
+	assert.Error(t, err)
+}
+
+func TestNewKeySet(t *testing.T) {
+	hs256, err := jwtkeys.Generate(jwtkeys.HS256, "hs256")
+	require.NoError(t, err)
+
+	eddsa, err := jwtkeys.Generate(jwtkeys.EdDSA, "eddsa")
+	require.NoError(t, err)
+
+	public, err := jwk.PublicKeyOf(eddsa)
+	require.NoError(t, err)
+
+	short, err := jwk.New([]byte("short secret"))
+	require.NoError(t, err)
+	require.NoError(t, short.Set(jwk.KeyIDKey, "short"))
+
+	noKID, err := jwtkeys.Generate(jwtkeys.HS256, "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		keys      []jwk.Key
+		signingID string
+		want      error
+	}{
+		{
+			name:      "case 1",
+			keys:      []jwk.Key{hs256, eddsa},
+			signingID: "eddsa",
+			want:      nil,
+		},
+		{
+			name:      "case 2",
+			keys:      []jwk.Key{hs256, public},
+			signingID: "",
+			want:      nil,
+		},
+		{
+			name:      "case 3",
+			keys:      nil,
+			signingID: "",
+			want:      jwtkeys.ErrJWTKeysEmpity,
+		},
+		{
+			name:      "case 4",
+			keys:      []jwk.Key{hs256, eddsa},
+			signingID: "",
+			want:      jwtkeys.ErrJWTKeysSigningKeyNotSet,
+		},
+		{
+			name:      "case 5",
+			keys:      []jwk.Key{hs256, public},
+			signingID: "eddsa",
+			want:      jwtkeys.ErrJWTKeysSigningKeyPublic,
+		},
+		{
+			name:      "case 6",
+			keys:      []jwk.Key{hs256},
+			signingID: "eddsa",
+			want:      jwtkeys.ErrJWTKeysSigningKeyUnknown,
+		},
+		{
+			name:      "case 7",
+			keys:      []jwk.Key{hs256, eddsa, public},
+			signingID: "eddsa",
+			want:      jwtkeys.ErrJWTKeysDuplicateKeyID,
+		},
+		{
+			name:      "case 8",
+			keys:      []jwk.Key{short},
+			signingID: "",
+			want:      jwtkeys.ErrJWTKeysInvalidKey,
+		},
+		{
+			name:      "case 9",
+			keys:      []jwk.Key{noKID},
+			signingID: "",
+			want:      jwtkeys.ErrJWTKeysMissingKeyID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jwtkeys.NewKeySet(tt.keys, tt.signingID)
+			assert.True(t, errors.Is(err, tt.want), err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	current, err := jwtkeys.Generate(jwtkeys.ES256, "current")
+	require.NoError(t, err)
+
+	data, err := json.Marshal(current)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "current.json"), data, 0o600))
+
+	_, previous, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(previous)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "previous.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("not a key"), 0o600))
+
+	previousKey, err := jwk.New(previous)
+	require.NoError(t, err)
+	require.NoError(t, previousKey.Set(jwk.KeyIDKey, "previous"))
+
+	previousKS, err := jwtkeys.NewKeySet([]jwk.Key{previousKey}, "")
+	require.NoError(t, err)
+
+	_, previousToken, err := previousKS.Encode(map[string]interface{}{"user_id": "1"})
+	require.NoError(t, err)
+
+	ks, err := jwtkeys.Load(dir, "current")
+	require.NoError(t, err)
+	assert.Equal(t, "current", ks.SigningKeyID())
+
+	_, err = ks.Verify(previousToken)
+	assert.NoError(t, err)
+
+	_, err = jwtkeys.Load(dir, "")
+	assert.ErrorIs(t, err, jwtkeys.ErrJWTKeysSigningKeyNotSet)
+
+	ks, err = jwtkeys.Load(filepath.Join(dir, "current.json"), "")
+	require.NoError(t, err)
+	assert.Equal(t, "current", ks.SigningKeyID())
+
+	_, err = jwtkeys.Load(filepath.Join(dir, "missing.json"), "")
+	assert.Error(t, err)
+}
+
+func TestVerifier(t *testing.T) {
+	ks, err := jwtkeys.Ephemeral()
+	require.NoError(t, err)
+
+	_, tokenString, err := ks.Encode(map[string]interface{}{"user_id": "1"})
+	require.NoError(t, err)
+
+	preauthenticated := jwt.New()
+	require.NoError(t, preauthenticated.Set("user_id", "2"))
+
+	tests := []struct {
+		name          string
+		authorization string
+		cookie        string
+		preauth       jwt.Token
+		wantStatus    int
+		wantUserID    string
+	}{
+		{
+			name:          "case 1",
+			authorization: "Bearer " + tokenString,
+			wantStatus:    http.StatusOK,
+			wantUserID:    "1",
+		},
+		{
+			name:       "case 2",
+			cookie:     tokenString,
+			wantStatus: http.StatusOK,
+			wantUserID: "1",
+		},
+		{
+			name:          "case 3",
+			authorization: "Bearer invalid",
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:       "case 4",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "case 5",
+			authorization: "Bearer gpk_api_token",
+			preauth:       preauthenticated,
+			wantStatus:    http.StatusOK,
+			wantUserID:    "2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID interface{}
+
+			handler := jwtkeys.Verifier(ks)(jwtkeys.Authenticator(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, claims, _ := jwtauth.FromContext(r.Context())
+				userID = claims["user_id"]
+			})))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "jwt", Value: tt.cookie})
+			}
+
+			if tt.preauth != nil {
+				r = r.WithContext(jwtauth.NewContext(r.Context(), tt.preauth, nil))
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantUserID != "" {
+				assert.Equal(t, tt.wantUserID, userID)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vukit/gophkeeper/internal/server/logger"
+)
+
+// Policy параметры ограничения неудачных попыток аутентификации: после Threshold неудачных
+// попыток подряд попытки блокируются на время, которое удваивается с каждой следующей неудачей,
+// начиная с BaseDelay и не более MaxDelay. Счётчик сбрасывается, если неудачных попыток не было в течение Window
+type Policy struct {
+	Threshold int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Window    time.Duration
+}
+
+// Delay возвращает время блокировки после failures неудачных попыток подряд
+func (p Policy) Delay(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+
+	delay := p.BaseDelay
+
+	for i := p.Threshold; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	return delay
+}
+
+// Store хранилище счётчиков неудачных попыток, общее для всех экземпляров сервера
+type Store interface {
+	FindAuthLockout(ctx context.Context, key string) (retryAfter time.Duration, err error)
+	SaveAuthAttempt(ctx context.Context, key string, policy Policy) (failures int, retryAfter time.Duration, err error)
+	DeleteAuthAttempt(ctx context.Context, key string, policy Policy) (err error)
+	DeleteAuthFailures(ctx context.Context, key string) (err error)
+}
+
+// Limiter ограничивает неудачные попытки аутентификации одновременно по учётной записи и по IP адресу.
+// Попытка учитывается как неудачная до проверки учётных данных, чтобы параллельные запросы не получили
+// больше попыток, чем разрешает политика, и отменяется, если проверка прошла успешно
+type Limiter struct {
+	store   Store
+	account Policy
+	ip      Policy
+	mLogger *logger.Logger
+}
+
+// NewLimiter возвращает ограничитель с политиками account для учётной записи и ip для IP адреса
+func NewLimiter(store Store, account, ip Policy, mLogger *logger.Logger) *Limiter {
+	return &Limiter{store: store, account: account, ip: ip, mLogger: mLogger}
+}
+
+// Attempt учитывает попытку аутентификации пользователя username с адреса ip как неудачную и возвращает
+// время, через которое можно повторить попытку, либо ноль, если попытка разрешена. Заблокированная
+// попытка не учитывается
+func (l *Limiter) Attempt(ctx context.Context, username, ip string) (retryAfter time.Duration, err error) {
+	counters := []struct {
+		key    string
+		policy Policy
+	}{
+		{key: accountKey(username), policy: l.account},
+		{key: ipKey(ip), policy: l.ip},
+	}
+
+	for i, counter := range counters {
+		_, keyRetryAfter, err := l.store.SaveAuthAttempt(ctx, counter.key, counter.policy)
+		if err != nil {
+			return 0, err
+		}
+
+		if keyRetryAfter <= 0 {
+			continue
+		}
+
+		for _, counted := range counters[:i] {
+			if err = l.store.DeleteAuthAttempt(ctx, counted.key, counted.policy); err != nil {
+				return 0, err
+			}
+		}
+
+		l.mLogger.Warning(fmt.Sprintf("locked out sign-in attempt: username=%q ip=%s retry_after=%s",
+			username, ip, keyRetryAfter))
+
+		return keyRetryAfter, nil
+	}
+
+	return 0, nil
+}
+
+// Fail протоколирует неудачную попытку аутентификации пользователя username с адреса ip, которая уже
+// учтена методом Attempt
+func (l *Limiter) Fail(ctx context.Context, username, ip string) (err error) {
+	l.mLogger.Warning(fmt.Sprintf("failed sign-in attempt: username=%q ip=%s", username, ip))
+
+	accountRetryAfter, err := l.store.FindAuthLockout(ctx, accountKey(username))
+	if err != nil {
+		return err
+	}
+
+	ipRetryAfter, err := l.store.FindAuthLockout(ctx, ipKey(ip))
+	if err != nil {
+		return err
+	}
+
+	if accountRetryAfter > 0 || ipRetryAfter > 0 {
+		l.mLogger.Warning(fmt.Sprintf("sign-in locked out: username=%q ip=%s account_lockout=%s ip_lockout=%s",
+			username, ip, accountRetryAfter, ipRetryAfter))
+	}
+
+	return nil
+}
+
+// Succeed сбрасывает счётчик неудачных попыток учётной записи username после успешной аутентификации
+// и отменяет попытку с адреса ip, счётчик IP адреса не сбрасывается, чтобы вход в свою учётную запись
+// не снимал ограничение перебора чужих
+func (l *Limiter) Succeed(ctx context.Context, username, ip string) (err error) {
+	if err = l.store.DeleteAuthFailures(ctx, accountKey(username)); err != nil {
+		return err
+	}
+
+	return l.store.DeleteAuthAttempt(ctx, ipKey(ip), l.ip)
+}
+
+// Release отменяет попытку аутентификации пользователя username с адреса ip, проверка которой прошла
+// успешно, не сбрасывая счётчик неудачных попыток учётной записи
+func (l *Limiter) Release(ctx context.Context, username, ip string) (err error) {
+	if err = l.store.DeleteAuthAttempt(ctx, accountKey(username), l.account); err != nil {
+		return err
+	}
+
+	return l.store.DeleteAuthAttempt(ctx, ipKey(ip), l.ip)
+}
+
+func accountKey(username string) string {
+	return "account:" + username
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
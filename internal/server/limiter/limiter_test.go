@@ -0,0 +1,143 @@
+package limiter_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vukit/gophkeeper/internal/server/limiter"
+	"github.com/vukit/gophkeeper/internal/server/logger"
+)
+
+// memoryStore хранилище счётчиков в памяти, время блокировки не истекает
+type memoryStore struct {
+	failures map[string]int
+	lockouts map[string]time.Duration
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{failures: map[string]int{}, lockouts: map[string]time.Duration{}}
+}
+
+func (s *memoryStore) FindAuthLockout(ctx context.Context, key string) (time.Duration, error) {
+	return s.lockouts[key], nil
+}
+
+func (s *memoryStore) SaveAuthAttempt(ctx context.Context, key string, policy limiter.Policy) (int, time.Duration, error) {
+	if s.lockouts[key] > 0 {
+		return 0, s.lockouts[key], nil
+	}
+
+	s.failures[key]++
+	s.lockouts[key] = policy.Delay(s.failures[key])
+
+	return s.failures[key], 0, nil
+}
+
+func (s *memoryStore) DeleteAuthAttempt(ctx context.Context, key string, policy limiter.Policy) error {
+	if s.failures[key] > 0 {
+		s.failures[key]--
+	}
+
+	s.lockouts[key] = policy.Delay(s.failures[key])
+
+	return nil
+}
+
+func (s *memoryStore) DeleteAuthFailures(ctx context.Context, key string) error {
+	delete(s.failures, key)
+	delete(s.lockouts, key)
+
+	return nil
+}
+
+func TestPolicyDelay(t *testing.T) {
+	policy := limiter.Policy{Threshold: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{
+			name:     "case 1",
+			failures: 2,
+			want:     0,
+		},
+		{
+			name:     "case 2",
+			failures: 3,
+			want:     time.Second,
+		},
+		{
+			name:     "case 3",
+			failures: 5,
+			want:     4 * time.Second,
+		},
+		{
+			name:     "case 4",
+			failures: 100,
+			want:     10 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.Delay(tt.failures))
+		})
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	log := &bytes.Buffer{}
+
+	account := limiter.Policy{Threshold: 2, BaseDelay: time.Second, MaxDelay: time.Minute}
+	ip := limiter.Policy{Threshold: 3, BaseDelay: time.Second, MaxDelay: time.Minute}
+
+	l := limiter.NewLimiter(store, account, ip, logger.NewLogger(log))
+
+	attempt := func(username, ip string, want time.Duration) {
+		t.Helper()
+
+		retryAfter, err := l.Attempt(ctx, username, ip)
+		require.NoError(t, err)
+		assert.Equal(t, want, retryAfter)
+	}
+
+	attempt("mark", "10.0.0.1", 0)
+	require.NoError(t, l.Fail(ctx, "mark", "10.0.0.1"))
+	attempt("mark", "10.0.0.1", 0)
+	require.NoError(t, l.Fail(ctx, "mark", "10.0.0.1"))
+	attempt("mark", "10.0.0.2", time.Second)
+
+	require.NoError(t, l.Succeed(ctx, "mark", "10.0.0.2"))
+	attempt("mark", "10.0.0.2", 0)
+
+	// попытки учитываются до проверки, поэтому параллельные запросы блокируются,
+	// не дожидаясь результата предыдущих
+	attempt("anna", "10.0.0.3", 0)
+	attempt("anna", "10.0.0.3", 0)
+	attempt("anna", "10.0.0.3", time.Second)
+
+	require.NoError(t, l.Succeed(ctx, "anna", "10.0.0.3"))
+	attempt("anna", "10.0.0.3", 0)
+
+	attempt("john", "10.0.0.1", 0)
+	attempt("olga", "10.0.0.1", time.Second)
+	assert.Zero(t, store.failures["account:olga"])
+
+	require.NoError(t, l.Release(ctx, "john", "10.0.0.1"))
+	assert.Zero(t, store.failures["account:john"])
+	attempt("olga", "10.0.0.1", 0)
+
+	require.NoError(t, l.Fail(ctx, "olga", "10.0.0.1"))
+
+	assert.Contains(t, log.String(), "failed sign-in attempt")
+	assert.Contains(t, log.String(), "sign-in locked out")
+	assert.Contains(t, log.String(), "locked out sign-in attempt")
+}
@@ -0,0 +1 @@
+alter table users alter column "password" type char(64) using left("password", 64);
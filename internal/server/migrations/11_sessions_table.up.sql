@@ -0,0 +1,12 @@
+create table sessions (
+    "session_id"    serial primary key,
+    "user_id"       int not null references users on delete cascade,
+    "token_hash"    char(64) not null,
+    "previous_hash" char(64) not null default '',
+    "expires_at"    timestamp with time zone not null,
+    "created_at"    timestamp with time zone not null default now(),
+    unique ("token_hash")
+);
+
+create index "sessions_user_id_idx" ON sessions ("user_id");
+create index "sessions_previous_hash_idx" ON sessions ("previous_hash");
@@ -0,0 +1,5 @@
+drop table totp_backup_codes cascade;
+
+alter table users drop column "totp_secret";
+alter table users drop column "totp_pending";
+alter table users drop column "totp_last_step";
@@ -0,0 +1,11 @@
+alter table users add column "totp_secret" character varying not null default '';
+alter table users add column "totp_pending" character varying not null default '';
+alter table users add column "totp_last_step" bigint not null default 0;
+
+create table totp_backup_codes (
+    "backup_code_id"    serial primary key,
+    "user_id"           int not null references users on delete cascade,
+    "code_hash"         char(64) not null
+);
+
+create index "totp_backup_codes_user_id_idx" ON totp_backup_codes ("user_id");
@@ -0,0 +1 @@
+drop table auth_failures cascade;
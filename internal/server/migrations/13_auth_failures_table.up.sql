@@ -0,0 +1,8 @@
+create table auth_failures (
+    "key"           varchar(128) primary key,
+    "failures"      int not null default 0,
+    "locked_until"  timestamp with time zone not null default now(),
+    "updated_at"    timestamp with time zone not null default now()
+);
+
+create index "auth_failures_updated_at_idx" ON auth_failures ("updated_at");
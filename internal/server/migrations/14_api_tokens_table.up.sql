@@ -0,0 +1,13 @@
+create table api_tokens (
+    "token_id"      serial primary key,
+    "user_id"       int not null references users on delete cascade,
+    "name"          varchar(64) not null,
+    "token_hash"    char(64) not null,
+    "scopes"        varchar(256) not null,
+    "expires_at"    timestamp with time zone,
+    "created_at"    timestamp with time zone not null default now(),
+    "last_used_at"  timestamp with time zone,
+    unique ("token_hash")
+);
+
+create index "api_tokens_user_id_idx" ON api_tokens ("user_id");
@@ -0,0 +1 @@
+drop table notes cascade;
@@ -0,0 +1,7 @@
+create table notes (
+    "note_id"   serial primary key,
+    "user_id"   int not null references users on delete cascade,
+    "uid"       varchar(64) not null default '',
+    "title"     character varying not null,
+    "text"      character varying not null
+);
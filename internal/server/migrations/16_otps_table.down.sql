@@ -0,0 +1 @@
+drop table otps cascade;
@@ -0,0 +1,11 @@
+create table otps (
+    "otp_id"    serial primary key,
+    "user_id"   int not null references users on delete cascade,
+    "login_id"  int references logins on delete set null,
+    "uid"       varchar(64) not null default '',
+    "name"      character varying not null,
+    "secret"    character varying not null,
+    "algorithm" varchar(16) not null,
+    "digits"    int not null,
+    "period"    int not null
+);
@@ -0,0 +1 @@
+drop table ssh_keys cascade;
@@ -0,0 +1,10 @@
+create table ssh_keys (
+    "ssh_key_id"  serial primary key,
+    "user_id"     int not null references users on delete cascade,
+    "uid"         varchar(64) not null default '',
+    "private_key" character varying not null,
+    "public_key"  character varying not null,
+    "comment"     character varying not null,
+    "fingerprint" character varying not null,
+    "confirm"     boolean not null default false
+);
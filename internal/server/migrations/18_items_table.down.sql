@@ -0,0 +1,98 @@
+create table logins (
+    "login_id"  serial primary key,
+    "user_id"   int not null references users on delete cascade,
+    "username"  character varying not null,
+    "password"  character varying not null,
+    "metainfo"  character varying not null,
+    "uid"       varchar(64) not null default ''
+);
+
+create table cards (
+    "card_id"   serial primary key,
+    "user_id"   int not null references users on delete cascade,
+    "bank"      character varying not null,
+    "number"    character varying not null,
+    "date"      character varying not null,
+    "cvv"       character varying not null,
+    "metainfo"  character varying not null,
+    "uid"       varchar(64) not null default ''
+);
+
+create table files (
+    "file_id"   serial primary key,
+    "user_id"   int not null references users on delete cascade,
+    "path"      character varying not null,
+    "name"      character varying not null,
+    "metainfo"  character varying not null,
+    "uid"       varchar(64) not null default ''
+);
+
+create table notes (
+    "note_id"   serial primary key,
+    "user_id"   int not null references users on delete cascade,
+    "uid"       varchar(64) not null default '',
+    "title"     character varying not null,
+    "text"      character varying not null
+);
+
+create table otps (
+    "otp_id"    serial primary key,
+    "user_id"   int not null references users on delete cascade,
+    "login_id"  int references logins on delete set null,
+    "uid"       varchar(64) not null default '',
+    "name"      character varying not null,
+    "secret"    character varying not null,
+    "algorithm" varchar(16) not null,
+    "digits"    int not null,
+    "period"    int not null
+);
+
+create table ssh_keys (
+    "ssh_key_id"  serial primary key,
+    "user_id"     int not null references users on delete cascade,
+    "uid"         varchar(64) not null default '',
+    "private_key" character varying not null,
+    "public_key"  character varying not null,
+    "comment"     character varying not null,
+    "fingerprint" character varying not null,
+    "confirm"     boolean not null default false
+);
+
+insert into logins (login_id, user_id, uid, username, password, metainfo)
+select item_id, user_id, uid, data->>'username', data->>'password', data->>'metainfo'
+from items where type = 'login';
+
+insert into cards (card_id, user_id, uid, bank, number, date, cvv, metainfo)
+select item_id, user_id, uid, data->>'bank', data->>'number', data->>'date', data->>'cvv', data->>'metainfo'
+from items where type = 'card';
+
+insert into files (file_id, user_id, uid, path, name, metainfo)
+select item_id, user_id, uid, data->>'path', data->>'name', data->>'metainfo'
+from items where type = 'file';
+
+insert into notes (note_id, user_id, uid, title, text)
+select item_id, user_id, uid, data->>'title', data->>'text'
+from items where type = 'note';
+
+insert into otps (otp_id, user_id, login_id, uid, name, secret, algorithm, digits, period)
+select o.item_id, o.user_id, l.login_id, o.uid, o.data->>'name', o.data->>'secret',
+    o.data->>'algorithm', (o.data->>'digits')::int, (o.data->>'period')::int
+from items o left join logins l on l.login_id = (o.data->>'login_id')::int and l.user_id = o.user_id
+where o.type = 'otp';
+
+insert into ssh_keys (ssh_key_id, user_id, uid, private_key, public_key, comment, fingerprint, confirm)
+select item_id, user_id, uid, data->>'private_key', data->>'public_key', data->>'comment',
+    data->>'fingerprint', (data->>'confirm')::boolean
+from items where type = 'ssh_key';
+
+select setval(pg_get_serial_sequence(t, c), coalesce((select max(item_id) from items), 0) + 1, false)
+from (values ('logins', 'login_id'), ('cards', 'card_id'), ('files', 'file_id'),
+    ('notes', 'note_id'), ('otps', 'otp_id'), ('ssh_keys', 'ssh_key_id')) as s (t, c);
+
+alter table rekey_files drop constraint rekey_files_file_id_fkey;
+
+delete from rekey_files r where not exists (select 1 from files f where f.file_id = r.file_id);
+
+alter table rekey_files add constraint rekey_files_file_id_fkey foreign key ("file_id") references files on delete cascade;
+
+drop table items cascade;
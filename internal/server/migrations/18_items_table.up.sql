@@ -0,0 +1,62 @@
+create table items (
+    "item_id"    serial primary key,
+    "user_id"    int not null references users on delete cascade,
+    "uid"        varchar(64) not null default '',
+    "type"       varchar(32) not null,
+    "data"       jsonb not null,
+    "created_at" timestamp with time zone not null default now(),
+    "updated_at" timestamp with time zone not null default now(),
+    "legacy_id"  int
+);
+
+create index items_user_id_type_idx on items (user_id, type);
+
+insert into items (user_id, uid, type, data, legacy_id)
+select user_id, uid, 'login',
+    jsonb_build_object('username', username, 'password', password, 'metainfo', metainfo, 'fields', '[]'::jsonb),
+    login_id
+from logins order by login_id;
+
+insert into items (user_id, uid, type, data, legacy_id)
+select user_id, uid, 'card',
+    jsonb_build_object('bank', bank, 'number', number, 'date', date, 'cvv', cvv, 'metainfo', metainfo, 'fields', '[]'::jsonb),
+    card_id
+from cards order by card_id;
+
+insert into items (user_id, uid, type, data, legacy_id)
+select user_id, uid, 'file',
+    jsonb_build_object('path', path, 'name', name, 'metainfo', metainfo, 'fields', '[]'::jsonb),
+    file_id
+from files order by file_id;
+
+insert into items (user_id, uid, type, data, legacy_id)
+select user_id, uid, 'note',
+    jsonb_build_object('title', title, 'text', text, 'fields', '[]'::jsonb),
+    note_id
+from notes order by note_id;
+
+insert into items (user_id, uid, type, data, legacy_id)
+select o.user_id, o.uid, 'otp',
+    jsonb_build_object('login_id', coalesce(l.item_id, 0), 'name', o.name, 'secret', o.secret,
+        'algorithm', o.algorithm, 'digits', o.digits, 'period', o.period, 'fields', '[]'::jsonb),
+    o.otp_id
+from otps o left join items l on l.type = 'login' and l.legacy_id = o.login_id
+order by o.otp_id;
+
+insert into items (user_id, uid, type, data, legacy_id)
+select user_id, uid, 'ssh_key',
+    jsonb_build_object('private_key', private_key, 'public_key', public_key, 'comment', comment,
+        'fingerprint', fingerprint, 'confirm', confirm, 'fields', '[]'::jsonb),
+    ssh_key_id
+from ssh_keys order by ssh_key_id;
+
+alter table rekey_files drop constraint rekey_files_file_id_fkey;
+
+update rekey_files r set file_id = i.item_id
+from items i where i.type = 'file' and i.legacy_id = r.file_id;
+
+alter table rekey_files add constraint rekey_files_file_id_fkey foreign key ("file_id") references items on delete cascade;
+
+alter table items drop column "legacy_id";
+
+drop table ssh_keys, otps, notes, files, cards, logins cascade;
@@ -0,0 +1,7 @@
+drop table item_tags;
+
+alter table items drop column "folder_id";
+
+drop table tags;
+
+drop table folders;
@@ -0,0 +1,23 @@
+create table folders (
+    "folder_id" serial primary key,
+    "user_id"   int not null references users on delete cascade,
+    "uid"       varchar(64) not null,
+    "name"      character varying not null
+);
+
+create table tags (
+    "tag_id"  serial primary key,
+    "user_id" int not null references users on delete cascade,
+    "uid"     varchar(64) not null,
+    "name"    character varying not null
+);
+
+alter table items add column "folder_id" int references folders on delete set null;
+
+create table item_tags (
+    "item_id" int not null references items on delete cascade,
+    "tag_id"  int not null references tags on delete cascade,
+    primary key ("item_id", "tag_id")
+);
+
+create index item_tags_tag_id_idx on item_tags ("tag_id");
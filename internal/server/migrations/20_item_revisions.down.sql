@@ -0,0 +1,3 @@
+drop table item_revisions;
+
+alter table items drop column "device";
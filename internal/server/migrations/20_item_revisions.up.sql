@@ -0,0 +1,15 @@
+alter table items add column "device" varchar(64) not null default '';
+
+create table item_revisions (
+    "revision_id" serial primary key,
+    "item_id"     int not null references items on delete cascade,
+    "user_id"     int not null references users on delete cascade,
+    "uid"         varchar(64) not null,
+    "data"        jsonb not null,
+    "folder_id"   int not null default 0,
+    "tag_ids"     jsonb not null default '[]',
+    "device"      varchar(64) not null default '',
+    "created_at"  timestamp with time zone not null
+);
+
+create index item_revisions_item_id_idx on item_revisions ("item_id", "revision_id");
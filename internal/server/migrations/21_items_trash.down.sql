@@ -0,0 +1,3 @@
+delete from items where "deleted_at" is not null;
+
+alter table items drop column "deleted_at";
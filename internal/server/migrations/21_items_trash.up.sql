@@ -0,0 +1,3 @@
+alter table items add column "deleted_at" timestamp with time zone;
+
+create index items_deleted_at_idx on items ("deleted_at") where "deleted_at" is not null;
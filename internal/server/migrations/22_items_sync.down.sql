@@ -0,0 +1,4 @@
+alter table items drop column "sync_revision";
+
+alter table users drop column "sync_purged";
+alter table users drop column "sync_revision";
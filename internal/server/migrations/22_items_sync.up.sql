@@ -0,0 +1,6 @@
+alter table users add column "sync_revision" bigint not null default 0;
+alter table users add column "sync_purged" bigint not null default 0;
+
+alter table items add column "sync_revision" bigint not null default 0;
+
+create index items_user_id_sync_revision_idx on items ("user_id", "sync_revision");
@@ -0,0 +1 @@
+drop table mfa_tokens;
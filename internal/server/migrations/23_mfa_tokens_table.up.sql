@@ -0,0 +1,8 @@
+create table mfa_tokens (
+    "token_id"   varchar(64) primary key,
+    "user_id"    int not null references users on delete cascade,
+    "attempts"   int not null default 0,
+    "expires_at" timestamp with time zone not null
+);
+
+create index "mfa_tokens_user_id_idx" ON mfa_tokens ("user_id");
@@ -0,0 +1,5 @@
+alter table sessions add column "previous_hash" char(64) not null default '';
+
+create index "sessions_previous_hash_idx" ON sessions ("previous_hash");
+
+drop table session_tokens;
@@ -0,0 +1,11 @@
+create table session_tokens (
+    "token_hash" char(64) primary key,
+    "session_id" int not null references sessions on delete cascade
+);
+
+create index "session_tokens_session_id_idx" ON session_tokens ("session_id");
+
+insert into session_tokens ("token_hash", "session_id")
+    select "previous_hash", "session_id" from sessions where "previous_hash" <> '' on conflict do nothing;
+
+alter table sessions drop column "previous_hash";
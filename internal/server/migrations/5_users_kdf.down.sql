@@ -0,0 +1 @@
+alter table users drop column "kdf_algorithm", drop column "kdf_salt", drop column "kdf_time", drop column "kdf_memory", drop column "kdf_threads";
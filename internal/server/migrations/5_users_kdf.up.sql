@@ -0,0 +1,6 @@
+alter table users
+    add column "kdf_algorithm" varchar(16) not null default 'sha256',
+    add column "kdf_salt"      character varying not null default '',
+    add column "kdf_time"      int not null default 0,
+    add column "kdf_memory"    int not null default 0,
+    add column "kdf_threads"   int not null default 0;
@@ -0,0 +1 @@
+alter table users add column "kdf_version" int not null default 0;
@@ -0,0 +1 @@
+drop table rekey_files cascade;
@@ -0,0 +1,6 @@
+create table rekey_files (
+    "user_id"   int not null references users on delete cascade,
+    "file_id"   int not null references files on delete cascade,
+    "path"      character varying not null,
+    primary key ("user_id", "file_id")
+);
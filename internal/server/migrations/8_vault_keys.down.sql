@@ -0,0 +1,2 @@
+drop table recovery_keys cascade;
+alter table users drop column "vault_key";
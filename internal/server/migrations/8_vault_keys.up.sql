@@ -0,0 +1,10 @@
+alter table users add column "vault_key" character varying not null default '';
+
+create table recovery_keys (
+    "recovery_key_id"   serial primary key,
+    "user_id"           int not null references users on delete cascade,
+    "verifier"          char(64) not null,
+    "vault_key"         character varying not null
+);
+
+create index "recovery_keys_user_id_idx" ON recovery_keys ("user_id");
@@ -0,0 +1,3 @@
+alter table files drop column "uid";
+alter table cards drop column "uid";
+alter table logins drop column "uid";
@@ -0,0 +1,3 @@
+alter table logins add column "uid" varchar(64) not null default '';
+alter table cards add column "uid" varchar(64) not null default '';
+alter table files add column "uid" varchar(64) not null default '';
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Имена элементов архива выгрузки учётной записи: описание учётной записи с зашифрованными
+// записями и каталог с зашифрованным содержимым файлов
+const (
+	AccountArchiveManifest = "account.json"
+	AccountArchiveFiles    = "files/"
+	AccountArchiveVersion  = 1
+)
+
+// Account модель выгрузки учётной записи: данные пользователя выгружаются зашифрованными,
+// вместе с параметрами получения ключей и зашифрованным ключом хранилища, поэтому их можно
+// расшифровать мастер-паролем учётной записи и загрузить в другую учётную запись.
+// Path файла — имя элемента архива с его содержимым
+type Account struct {
+	Version    int       `json:"version"`
+	Username   string    `json:"username"`
+	ExportedAt time.Time `json:"exported_at"`
+	KDF        KDF       `json:"kdf"`
+	VaultKey   string    `json:"vault_key"`
+	Logins     []Login   `json:"logins"`
+	Cards      []Card    `json:"cards"`
+	Files      []File    `json:"files"`
+	Notes      []Note    `json:"notes"`
+	OTPs       []OTP     `json:"otps"`
+	SSHKeys    []SSHKey  `json:"ssh_keys"`
+	Items      []Item    `json:"items"`
+	Folders    []Folder  `json:"folders"`
+	Tags       []Tag     `json:"tags"`
+}
+
+// AccountDeletion модель запроса удаления учётной записи пользователя
+type AccountDeletion struct {
+	Password string `json:"password"`
+}
+
+var ErrAccountPasswordEmpity = errors.New("password empity")
+
+// Validate проверяет корректность модели запроса удаления учётной записи
+func (r *AccountDeletion) Validate() error {
+	if strings.TrimSpace(r.Password) == "" {
+		return ErrAccountPasswordEmpity
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+func TestAccountDeletion(t *testing.T) {
+	tests := []struct {
+		name     string
+		deletion model.AccountDeletion
+		want     error
+	}{
+		{
+			name:     "case 1",
+			deletion: model.AccountDeletion{Password: "password"},
+			want:     nil,
+		},
+		{
+			name:     "case 2",
+			deletion: model.AccountDeletion{Password: " "},
+			want:     model.ErrAccountPasswordEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.deletion.Validate())
+		})
+	}
+}
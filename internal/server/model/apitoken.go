@@ -0,0 +1,139 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Области доступа токенов API
+const (
+	ScopeLoginsRead   = "logins:read"
+	ScopeLoginsWrite  = "logins:write"
+	ScopeCardsRead    = "cards:read"
+	ScopeCardsWrite   = "cards:write"
+	ScopeFilesRead    = "files:read"
+	ScopeFilesWrite   = "files:write"
+	ScopeNotesRead    = "notes:read"
+	ScopeNotesWrite   = "notes:write"
+	ScopeOTPsRead     = "otps:read"
+	ScopeOTPsWrite    = "otps:write"
+	ScopeSSHKeysRead  = "sshkeys:read"
+	ScopeSSHKeysWrite = "sshkeys:write"
+	ScopeItemsRead    = "items:read"
+	ScopeItemsWrite   = "items:write"
+	ScopeFoldersRead  = "folders:read"
+	ScopeFoldersWrite = "folders:write"
+	ScopeTagsRead     = "tags:read"
+	ScopeTagsWrite    = "tags:write"
+	ScopeHistoryRead  = "history:read"
+	ScopeHistoryWrite = "history:write"
+	ScopeTrashRead    = "trash:read"
+	ScopeTrashWrite   = "trash:write"
+	ScopeSyncRead     = "sync:read"
+)
+
+// Scopes все области доступа токенов API
+var Scopes = []string{
+	ScopeLoginsRead, ScopeLoginsWrite,
+	ScopeCardsRead, ScopeCardsWrite,
+	ScopeFilesRead, ScopeFilesWrite,
+	ScopeNotesRead, ScopeNotesWrite,
+	ScopeOTPsRead, ScopeOTPsWrite,
+	ScopeSSHKeysRead, ScopeSSHKeysWrite,
+	ScopeItemsRead, ScopeItemsWrite,
+	ScopeFoldersRead, ScopeFoldersWrite,
+	ScopeTagsRead, ScopeTagsWrite,
+	ScopeHistoryRead, ScopeHistoryWrite,
+	ScopeTrashRead, ScopeTrashWrite,
+	ScopeSyncRead,
+}
+
+// APITokenPrefix префикс токенов API, по которому они отличаются от токенов доступа сеанса
+const APITokenPrefix = "gpk_"
+
+// APIToken модель токена API пользователя для доступа без мастер-пароля, сам токен хранится
+// на сервере только в виде хеша и возвращается один раз при создании
+type APIToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"-"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// APITokenCreated модель ответа сервера при создании токена API
+type APITokenCreated struct {
+	APIToken
+	Token string `json:"token"`
+}
+
+const maxAPITokenNameLength = 64
+
+var (
+	ErrAPITokenNameEmpity     = errors.New("token name empity")
+	ErrAPITokenLongName       = fmt.Errorf("token name length is more than %d characters", maxAPITokenNameLength)
+	ErrAPITokenScopesEmpity   = errors.New("token scopes empity")
+	ErrAPITokenUnknownScope   = errors.New("unknown token scope")
+	ErrAPITokenDuplicateScope = errors.New("duplicate token scope")
+	ErrAPITokenExpired        = errors.New("token expiry is in the past")
+)
+
+// Validate проверяет корректность модели токена API
+func (r *APIToken) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrAPITokenNameEmpity
+	}
+
+	if len(r.Name) > maxAPITokenNameLength {
+		return ErrAPITokenLongName
+	}
+
+	if len(r.Scopes) == 0 {
+		return ErrAPITokenScopesEmpity
+	}
+
+	seen := make(map[string]bool, len(r.Scopes))
+
+	for _, scope := range r.Scopes {
+		if !isScope(scope) {
+			return fmt.Errorf("%w: %s", ErrAPITokenUnknownScope, scope)
+		}
+
+		if seen[scope] {
+			return fmt.Errorf("%w: %s", ErrAPITokenDuplicateScope, scope)
+		}
+
+		seen[scope] = true
+	}
+
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return ErrAPITokenExpired
+	}
+
+	return nil
+}
+
+// HasScope проверяет, что токену API разрешена область доступа scope
+func (r *APIToken) HasScope(scope string) bool {
+	for _, s := range r.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
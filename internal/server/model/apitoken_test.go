@@ -0,0 +1,78 @@
+package model_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+func TestAPIToken(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name  string
+		token model.APIToken
+		want  error
+	}{
+		{
+			name:  "case 1",
+			token: model.APIToken{Name: "ci", Scopes: []string{model.ScopeLoginsRead, model.ScopeFilesRead}},
+			want:  nil,
+		},
+		{
+			name:  "case 2",
+			token: model.APIToken{Name: "ci", Scopes: []string{model.ScopeFilesWrite}, ExpiresAt: &future},
+			want:  nil,
+		},
+		{
+			name:  "case 3",
+			token: model.APIToken{Name: " ", Scopes: []string{model.ScopeLoginsRead}},
+			want:  model.ErrAPITokenNameEmpity,
+		},
+		{
+			name:  "case 4",
+			token: model.APIToken{Name: strings.Repeat("a", 65), Scopes: []string{model.ScopeLoginsRead}},
+			want:  model.ErrAPITokenLongName,
+		},
+		{
+			name:  "case 5",
+			token: model.APIToken{Name: "ci"},
+			want:  model.ErrAPITokenScopesEmpity,
+		},
+		{
+			name:  "case 6",
+			token: model.APIToken{Name: "ci", Scopes: []string{"admin"}},
+			want:  model.ErrAPITokenUnknownScope,
+		},
+		{
+			name:  "case 7",
+			token: model.APIToken{Name: "ci", Scopes: []string{model.ScopeCardsRead, model.ScopeCardsRead}},
+			want:  model.ErrAPITokenDuplicateScope,
+		},
+		{
+			name:  "case 8",
+			token: model.APIToken{Name: "ci", Scopes: []string{model.ScopeCardsRead}, ExpiresAt: &past},
+			want:  model.ErrAPITokenExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.token.Validate()
+			assert.True(t, errors.Is(err, tt.want), err)
+		})
+	}
+}
+
+func TestAPITokenHasScope(t *testing.T) {
+	token := model.APIToken{Scopes: []string{model.ScopeLoginsRead, model.ScopeCardsWrite}}
+
+	assert.True(t, token.HasScope(model.ScopeLoginsRead))
+	assert.True(t, token.HasScope(model.ScopeCardsWrite))
+	assert.False(t, token.HasScope(model.ScopeLoginsWrite))
+}
@@ -7,13 +7,16 @@ import (
 
 // Card модель банковской карты сервера
 type Card struct {
-	ID       int    `json:"id"`
-	UserID   int    `json:"-"`
-	Bank     string `json:"bank"`
-	Number   string `json:"number"`
-	Date     string `json:"date"`
-	CVV      string `json:"cvv"`
-	MetaInfo string `json:"metainfo"`
+	ID       int     `json:"id"`
+	UserID   int     `json:"-"`
+	UID      string  `json:"uid"`
+	Bank     string  `json:"bank"`
+	Number   string  `json:"number"`
+	Date     string  `json:"date"`
+	CVV      string  `json:"cvv"`
+	MetaInfo string  `json:"metainfo"`
+	Fields   []Field `json:"fields"`
+	Placement
 }
 
 var (
@@ -41,5 +44,9 @@ func (r *Card) Validate() error {
 		return ErrCardCVVEmpity
 	}
 
-	return nil
+	if err := r.Placement.Validate(); err != nil {
+		return err
+	}
+
+	return ValidateFields(r.Fields)
 }
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// Credential модель замены учётных данных пользователя сервера, пустой VaultKey
+// оставляет прежний зашифрованный ключ шифрования хранилища и допустим только до схемы KDFVersionSplitKeys
+type Credential struct {
+	Password    string `json:"password"`
+	NewPassword string `json:"new_password"`
+	KDF         KDF    `json:"kdf"`
+	VaultKey    string `json:"vault_key"`
+}
+
+var ErrCredentialPasswordEmpity = errors.New("password and/or new password empity")
+
+// Validate проверяет корректность модели замены учётных данных
+func (r *Credential) Validate() error {
+	if strings.TrimSpace(r.Password) == "" || strings.TrimSpace(r.NewPassword) == "" {
+		return ErrCredentialPasswordEmpity
+	}
+
+	if err := r.KDF.Validate(); err != nil {
+		return err
+	}
+
+	return r.KDF.ValidateVaultKey(r.VaultKey)
+}
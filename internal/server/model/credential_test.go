@@ -0,0 +1,72 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+func TestCredential(t *testing.T) {
+	tests := []struct {
+		name        string
+		password    string
+		newPassword string
+		kdf         model.KDF
+		vaultKey    string
+		want        error
+	}{
+		{
+			name:        "case 1",
+			password:    "secret",
+			newPassword: "verifier",
+			kdf:         model.KDF{Version: model.KDFVersionSplitAuth, Algorithm: model.KDFSHA256},
+			want:        nil,
+		},
+		{
+			name:        "case 2",
+			password:    "",
+			newPassword: "verifier",
+			kdf:         model.KDF{Version: model.KDFVersionSplitAuth, Algorithm: model.KDFSHA256},
+			want:        model.ErrCredentialPasswordEmpity,
+		},
+		{
+			name:        "case 3",
+			password:    "secret",
+			newPassword: "",
+			kdf:         model.KDF{Version: model.KDFVersionSplitAuth, Algorithm: model.KDFSHA256},
+			want:        model.ErrCredentialPasswordEmpity,
+		},
+		{
+			name:        "case 4",
+			password:    "secret",
+			newPassword: "verifier",
+			kdf:         model.KDF{Version: model.KDFVersionSplitAuth, Algorithm: "md5"},
+			want:        model.ErrKDFUnknownAlgorithm,
+		},
+		{
+			name:        "case 5",
+			password:    "secret",
+			newPassword: "verifier",
+			kdf:         model.KDF{Version: model.KDFVersionSplitKeys, Algorithm: model.KDFSHA256},
+			vaultKey:    "",
+			want:        model.ErrKDFVaultKeyEmpity,
+		},
+		{
+			name:        "case 6",
+			password:    "secret",
+			newPassword: "verifier",
+			kdf:         model.KDF{Version: model.KDFVersionSplitKeys, Algorithm: model.KDFSHA256},
+			vaultKey:    "vault key",
+			want:        nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential := model.Credential{Password: tt.password, NewPassword: tt.newPassword, KDF: tt.kdf, VaultKey: tt.vaultKey}
+			assert.Equal(t, tt.want, credential.Validate())
+		})
+	}
+
+}
@@ -7,11 +7,14 @@ import (
 
 // File модель файла пользователя
 type File struct {
-	ID       int    `json:"id"`
-	UserID   int    `json:"-"`
-	Path     string `json:"path"`
-	Name     string `json:"name"`
-	MetaInfo string `json:"metainfo"`
+	ID       int     `json:"id"`
+	UserID   int     `json:"-"`
+	UID      string  `json:"uid"`
+	Path     string  `json:"path"`
+	Name     string  `json:"name"`
+	MetaInfo string  `json:"metainfo"`
+	Fields   []Field `json:"fields"`
+	Placement
 }
 
 var (
@@ -34,5 +37,9 @@ func (r *File) Validate() error {
 		return ErrFileMetainfoEmpity
 	}
 
-	return nil
+	if err := r.Placement.Validate(); err != nil {
+		return err
+	}
+
+	return ValidateFields(r.Fields)
 }
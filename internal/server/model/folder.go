@@ -0,0 +1,90 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+const placementMaxTags = 64
+
+// Folder модель папки записей пользователя сервера, название папки зашифровано клиентом
+type Folder struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"-"`
+	UID    string `json:"uid"`
+	Name   string `json:"name"`
+}
+
+// Tag модель метки записей пользователя сервера, название метки зашифровано клиентом
+type Tag struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"-"`
+	UID    string `json:"uid"`
+	Name   string `json:"name"`
+}
+
+// Placement размещение записи: папка FolderID, ноль — запись вне папок, и метки TagIDs
+type Placement struct {
+	FolderID int   `json:"folder_id"`
+	TagIDs   []int `json:"tag_ids"`
+}
+
+var (
+	ErrFolderNameEmpity   = errors.New("folder name empity")
+	ErrFolderUIDEmpity    = errors.New("folder uid empity")
+	ErrTagNameEmpity      = errors.New("tag name empity")
+	ErrTagUIDEmpity       = errors.New("tag uid empity")
+	ErrPlacementInvalid   = errors.New("invalid folder or tag id")
+	ErrPlacementTagsLimit = errors.New("too many tags")
+)
+
+// Validate проверяет корректность модели папки записей
+func (r *Folder) Validate() error {
+	if strings.TrimSpace(r.UID) == "" {
+		return ErrFolderUIDEmpity
+	}
+
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrFolderNameEmpity
+	}
+
+	return nil
+}
+
+// Validate проверяет корректность модели метки записей
+func (r *Tag) Validate() error {
+	if strings.TrimSpace(r.UID) == "" {
+		return ErrTagUIDEmpity
+	}
+
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrTagNameEmpity
+	}
+
+	return nil
+}
+
+// Validate проверяет корректность размещения записи, принадлежность папки и меток
+// пользователю проверяется при сохранении записи
+func (r *Placement) Validate() error {
+	if r.FolderID < 0 {
+		return ErrPlacementInvalid
+	}
+
+	if len(r.TagIDs) > placementMaxTags {
+		return ErrPlacementTagsLimit
+	}
+
+	for _, id := range r.TagIDs {
+		if id <= 0 {
+			return ErrPlacementInvalid
+		}
+	}
+
+	return nil
+}
+
+// ItemPlacement возвращает размещение записи
+func (r Placement) ItemPlacement() Placement {
+	return r
+}
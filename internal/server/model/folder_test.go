@@ -0,0 +1,108 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+func TestFolder(t *testing.T) {
+	tests := []struct {
+		name   string
+		folder model.Folder
+		want   error
+	}{
+		{
+			name:   "case 1",
+			folder: model.Folder{UID: "a1b2", Name: "c3d4"},
+			want:   nil,
+		},
+		{
+			name:   "case 2",
+			folder: model.Folder{UID: "", Name: "c3d4"},
+			want:   model.ErrFolderUIDEmpity,
+		},
+		{
+			name:   "case 3",
+			folder: model.Folder{UID: "a1b2", Name: " "},
+			want:   model.ErrFolderNameEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.folder.Validate())
+		})
+	}
+}
+
+func TestTag(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  model.Tag
+		want error
+	}{
+		{
+			name: "case 1",
+			tag:  model.Tag{UID: "a1b2", Name: "c3d4"},
+			want: nil,
+		},
+		{
+			name: "case 2",
+			tag:  model.Tag{UID: "", Name: "c3d4"},
+			want: model.ErrTagUIDEmpity,
+		},
+		{
+			name: "case 3",
+			tag:  model.Tag{UID: "a1b2", Name: ""},
+			want: model.ErrTagNameEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.tag.Validate())
+		})
+	}
+}
+
+func TestPlacement(t *testing.T) {
+	tests := []struct {
+		name      string
+		placement model.Placement
+		want      error
+	}{
+		{
+			name:      "case 1",
+			placement: model.Placement{},
+			want:      nil,
+		},
+		{
+			name:      "case 2",
+			placement: model.Placement{FolderID: 1, TagIDs: []int{1, 2}},
+			want:      nil,
+		},
+		{
+			name:      "case 3",
+			placement: model.Placement{FolderID: -1},
+			want:      model.ErrPlacementInvalid,
+		},
+		{
+			name:      "case 4",
+			placement: model.Placement{TagIDs: []int{1, 0}},
+			want:      model.ErrPlacementInvalid,
+		},
+		{
+			name:      "case 5",
+			placement: model.Placement{TagIDs: make([]int, 65)},
+			want:      model.ErrPlacementTagsLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.placement.Validate())
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Типы записей хранилища: записи типизированных данных хранятся в общем хранилище записей
+// и доступны только через свои endpoints, остальные типы — записи произвольной структуры
+const (
+	ItemTypeLogin  = "login"
+	ItemTypeCard   = "card"
+	ItemTypeFile   = "file"
+	ItemTypeNote   = "note"
+	ItemTypeOTP    = "otp"
+	ItemTypeSSHKey = "ssh_key"
+)
+
+// Типы дополнительных полей записей
+const (
+	FieldTypeText   = "text"
+	FieldTypeHidden = "hidden"
+	FieldTypeURL    = "url"
+	FieldTypeDate   = "date"
+)
+
+const (
+	itemMaxTypeLength = 32
+	itemMaxFields     = 64
+)
+
+// Item модель записи хранилища сервера произвольного типа: Data — содержимое записи,
+// зашифрованное клиентом целиком
+type Item struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"-"`
+	UID       string    `json:"uid"`
+	Type      string    `json:"type"`
+	Data      string    `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Placement
+}
+
+// Field модель дополнительного поля записи: название и значение зашифрованы клиентом
+type Field struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+var (
+	ErrItemTypeEmpity    = errors.New("item type empity")
+	ErrItemTypeInvalid   = errors.New("invalid item type")
+	ErrItemTypeReserved  = errors.New("item type is reserved for typed endpoints")
+	ErrItemDataEmpity    = errors.New("item data empity")
+	ErrFieldNameEmpity   = errors.New("field name empity")
+	ErrFieldValueEmpity  = errors.New("field value empity")
+	ErrFieldTypeInvalid  = errors.New("invalid field type")
+	ErrFieldsLimitExceed = errors.New("too many fields")
+)
+
+// IsTypedItem сообщает, что записи типа itemType доступны только через свои endpoints
+func IsTypedItem(itemType string) bool {
+	switch itemType {
+	case ItemTypeLogin, ItemTypeCard, ItemTypeFile, ItemTypeNote, ItemTypeOTP, ItemTypeSSHKey:
+		return true
+	default:
+		return false
+	}
+}
+
+// Validate проверяет корректность модели записи хранилища сервера
+func (r *Item) Validate() error {
+	if r.Type == "" {
+		return ErrItemTypeEmpity
+	}
+
+	if len(r.Type) > itemMaxTypeLength || strings.TrimFunc(r.Type, isItemTypeRune) != "" {
+		return ErrItemTypeInvalid
+	}
+
+	if IsTypedItem(r.Type) {
+		return ErrItemTypeReserved
+	}
+
+	if strings.TrimSpace(r.Data) == "" {
+		return ErrItemDataEmpity
+	}
+
+	return r.Placement.Validate()
+}
+
+// ValidateFields проверяет корректность дополнительных полей записи
+func ValidateFields(fields []Field) error {
+	if len(fields) > itemMaxFields {
+		return ErrFieldsLimitExceed
+	}
+
+	for _, field := range fields {
+		if strings.TrimSpace(field.Name) == "" {
+			return ErrFieldNameEmpity
+		}
+
+		if strings.TrimSpace(field.Value) == "" {
+			return ErrFieldValueEmpity
+		}
+
+		switch field.Type {
+		case FieldTypeText, FieldTypeHidden, FieldTypeURL, FieldTypeDate:
+		default:
+			return ErrFieldTypeInvalid
+		}
+	}
+
+	return nil
+}
+
+func isItemTypeRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_'
+}
@@ -0,0 +1,99 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+func TestItem(t *testing.T) {
+	tests := []struct {
+		name string
+		item model.Item
+		want error
+	}{
+		{
+			name: "case 1",
+			item: model.Item{Type: "custom", Data: "a1b2"},
+			want: nil,
+		},
+		{
+			name: "case 2",
+			item: model.Item{Type: "", Data: "a1b2"},
+			want: model.ErrItemTypeEmpity,
+		},
+		{
+			name: "case 3",
+			item: model.Item{Type: "Custom Item", Data: "a1b2"},
+			want: model.ErrItemTypeInvalid,
+		},
+		{
+			name: "case 4",
+			item: model.Item{Type: strings.Repeat("a", 33), Data: "a1b2"},
+			want: model.ErrItemTypeInvalid,
+		},
+		{
+			name: "case 5",
+			item: model.Item{Type: model.ItemTypeLogin, Data: "a1b2"},
+			want: model.ErrItemTypeReserved,
+		},
+		{
+			name: "case 6",
+			item: model.Item{Type: "custom", Data: " "},
+			want: model.ErrItemDataEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.item.Validate())
+		})
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []model.Field
+		want   error
+	}{
+		{
+			name:   "case 1",
+			fields: []model.Field{{Name: "a1", Type: model.FieldTypeText, Value: "b2"}, {Name: "c3", Type: model.FieldTypeDate, Value: "d4"}},
+			want:   nil,
+		},
+		{
+			name:   "case 2",
+			fields: nil,
+			want:   nil,
+		},
+		{
+			name:   "case 3",
+			fields: []model.Field{{Name: "", Type: model.FieldTypeText, Value: "b2"}},
+			want:   model.ErrFieldNameEmpity,
+		},
+		{
+			name:   "case 4",
+			fields: []model.Field{{Name: "a1", Type: model.FieldTypeHidden, Value: ""}},
+			want:   model.ErrFieldValueEmpity,
+		},
+		{
+			name:   "case 5",
+			fields: []model.Field{{Name: "a1", Type: "password", Value: "b2"}},
+			want:   model.ErrFieldTypeInvalid,
+		},
+		{
+			name:   "case 6",
+			fields: make([]model.Field, 65),
+			want:   model.ErrFieldsLimitExceed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, model.ValidateFields(tt.fields))
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Алгоритмы получения ключа шифрования из мастер-пароля
+const (
+	KDFSHA256   = "sha256"
+	KDFArgon2id = "argon2id"
+)
+
+// Версии схемы получения ключей из мастер-пароля:
+// KDFVersionLegacy — клиент передаёт мастер-пароль и шифрует данные ключом KDF,
+// KDFVersionSplitAuth — клиент передаёт только ключ аутентификации, данные шифруются ключом KDF,
+// KDFVersionSplitKeys — ключ аутентификации и ключ шифрования независимо получаются из ключа KDF
+const (
+	KDFVersionLegacy uint8 = iota
+	KDFVersionSplitAuth
+	KDFVersionSplitKeys
+)
+
+// Наибольшие параметры Argon2id: параметры, которые сервер возвращает клиенту до аутентификации,
+// не должны исчерпывать память и время клиента
+const (
+	maxKDFTime    = 10
+	maxKDFMemory  = 1024 * 1024 // КиБ
+	maxKDFThreads = 16
+)
+
+// KDF модель параметров получения ключа шифрования пользователя сервера
+type KDF struct {
+	Version   uint8  `json:"version"`
+	Algorithm string `json:"algorithm"`
+	Salt      string `json:"salt"`
+	Time      uint32 `json:"time"`
+	Memory    uint32 `json:"memory"`
+	Threads   uint8  `json:"threads"`
+}
+
+var (
+	ErrKDFUnknownVersion   = errors.New("unknown kdf version")
+	ErrKDFUnknownAlgorithm = errors.New("unknown kdf algorithm")
+	ErrKDFInvalidSalt      = errors.New("invalid kdf salt")
+	ErrKDFInvalidParams    = errors.New("invalid kdf parameters")
+	ErrKDFParamsTooLarge   = errors.New("kdf parameters are too large")
+	ErrKDFDowngrade        = errors.New("kdf version downgrade is not allowed")
+	ErrKDFVaultKeyEmpity   = errors.New("vault key empity")
+)
+
+// Validate проверяет корректность модели параметров получения ключа шифрования
+func (r *KDF) Validate() error {
+	if r.Version > KDFVersionSplitKeys {
+		return ErrKDFUnknownVersion
+	}
+
+	switch r.Algorithm {
+	case KDFSHA256:
+		return nil
+	case KDFArgon2id:
+		if salt, err := hex.DecodeString(r.Salt); err != nil || len(salt) == 0 {
+			return ErrKDFInvalidSalt
+		}
+
+		if r.Time == 0 || r.Memory == 0 || r.Threads == 0 {
+			return ErrKDFInvalidParams
+		}
+
+		if r.Time > maxKDFTime || r.Memory > maxKDFMemory || r.Threads > maxKDFThreads {
+			return ErrKDFParamsTooLarge
+		}
+
+		return nil
+	default:
+		return ErrKDFUnknownAlgorithm
+	}
+}
+
+// ValidateVaultKey проверяет, что для схемы KDFVersionSplitKeys передан зашифрованный ключ хранилища
+// vaultKey: данные такой учётной записи ключом из мастер-пароля не расшифровываются
+func (r *KDF) ValidateVaultKey(vaultKey string) error {
+	if r.Version == KDFVersionSplitKeys && strings.TrimSpace(vaultKey) == "" {
+		return ErrKDFVaultKeyEmpity
+	}
+
+	return nil
+}
+
+// ValidateTransition проверяет, что учётная запись со схемой version переходит на ту же или более новую схему
+func (r *KDF) ValidateTransition(version uint8) error {
+	if r.Version < version {
+		return ErrKDFDowngrade
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+func TestKDF(t *testing.T) {
+	tests := []struct {
+		name string
+		kdf  model.KDF
+		want error
+	}{
+		{
+			name: "case 1",
+			kdf:  model.KDF{Algorithm: model.KDFSHA256},
+			want: nil,
+		},
+		{
+			name: "case 2",
+			kdf:  model.KDF{Algorithm: model.KDFArgon2id, Salt: "00112233445566778899aabbccddeeff", Time: 3, Memory: 65536, Threads: 4},
+			want: nil,
+		},
+		{
+			name: "case 3",
+			kdf:  model.KDF{Algorithm: model.KDFArgon2id, Salt: "salt", Time: 3, Memory: 65536, Threads: 4},
+			want: model.ErrKDFInvalidSalt,
+		},
+		{
+			name: "case 4",
+			kdf:  model.KDF{Algorithm: model.KDFArgon2id, Salt: "00112233445566778899aabbccddeeff", Time: 0, Memory: 65536, Threads: 4},
+			want: model.ErrKDFInvalidParams,
+		},
+		{
+			name: "case 5",
+			kdf:  model.KDF{Algorithm: "md5"},
+			want: model.ErrKDFUnknownAlgorithm,
+		},
+		{
+			name: "case 6",
+			kdf:  model.KDF{Version: 3, Algorithm: model.KDFSHA256},
+			want: model.ErrKDFUnknownVersion,
+		},
+		{
+			name: "case 7",
+			kdf:  model.KDF{Algorithm: model.KDFArgon2id, Salt: "00112233445566778899aabbccddeeff", Time: 3, Memory: 4 * 1024 * 1024, Threads: 4},
+			want: model.ErrKDFParamsTooLarge,
+		},
+		{
+			name: "case 8",
+			kdf:  model.KDF{Algorithm: model.KDFArgon2id, Salt: "00112233445566778899aabbccddeeff", Time: 1000, Memory: 65536, Threads: 4},
+			want: model.ErrKDFParamsTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.kdf.Validate())
+		})
+	}
+
+}
+
+func TestKDFTransition(t *testing.T) {
+	tests := []struct {
+		name     string
+		kdf      model.KDF
+		version  uint8
+		vaultKey string
+		want     error
+	}{
+		{
+			name:     "case 1",
+			kdf:      model.KDF{Version: model.KDFVersionSplitKeys, Algorithm: model.KDFSHA256},
+			version:  model.KDFVersionLegacy,
+			vaultKey: "vault key",
+			want:     nil,
+		},
+		{
+			name:     "case 2",
+			kdf:      model.KDF{Version: model.KDFVersionSplitAuth, Algorithm: model.KDFSHA256},
+			version:  model.KDFVersionSplitKeys,
+			vaultKey: "",
+			want:     model.ErrKDFDowngrade,
+		},
+		{
+			name:     "case 3",
+			kdf:      model.KDF{Version: model.KDFVersionSplitKeys, Algorithm: model.KDFSHA256},
+			version:  model.KDFVersionSplitKeys,
+			vaultKey: " ",
+			want:     model.ErrKDFVaultKeyEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.kdf.ValidateTransition(tt.version)
+			if err == nil {
+				err = tt.kdf.ValidateVaultKey(tt.vaultKey)
+			}
+			assert.Equal(t, tt.want, err)
+		})
+	}
+}
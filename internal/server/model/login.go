@@ -7,11 +7,14 @@ import (
 
 // Login модель логина сервера
 type Login struct {
-	ID       int    `json:"id"`
-	UserID   int    `json:"-"`
-	Username string `json:"username"`
-	Password string `json:"password"`
-	MetaInfo string `json:"metainfo"`
+	ID       int     `json:"id"`
+	UserID   int     `json:"-"`
+	UID      string  `json:"uid"`
+	Username string  `json:"username"`
+	Password string  `json:"password"`
+	MetaInfo string  `json:"metainfo"`
+	Fields   []Field `json:"fields"`
+	Placement
 }
 
 var (
@@ -29,5 +32,9 @@ func (r *Login) Validate() error {
 		return ErrLoginPasswordEmpity
 	}
 
-	return nil
+	if err := r.Placement.Validate(); err != nil {
+		return err
+	}
+
+	return ValidateFields(r.Fields)
 }
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// Note модель заметки сервера
+type Note struct {
+	ID     int     `json:"id"`
+	UserID int     `json:"-"`
+	UID    string  `json:"uid"`
+	Title  string  `json:"title"`
+	Text   string  `json:"text"`
+	Fields []Field `json:"fields"`
+	Placement
+}
+
+var (
+	ErrNoteTitleEmpity = errors.New("title empity")
+	ErrNoteTextEmpity  = errors.New("text empity")
+)
+
+// Validate проверяет корректность модели заметки сервера
+func (r *Note) Validate() error {
+	if strings.TrimSpace(r.Title) == "" {
+		return ErrNoteTitleEmpity
+	}
+
+	if strings.TrimSpace(r.Text) == "" {
+		return ErrNoteTextEmpity
+	}
+
+	if err := r.Placement.Validate(); err != nil {
+		return err
+	}
+
+	return ValidateFields(r.Fields)
+}
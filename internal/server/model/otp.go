@@ -0,0 +1,75 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// Алгоритмы HMAC одноразовых паролей
+const (
+	OTPAlgorithmSHA1   = "SHA1"
+	OTPAlgorithmSHA256 = "SHA256"
+	OTPAlgorithmSHA512 = "SHA512"
+)
+
+// Допустимые параметры одноразовых паролей
+const (
+	otpMinDigits = 6
+	otpMaxDigits = 8
+	otpMaxPeriod = 3600
+)
+
+// OTP модель ключа одноразовых паролей сервера: название и секрет зашифрованы клиентом,
+// ключ может быть привязан к логину пользователя LoginID
+type OTP struct {
+	ID        int     `json:"id"`
+	UserID    int     `json:"-"`
+	UID       string  `json:"uid"`
+	LoginID   int     `json:"login_id"`
+	Name      string  `json:"name"`
+	Secret    string  `json:"secret"`
+	Algorithm string  `json:"algorithm"`
+	Digits    int     `json:"digits"`
+	Period    int     `json:"period"`
+	Fields    []Field `json:"fields"`
+	Placement
+}
+
+var (
+	ErrOTPNameEmpity       = errors.New("name empity")
+	ErrOTPSecretEmpity     = errors.New("secret empity")
+	ErrOTPInvalidAlgorithm = errors.New("invalid otp algorithm")
+	ErrOTPInvalidDigits    = errors.New("invalid otp digits")
+	ErrOTPInvalidPeriod    = errors.New("invalid otp period")
+)
+
+// Validate проверяет корректность модели ключа одноразовых паролей сервера
+func (r *OTP) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrOTPNameEmpity
+	}
+
+	if strings.TrimSpace(r.Secret) == "" {
+		return ErrOTPSecretEmpity
+	}
+
+	switch r.Algorithm {
+	case OTPAlgorithmSHA1, OTPAlgorithmSHA256, OTPAlgorithmSHA512:
+	default:
+		return ErrOTPInvalidAlgorithm
+	}
+
+	if r.Digits < otpMinDigits || r.Digits > otpMaxDigits {
+		return ErrOTPInvalidDigits
+	}
+
+	if r.Period <= 0 || r.Period > otpMaxPeriod {
+		return ErrOTPInvalidPeriod
+	}
+
+	if err := r.Placement.Validate(); err != nil {
+		return err
+	}
+
+	return ValidateFields(r.Fields)
+}
@@ -0,0 +1,53 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+func TestOTP(t *testing.T) {
+	tests := []struct {
+		name string
+		otp  model.OTP
+		want error
+	}{
+		{
+			name: "case 1",
+			otp:  model.OTP{Name: "a1b2", Secret: "c3d4", Algorithm: model.OTPAlgorithmSHA1, Digits: 6, Period: 30},
+			want: nil,
+		},
+		{
+			name: "case 2",
+			otp:  model.OTP{Name: "", Secret: "c3d4", Algorithm: model.OTPAlgorithmSHA1, Digits: 6, Period: 30},
+			want: model.ErrOTPNameEmpity,
+		},
+		{
+			name: "case 3",
+			otp:  model.OTP{Name: "a1b2", Secret: "", Algorithm: model.OTPAlgorithmSHA1, Digits: 6, Period: 30},
+			want: model.ErrOTPSecretEmpity,
+		},
+		{
+			name: "case 4",
+			otp:  model.OTP{Name: "a1b2", Secret: "c3d4", Algorithm: "MD5", Digits: 6, Period: 30},
+			want: model.ErrOTPInvalidAlgorithm,
+		},
+		{
+			name: "case 5",
+			otp:  model.OTP{Name: "a1b2", Secret: "c3d4", Algorithm: model.OTPAlgorithmSHA512, Digits: 9, Period: 30},
+			want: model.ErrOTPInvalidDigits,
+		},
+		{
+			name: "case 6",
+			otp:  model.OTP{Name: "a1b2", Secret: "c3d4", Algorithm: model.OTPAlgorithmSHA256, Digits: 8, Period: 0},
+			want: model.ErrOTPInvalidPeriod,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.otp.Validate())
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrPasswordChangeFileEmpity = errors.New("file name and/or metainfo empity")
+
+// PasswordChange модель смены мастер-пароля пользователя сервера: новые учётные данные
+// и все данные пользователя, перешифрованные новым ключом. Перешифрованное содержимое
+// файлов загружается заранее и подменяется вместе с остальными данными.
+// При замене ключа шифрования хранилища ключи восстановления заменяются RecoveryKeys
+type PasswordChange struct {
+	Credential
+	Logins  []Login  `json:"logins"`
+	Cards   []Card   `json:"cards"`
+	Files   []File   `json:"files"`
+	Notes   []Note   `json:"notes"`
+	OTPs    []OTP    `json:"otps"`
+	SSHKeys []SSHKey `json:"ssh_keys"`
+	Items   []Item   `json:"items"`
+	Folders []Folder `json:"folders"`
+	Tags    []Tag    `json:"tags"`
+
+	RecoveryKeys []RecoveryKey `json:"recovery_keys"`
+
+	Revision int64 `json:"revision"` // ревизия хранилища, на которой клиент прочитал данные
+}
+
+// Validate проверяет корректность модели смены мастер-пароля
+func (r *PasswordChange) Validate() error {
+	if err := r.Credential.Validate(); err != nil {
+		return err
+	}
+
+	for i := range r.Logins {
+		if err := r.Logins[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	for i := range r.Cards {
+		if err := r.Cards[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	for i := range r.Notes {
+		if err := r.Notes[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	for i := range r.OTPs {
+		if err := r.OTPs[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	for i := range r.SSHKeys {
+		if err := r.SSHKeys[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	for i := range r.Items {
+		if err := r.Items[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	for i := range r.Folders {
+		if err := r.Folders[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	for i := range r.Tags {
+		if err := r.Tags[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := ValidateRecoveryKeys(r.RecoveryKeys); err != nil {
+		return err
+	}
+
+	for i := range r.Files {
+		if strings.TrimSpace(r.Files[i].Name) == "" || strings.TrimSpace(r.Files[i].MetaInfo) == "" {
+			return ErrPasswordChangeFileEmpity
+		}
+
+		if err := r.Files[i].Placement.Validate(); err != nil {
+			return err
+		}
+
+		if err := ValidateFields(r.Files[i].Fields); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+func TestPasswordChange(t *testing.T) {
+	credential := model.Credential{
+		Password:    "secret",
+		NewPassword: "verifier",
+		KDF:         model.KDF{Version: model.KDFVersionSplitKeys, Algorithm: model.KDFSHA256},
+		VaultKey:    "vault key",
+	}
+
+	tests := []struct {
+		name   string
+		change model.PasswordChange
+		want   error
+	}{
+		{
+			name: "case 1",
+			change: model.PasswordChange{
+				Credential: credential,
+				Logins:     []model.Login{{ID: 1, Username: "mark", Password: "secret"}},
+				Files:      []model.File{{ID: 1, Name: "test.pdf", MetaInfo: "this is test file"}},
+			},
+			want: nil,
+		},
+		{
+			name: "case 2",
+			change: model.PasswordChange{
+				Credential: model.Credential{Password: "secret", KDF: credential.KDF},
+			},
+			want: model.ErrCredentialPasswordEmpity,
+		},
+		{
+			name: "case 3",
+			change: model.PasswordChange{
+				Credential: credential,
+				Logins:     []model.Login{{ID: 1, Username: "mark", Password: ""}},
+			},
+			want: model.ErrLoginPasswordEmpity,
+		},
+		{
+			name: "case 4",
+			change: model.PasswordChange{
+				Credential: credential,
+				Files:      []model.File{{ID: 1, Name: "", MetaInfo: "this is test file"}},
+			},
+			want: model.ErrPasswordChangeFileEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.change.Validate())
+		})
+	}
+
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RecoveryKey модель ключа восстановления пользователя сервера: проверочное значение
+// кода восстановления и ключ шифрования хранилища, зашифрованный кодом восстановления
+type RecoveryKey struct {
+	Verifier string `json:"verifier"`
+	VaultKey string `json:"vault_key"`
+}
+
+// RecoveryKeysChange модель замены ключей восстановления пользователя сервера,
+// текущий пароль Password подтверждает замену
+type RecoveryKeysChange struct {
+	Password     string        `json:"password"`
+	RecoveryKeys []RecoveryKey `json:"recovery_keys"`
+}
+
+// Recovery модель восстановления доступа пользователя сервера по коду восстановления
+type Recovery struct {
+	Username    string `json:"username"`
+	Verifier    string `json:"verifier"`
+	NewPassword string `json:"new_password"`
+	VaultKey    string `json:"vault_key"`
+	KDF         KDF    `json:"kdf"`
+}
+
+// RecoveryResponse модель ответа сервера с ключом шифрования хранилища,
+// зашифрованным кодом восстановления
+type RecoveryResponse struct {
+	VaultKey string `json:"vault_key"`
+}
+
+const maxRecoveryKeys = 16
+
+var (
+	ErrRecoveryKeyEmpity        = errors.New("recovery verifier and/or vault key empity")
+	ErrRecoveryTooManyKeys      = fmt.Errorf("number of recovery keys is more than %d", maxRecoveryKeys)
+	ErrRecoveryUsernameEmpity   = errors.New("username and/or recovery verifier empity")
+	ErrRecoveryCredentialEmpity = errors.New("new password and/or vault key empity")
+	ErrRecoveryPasswordEmpity   = errors.New("password empity")
+)
+
+// Validate проверяет корректность модели ключа восстановления
+func (r *RecoveryKey) Validate() error {
+	if strings.TrimSpace(r.Verifier) == "" || strings.TrimSpace(r.VaultKey) == "" {
+		return ErrRecoveryKeyEmpity
+	}
+
+	return nil
+}
+
+// ValidateRecoveryKeys проверяет корректность набора ключей восстановления
+func ValidateRecoveryKeys(keys []RecoveryKey) error {
+	if len(keys) > maxRecoveryKeys {
+		return ErrRecoveryTooManyKeys
+	}
+
+	for i := range keys {
+		if err := keys[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Validate проверяет корректность модели замены ключей восстановления
+func (r *RecoveryKeysChange) Validate() error {
+	if strings.TrimSpace(r.Password) == "" {
+		return ErrRecoveryPasswordEmpity
+	}
+
+	return ValidateRecoveryKeys(r.RecoveryKeys)
+}
+
+// ValidateRequest проверяет корректность запроса ключа шифрования хранилища по коду восстановления
+func (r *Recovery) ValidateRequest() error {
+	if strings.TrimSpace(r.Username) == "" || strings.TrimSpace(r.Verifier) == "" {
+		return ErrRecoveryUsernameEmpity
+	}
+
+	return nil
+}
+
+// Validate проверяет корректность модели восстановления доступа
+func (r *Recovery) Validate() error {
+	if err := r.ValidateRequest(); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(r.NewPassword) == "" || strings.TrimSpace(r.VaultKey) == "" {
+		return ErrRecoveryCredentialEmpity
+	}
+
+	return r.KDF.Validate()
+}
@@ -0,0 +1,105 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+func TestRecovery(t *testing.T) {
+	kdf := model.KDF{Version: model.KDFVersionSplitKeys, Algorithm: model.KDFSHA256}
+
+	tests := []struct {
+		name     string
+		recovery model.Recovery
+		want     error
+	}{
+		{
+			name:     "case 1",
+			recovery: model.Recovery{Username: "mark", Verifier: "verifier", NewPassword: "secret", VaultKey: "key", KDF: kdf},
+			want:     nil,
+		},
+		{
+			name:     "case 2",
+			recovery: model.Recovery{Username: "", Verifier: "verifier", NewPassword: "secret", VaultKey: "key", KDF: kdf},
+			want:     model.ErrRecoveryUsernameEmpity,
+		},
+		{
+			name:     "case 3",
+			recovery: model.Recovery{Username: "mark", Verifier: "verifier", NewPassword: "secret", VaultKey: "", KDF: kdf},
+			want:     model.ErrRecoveryCredentialEmpity,
+		},
+		{
+			name:     "case 4",
+			recovery: model.Recovery{Username: "mark", Verifier: "verifier", NewPassword: "secret", VaultKey: "key", KDF: model.KDF{Algorithm: "md5"}},
+			want:     model.ErrKDFUnknownAlgorithm,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.recovery.Validate())
+		})
+	}
+}
+
+func TestRecoveryKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []model.RecoveryKey
+		want error
+	}{
+		{
+			name: "case 1",
+			keys: []model.RecoveryKey{{Verifier: "verifier", VaultKey: "key"}},
+			want: nil,
+		},
+		{
+			name: "case 2",
+			keys: []model.RecoveryKey{{Verifier: "verifier", VaultKey: "key"}, {Verifier: "", VaultKey: "key"}},
+			want: model.ErrRecoveryKeyEmpity,
+		},
+		{
+			name: "case 3",
+			keys: make([]model.RecoveryKey, 17),
+			want: model.ErrRecoveryTooManyKeys,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, model.ValidateRecoveryKeys(tt.keys))
+		})
+	}
+}
+
+func TestRecoveryKeysChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		change model.RecoveryKeysChange
+		want   error
+	}{
+		{
+			name:   "case 1",
+			change: model.RecoveryKeysChange{Password: "secret", RecoveryKeys: []model.RecoveryKey{{Verifier: "verifier", VaultKey: "key"}}},
+			want:   nil,
+		},
+		{
+			name:   "case 2",
+			change: model.RecoveryKeysChange{Password: " ", RecoveryKeys: []model.RecoveryKey{{Verifier: "verifier", VaultKey: "key"}}},
+			want:   model.ErrRecoveryPasswordEmpity,
+		},
+		{
+			name:   "case 3",
+			change: model.RecoveryKeysChange{Password: "secret", RecoveryKeys: []model.RecoveryKey{{Verifier: "", VaultKey: "key"}}},
+			want:   model.ErrRecoveryKeyEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.change.Validate())
+		})
+	}
+}
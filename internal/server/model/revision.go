@@ -0,0 +1,41 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const revisionMaxDeviceLength = 64
+
+// Revision модель предыдущей редакции записи хранилища сервера: данные Data и размещение записи
+// в том виде, в котором их сохранило устройство Device в момент CreatedAt
+type Revision struct {
+	ID        int             `json:"id"`
+	ItemID    int             `json:"item_id"`
+	UID       string          `json:"uid"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data" swaggertype:"object"`
+	Device    string          `json:"device"`
+	CreatedAt time.Time       `json:"created_at"`
+	Placement
+}
+
+// DeviceName возвращает название устройства, сохраняющего запись, без управляющих символов
+// и не длиннее допустимого
+func DeviceName(name string) string {
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+
+		return r
+	}, name))
+
+	if runes := []rune(name); len(runes) > revisionMaxDeviceLength {
+		name = string(runes[:revisionMaxDeviceLength])
+	}
+
+	return name
+}
@@ -0,0 +1,44 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+func TestDeviceName(t *testing.T) {
+	tests := []struct {
+		name   string
+		device string
+		want   string
+	}{
+		{
+			name:   "case 1",
+			device: "laptop",
+			want:   "laptop",
+		},
+		{
+			name:   "case 2",
+			device: " work\r\npc\t",
+			want:   "workpc",
+		},
+		{
+			name:   "case 3",
+			device: strings.Repeat("ж", 70),
+			want:   strings.Repeat("ж", 64),
+		},
+		{
+			name:   "case 4",
+			device: "",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, model.DeviceName(tt.device))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// SSHKey модель ключа SSH сервера, поля ключа зашифрованы клиентом. Confirm — использование
+// ключа агентом SSH клиента требует подтверждения пользователя
+type SSHKey struct {
+	ID          int     `json:"id"`
+	UserID      int     `json:"-"`
+	UID         string  `json:"uid"`
+	PrivateKey  string  `json:"private_key"`
+	PublicKey   string  `json:"public_key"`
+	Comment     string  `json:"comment"`
+	Fingerprint string  `json:"fingerprint"`
+	Confirm     bool    `json:"confirm"`
+	Fields      []Field `json:"fields"`
+	Placement
+}
+
+var (
+	ErrSSHKeyPrivateKeyEmpity  = errors.New("private key empity")
+	ErrSSHKeyPublicKeyEmpity   = errors.New("public key empity")
+	ErrSSHKeyCommentEmpity     = errors.New("comment empity")
+	ErrSSHKeyFingerprintEmpity = errors.New("fingerprint empity")
+)
+
+// Validate проверяет корректность модели ключа SSH сервера
+func (r *SSHKey) Validate() error {
+	if strings.TrimSpace(r.PrivateKey) == "" {
+		return ErrSSHKeyPrivateKeyEmpity
+	}
+
+	if strings.TrimSpace(r.PublicKey) == "" {
+		return ErrSSHKeyPublicKeyEmpity
+	}
+
+	if strings.TrimSpace(r.Comment) == "" {
+		return ErrSSHKeyCommentEmpity
+	}
+
+	if strings.TrimSpace(r.Fingerprint) == "" {
+		return ErrSSHKeyFingerprintEmpity
+	}
+
+	if err := r.Placement.Validate(); err != nil {
+		return err
+	}
+
+	return ValidateFields(r.Fields)
+}
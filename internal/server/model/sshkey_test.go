@@ -0,0 +1,48 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+func TestSSHKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  model.SSHKey
+		want error
+	}{
+		{
+			name: "case 1",
+			key:  model.SSHKey{PrivateKey: "a1", PublicKey: "b2", Comment: "c3", Fingerprint: "d4"},
+			want: nil,
+		},
+		{
+			name: "case 2",
+			key:  model.SSHKey{PrivateKey: "", PublicKey: "b2", Comment: "c3", Fingerprint: "d4"},
+			want: model.ErrSSHKeyPrivateKeyEmpity,
+		},
+		{
+			name: "case 3",
+			key:  model.SSHKey{PrivateKey: "a1", PublicKey: "", Comment: "c3", Fingerprint: "d4"},
+			want: model.ErrSSHKeyPublicKeyEmpity,
+		},
+		{
+			name: "case 4",
+			key:  model.SSHKey{PrivateKey: "a1", PublicKey: "b2", Comment: "", Fingerprint: "d4"},
+			want: model.ErrSSHKeyCommentEmpity,
+		},
+		{
+			name: "case 5",
+			key:  model.SSHKey{PrivateKey: "a1", PublicKey: "b2", Comment: "c3", Fingerprint: ""},
+			want: model.ErrSSHKeyFingerprintEmpity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.key.Validate())
+		})
+	}
+}
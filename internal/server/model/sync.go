@@ -0,0 +1,24 @@
+package model
+
+import "encoding/json"
+
+// SyncItem модель записи хранилища сервера любого типа, изменённой после ревизии синхронизации:
+// данные Data зашифрованы клиентом так же, как данные записи, путь к содержимому файла не возвращается.
+// Удалённая запись Deleted возвращается без данных
+type SyncItem struct {
+	ID      int             `json:"id"`
+	UID     string          `json:"uid,omitempty"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+	Deleted bool            `json:"deleted,omitempty"`
+	Placement
+}
+
+// Sync модель изменений хранилища пользователя: Revision — текущая ревизия синхронизации,
+// Items — записи, изменённые после запрошенной ревизии. Если Reset, Items содержит все записи
+// хранилища и заменяет локальное представление клиента целиком
+type Sync struct {
+	Revision int64      `json:"revision"`
+	Reset    bool       `json:"reset,omitempty"`
+	Items    []SyncItem `json:"items"`
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TOTP модель состояния двухфакторной аутентификации пользователя сервера: действующий секрет,
+// секрет, ожидающий подтверждения, и номер последнего использованного интервала одноразового пароля
+type TOTP struct {
+	Username string
+	Secret   string
+	Pending  string
+	LastStep int64
+}
+
+// TOTPEnrollment модель ответа сервера с секретом подключаемой двухфакторной аутентификации
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TOTPCode модель запроса с одноразовым паролем или резервным кодом
+type TOTPCode struct {
+	Code string `json:"code"`
+}
+
+// TOTPSignIn модель второго шага аутентификации пользователя
+type TOTPSignIn struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// TOTPBackupCodes модель ответа сервера с резервными кодами двухфакторной аутентификации
+type TOTPBackupCodes struct {
+	BackupCodes []string `json:"backup_codes"`
+}
+
+const maxTOTPCodeLength = 32
+
+var (
+	ErrTOTPCodeEmpity     = errors.New("authentication code empity")
+	ErrTOTPLongCode       = fmt.Errorf("authentication code length is more than %d characters", maxTOTPCodeLength)
+	ErrTOTPMFATokenEmpity = errors.New("mfa token empity")
+)
+
+// Enabled сообщает, подключена ли двухфакторная аутентификация
+func (r *TOTP) Enabled() bool {
+	return r.Secret != ""
+}
+
+// Validate проверяет корректность модели одноразового пароля
+func (r *TOTPCode) Validate() error {
+	if strings.TrimSpace(r.Code) == "" {
+		return ErrTOTPCodeEmpity
+	}
+
+	if len(r.Code) > maxTOTPCodeLength {
+		return ErrTOTPLongCode
+	}
+
+	return nil
+}
+
+// Validate проверяет корректность модели второго шага аутентификации
+func (r *TOTPSignIn) Validate() error {
+	if strings.TrimSpace(r.MFAToken) == "" {
+		return ErrTOTPMFATokenEmpity
+	}
+
+	return (&TOTPCode{Code: r.Code}).Validate()
+}
+
+// NormalizeBackupCode приводит резервный код к виду, в котором хранится его хеш:
+// регистр, пробелы и дефисы не учитываются
+func NormalizeBackupCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+
+		return r
+	}, strings.ToLower(code))
+}
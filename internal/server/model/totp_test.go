@@ -0,0 +1,48 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+func TestTOTPSignIn(t *testing.T) {
+	tests := []struct {
+		name   string
+		signIn model.TOTPSignIn
+		want   error
+	}{
+		{
+			name:   "case 1",
+			signIn: model.TOTPSignIn{MFAToken: "token", Code: "123456"},
+			want:   nil,
+		},
+		{
+			name:   "case 2",
+			signIn: model.TOTPSignIn{MFAToken: "", Code: "123456"},
+			want:   model.ErrTOTPMFATokenEmpity,
+		},
+		{
+			name:   "case 3",
+			signIn: model.TOTPSignIn{MFAToken: "token", Code: " "},
+			want:   model.ErrTOTPCodeEmpity,
+		},
+		{
+			name:   "case 4",
+			signIn: model.TOTPSignIn{MFAToken: "token", Code: strings.Repeat("1", 33)},
+			want:   model.ErrTOTPLongCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.signIn.Validate())
+		})
+	}
+}
+
+func TestNormalizeBackupCode(t *testing.T) {
+	assert.Equal(t, "abcd2345", model.NormalizeBackupCode(" ABCD-2345 "))
+}
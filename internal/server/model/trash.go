@@ -0,0 +1,17 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TrashItem модель записи хранилища сервера любого типа в корзине: данные Data зашифрованы клиентом
+// так же, как данные записи, путь к содержимому файла не возвращается
+type TrashItem struct {
+	ID        int             `json:"id"`
+	UID       string          `json:"uid"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data" swaggertype:"object"`
+	DeletedAt time.Time       `json:"deleted_at"`
+	Placement
+}
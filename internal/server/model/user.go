@@ -8,9 +8,25 @@ import (
 
 // User модель пользователя сервера
 type User struct {
-	ID       int `json:"-"`
-	Username string
-	Password string
+	ID           int `json:"-"`
+	Username     string
+	Password     string
+	KDF          KDF           `json:"kdf"`
+	VaultKey     string        `json:"vault_key"`
+	RecoveryKeys []RecoveryKey `json:"recovery_keys"`
+}
+
+// SignInResponse модель ответа сервера при аутентификации пользователя, если у пользователя подключена
+// двухфакторная аутентификация, возвращается только токен второго шага MFAToken
+type SignInResponse struct {
+	KDF      KDF    `json:"kdf"`
+	VaultKey string `json:"vault_key"`
+	MFAToken string `json:"mfa_token,omitempty"`
+}
+
+// PreLoginResponse модель ответа сервера с параметрами получения ключей до аутентификации
+type PreLoginResponse struct {
+	KDF KDF `json:"kdf"`
 }
 
 const maxUserUsernameLegth = 64
@@ -30,5 +46,5 @@ func (r *User) Validate() error {
 		return ErrUserLongUsername
 	}
 
-	return nil
+	return ValidateRecoveryKeys(r.RecoveryKeys)
 }
@@ -0,0 +1,228 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// UserURIPrefix префикс URI в расширении SAN клиентского сертификата, которым сертификат
+// привязывается к пользователю: urn:gophkeeper:user:<имя пользователя>
+const UserURIPrefix = "urn:gophkeeper:user:"
+
+const organization = "GophKeeper"
+
+var (
+	ErrPKINoCertificates = errors.New("no certificates found")
+	ErrPKIInvalidKey     = errors.New("invalid private key")
+	ErrPKINotCA          = errors.New("certificate is not a CA")
+	ErrPKINoHosts        = errors.New("no server hosts")
+)
+
+// NewCA возвращает сертификат и ключ удостоверяющего центра с именем commonName
+func NewCA(commonName string, validity time.Duration) (cert *x509.Certificate, key crypto.Signer, err error) {
+	template, err := newTemplate(commonName, validity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	return issue(template, nil, nil)
+}
+
+// IssueServer выпускает сертификат сервера для имён и IP адресов hosts, подписанный удостоверяющим центром ca
+func IssueServer(ca *x509.Certificate, caKey crypto.Signer, hosts []string, validity time.Duration) (cert *x509.Certificate, key crypto.Signer, err error) {
+	if len(hosts) == 0 {
+		return nil, nil, ErrPKINoHosts
+	}
+
+	template, err := newTemplate(hosts[0], validity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	return issue(template, ca, caKey)
+}
+
+// IssueClient выпускает сертификат устройства name, подписанный удостоверяющим центром ca.
+// Если задано имя пользователя username, сертификат принимается сервером только для этого пользователя
+func IssueClient(ca *x509.Certificate, caKey crypto.Signer, name, username string, validity time.Duration) (cert *x509.Certificate, key crypto.Signer, err error) {
+	template, err := newTemplate(name, validity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if username != "" {
+		template.URIs = []*url.URL{{Scheme: "urn", Opaque: strings.TrimPrefix(UserURIPrefix, "urn:") + url.PathEscape(username)}}
+	}
+
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	return issue(template, ca, caKey)
+}
+
+// CertificateUser возвращает имя пользователя, к которому привязан клиентский сертификат cert
+func CertificateUser(cert *x509.Certificate) (username string, ok bool) {
+	for _, uri := range cert.URIs {
+		if value := uri.String(); strings.HasPrefix(value, UserURIPrefix) {
+			username, err := url.PathUnescape(strings.TrimPrefix(value, UserURIPrefix))
+			if err != nil {
+				return "", false
+			}
+
+			return username, true
+		}
+	}
+
+	return "", false
+}
+
+// SPKIPin возвращает отпечаток открытого ключа сертификата sha256/<base64>, которым клиент закрепляет сервер
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// EncodeCertificate возвращает сертификат в формате PEM
+func EncodeCertificate(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+// EncodeKey возвращает закрытый ключ в формате PEM PKCS #8
+func EncodeKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// LoadCA загружает сертификат и ключ удостоверяющего центра из файлов PEM
+func LoadCA(certFile, keyFile string) (cert *x509.Certificate, key crypto.Signer, err error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err = x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !cert.IsCA {
+		return nil, nil, ErrPKINotCA
+	}
+
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, ErrPKIInvalidKey
+	}
+
+	return cert, key, nil
+}
+
+// CertPool возвращает набор сертификатов из файла PEM file
+func CertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, ErrPKINoCertificates
+	}
+
+	return pool, nil
+}
+
+// ServerTLSConfig возвращает конфигурацию TLS сервера, который требует клиентский сертификат,
+// выданный удостоверяющим центром из файла clientCAFile
+func ServerTLSConfig(clientCAFile string) (*tls.Config, error) {
+	pool, err := CertPool(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+func newTemplate(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	return &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{organization},
+			CommonName:   commonName,
+		},
+		NotBefore: now.Add(-time.Hour),
+		NotAfter:  now.Add(validity),
+	}, nil
+}
+
+// issue создаёт ключ ECDSA P-256 и сертификат по шаблону template, подписанный ключом parentKey,
+// без parent сертификат подписывается собственным ключом
+func issue(template, parent *x509.Certificate, parentKey crypto.Signer) (cert *x509.Certificate, key crypto.Signer, err error) {
+	key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, key, nil
+}
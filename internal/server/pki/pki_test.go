@@ -0,0 +1,149 @@
+package pki_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vukit/gophkeeper/internal/server/pki"
+)
+
+func TestIssue(t *testing.T) {
+	ca, caKey, err := pki.NewCA("GophKeeper CA", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, ca.IsCA)
+
+	otherCA, otherCAKey, err := pki.NewCA("Other CA", time.Hour)
+	require.NoError(t, err)
+
+	server, _, err := pki.IssueServer(ca, caKey, []string{"localhost", "127.0.0.1"}, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"localhost"}, server.DNSNames)
+	assert.Len(t, server.IPAddresses, 1)
+
+	_, _, err = pki.IssueServer(ca, caKey, nil, time.Hour)
+	assert.ErrorIs(t, err, pki.ErrPKINoHosts)
+
+	client, _, err := pki.IssueClient(ca, caKey, "laptop", "user name", time.Hour)
+	require.NoError(t, err)
+
+	username, ok := pki.CertificateUser(client)
+	assert.True(t, ok)
+	assert.Equal(t, "user name", username)
+
+	device, _, err := pki.IssueClient(ca, caKey, "ci", "", time.Hour)
+	require.NoError(t, err)
+
+	_, ok = pki.CertificateUser(device)
+	assert.False(t, ok)
+
+	foreign, _, err := pki.IssueClient(otherCA, otherCAKey, "laptop", "", time.Hour)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	tests := []struct {
+		name    string
+		cert    *x509.Certificate
+		usage   x509.ExtKeyUsage
+		wantErr bool
+	}{
+		{
+			name:  "case 1",
+			cert:  server,
+			usage: x509.ExtKeyUsageServerAuth,
+		},
+		{
+			name:  "case 2",
+			cert:  client,
+			usage: x509.ExtKeyUsageClientAuth,
+		},
+		{
+			name:    "case 3",
+			cert:    client,
+			usage:   x509.ExtKeyUsageServerAuth,
+			wantErr: true,
+		},
+		{
+			name:    "case 4",
+			cert:    foreign,
+			usage:   x509.ExtKeyUsageClientAuth,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{tt.usage}})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+
+	ca, caKey, err := pki.NewCA("GophKeeper CA", time.Hour)
+	require.NoError(t, err)
+
+	caFile := filepath.Join(dir, "ca.pem")
+	caKeyFile := filepath.Join(dir, "ca-key.pem")
+
+	caKeyPEM, err := pki.EncodeKey(caKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(caFile, pki.EncodeCertificate(ca), 0o600))
+	require.NoError(t, os.WriteFile(caKeyFile, caKeyPEM, 0o600))
+
+	ca, caKey, err = pki.LoadCA(caFile, caKeyFile)
+	require.NoError(t, err)
+
+	serverCert, serverKey, err := pki.IssueServer(ca, caKey, []string{"127.0.0.1"}, time.Hour)
+	require.NoError(t, err)
+
+	clientCert, clientKey, err := pki.IssueClient(ca, caKey, "laptop", "user", time.Hour)
+	require.NoError(t, err)
+
+	tlsConfig, err := pki.ServerTLSConfig(caFile)
+	require.NoError(t, err)
+
+	tlsConfig.Certificates = []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, _ := pki.CertificateUser(r.TLS.VerifiedChains[0][0])
+		w.Header().Set("X-User", username)
+	}))
+	server.TLS = tlsConfig
+	server.StartTLS()
+
+	defer server.Close()
+
+	roots, err := pki.CertPool(caFile)
+	require.NoError(t, err)
+
+	newClient := func(certs []tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+			MinVersion:   tls.VersionTLS12,
+		}}}
+	}
+
+	resp, err := newClient([]tls.Certificate{{Certificate: [][]byte{clientCert.Raw}, PrivateKey: clientKey}}).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "user", resp.Header.Get("X-User"))
+
+	_, err = newClient(nil).Get(server.URL)
+	assert.Error(t, err)
+
+	_, _, err = pki.LoadCA(caFile, filepath.Join(dir, "missing.pem"))
+	assert.Error(t, err)
+}
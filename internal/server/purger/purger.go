@@ -0,0 +1,76 @@
+package purger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vukit/gophkeeper/internal/server/logger"
+)
+
+// Store хранилище записей, из корзины которого окончательно удаляются записи
+type Store interface {
+	PurgeTrash(ctx context.Context, before time.Time) (paths []string, err error)
+}
+
+// FileStore файловое хранилище содержимого файлов
+type FileStore interface {
+	DeleteFile(ctx context.Context, filePath string) (err error)
+}
+
+// Purger периодически окончательно удаляет записи, которые находятся в корзине дольше Retention,
+// вместе с содержимым удалённых файлов
+type Purger struct {
+	store     Store
+	files     FileStore
+	retention time.Duration
+	interval  time.Duration
+	mLogger   *logger.Logger
+}
+
+// NewPurger возвращает задание очистки корзины: записи хранятся в корзине retention,
+// корзина проверяется каждые interval
+func NewPurger(store Store, files FileStore, retention, interval time.Duration, mLogger *logger.Logger) *Purger {
+	return &Purger{store: store, files: files, retention: retention, interval: interval, mLogger: mLogger}
+}
+
+// Run очищает корзину сразу и затем каждые interval до завершения контекста ctx,
+// ошибки очистки записываются в журнал и не останавливают задание
+func (p *Purger) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Purge(ctx); err != nil {
+			p.mLogger.Warning(fmt.Sprintf("trash purge failed: %s", err))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Purge окончательно удаляет записи с истёкшим сроком хранения в корзине. Содержимое файлов удаляется
+// после удаления записей, поэтому при сбое файлового хранилища остаётся только недоступное содержимое,
+// а не записи без содержимого
+func (p *Purger) Purge(ctx context.Context) error {
+	paths, err := p.store.PurgeTrash(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		return err
+	}
+
+	for _, filePath := range paths {
+		if err = p.files.DeleteFile(ctx, filePath); err != nil {
+			p.mLogger.Warning(fmt.Sprintf("trash purge: %s", err))
+		}
+	}
+
+	if len(paths) > 0 {
+		p.mLogger.Info(fmt.Sprintf("trash purge: deleted %d files", len(paths)))
+	}
+
+	return nil
+}
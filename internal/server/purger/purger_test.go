@@ -0,0 +1,82 @@
+package purger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vukit/gophkeeper/internal/server/logger"
+	"github.com/vukit/gophkeeper/internal/server/purger"
+)
+
+// memoryStore корзина в памяти: время удаления записей по путям к содержимому файлов
+type memoryStore struct {
+	deleted map[string]time.Time
+}
+
+func (s *memoryStore) PurgeTrash(ctx context.Context, before time.Time) ([]string, error) {
+	paths := make([]string, 0)
+
+	for path, deletedAt := range s.deleted {
+		if deletedAt.Before(before) {
+			paths = append(paths, path)
+			delete(s.deleted, path)
+		}
+	}
+
+	return paths, nil
+}
+
+// memoryFiles файловое хранилище в памяти, содержимое broken не удаляется
+type memoryFiles struct {
+	files  map[string]bool
+	broken string
+}
+
+func (f *memoryFiles) DeleteFile(ctx context.Context, filePath string) error {
+	if filePath == f.broken {
+		return errors.New("storage is unavailable")
+	}
+
+	delete(f.files, filePath)
+
+	return nil
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	log := &bytes.Buffer{}
+
+	store := &memoryStore{deleted: map[string]time.Time{
+		"old":    time.Now().Add(-48 * time.Hour),
+		"broken": time.Now().Add(-48 * time.Hour),
+		"new":    time.Now().Add(-time.Hour),
+	}}
+	files := &memoryFiles{files: map[string]bool{"old": true, "broken": true, "new": true}, broken: "broken"}
+
+	p := purger.NewPurger(store, files, 24*time.Hour, time.Hour, logger.NewLogger(log))
+
+	require.NoError(t, p.Purge(ctx))
+
+	assert.Equal(t, map[string]bool{"broken": true, "new": true}, files.files)
+	assert.Contains(t, store.deleted, "new")
+	assert.NotContains(t, store.deleted, "broken")
+	assert.Contains(t, log.String(), "storage is unavailable")
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	store := &memoryStore{deleted: map[string]time.Time{"old": time.Now().Add(-48 * time.Hour)}}
+	files := &memoryFiles{files: map[string]bool{"old": true}}
+
+	p := purger.NewPurger(store, files, 24*time.Hour, time.Hour, logger.NewLogger(&bytes.Buffer{}))
+
+	require.NoError(t, p.Run(ctx))
+	assert.Empty(t, files.files)
+}
@@ -6,4 +6,20 @@ var (
 	ErrDBNoDBConn                    = errors.New("no database connection")
 	ErrDBUsernameIsAlreadyTaken      = errors.New("username is already taken")
 	ErrDBInvalidUsernamePasswordPair = errors.New("invalid username/password pair")
+	ErrDBFileNotFound                = errors.New("file not found")
+	ErrDBLoginNotFound               = errors.New("login not found")
+	ErrDBItemNotFound                = errors.New("item not found")
+	ErrDBFolderNotFound              = errors.New("folder not found")
+	ErrDBTagNotFound                 = errors.New("tag not found")
+	ErrDBRevisionNotFound            = errors.New("revision not found")
+	ErrDBFileNotStaged               = errors.New("re-encrypted file content is not uploaded")
+	ErrDBInvalidRecoveryCode         = errors.New("invalid username/recovery code pair")
+	ErrDBInvalidRefreshToken         = errors.New("invalid refresh token")
+	ErrDBSessionNotFound             = errors.New("session not found")
+	ErrDBAPITokenNotFound            = errors.New("api token not found")
+	ErrDBInvalidTOTPCode             = errors.New("invalid authentication code")
+	ErrDBTOTPCodeUsed                = errors.New("authentication code was already used")
+	ErrDBTOTPNotPending              = errors.New("two-factor authentication enrollment is not started")
+	ErrDBVaultChanged                = errors.New("vault was changed during password change, try again")
+	ErrDBMFATokenNotFound            = errors.New("mfa token not found")
 )
@@ -0,0 +1,71 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strconv"
+
+	"github.com/jackc/pgx/v4/stdlib"
+)
+
+// changesChannel канал уведомлений PostgreSQL об изменении хранилищ пользователей
+const changesChannel = "gophkeeper_changes"
+
+// NotifyChange уведомляет все экземпляры сервера об изменении хранилища пользователя userID
+func (repo RepoPostgreSQL) NotifyChange(ctx context.Context, userID int) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	_, err = repo.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, changesChannel, strconv.Itoa(userID))
+
+	return err
+}
+
+// ListenChanges передаёт в changed пользователей, об изменении хранилищ которых уведомил любой
+// экземпляр сервера, до завершения контекста ctx или сбоя соединения. Соединение, на котором
+// выполнялся LISTEN, не возвращается в пул
+func (repo RepoPostgreSQL) ListenChanges(ctx context.Context, changed func(userID int)) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	conn, err := repo.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	errRaw := conn.Raw(func(driverConn interface{}) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			err = ErrDBNoDBConn
+
+			return nil
+		}
+
+		if _, err = pgxConn.Conn().Exec(ctx, `LISTEN `+changesChannel); err != nil {
+			return driver.ErrBadConn
+		}
+
+		for {
+			notification, errWait := pgxConn.Conn().WaitForNotification(ctx)
+			if errWait != nil {
+				err = errWait
+
+				return driver.ErrBadConn
+			}
+
+			userID, errAtoi := strconv.Atoi(notification.Payload)
+			if errAtoi == nil {
+				changed(userID)
+			}
+		}
+	})
+	if err == nil && !errors.Is(errRaw, driver.ErrBadConn) {
+		err = errRaw
+	}
+
+	return err
+}
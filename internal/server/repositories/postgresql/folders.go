@@ -0,0 +1,202 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+// SaveFolder используется при сохранении папки записей пользователя, ревизия синхронизации
+// пользователя увеличивается, чтобы клиенты обновили папки
+func (repo RepoPostgreSQL) SaveFolder(ctx context.Context, folder *model.Folder) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	return inTx(ctx, repo.db, func(q querier) error {
+		if _, err := nextSyncRevision(ctx, q, folder.UserID); err != nil {
+			return err
+		}
+
+		return saveFolder(ctx, q, folder.UserID, folder)
+	})
+}
+
+// DeleteFolder используется при удалении папки записей пользователя, записи папки остаются вне папок
+// и получают новую ревизию синхронизации
+func (repo RepoPostgreSQL) DeleteFolder(ctx context.Context, folder *model.Folder) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	return inTx(ctx, repo.db, func(q querier) error {
+		err := touchItems(ctx, q, folder.UserID, `folder_id = $3`, folder.ID)
+		if err != nil {
+			return err
+		}
+
+		return deleteOwned(ctx, q, `DELETE FROM folders WHERE folder_id = $1 and user_id = $2`, folder.ID, folder.UserID, ErrDBFolderNotFound)
+	})
+}
+
+// FindFolders возвращает папки записей пользователя
+func (repo RepoPostgreSQL) FindFolders(ctx context.Context, user model.User) (folders []model.Folder, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
+	}
+
+	return findFolders(ctx, repo.db, user.ID)
+}
+
+// SaveTag используется при сохранении метки записей пользователя, ревизия синхронизации
+// пользователя увеличивается, чтобы клиенты обновили метки
+func (repo RepoPostgreSQL) SaveTag(ctx context.Context, tag *model.Tag) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	return inTx(ctx, repo.db, func(q querier) error {
+		if _, err := nextSyncRevision(ctx, q, tag.UserID); err != nil {
+			return err
+		}
+
+		return saveTag(ctx, q, tag.UserID, tag)
+	})
+}
+
+// DeleteTag используется при удалении метки записей пользователя, метка снимается со всех записей,
+// которые получают новую ревизию синхронизации
+func (repo RepoPostgreSQL) DeleteTag(ctx context.Context, tag *model.Tag) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	return inTx(ctx, repo.db, func(q querier) error {
+		err := touchItems(ctx, q, tag.UserID, `item_id IN (SELECT item_id FROM item_tags WHERE tag_id = $3)`, tag.ID)
+		if err != nil {
+			return err
+		}
+
+		return deleteOwned(ctx, q, `DELETE FROM tags WHERE tag_id = $1 and user_id = $2`, tag.ID, tag.UserID, ErrDBTagNotFound)
+	})
+}
+
+// FindTags возвращает метки записей пользователя
+func (repo RepoPostgreSQL) FindTags(ctx context.Context, user model.User) (tags []model.Tag, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
+	}
+
+	return findTags(ctx, repo.db, user.ID)
+}
+
+func saveFolder(ctx context.Context, q querier, userID int, folder *model.Folder) error {
+	if folder.ID == 0 {
+		return q.QueryRowContext(ctx,
+			`INSERT INTO folders (user_id, uid, name) VALUES($1, $2, $3) RETURNING folder_id`,
+			userID,
+			folder.UID,
+			folder.Name).Scan(&folder.ID)
+	}
+
+	return updateOwned(ctx, q, `UPDATE folders SET uid = $1, name = $2 WHERE folder_id = $3 and user_id = $4`,
+		ErrDBFolderNotFound, folder.UID, folder.Name, folder.ID, userID)
+}
+
+func saveTag(ctx context.Context, q querier, userID int, tag *model.Tag) error {
+	if tag.ID == 0 {
+		return q.QueryRowContext(ctx,
+			`INSERT INTO tags (user_id, uid, name) VALUES($1, $2, $3) RETURNING tag_id`,
+			userID,
+			tag.UID,
+			tag.Name).Scan(&tag.ID)
+	}
+
+	return updateOwned(ctx, q, `UPDATE tags SET uid = $1, name = $2 WHERE tag_id = $3 and user_id = $4`,
+		ErrDBTagNotFound, tag.UID, tag.Name, tag.ID, userID)
+}
+
+func findFolders(ctx context.Context, q querier, userID int) (folders []model.Folder, err error) {
+	rows, err := q.QueryContext(ctx, `SELECT folder_id, uid, name FROM folders WHERE user_id = $1 ORDER BY folder_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	folders = make([]model.Folder, 0)
+
+	for rows.Next() {
+		folder := model.Folder{}
+
+		if err = rows.Scan(&folder.ID, &folder.UID, &folder.Name); err != nil {
+			return nil, err
+		}
+
+		folders = append(folders, folder)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return folders, nil
+}
+
+func findTags(ctx context.Context, q querier, userID int) (tags []model.Tag, err error) {
+	rows, err := q.QueryContext(ctx, `SELECT tag_id, uid, name FROM tags WHERE user_id = $1 ORDER BY tag_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tags = make([]model.Tag, 0)
+
+	for rows.Next() {
+		tag := model.Tag{}
+
+		if err = rows.Scan(&tag.ID, &tag.UID, &tag.Name); err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// updateOwned выполняет запрос изменения query, возвращает notFound, если запрос не изменил ни одной строки
+func updateOwned(ctx context.Context, q querier, query string, notFound error, args ...interface{}) error {
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// deleteOwned выполняет запрос удаления query строки id пользователя userID,
+// возвращает notFound, если строки нет
+func deleteOwned(ctx context.Context, q querier, query string, id, userID int, notFound error) error {
+	return updateOwned(ctx, q, query, notFound, id, userID)
+}
+
+// Запросы блокировки папок и меток пользователя для checkIDs
+const (
+	folderIDs = `SELECT folder_id FROM folders WHERE user_id = $1 FOR UPDATE`
+	tagIDs    = `SELECT tag_id FROM tags WHERE user_id = $1 FOR UPDATE`
+)
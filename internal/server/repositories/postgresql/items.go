@@ -0,0 +1,504 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+// querier выполняет запросы в соединении с БД или в транзакции
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// SaveItem используется при сохранении записи произвольного типа пользователя
+func (repo RepoPostgreSQL) SaveItem(ctx context.Context, item *model.Item) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	return saveCustomItem(ctx, repo.db, item.UserID, item, repo.revisions)
+}
+
+// DeleteItem используется при удалении записи произвольного типа пользователя в корзину
+func (repo RepoPostgreSQL) DeleteItem(ctx context.Context, item *model.Item) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	var itemType string
+
+	err = repo.db.QueryRowContext(ctx,
+		`SELECT type FROM items WHERE item_id = $1 and user_id = $2 and deleted_at IS NULL`,
+		item.ID,
+		item.UserID).Scan(&itemType)
+	if errors.Is(err, sql.ErrNoRows) || model.IsTypedItem(itemType) {
+		return ErrDBItemNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return deleteItem(ctx, repo.db, item.UserID, item.ID, itemType)
+}
+
+// FindItems возвращает записи произвольного типа пользователя, записи типизированных данных
+// доступны только через свои endpoints
+func (repo RepoPostgreSQL) FindItems(ctx context.Context, user model.User) (items []model.Item, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
+	}
+
+	return findItems(ctx, repo.db, user.ID, true)
+}
+
+// saveItem сохраняет данные data записи типа itemType в JSON и её размещение place,
+// новой записи назначается идентификатор id. Прежняя редакция изменяемой записи сохраняется
+// в истории, в которой остаются последние revisions редакций, записи назначается новая ревизия синхронизации
+func saveItem(
+	ctx context.Context,
+	q querier,
+	userID int,
+	id *int,
+	uid, itemType string,
+	data []byte,
+	place model.Placement,
+	revisions int,
+) error {
+	return inTx(ctx, q, func(q querier) error {
+		if place.FolderID != 0 {
+			var exists bool
+
+			err := q.QueryRowContext(ctx,
+				`SELECT EXISTS(SELECT 1 FROM folders WHERE folder_id = $1 and user_id = $2)`,
+				place.FolderID,
+				userID).Scan(&exists)
+			if err != nil {
+				return err
+			}
+
+			if !exists {
+				return ErrDBFolderNotFound
+			}
+		}
+
+		revision, err := nextSyncRevision(ctx, q, userID)
+		if err != nil {
+			return err
+		}
+
+		if *id == 0 {
+			err = q.QueryRowContext(ctx,
+				`INSERT INTO items (user_id, uid, type, data, folder_id, device, sync_revision)
+				VALUES($1, $2, $3, $4, NULLIF($5, 0), $6, $7) RETURNING item_id`,
+				userID,
+				uid,
+				itemType,
+				data,
+				place.FolderID,
+				contextDevice(ctx),
+				revision).Scan(id)
+			if err != nil {
+				return err
+			}
+		} else {
+			if err := saveRevision(ctx, q, userID, *id, itemType, revisions); err != nil {
+				return err
+			}
+
+			result, err := q.ExecContext(ctx,
+				`UPDATE items SET uid = $1, data = $2, folder_id = NULLIF($3, 0), updated_at = now(),
+				device = COALESCE(NULLIF($4, ''), device), sync_revision = $5
+				WHERE item_id = $6 and user_id = $7 and type = $8 and deleted_at IS NULL`,
+				uid,
+				data,
+				place.FolderID,
+				contextDevice(ctx),
+				revision,
+				*id,
+				userID,
+				itemType)
+			if err != nil {
+				return err
+			}
+
+			rows, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if rows == 0 {
+				return ErrDBItemNotFound
+			}
+		}
+
+		return saveItemTags(ctx, q, userID, *id, place.TagIDs)
+	})
+}
+
+// saveItemTags заменяет метки записи id метками tagIDs пользователя
+func saveItemTags(ctx context.Context, q querier, userID, id int, tagIDs []int) error {
+	_, err := q.ExecContext(ctx, `DELETE FROM item_tags WHERE item_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	saved := make(map[int]bool, len(tagIDs))
+
+	for _, tagID := range tagIDs {
+		if saved[tagID] {
+			continue
+		}
+
+		result, err := q.ExecContext(ctx,
+			`INSERT INTO item_tags (item_id, tag_id) SELECT $1, tag_id FROM tags WHERE tag_id = $2 and user_id = $3`,
+			id,
+			tagID,
+			userID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrDBTagNotFound
+		}
+
+		saved[tagID] = true
+	}
+
+	return nil
+}
+
+// inTx выполняет fn в транзакции q или, если q — соединение с БД, в новой транзакции
+func inTx(ctx context.Context, q querier, fn func(q querier) error) error {
+	db, ok := q.(*sql.DB)
+	if !ok {
+		return fn(q)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deleteItem перемещает запись типа itemType в корзину с новой ревизией синхронизации,
+// окончательно запись удаляется purgeTrash
+func deleteItem(ctx context.Context, q querier, userID, id int, itemType string) error {
+	return inTx(ctx, q, func(q querier) error {
+		revision, err := nextSyncRevision(ctx, q, userID)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.ExecContext(ctx,
+			`UPDATE items SET deleted_at = now(), sync_revision = $1
+			WHERE item_id = $2 and user_id = $3 and type = $4 and deleted_at IS NULL`,
+			revision,
+			id,
+			userID,
+			itemType)
+
+		return err
+	})
+}
+
+// saveCustomItem сохраняет запись произвольного типа, её зашифрованные данные хранятся строкой JSON
+func saveCustomItem(ctx context.Context, q querier, userID int, item *model.Item, revisions int) error {
+	data, err := json.Marshal(item.Data)
+	if err != nil {
+		return err
+	}
+
+	return saveItem(ctx, q, userID, &item.ID, item.UID, item.Type, data, item.Placement, revisions)
+}
+
+// placed запись с размещением в папке и метками
+type placed interface {
+	ItemPlacement() model.Placement
+}
+
+// saveTypedItem сохраняет типизированную запись v типа itemType
+func saveTypedItem(ctx context.Context, q querier, userID int, id *int, uid, itemType string, v placed, revisions int) error {
+	data, err := typedPayload(v)
+	if err != nil {
+		return err
+	}
+
+	return saveItem(ctx, q, userID, id, uid, itemType, data, v.ItemPlacement(), revisions)
+}
+
+func findLogins(ctx context.Context, q querier, userID int, newestFirst bool) (logins []model.Login, err error) {
+	logins = make([]model.Login, 0)
+
+	err = findTypedItems(ctx, q, userID, model.ItemTypeLogin, newestFirst, func(id int, uid string, data []byte, place model.Placement) error {
+		login := model.Login{}
+		if err := json.Unmarshal(data, &login); err != nil {
+			return err
+		}
+
+		login.ID, login.UID, login.Placement = id, uid, place
+		logins = append(logins, login)
+
+		return nil
+	})
+
+	return logins, err
+}
+
+func findCards(ctx context.Context, q querier, userID int, newestFirst bool) (cards []model.Card, err error) {
+	cards = make([]model.Card, 0)
+
+	err = findTypedItems(ctx, q, userID, model.ItemTypeCard, newestFirst, func(id int, uid string, data []byte, place model.Placement) error {
+		card := model.Card{}
+		if err := json.Unmarshal(data, &card); err != nil {
+			return err
+		}
+
+		card.ID, card.UID, card.Placement = id, uid, place
+		cards = append(cards, card)
+
+		return nil
+	})
+
+	return cards, err
+}
+
+func findNotes(ctx context.Context, q querier, userID int, newestFirst bool) (notes []model.Note, err error) {
+	notes = make([]model.Note, 0)
+
+	err = findTypedItems(ctx, q, userID, model.ItemTypeNote, newestFirst, func(id int, uid string, data []byte, place model.Placement) error {
+		note := model.Note{}
+		if err := json.Unmarshal(data, &note); err != nil {
+			return err
+		}
+
+		note.ID, note.UID, note.Placement = id, uid, place
+		notes = append(notes, note)
+
+		return nil
+	})
+
+	return notes, err
+}
+
+func findOTPs(ctx context.Context, q querier, userID int, newestFirst bool) (otps []model.OTP, err error) {
+	otps = make([]model.OTP, 0)
+
+	err = findTypedItems(ctx, q, userID, model.ItemTypeOTP, newestFirst, func(id int, uid string, data []byte, place model.Placement) error {
+		otp := model.OTP{}
+		if err := json.Unmarshal(data, &otp); err != nil {
+			return err
+		}
+
+		otp.ID, otp.UID, otp.Placement = id, uid, place
+		otps = append(otps, otp)
+
+		return nil
+	})
+
+	return otps, err
+}
+
+func findSSHKeys(ctx context.Context, q querier, userID int, newestFirst bool) (keys []model.SSHKey, err error) {
+	keys = make([]model.SSHKey, 0)
+
+	err = findTypedItems(ctx, q, userID, model.ItemTypeSSHKey, newestFirst, func(id int, uid string, data []byte, place model.Placement) error {
+		key := model.SSHKey{}
+		if err := json.Unmarshal(data, &key); err != nil {
+			return err
+		}
+
+		key.ID, key.UID, key.Placement = id, uid, place
+		keys = append(keys, key)
+
+		return nil
+	})
+
+	return keys, err
+}
+
+func findFiles(ctx context.Context, q querier, userID int, newestFirst bool) (files []model.File, err error) {
+	files = make([]model.File, 0)
+
+	err = findTypedItems(ctx, q, userID, model.ItemTypeFile, newestFirst, func(id int, uid string, data []byte, place model.Placement) error {
+		file := model.File{}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return err
+		}
+
+		file.ID, file.UID, file.Placement = id, uid, place
+		files = append(files, file)
+
+		return nil
+	})
+
+	return files, err
+}
+
+// placementColumns столбцы размещения записи items i: папка и идентификаторы меток в JSON
+const placementColumns = `COALESCE(i.folder_id, 0),
+	COALESCE((SELECT json_agg(t.tag_id ORDER BY t.tag_id) FROM item_tags t WHERE t.item_id = i.item_id), '[]')`
+
+// findTypedItems читает записи типа itemType и передаёт идентификаторы, данные и размещение каждой записи в scan
+func findTypedItems(
+	ctx context.Context,
+	q querier,
+	userID int,
+	itemType string,
+	newestFirst bool,
+	scan func(id int, uid string, data []byte, place model.Placement) error,
+) error {
+	query := `SELECT i.item_id, i.uid, i.data, ` + placementColumns + ` FROM items i
+		WHERE i.user_id = $1 and i.type = $2 and i.deleted_at IS NULL ORDER BY i.item_id`
+	if newestFirst {
+		query += ` DESC`
+	}
+
+	rows, err := q.QueryContext(ctx, query, userID, itemType)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id    int
+			uid   string
+			data  []byte
+			place model.Placement
+		)
+
+		if err = scanPlacement(rows, &place, &id, &uid, &data); err != nil {
+			return err
+		}
+
+		if err = scan(id, uid, data, place); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// findItems читает записи произвольного типа пользователя
+func findItems(ctx context.Context, q querier, userID int, newestFirst bool) (items []model.Item, err error) {
+	query := `SELECT i.item_id, i.uid, i.type, i.data, i.created_at, i.updated_at, ` + placementColumns + ` FROM items i
+		WHERE i.user_id = $1 and i.type NOT IN ($2, $3, $4, $5, $6, $7) and i.deleted_at IS NULL ORDER BY i.item_id`
+	if newestFirst {
+		query += ` DESC`
+	}
+
+	rows, err := q.QueryContext(ctx, query, userID,
+		model.ItemTypeLogin, model.ItemTypeCard, model.ItemTypeFile, model.ItemTypeNote, model.ItemTypeOTP, model.ItemTypeSSHKey)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items = make([]model.Item, 0)
+
+	for rows.Next() {
+		item := model.Item{}
+
+		var data []byte
+
+		if err = scanPlacement(rows, &item.Placement, &item.ID, &item.UID, &item.Type, &data, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal(data, &item.Data); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// scanPlacement читает строку запроса со столбцами dest, за которыми следуют placementColumns
+func scanPlacement(rows *sql.Rows, place *model.Placement, dest ...interface{}) error {
+	var tagIDs []byte
+
+	if err := rows.Scan(append(dest, &place.FolderID, &tagIDs)...); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(tagIDs, &place.TagIDs)
+}
+
+// findTypedItem читает данные записи id типа itemType в data, возвращает ErrDBItemNotFound, если записи нет
+func findTypedItem(ctx context.Context, q querier, userID, id int, itemType string, data interface{}) (uid string, err error) {
+	var payload []byte
+
+	err = q.QueryRowContext(ctx,
+		`SELECT uid, data FROM items WHERE item_id = $1 and user_id = $2 and type = $3 and deleted_at IS NULL`,
+		id,
+		userID,
+		itemType).Scan(&uid, &payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrDBItemNotFound
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return uid, json.Unmarshal(payload, data)
+}
+
+// typedPayload возвращает данные типизированной записи v в JSON без идентификаторов и размещения записи,
+// которые хранятся в столбцах таблицы
+func typedPayload(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	delete(fields, "id")
+	delete(fields, "uid")
+	delete(fields, "folder_id")
+	delete(fields, "tag_ids")
+
+	return json.Marshal(fields)
+}
+
+// customItemIDs запрос блокировки записей произвольного типа для checkIDs, записи в корзине не блокируются
+const customItemIDs = `SELECT item_id FROM items WHERE user_id = $1 and type NOT IN ('` +
+	model.ItemTypeLogin + `', '` + model.ItemTypeCard + `', '` + model.ItemTypeFile + `', '` +
+	model.ItemTypeNote + `', '` + model.ItemTypeOTP + `', '` + model.ItemTypeSSHKey + `') and deleted_at IS NULL FOR UPDATE`
+
+// typedItemIDs возвращает запрос блокировки записей типа itemType для checkIDs, записи в корзине не блокируются
+func typedItemIDs(itemType string) string {
+	return `SELECT item_id FROM items WHERE user_id = $1 and type = '` + itemType + `' and deleted_at IS NULL FOR UPDATE`
+}
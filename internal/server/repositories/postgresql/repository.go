@@ -6,8 +6,12 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgconn"
+	"github.com/vukit/gophkeeper/internal/server/hasher"
+	"github.com/vukit/gophkeeper/internal/server/limiter"
 	"github.com/vukit/gophkeeper/internal/server/model"
 
 	// Register pgx stdlib
@@ -16,14 +20,17 @@ import (
 
 // RepoPostgreSQL структура PostgreSQL репозитория
 type RepoPostgreSQL struct {
-	db *sql.DB
+	db        *sql.DB
+	hasher    *hasher.Hasher
+	revisions int
 }
 
-// NewRepo возвращает PostgreSQL репозиторий
-func NewRepo(dsn string) (repo RepoPostgreSQL, err error) {
+// NewRepo возвращает PostgreSQL репозиторий, пароли пользователей хешируются hasher,
+// в истории каждой записи хранится не больше revisions прежних редакций
+func NewRepo(dsn string, hasher *hasher.Hasher, revisions int) (repo RepoPostgreSQL, err error) {
 	db, err := sql.Open("pgx", dsn)
 
-	repo = RepoPostgreSQL{db: db}
+	repo = RepoPostgreSQL{db: db, hasher: hasher, revisions: revisions}
 
 	if err != nil {
 		return repo, err
@@ -38,86 +45,1417 @@ func (repo RepoPostgreSQL) SaveUser(ctx context.Context, user model.User) (userI
 		return 0, ErrDBNoDBConn
 	}
 
-	passwordHash := sha256.Sum256([]byte(user.Password))
+	passwordHash, err := repo.hasher.Hash(user.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO users (username, password, kdf_version, kdf_algorithm, kdf_salt, kdf_time, kdf_memory, kdf_threads, vault_key)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING user_id`,
+		user.Username,
+		passwordHash,
+		user.KDF.Version,
+		user.KDF.Algorithm,
+		user.KDF.Salt,
+		user.KDF.Time,
+		user.KDF.Memory,
+		user.KDF.Threads,
+		user.VaultKey).Scan(&userID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, ErrDBUsernameIsAlreadyTaken
+		}
+
+		return 0, err
+	}
+
+	err = insertRecoveryKeys(ctx, tx, userID, user.RecoveryKeys)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+// FindUser используется при аутентификации пользователя. Хеш пароля, полученный устаревшим
+// алгоритмом или с другими параметрами, после успешной проверки заменяется новым
+func (repo RepoPostgreSQL) FindUser(ctx context.Context, user model.User) (userID int, err error) {
+	if repo.db == nil {
+		return 0, ErrDBNoDBConn
+	}
+
+	var passwordHash string
+
+	err = repo.db.QueryRowContext(ctx,
+		`SELECT user_id, password FROM users WHERE username = $1`,
+		user.Username).Scan(&userID, &passwordHash)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			repo.hasher.VerifyDummy(user.Password)
+
+			return 0, ErrDBInvalidUsernamePasswordPair
+		default:
+			return 0, err
+		}
+	}
+
+	ok, rehash, err := repo.hasher.Verify(user.Password, passwordHash)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return 0, ErrDBInvalidUsernamePasswordPair
+	}
+
+	if rehash {
+		newPasswordHash, err := repo.hasher.Hash(user.Password)
+		if err != nil {
+			return 0, err
+		}
+
+		// хеш заменяется, только если пароль не изменился с момента проверки
+		_, err = repo.db.ExecContext(ctx,
+			`UPDATE users SET password = $1 WHERE user_id = $2 and password = $3`,
+			newPasswordHash,
+			userID,
+			passwordHash)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return userID, nil
+}
+
+// FindKDF возвращает параметры получения ключа шифрования пользователя
+func (repo RepoPostgreSQL) FindKDF(ctx context.Context, username string) (kdf model.KDF, err error) {
+	if repo.db == nil {
+		return kdf, ErrDBNoDBConn
+	}
+
+	err = repo.db.QueryRowContext(ctx,
+		`SELECT kdf_version, kdf_algorithm, kdf_salt, kdf_time, kdf_memory, kdf_threads FROM users WHERE username = $1`,
+		username).Scan(&kdf.Version, &kdf.Algorithm, &kdf.Salt, &kdf.Time, &kdf.Memory, &kdf.Threads)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return kdf, ErrDBInvalidUsernamePasswordPair
+		default:
+			return kdf, err
+		}
+	}
+
+	return kdf, err
+}
+
+// FindVaultKey возвращает ключ шифрования хранилища пользователя, зашифрованный ключом из мастер-пароля
+func (repo RepoPostgreSQL) FindVaultKey(ctx context.Context, userID int) (vaultKey string, err error) {
+	if repo.db == nil {
+		return "", ErrDBNoDBConn
+	}
+
+	err = repo.db.QueryRowContext(ctx,
+		`SELECT vault_key FROM users WHERE user_id = $1`,
+		userID).Scan(&vaultKey)
+
+	return vaultKey, err
+}
+
+// UpdateCredential заменяет пароль и параметры получения ключей пользователя
+// при условии, что текущий пароль указан верно. Остальные сеансы и токены API пользователя
+// завершаются, сеансу sessionID выдаётся новый токен обновления refreshToken
+func (repo RepoPostgreSQL) UpdateCredential(
+	ctx context.Context,
+	userID, sessionID int,
+	credential model.Credential,
+	refreshToken string,
+	expiresAt time.Time,
+) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = repo.checkPassword(ctx, tx, userID, credential.Password)
+	if err != nil {
+		return err
+	}
+
+	err = checkKDFTransition(ctx, tx, userID, credential.KDF)
+	if err != nil {
+		return err
+	}
+
+	newPasswordHash, err := repo.hasher.Hash(credential.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET password = $1, kdf_version = $2, kdf_algorithm = $3, kdf_salt = $4, kdf_time = $5, kdf_memory = $6, kdf_threads = $7,
+		vault_key = COALESCE(NULLIF($8, ''), vault_key)
+		WHERE user_id = $9`,
+		newPasswordHash,
+		credential.KDF.Version,
+		credential.KDF.Algorithm,
+		credential.KDF.Salt,
+		credential.KDF.Time,
+		credential.KDF.Memory,
+		credential.KDF.Threads,
+		credential.VaultKey,
+		userID)
+	if err != nil {
+		return err
+	}
+
+	err = revokeSessions(ctx, tx, userID, sessionID, refreshToken, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SaveRekeyFile сохраняет путь к перешифрованному содержимому файла пользователя
+// до смены мастер-пароля, возвращает путь к ранее загруженному содержимому
+func (repo RepoPostgreSQL) SaveRekeyFile(ctx context.Context, userID, fileID int, filePath string) (oldPath string, err error) {
+	if repo.db == nil {
+		return "", ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT path FROM rekey_files WHERE user_id = $1 and file_id = $2 FOR UPDATE`,
+		userID, fileID).Scan(&oldPath)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO rekey_files (user_id, file_id, path)
+		SELECT user_id, item_id, $3 FROM items WHERE user_id = $1 and item_id = $2 and type = $4 and deleted_at IS NULL
+		ON CONFLICT (user_id, file_id) DO UPDATE SET path = EXCLUDED.path`,
+		userID, fileID, filePath, model.ItemTypeFile)
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+
+	if rows == 0 {
+		return "", ErrDBFileNotFound
+	}
+
+	return oldPath, tx.Commit()
+}
+
+// DeleteRekeyFiles удаляет сведения о перешифрованном содержимом файлов пользователя,
+// возвращает пути к содержимому, которое больше не используется
+func (repo RepoPostgreSQL) DeleteRekeyFiles(ctx context.Context, userID int) (paths []string, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
+	}
+
+	rows, err := repo.db.QueryContext(ctx,
+		`DELETE FROM rekey_files WHERE user_id = $1 RETURNING path`,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanPaths(rows)
+}
+
+// ChangePassword в одной транзакции заменяет все данные пользователя перешифрованными,
+// подменяет содержимое файлов загруженным заранее и заменяет учётные данные.
+// Набор записей в запросе должен совпадать с набором записей пользователя, а ревизия хранилища —
+// с ревизией, на которой клиент прочитал данные, иначе возвращается ErrDBVaultChanged.
+// Остальные сеансы и токены API пользователя завершаются, сеансу sessionID выдаётся новый токен
+// обновления refreshToken. Возвращает пути к прежнему содержимому файлов, которое больше не используется
+func (repo RepoPostgreSQL) ChangePassword(
+	ctx context.Context,
+	userID, sessionID int,
+	change model.PasswordChange,
+	refreshToken string,
+	expiresAt time.Time,
+) (oldPaths []string, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = repo.checkPassword(ctx, tx, userID, change.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkKDFTransition(ctx, tx, userID, change.KDF)
+	if err != nil {
+		return nil, err
+	}
+
+	var revision int64
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT sync_revision FROM users WHERE user_id = $1`,
+		userID).Scan(&revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if revision != change.Revision {
+		return nil, ErrDBVaultChanged
+	}
+
+	loginIDs := make([]int, 0, len(change.Logins))
+	for _, login := range change.Logins {
+		loginIDs = append(loginIDs, login.ID)
+	}
+
+	cardIDs := make([]int, 0, len(change.Cards))
+	for _, card := range change.Cards {
+		cardIDs = append(cardIDs, card.ID)
+	}
+
+	noteIDs := make([]int, 0, len(change.Notes))
+	for _, note := range change.Notes {
+		noteIDs = append(noteIDs, note.ID)
+	}
+
+	otpIDs := make([]int, 0, len(change.OTPs))
+	for _, otp := range change.OTPs {
+		otpIDs = append(otpIDs, otp.ID)
+	}
+
+	sshKeyIDs := make([]int, 0, len(change.SSHKeys))
+	for _, key := range change.SSHKeys {
+		sshKeyIDs = append(sshKeyIDs, key.ID)
+	}
+
+	fileIDs := make([]int, 0, len(change.Files))
+	for _, file := range change.Files {
+		fileIDs = append(fileIDs, file.ID)
+	}
+
+	itemIDs := make([]int, 0, len(change.Items))
+	for _, item := range change.Items {
+		itemIDs = append(itemIDs, item.ID)
+	}
+
+	changedFolderIDs := make([]int, 0, len(change.Folders))
+	for _, folder := range change.Folders {
+		changedFolderIDs = append(changedFolderIDs, folder.ID)
+	}
+
+	changedTagIDs := make([]int, 0, len(change.Tags))
+	for _, tag := range change.Tags {
+		changedTagIDs = append(changedTagIDs, tag.ID)
+	}
+
+	for _, locked := range []struct {
+		query string
+		ids   []int
+	}{
+		{typedItemIDs(model.ItemTypeLogin), loginIDs},
+		{typedItemIDs(model.ItemTypeCard), cardIDs},
+		{typedItemIDs(model.ItemTypeNote), noteIDs},
+		{typedItemIDs(model.ItemTypeOTP), otpIDs},
+		{typedItemIDs(model.ItemTypeSSHKey), sshKeyIDs},
+		{typedItemIDs(model.ItemTypeFile), fileIDs},
+		{customItemIDs, itemIDs},
+		{folderIDs, changedFolderIDs},
+		{tagIDs, changedTagIDs},
+	} {
+		if err = checkIDs(ctx, tx, locked.query, userID, locked.ids); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.Folders {
+		if err = saveFolder(ctx, tx, userID, &change.Folders[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.Tags {
+		if err = saveTag(ctx, tx, userID, &change.Tags[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.Logins {
+		login := &change.Logins[i]
+
+		if err = saveTypedItem(ctx, tx, userID, &login.ID, login.UID, model.ItemTypeLogin, login, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.Cards {
+		card := &change.Cards[i]
+
+		if err = saveTypedItem(ctx, tx, userID, &card.ID, card.UID, model.ItemTypeCard, card, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.Notes {
+		note := &change.Notes[i]
+
+		if err = saveTypedItem(ctx, tx, userID, &note.ID, note.UID, model.ItemTypeNote, note, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.OTPs {
+		otp := &change.OTPs[i]
+
+		if err = saveTypedItem(ctx, tx, userID, &otp.ID, otp.UID, model.ItemTypeOTP, otp, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.SSHKeys {
+		key := &change.SSHKeys[i]
+
+		if err = saveTypedItem(ctx, tx, userID, &key.ID, key.UID, model.ItemTypeSSHKey, key, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range change.Items {
+		if err = saveCustomItem(ctx, tx, userID, &change.Items[i], 0); err != nil {
+			return nil, err
+		}
+	}
+
+	oldPaths = make([]string, 0, len(change.Files))
+
+	for i := range change.Files {
+		file := &change.Files[i]
+
+		var oldPath string
+
+		err = tx.QueryRowContext(ctx,
+			`SELECT r.path, i.data->>'path' FROM rekey_files r JOIN items i ON i.item_id = r.file_id and i.user_id = r.user_id
+			WHERE r.user_id = $1 and r.file_id = $2 and i.type = $3`,
+			userID,
+			file.ID,
+			model.ItemTypeFile).Scan(&file.Path, &oldPath)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return nil, ErrDBFileNotStaged
+			default:
+				return nil, err
+			}
+		}
+
+		if err = saveTypedItem(ctx, tx, userID, &file.ID, file.UID, model.ItemTypeFile, file, 0); err != nil {
+			return nil, err
+		}
+
+		oldPaths = append(oldPaths, oldPath)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM rekey_files WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	newPasswordHash, err := repo.hasher.Hash(change.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET password = $1, kdf_version = $2, kdf_algorithm = $3, kdf_salt = $4, kdf_time = $5, kdf_memory = $6, kdf_threads = $7,
+		vault_key = COALESCE(NULLIF($8, ''), vault_key)
+		WHERE user_id = $9`,
+		newPasswordHash,
+		change.KDF.Version,
+		change.KDF.Algorithm,
+		change.KDF.Salt,
+		change.KDF.Time,
+		change.KDF.Memory,
+		change.KDF.Threads,
+		change.VaultKey,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if change.VaultKey != "" {
+		// прежние редакции записей и записи в корзине зашифрованы заменённым ключом хранилища
+		// и больше не расшифровываются, клиент получает подтверждение пользователя до замены ключа
+		_, err = tx.ExecContext(ctx, `DELETE FROM item_revisions WHERE user_id = $1`, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		trashPaths, err := purgeTrash(ctx, tx, `user_id = $1 and deleted_at IS NOT NULL`, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		oldPaths = append(oldPaths, trashPaths...)
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM recovery_keys WHERE user_id = $1`, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = insertRecoveryKeys(ctx, tx, userID, change.RecoveryKeys)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = revokeSessions(ctx, tx, userID, sessionID, refreshToken, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return oldPaths, tx.Commit()
+}
+
+// SaveRecoveryKeys заменяет ключи восстановления пользователя после проверки его текущего пароля password
+func (repo RepoPostgreSQL) SaveRecoveryKeys(ctx context.Context, userID int, password string, keys []model.RecoveryKey) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = repo.checkPassword(ctx, tx, userID, password)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM recovery_keys WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	err = insertRecoveryKeys(ctx, tx, userID, keys)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FindRecoveryKey возвращает ключ шифрования хранилища пользователя, зашифрованный кодом восстановления
+func (repo RepoPostgreSQL) FindRecoveryKey(ctx context.Context, recovery model.Recovery) (vaultKey string, err error) {
+	if repo.db == nil {
+		return "", ErrDBNoDBConn
+	}
+
+	verifierHash := sha256.Sum256([]byte(recovery.Verifier))
+
+	err = repo.db.QueryRowContext(ctx,
+		`SELECT r.vault_key FROM recovery_keys r JOIN users u USING (user_id) WHERE u.username = $1 and r.verifier = $2`,
+		recovery.Username,
+		hex.EncodeToString(verifierHash[:])).Scan(&vaultKey)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return "", ErrDBInvalidRecoveryCode
+		default:
+			return "", err
+		}
+	}
+
+	return vaultKey, nil
+}
+
+// Recover заменяет учётные данные пользователя по коду восстановления,
+// использованный код восстановления удаляется
+func (repo RepoPostgreSQL) Recover(ctx context.Context, recovery model.Recovery) (userID int, err error) {
+	if repo.db == nil {
+		return 0, ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	verifierHash := sha256.Sum256([]byte(recovery.Verifier))
+
+	var recoveryKeyID int
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT r.recovery_key_id, u.user_id FROM recovery_keys r JOIN users u USING (user_id)
+		WHERE u.username = $1 and r.verifier = $2 FOR UPDATE`,
+		recovery.Username,
+		hex.EncodeToString(verifierHash[:])).Scan(&recoveryKeyID, &userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, ErrDBInvalidRecoveryCode
+		default:
+			return 0, err
+		}
+	}
+
+	err = checkKDFTransition(ctx, tx, userID, recovery.KDF)
+	if err != nil {
+		return 0, err
+	}
+
+	newPasswordHash, err := repo.hasher.Hash(recovery.NewPassword)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET password = $1, kdf_version = $2, kdf_algorithm = $3, kdf_salt = $4, kdf_time = $5, kdf_memory = $6, kdf_threads = $7,
+		vault_key = $8 WHERE user_id = $9`,
+		newPasswordHash,
+		recovery.KDF.Version,
+		recovery.KDF.Algorithm,
+		recovery.KDF.Salt,
+		recovery.KDF.Time,
+		recovery.KDF.Memory,
+		recovery.KDF.Threads,
+		recovery.VaultKey,
+		userID)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM recovery_keys WHERE recovery_key_id = $1`, recoveryKeyID)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+// FindUsername возвращает имя пользователя
+func (repo RepoPostgreSQL) FindUsername(ctx context.Context, userID int) (username string, err error) {
+	if repo.db == nil {
+		return "", ErrDBNoDBConn
+	}
+
+	err = repo.db.QueryRowContext(ctx,
+		`SELECT username FROM users WHERE user_id = $1`,
+		userID).Scan(&username)
+
+	return username, err
+}
+
+// FindAccount возвращает все данные пользователя для выгрузки учётной записи. Данные читаются
+// в одной транзакции, поэтому выгрузка согласована; Path файла — путь к его содержимому
+func (repo RepoPostgreSQL) FindAccount(ctx context.Context, userID int) (account model.Account, err error) {
+	if repo.db == nil {
+		return account, ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return account, err
+	}
+	defer tx.Rollback()
+
+	kdf := &account.KDF
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT username, kdf_version, kdf_algorithm, kdf_salt, kdf_time, kdf_memory, kdf_threads, vault_key FROM users WHERE user_id = $1`,
+		userID).Scan(&account.Username, &kdf.Version, &kdf.Algorithm, &kdf.Salt, &kdf.Time, &kdf.Memory, &kdf.Threads, &account.VaultKey)
+	if err != nil {
+		return account, err
+	}
+
+	if account.Logins, err = findLogins(ctx, tx, userID, false); err != nil {
+		return account, err
+	}
+
+	if account.Cards, err = findCards(ctx, tx, userID, false); err != nil {
+		return account, err
+	}
+
+	if account.Files, err = findFiles(ctx, tx, userID, false); err != nil {
+		return account, err
+	}
+
+	if account.Notes, err = findNotes(ctx, tx, userID, false); err != nil {
+		return account, err
+	}
+
+	if account.OTPs, err = findOTPs(ctx, tx, userID, false); err != nil {
+		return account, err
+	}
+
+	if account.SSHKeys, err = findSSHKeys(ctx, tx, userID, false); err != nil {
+		return account, err
+	}
+
+	if account.Items, err = findItems(ctx, tx, userID, false); err != nil {
+		return account, err
+	}
+
+	if account.Folders, err = findFolders(ctx, tx, userID); err != nil {
+		return account, err
+	}
+
+	if account.Tags, err = findTags(ctx, tx, userID); err != nil {
+		return account, err
+	}
+
+	return account, tx.Commit()
+}
+
+// DeleteUser удаляет учётную запись пользователя со всеми данными при условии, что пароль указан верно,
+// возвращает пути к содержимому файлов пользователя, включая загруженное для смены мастер-пароля
+func (repo RepoPostgreSQL) DeleteUser(ctx context.Context, userID int, password string) (paths []string, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = repo.checkPassword(ctx, tx, userID, password)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT data->>'path' FROM items WHERE user_id = $1 and type = $2 UNION SELECT path FROM rekey_files WHERE user_id = $1`,
+		userID,
+		model.ItemTypeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err = scanPaths(rows)
+	rows.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM users WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return paths, tx.Commit()
+}
+
+// SaveSession создаёт сеанс пользователя с токеном обновления refreshToken,
+// истёкшие сеансы пользователя удаляются
+func (repo RepoPostgreSQL) SaveSession(ctx context.Context, userID int, refreshToken string, expiresAt time.Time) (sessionID int, err error) {
+	if repo.db == nil {
+		return 0, ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1 and expires_at < now()`, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO sessions (user_id, token_hash, expires_at) VALUES($1, $2, $3) RETURNING session_id`,
+		userID,
+		tokenHash(refreshToken),
+		expiresAt).Scan(&sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	return sessionID, tx.Commit()
+}
+
+// RotateSession заменяет токен обновления сеанса refreshToken на newRefreshToken и продлевает сеанс,
+// заменённый токен запоминается среди токенов сеанса. Повторное предъявление любого заменённого
+// токена сеанса считается признаком его кражи, сеанс при этом удаляется
+func (repo RepoPostgreSQL) RotateSession(
+	ctx context.Context,
+	refreshToken, newRefreshToken string,
+	expiresAt time.Time,
+) (sessionID, userID int, err error) {
+	if repo.db == nil {
+		return 0, 0, ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	hash := tokenHash(refreshToken)
+
+	err = tx.QueryRowContext(ctx,
+		`UPDATE sessions SET token_hash = $1, expires_at = $2
+		WHERE token_hash = $3 and expires_at > now() RETURNING session_id, user_id`,
+		tokenHash(newRefreshToken),
+		expiresAt,
+		hash).Scan(&sessionID, &userID)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, `INSERT INTO session_tokens (token_hash, session_id) VALUES($1, $2)`, hash, sessionID)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		return sessionID, userID, tx.Commit()
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM sessions WHERE token_hash = $1 or session_id IN (SELECT session_id FROM session_tokens WHERE token_hash = $1)`,
+		hash)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+
+	return 0, 0, ErrDBInvalidRefreshToken
+}
+
+// FindSession проверяет, что сеанс пользователя существует и не истёк
+func (repo RepoPostgreSQL) FindSession(ctx context.Context, userID, sessionID int) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	var found bool
+
+	err = repo.db.QueryRowContext(ctx,
+		`SELECT true FROM sessions WHERE session_id = $1 and user_id = $2 and expires_at > now()`,
+		sessionID,
+		userID).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrDBSessionNotFound
+	}
+
+	return err
+}
+
+// DeleteSession завершает сеанс пользователя
+func (repo RepoPostgreSQL) DeleteSession(ctx context.Context, userID, sessionID int) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	_, err = repo.db.ExecContext(ctx,
+		`DELETE FROM sessions WHERE session_id = $1 and user_id = $2`,
+		sessionID,
+		userID)
+
+	return err
+}
+
+// revokeSessions в транзакции tx завершает сеансы пользователя, кроме сеанса sessionID, и удаляет
+// его токены API. Сеансу sessionID выдаётся новый токен обновления refreshToken, прежний токен
+// запоминается среди токенов сеанса, и его повторное предъявление завершает сеанс
+func revokeSessions(ctx context.Context, tx *sql.Tx, userID, sessionID int, refreshToken string, expiresAt time.Time) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1 and session_id <> $2`, userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO session_tokens (token_hash, session_id)
+		SELECT token_hash, session_id FROM sessions WHERE session_id = $1 and user_id = $2`,
+		sessionID,
+		userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrDBSessionNotFound
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE sessions SET token_hash = $1, expires_at = $2 WHERE session_id = $3 and user_id = $4`,
+		tokenHash(refreshToken),
+		expiresAt,
+		sessionID,
+		userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM api_tokens WHERE user_id = $1`, userID)
+
+	return err
+}
+
+// DeleteSessions завершает все сеансы пользователя
+func (repo RepoPostgreSQL) DeleteSessions(ctx context.Context, userID int) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	_, err = repo.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1`, userID)
+
+	return err
+}
+
+// SaveAPIToken сохраняет токен API пользователя с секретом tokenString
+func (repo RepoPostgreSQL) SaveAPIToken(ctx context.Context, token *model.APIToken, tokenString string) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	return repo.db.QueryRowContext(ctx,
+		`INSERT INTO api_tokens (user_id, name, token_hash, scopes, expires_at) VALUES($1, $2, $3, $4, $5)
+		RETURNING token_id, created_at`,
+		token.UserID,
+		token.Name,
+		tokenHash(tokenString),
+		strings.Join(token.Scopes, ","),
+		token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+}
+
+// FindAPIToken возвращает действующий токен API по секрету tokenString и отмечает время его использования
+func (repo RepoPostgreSQL) FindAPIToken(ctx context.Context, tokenString string) (token model.APIToken, err error) {
+	if repo.db == nil {
+		return token, ErrDBNoDBConn
+	}
+
+	var scopes string
+
+	var expiresAt, lastUsedAt sql.NullTime
+
+	err = repo.db.QueryRowContext(ctx,
+		`UPDATE api_tokens SET last_used_at = now()
+		WHERE token_hash = $1 and (expires_at IS NULL or expires_at > now())
+		RETURNING token_id, user_id, name, scopes, expires_at, created_at, last_used_at`,
+		tokenHash(tokenString)).Scan(&token.ID, &token.UserID, &token.Name, &scopes, &expiresAt, &token.CreatedAt, &lastUsedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return token, ErrDBAPITokenNotFound
+		default:
+			return token, err
+		}
+	}
+
+	token.Scopes = splitScopes(scopes)
+	token.ExpiresAt = nullTime(expiresAt)
+	token.LastUsedAt = nullTime(lastUsedAt)
+
+	return token, nil
+}
+
+// FindAPITokens возвращает токены API пользователя, включая истёкшие
+func (repo RepoPostgreSQL) FindAPITokens(ctx context.Context, userID int) (tokens []model.APIToken, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
+	}
+
+	rows, err := repo.db.QueryContext(ctx,
+		`SELECT token_id, name, scopes, expires_at, created_at, last_used_at FROM api_tokens WHERE user_id = $1 ORDER BY token_id`,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tokens = make([]model.APIToken, 0)
+
+	for rows.Next() {
+		token := model.APIToken{UserID: userID}
+
+		var scopes string
+
+		var expiresAt, lastUsedAt sql.NullTime
+
+		err = rows.Scan(&token.ID, &token.Name, &scopes, &expiresAt, &token.CreatedAt, &lastUsedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		token.Scopes = splitScopes(scopes)
+		token.ExpiresAt = nullTime(expiresAt)
+		token.LastUsedAt = nullTime(lastUsedAt)
+
+		tokens = append(tokens, token)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// DeleteAPIToken отзывает токен API пользователя
+func (repo RepoPostgreSQL) DeleteAPIToken(ctx context.Context, userID, tokenID int) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	result, err := repo.db.ExecContext(ctx,
+		`DELETE FROM api_tokens WHERE user_id = $1 and token_id = $2`,
+		userID, tokenID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrDBAPITokenNotFound
+	}
+
+	return nil
+}
+
+// FindTOTP возвращает состояние двухфакторной аутентификации пользователя
+func (repo RepoPostgreSQL) FindTOTP(ctx context.Context, userID int) (totp model.TOTP, err error) {
+	if repo.db == nil {
+		return totp, ErrDBNoDBConn
+	}
+
+	err = repo.db.QueryRowContext(ctx,
+		`SELECT username, totp_secret, totp_pending, totp_last_step FROM users WHERE user_id = $1`,
+		userID).Scan(&totp.Username, &totp.Secret, &totp.Pending, &totp.LastStep)
+
+	return totp, err
+}
+
+// SaveTOTPPending сохраняет секрет двухфакторной аутентификации, ожидающий подтверждения
+func (repo RepoPostgreSQL) SaveTOTPPending(ctx context.Context, userID int, secret string) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	_, err = repo.db.ExecContext(ctx,
+		`UPDATE users SET totp_pending = $1 WHERE user_id = $2`,
+		secret,
+		userID)
+
+	return err
+}
+
+// EnableTOTP подключает двухфакторную аутентификацию с подтверждённым секретом secret,
+// step — номер интервала одноразового пароля, которым подтверждён секрет,
+// резервные коды пользователя заменяются кодами backupCodes
+func (repo RepoPostgreSQL) EnableTOTP(ctx context.Context, userID int, secret string, step uint64, backupCodes []string) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE users SET totp_secret = totp_pending, totp_pending = '', totp_last_step = $1
+		WHERE user_id = $2 and totp_pending = $3 and totp_pending <> ''`,
+		int64(step),
+		userID,
+		secret)
+	if err != nil {
+		return err
+	}
+
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return ErrDBTOTPNotPending
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM totp_backup_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, code := range backupCodes {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO totp_backup_codes (user_id, code_hash) VALUES($1, $2)`,
+			userID,
+			tokenHash(code))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DisableTOTP отключает двухфакторную аутентификацию и удаляет резервные коды пользователя
+func (repo RepoPostgreSQL) DisableTOTP(ctx context.Context, userID int) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET totp_secret = '', totp_pending = '', totp_last_step = 0 WHERE user_id = $1`,
+		userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM totp_backup_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseTOTPStep отмечает интервал step одноразового пароля использованным,
+// пароль того же или более раннего интервала повторно не принимается
+func (repo RepoPostgreSQL) UseTOTPStep(ctx context.Context, userID int, step uint64) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	result, err := repo.db.ExecContext(ctx,
+		`UPDATE users SET totp_last_step = $1 WHERE user_id = $2 and totp_last_step < $1`,
+		int64(step),
+		userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrDBTOTPCodeUsed
+	}
+
+	return nil
+}
+
+// UseTOTPBackupCode удаляет использованный резервный код пользователя
+func (repo RepoPostgreSQL) UseTOTPBackupCode(ctx context.Context, userID int, code string) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	result, err := repo.db.ExecContext(ctx,
+		`DELETE FROM totp_backup_codes WHERE backup_code_id = (
+			SELECT backup_code_id FROM totp_backup_codes WHERE user_id = $1 and code_hash = $2 LIMIT 1)`,
+		userID,
+		tokenHash(code))
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrDBInvalidTOTPCode
+	}
+
+	return nil
+}
+
+// SaveMFAToken сохраняет идентификатор tokenID токена второго шага аутентификации пользователя,
+// токены пользователя с истёкшим сроком действия удаляются
+func (repo RepoPostgreSQL) SaveMFAToken(ctx context.Context, userID int, tokenID string, expiresAt time.Time) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM mfa_tokens WHERE user_id = $1 and expires_at < now()`, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO mfa_tokens (token_id, user_id, expires_at) VALUES($1, $2, $3)`,
+		tokenID,
+		userID,
+		expiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountMFAAttempt учитывает попытку второго шага аутентификации по токену tokenID до проверки кода,
+// токен, по которому сделано maxAttempts попыток, больше не принимается
+func (repo RepoPostgreSQL) CountMFAAttempt(ctx context.Context, userID int, tokenID string, maxAttempts int) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	result, err := repo.db.ExecContext(ctx,
+		`UPDATE mfa_tokens SET attempts = attempts + 1
+		WHERE token_id = $1 and user_id = $2 and expires_at > now() and attempts < $3`,
+		tokenID,
+		userID,
+		maxAttempts)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
 
-	err = repo.db.QueryRowContext(ctx,
-		`INSERT INTO users (username, password) VALUES($1, $2) RETURNING user_id`,
-		user.Username,
-		hex.EncodeToString(passwordHash[:])).Scan(&userID)
+	if rows == 0 {
+		return ErrDBMFATokenNotFound
+	}
+
+	return nil
+}
+
+// DeleteMFAToken удаляет токен второго шага аутентификации после успешной проверки кода,
+// токен, уже использованный для входа, не найден
+func (repo RepoPostgreSQL) DeleteMFAToken(ctx context.Context, userID int, tokenID string) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	result, err := repo.db.ExecContext(ctx,
+		`DELETE FROM mfa_tokens WHERE token_id = $1 and user_id = $2 and expires_at > now()`,
+		tokenID,
+		userID)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			return 0, ErrDBUsernameIsAlreadyTaken
-		}
+		return err
+	}
 
-		return 0, err
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrDBMFATokenNotFound
 	}
 
-	return userID, err
+	return nil
 }
 
-// FindUser используется при аутентификации пользователя
-func (repo RepoPostgreSQL) FindUser(ctx context.Context, user model.User) (userID int, err error) {
+// FindAuthLockout возвращает оставшееся время блокировки попыток аутентификации по ключу key
+func (repo RepoPostgreSQL) FindAuthLockout(ctx context.Context, key string) (retryAfter time.Duration, err error) {
 	if repo.db == nil {
 		return 0, ErrDBNoDBConn
 	}
 
-	passwordHash := sha256.Sum256([]byte(user.Password))
+	var milliseconds float64
 
 	err = repo.db.QueryRowContext(ctx,
-		`SELECT user_id FROM users WHERE username = $1 and password = $2`,
-		user.Username,
-		hex.EncodeToString(passwordHash[:])).Scan(&userID)
+		`SELECT COALESCE(MAX(EXTRACT(EPOCH FROM locked_until - now()) * 1000), 0) FROM auth_failures WHERE key = $1`,
+		key).Scan(&milliseconds)
+	if err != nil || milliseconds <= 0 {
+		return 0, err
+	}
+
+	return time.Duration(milliseconds) * time.Millisecond, nil
+}
+
+// SaveAuthAttempt учитывает попытку аутентификации по ключу key как неудачную до проверки учётных
+// данных и блокирует следующие попытки согласно политике policy. Счётчик увеличивается и читается
+// одним запросом, поэтому параллельные попытки получают разные значения. Если попытки по ключу
+// заблокированы, попытка не учитывается и возвращается оставшееся время блокировки. Записи с истёкшим
+// окном учёта удаляются
+func (repo RepoPostgreSQL) SaveAuthAttempt(
+	ctx context.Context,
+	key string,
+	policy limiter.Policy,
+) (failures int, retryAfter time.Duration, err error) {
+	if repo.db == nil {
+		return 0, 0, ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
-		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			return 0, ErrDBInvalidUsernamePasswordPair
-		default:
-			return 0, err
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	window := policy.Window.Milliseconds()
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM auth_failures WHERE updated_at < now() - $1 * interval '1 millisecond' and locked_until < now()`,
+		window)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO auth_failures AS f (key, failures) VALUES($1, 1)
+		ON CONFLICT (key) DO UPDATE SET updated_at = now(), failures = CASE
+			WHEN f.updated_at < now() - $2 * interval '1 millisecond' THEN 1 ELSE f.failures + 1 END
+		WHERE f.locked_until <= now()
+		RETURNING f.failures`,
+		key,
+		window).Scan(&failures)
+	if errors.Is(err, sql.ErrNoRows) {
+		var milliseconds float64
+
+		err = tx.QueryRowContext(ctx,
+			`SELECT GREATEST(EXTRACT(EPOCH FROM locked_until - now()) * 1000, 1) FROM auth_failures WHERE key = $1`,
+			key).Scan(&milliseconds)
+		if err != nil {
+			return 0, 0, err
 		}
+
+		return 0, time.Duration(milliseconds) * time.Millisecond, tx.Commit()
+	}
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE auth_failures SET locked_until = now() + $1 * interval '1 millisecond' WHERE key = $2`,
+		policy.Delay(failures).Milliseconds(),
+		key)
+	if err != nil {
+		return 0, 0, err
 	}
 
-	return userID, err
+	return failures, 0, tx.Commit()
 }
 
-// SaveLogin используется при сохранении данных логина пользователя
-func (repo RepoPostgreSQL) SaveLogin(ctx context.Context, login *model.Login) (err error) {
+// DeleteAuthAttempt отменяет попытку аутентификации по ключу key, проверка которой прошла успешно,
+// и сокращает блокировку до времени, которое соответствует оставшимся неудачным попыткам
+func (repo RepoPostgreSQL) DeleteAuthAttempt(ctx context.Context, key string, policy limiter.Policy) (err error) {
 	if repo.db == nil {
 		return ErrDBNoDBConn
 	}
 
-	if login.ID == 0 {
-		_, err = repo.db.ExecContext(ctx,
-			`INSERT INTO logins (user_id, username, password, metainfo) VALUES($1, $2, $3, $4)`,
-			login.UserID,
-			login.Username,
-			login.Password,
-			login.MetaInfo)
-	} else {
-		_, err = repo.db.ExecContext(ctx,
-			`UPDATE logins SET username = $1, password = $2, metainfo = $3 WHERE login_id = $4 and user_id = $5`,
-			login.Username,
-			login.Password,
-			login.MetaInfo,
-			login.ID,
-			login.UserID)
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var failures int
+
+	err = tx.QueryRowContext(ctx,
+		`UPDATE auth_failures SET failures = GREATEST(failures - 1, 0) WHERE key = $1 RETURNING failures`,
+		key).Scan(&failures)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE auth_failures SET locked_until = LEAST(locked_until, now() + $1 * interval '1 millisecond')
+		WHERE key = $2`,
+		policy.Delay(failures).Milliseconds(),
+		key)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteAuthFailures сбрасывает счётчик неудачных попыток аутентификации по ключу key
+func (repo RepoPostgreSQL) DeleteAuthFailures(ctx context.Context, key string) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
 	}
 
+	_, err = repo.db.ExecContext(ctx, `DELETE FROM auth_failures WHERE key = $1`, key)
+
 	return err
 }
 
+// SaveLogin используется при сохранении данных логина пользователя
+func (repo RepoPostgreSQL) SaveLogin(ctx context.Context, login *model.Login) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	return saveTypedItem(ctx, repo.db, login.UserID, &login.ID, login.UID, model.ItemTypeLogin, login, repo.revisions)
+}
+
 // DeleteLogin используется при удалении данных логина пользователя
 func (repo RepoPostgreSQL) DeleteLogin(ctx context.Context, login *model.Login) (err error) {
 	if repo.db == nil {
 		return ErrDBNoDBConn
 	}
 
-	_, err = repo.db.ExecContext(ctx,
-		`DELETE FROM logins WHERE login_id = $1 and user_id = $2`,
-		login.ID,
-		login.UserID)
-
-	return err
+	return deleteItem(ctx, repo.db, login.UserID, login.ID, model.ItemTypeLogin)
 }
 
 // FindLogins возвращает данные логинов пользователя
@@ -126,114 +1464,133 @@ func (repo RepoPostgreSQL) FindLogins(ctx context.Context, user model.User) (log
 		return nil, ErrDBNoDBConn
 	}
 
-	rows, err := repo.db.QueryContext(ctx,
-		"SELECT login_id, username, password, metainfo FROM logins WHERE user_id = $1 ORDER BY login_id DESC",
-		user.ID)
-	if err != nil {
-		return nil, err
-	}
+	return findLogins(ctx, repo.db, user.ID, true)
+}
 
-	defer rows.Close()
+// SaveCard используется при сохранении данных банковской карты пользователя
+func (repo RepoPostgreSQL) SaveCard(ctx context.Context, card *model.Card) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
 
-	logins = make([]model.Login, 0)
+	return saveTypedItem(ctx, repo.db, card.UserID, &card.ID, card.UID, model.ItemTypeCard, card, repo.revisions)
+}
 
-	for rows.Next() {
-		login := model.Login{}
+// DeleteCard используется при удалении данных банковской карты пользователя
+func (repo RepoPostgreSQL) DeleteCard(ctx context.Context, card *model.Card) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
 
-		err = rows.Scan(&login.ID, &login.Username, &login.Password, &login.MetaInfo)
-		if err != nil {
-			return nil, err
-		}
+	return deleteItem(ctx, repo.db, card.UserID, card.ID, model.ItemTypeCard)
+}
 
-		logins = append(logins, login)
+// FindCards возвращает данные банковских карт пользователя
+func (repo RepoPostgreSQL) FindCards(ctx context.Context, user model.User) (cards []model.Card, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
 	}
 
-	err = rows.Err()
-	if err != nil {
-		return nil, err
+	return findCards(ctx, repo.db, user.ID, true)
+}
+
+// SaveNote используется при сохранении заметки пользователя
+func (repo RepoPostgreSQL) SaveNote(ctx context.Context, note *model.Note) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
 	}
 
-	return logins, err
+	return saveTypedItem(ctx, repo.db, note.UserID, &note.ID, note.UID, model.ItemTypeNote, note, repo.revisions)
 }
 
-// SaveCard используется при сохранении данных банковской карты пользователя
-func (repo RepoPostgreSQL) SaveCard(ctx context.Context, card *model.Card) (err error) {
+// DeleteNote используется при удалении заметки пользователя
+func (repo RepoPostgreSQL) DeleteNote(ctx context.Context, note *model.Note) (err error) {
 	if repo.db == nil {
 		return ErrDBNoDBConn
 	}
 
-	if card.ID == 0 {
-		_, err = repo.db.ExecContext(ctx,
-			`INSERT INTO cards (user_id, bank, number, date, cvv, metainfo) VALUES($1, $2, $3, $4, $5, $6)`,
-			card.UserID,
-			card.Bank,
-			card.Number,
-			card.Date,
-			card.CVV,
-			card.MetaInfo)
-	} else {
-		_, err = repo.db.ExecContext(ctx,
-			`UPDATE cards SET bank = $1, number = $2, date = $3, cvv = $4, metainfo = $5 WHERE card_id = $6 and user_id = $7`,
-			card.Bank,
-			card.Number,
-			card.Date,
-			card.CVV,
-			card.MetaInfo,
-			card.ID,
-			card.UserID)
+	return deleteItem(ctx, repo.db, note.UserID, note.ID, model.ItemTypeNote)
+}
+
+// FindNotes возвращает заметки пользователя
+func (repo RepoPostgreSQL) FindNotes(ctx context.Context, user model.User) (notes []model.Note, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
 	}
 
-	return err
+	return findNotes(ctx, repo.db, user.ID, true)
 }
 
-// DeleteCard используется при удалении данных банковской карты пользователя
-func (repo RepoPostgreSQL) DeleteCard(ctx context.Context, card *model.Card) (err error) {
+// SaveOTP используется при сохранении ключа одноразовых паролей пользователя,
+// логин, к которому привязывается ключ, должен принадлежать пользователю
+func (repo RepoPostgreSQL) SaveOTP(ctx context.Context, otp *model.OTP) (err error) {
 	if repo.db == nil {
 		return ErrDBNoDBConn
 	}
 
-	_, err = repo.db.ExecContext(ctx,
-		`DELETE FROM cards WHERE card_id = $1 and user_id = $2`,
-		card.ID,
-		card.UserID)
+	if otp.LoginID != 0 {
+		var exists bool
 
-	return err
+		err = repo.db.QueryRowContext(ctx,
+			`SELECT EXISTS(SELECT 1 FROM items WHERE item_id = $1 and user_id = $2 and type = $3 and deleted_at IS NULL)`,
+			otp.LoginID,
+			otp.UserID,
+			model.ItemTypeLogin).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return ErrDBLoginNotFound
+		}
+	}
+
+	return saveTypedItem(ctx, repo.db, otp.UserID, &otp.ID, otp.UID, model.ItemTypeOTP, otp, repo.revisions)
 }
 
-// FindCards возвращает данные банковских карт пользователя
-func (repo RepoPostgreSQL) FindCards(ctx context.Context, user model.User) (cards []model.Card, err error) {
+// DeleteOTP используется при удалении ключа одноразовых паролей пользователя
+func (repo RepoPostgreSQL) DeleteOTP(ctx context.Context, otp *model.OTP) (err error) {
 	if repo.db == nil {
-		return nil, ErrDBNoDBConn
+		return ErrDBNoDBConn
 	}
 
-	rows, err := repo.db.QueryContext(ctx,
-		"SELECT card_id, bank, number, date, cvv, metainfo FROM cards WHERE user_id = $1 ORDER BY card_id DESC",
-		user.ID)
-	if err != nil {
-		return nil, err
-	}
+	return deleteItem(ctx, repo.db, otp.UserID, otp.ID, model.ItemTypeOTP)
+}
 
-	defer rows.Close()
+// FindOTPs возвращает ключи одноразовых паролей пользователя
+func (repo RepoPostgreSQL) FindOTPs(ctx context.Context, user model.User) (otps []model.OTP, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
+	}
 
-	cards = make([]model.Card, 0)
+	return findOTPs(ctx, repo.db, user.ID, true)
+}
 
-	for rows.Next() {
-		card := model.Card{}
+// SaveSSHKey используется при сохранении ключа SSH пользователя
+func (repo RepoPostgreSQL) SaveSSHKey(ctx context.Context, key *model.SSHKey) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
 
-		err = rows.Scan(&card.ID, &card.Bank, &card.Number, &card.Date, &card.CVV, &card.MetaInfo)
-		if err != nil {
-			return nil, err
-		}
+	return saveTypedItem(ctx, repo.db, key.UserID, &key.ID, key.UID, model.ItemTypeSSHKey, key, repo.revisions)
+}
 
-		cards = append(cards, card)
+// DeleteSSHKey используется при удалении ключа SSH пользователя
+func (repo RepoPostgreSQL) DeleteSSHKey(ctx context.Context, key *model.SSHKey) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
 	}
 
-	err = rows.Err()
-	if err != nil {
-		return nil, err
+	return deleteItem(ctx, repo.db, key.UserID, key.ID, model.ItemTypeSSHKey)
+}
+
+// FindSSHKeys возвращает ключи SSH пользователя
+func (repo RepoPostgreSQL) FindSSHKeys(ctx context.Context, user model.User) (keys []model.SSHKey, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
 	}
 
-	return cards, err
+	return findSSHKeys(ctx, repo.db, user.ID, true)
 }
 
 // SaveFile используется при сохранении данных файла пользователя
@@ -242,24 +1599,7 @@ func (repo RepoPostgreSQL) SaveFile(ctx context.Context, file *model.File) (err
 		return ErrDBNoDBConn
 	}
 
-	if file.ID == 0 {
-		_, err = repo.db.ExecContext(ctx,
-			`INSERT INTO files (user_id, path, name, metainfo) VALUES($1, $2, $3, $4)`,
-			file.UserID,
-			file.Path,
-			file.Name,
-			file.MetaInfo)
-	} else {
-		_, err = repo.db.ExecContext(ctx,
-			`UPDATE files SET path = $1, name = $2, metainfo = $3 WHERE file_id = $4 and user_id = $5`,
-			file.Path,
-			file.Name,
-			file.MetaInfo,
-			file.ID,
-			file.UserID)
-	}
-
-	return err
+	return saveTypedItem(ctx, repo.db, file.UserID, &file.ID, file.UID, model.ItemTypeFile, file, repo.revisions)
 }
 
 // FindFile получает данные файла по Id пользователя и Id файла
@@ -270,13 +1610,19 @@ func (repo RepoPostgreSQL) FindFile(ctx context.Context, fileID, userID int) (fi
 
 	file = &model.File{}
 
-	err = repo.db.QueryRowContext(ctx,
-		"SELECT file_id, user_id, path, name, metainfo FROM files WHERE file_id = $1 and user_id = $2",
-		fileID, userID).Scan(&file.ID, &file.UserID, &file.Path, &file.Name, &file.MetaInfo)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+	uid, err := findTypedItem(ctx, repo.db, userID, fileID, model.ItemTypeFile, file)
+	if errors.Is(err, ErrDBItemNotFound) {
+		return &model.File{}, nil
+	}
+
+	if err != nil {
 		return nil, err
 	}
 
+	file.ID = fileID
+	file.UserID = userID
+	file.UID = uid
+
 	return file, nil
 }
 
@@ -286,55 +1632,176 @@ func (repo RepoPostgreSQL) DeleteFile(ctx context.Context, file *model.File) (er
 		return ErrDBNoDBConn
 	}
 
-	_, err = repo.db.ExecContext(ctx,
-		`DELETE FROM files WHERE file_id = $1 and user_id = $2`,
-		file.ID,
-		file.UserID)
-
-	return err
+	return deleteItem(ctx, repo.db, file.UserID, file.ID, model.ItemTypeFile)
 }
 
-// FindFiles возвращает данные файлов пользователя
+// FindFiles возвращает данные файлов пользователя, путь к содержимому файла не возвращается
 func (repo RepoPostgreSQL) FindFiles(ctx context.Context, user model.User) (files []model.File, err error) {
 	if repo.db == nil {
 		return nil, ErrDBNoDBConn
 	}
 
-	rows, err := repo.db.QueryContext(ctx,
-		"SELECT file_id, name, metainfo FROM files WHERE user_id = $1 ORDER BY file_id DESC",
-		user.ID)
+	files, err = findFiles(ctx, repo.db, user.ID, true)
 	if err != nil {
 		return nil, err
 	}
 
+	for i := range files {
+		files[i].Path = ""
+	}
+
+	return files, nil
+}
+
+// Close закрывает соединение с БД
+func (repo RepoPostgreSQL) Close() error {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	return repo.db.Close()
+}
+
+// checkIDs проверяет, что запрос query возвращает ровно набор идентификаторов ids
+func checkIDs(ctx context.Context, tx *sql.Tx, query string, userID int, ids []int) error {
+	rows, err := tx.QueryContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
 	defer rows.Close()
 
-	files = make([]model.File, 0)
+	expected := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		expected[id] = true
+	}
+
+	count := 0
 
 	for rows.Next() {
-		file := model.File{}
+		var id int
 
-		err = rows.Scan(&file.ID, &file.Name, &file.MetaInfo)
-		if err != nil {
+		if err = rows.Scan(&id); err != nil {
+			return err
+		}
+
+		if !expected[id] {
+			return ErrDBVaultChanged
+		}
+
+		count++
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if count != len(expected) || count != len(ids) {
+		return ErrDBVaultChanged
+	}
+
+	return nil
+}
+
+// scanPaths возвращает пути к файлам из результата запроса
+func scanPaths(rows *sql.Rows) (paths []string, err error) {
+	paths = make([]string, 0)
+
+	for rows.Next() {
+		var path string
+
+		if err = rows.Scan(&path); err != nil {
 			return nil, err
 		}
 
-		files = append(files, file)
+		paths = append(paths, path)
 	}
 
-	err = rows.Err()
-	if err != nil {
+	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return files, err
+	return paths, nil
 }
 
-// Close закрывает соединение с БД
-func (repo RepoPostgreSQL) Close() error {
-	if repo.db == nil {
-		return ErrDBNoDBConn
+// insertRecoveryKeys сохраняет ключи восстановления пользователя в транзакции tx
+func insertRecoveryKeys(ctx context.Context, tx *sql.Tx, userID int, keys []model.RecoveryKey) error {
+	for _, key := range keys {
+		verifierHash := sha256.Sum256([]byte(key.Verifier))
+
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO recovery_keys (user_id, verifier, vault_key) VALUES($1, $2, $3)`,
+			userID,
+			hex.EncodeToString(verifierHash[:]),
+			key.VaultKey)
+		if err != nil {
+			return err
+		}
 	}
 
-	return repo.db.Close()
+	return nil
+}
+
+// checkPassword проверяет пароль пользователя и блокирует его запись до конца транзакции tx
+func (repo RepoPostgreSQL) checkPassword(ctx context.Context, tx *sql.Tx, userID int, password string) error {
+	var passwordHash string
+
+	err := tx.QueryRowContext(ctx,
+		`SELECT password FROM users WHERE user_id = $1 FOR UPDATE`,
+		userID).Scan(&passwordHash)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrDBInvalidUsernamePasswordPair
+		default:
+			return err
+		}
+	}
+
+	ok, _, err := repo.hasher.Verify(password, passwordHash)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrDBInvalidUsernamePasswordPair
+	}
+
+	return nil
+}
+
+// checkKDFTransition проверяет, что пользователь не переходит на более старую схему получения ключей
+func checkKDFTransition(ctx context.Context, tx *sql.Tx, userID int, kdf model.KDF) error {
+	var version uint8
+
+	err := tx.QueryRowContext(ctx,
+		`SELECT kdf_version FROM users WHERE user_id = $1`,
+		userID).Scan(&version)
+	if err != nil {
+		return err
+	}
+
+	return kdf.ValidateTransition(version)
+}
+
+func tokenHash(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
+
+func splitScopes(scopes string) []string {
+	if scopes == "" {
+		return []string{}
+	}
+
+	return strings.Split(scopes, ",")
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
 }
@@ -0,0 +1,185 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+// deviceKey ключ контекста с названием устройства, которое сохраняет записи
+type deviceKey struct{}
+
+// WithDevice возвращает контекст сохранения записей устройством device,
+// название устройства хранится вместе с редакциями записей
+func WithDevice(ctx context.Context, device string) context.Context {
+	return context.WithValue(ctx, deviceKey{}, model.DeviceName(device))
+}
+
+// contextDevice возвращает название устройства, которое сохраняет записи в контексте ctx
+func contextDevice(ctx context.Context) string {
+	device, _ := ctx.Value(deviceKey{}).(string)
+
+	return device
+}
+
+// FindRevisions возвращает прежние редакции записи itemID пользователя, начиная с последней
+func (repo RepoPostgreSQL) FindRevisions(ctx context.Context, userID, itemID int) (revisions []model.Revision, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
+	}
+
+	var itemType string
+
+	err = repo.db.QueryRowContext(ctx,
+		`SELECT type FROM items WHERE item_id = $1 and user_id = $2 and deleted_at IS NULL`,
+		itemID,
+		userID).Scan(&itemType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDBItemNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.QueryContext(ctx,
+		`SELECT revision_id, uid, data, device, created_at, folder_id, tag_ids FROM item_revisions
+		WHERE item_id = $1 and user_id = $2 ORDER BY revision_id DESC`,
+		itemID,
+		userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	revisions = make([]model.Revision, 0)
+
+	for rows.Next() {
+		revision := model.Revision{ItemID: itemID, Type: itemType}
+
+		var data []byte
+
+		err = scanPlacement(rows, &revision.Placement, &revision.ID, &revision.UID, &data, &revision.Device, &revision.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		revision.Data = data
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// RestoreRevision восстанавливает редакцию revisionID записи itemID пользователя, текущая редакция
+// записи сохраняется в истории. Папка, метки и логин ключа одноразовых паролей, удалённые
+// после сохранения редакции, в восстановленной записи не указываются
+func (repo RepoPostgreSQL) RestoreRevision(ctx context.Context, userID, itemID, revisionID int) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var (
+		uid      string
+		itemType string
+		data     []byte
+		tagIDs   []byte
+		place    model.Placement
+	)
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT type FROM items WHERE item_id = $1 and user_id = $2 and deleted_at IS NULL FOR UPDATE`,
+		itemID,
+		userID).Scan(&itemType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrDBItemNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT r.uid, r.data,
+			COALESCE((SELECT f.folder_id FROM folders f WHERE f.folder_id = r.folder_id and f.user_id = r.user_id), 0),
+			COALESCE((SELECT json_agg(t.tag_id ORDER BY t.tag_id) FROM tags t
+				WHERE t.user_id = r.user_id and t.tag_id IN (SELECT jsonb_array_elements_text(r.tag_ids)::int)), '[]')
+		FROM item_revisions r WHERE r.revision_id = $1 and r.item_id = $2 and r.user_id = $3`,
+		revisionID,
+		itemID,
+		userID).Scan(&uid, &data, &place.FolderID, &tagIDs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrDBRevisionNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(tagIDs, &place.TagIDs); err != nil {
+		return err
+	}
+
+	if err = saveItem(ctx, tx, userID, &itemID, uid, itemType, data, place, repo.revisions); err != nil {
+		return err
+	}
+
+	if itemType == model.ItemTypeOTP {
+		_, err = tx.ExecContext(ctx,
+			`UPDATE items o SET data = jsonb_set(o.data, '{login_id}', '0')
+			WHERE o.item_id = $1 and o.user_id = $2 and COALESCE((o.data->>'login_id')::int, 0) <> 0 and NOT EXISTS
+				(SELECT 1 FROM items l WHERE l.item_id = (o.data->>'login_id')::int and l.user_id = o.user_id and l.type = $3)`,
+			itemID,
+			userID,
+			model.ItemTypeLogin)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// saveRevision сохраняет текущую редакцию записи id типа itemType в истории и удаляет из истории
+// редакции сверх revisions последних. Редакции файлов не хранятся: прежнее содержимое файла
+// удаляется из файлового репозитория при загрузке нового
+func saveRevision(ctx context.Context, q querier, userID, id int, itemType string, revisions int) error {
+	if revisions <= 0 || itemType == model.ItemTypeFile {
+		return nil
+	}
+
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO item_revisions (item_id, user_id, uid, data, folder_id, tag_ids, device, created_at)
+		SELECT i.item_id, i.user_id, i.uid, i.data, COALESCE(i.folder_id, 0),
+			COALESCE((SELECT jsonb_agg(t.tag_id ORDER BY t.tag_id) FROM item_tags t WHERE t.item_id = i.item_id), '[]'),
+			i.device, i.updated_at
+		FROM items i WHERE i.item_id = $1 and i.user_id = $2 and i.type = $3`,
+		id,
+		userID,
+		itemType)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx,
+		`DELETE FROM item_revisions WHERE item_id = $1 and revision_id NOT IN
+			(SELECT revision_id FROM item_revisions WHERE item_id = $1 ORDER BY revision_id DESC LIMIT $2)`,
+		id,
+		revisions)
+
+	return err
+}
@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+// FindSync возвращает записи пользователя всех типов, изменённые после ревизии синхронизации since,
+// удалённые в корзину записи возвращаются как удалённые без данных. Если ревизия since неизвестна
+// серверу или окончательно удалённые записи уже не могут быть переданы как удалённые, возвращаются
+// все записи хранилища с признаком Reset
+func (repo RepoPostgreSQL) FindSync(ctx context.Context, userID int, since int64) (sync model.Sync, err error) {
+	if repo.db == nil {
+		return sync, ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return sync, err
+	}
+	defer tx.Rollback()
+
+	var purged int64
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT sync_revision, sync_purged FROM users WHERE user_id = $1`,
+		userID).Scan(&sync.Revision, &purged)
+	if err != nil {
+		return sync, err
+	}
+
+	query := `SELECT i.item_id, i.uid, i.type,
+			CASE WHEN i.deleted_at IS NOT NULL THEN NULL WHEN i.type = $2 THEN i.data - 'path' ELSE i.data END,
+			i.deleted_at IS NOT NULL, ` + placementColumns + ` FROM items i
+		WHERE i.user_id = $1 and `
+	args := []interface{}{userID, model.ItemTypeFile}
+
+	sync.Reset = since <= 0 || since < purged || since > sync.Revision
+	if sync.Reset {
+		query += `i.deleted_at IS NULL`
+	} else {
+		query += `i.sync_revision > $3`
+		args = append(args, since)
+	}
+
+	rows, err := tx.QueryContext(ctx, query+` ORDER BY i.item_id`, args...)
+	if err != nil {
+		return sync, err
+	}
+
+	defer rows.Close()
+
+	sync.Items = make([]model.SyncItem, 0)
+
+	for rows.Next() {
+		item := model.SyncItem{}
+
+		var data []byte
+
+		if err = scanPlacement(rows, &item.Placement, &item.ID, &item.UID, &item.Type, &data, &item.Deleted); err != nil {
+			return sync, err
+		}
+
+		if item.Deleted {
+			item.UID, item.Placement = "", model.Placement{}
+		}
+
+		item.Data = data
+		sync.Items = append(sync.Items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return sync, err
+	}
+
+	return sync, tx.Commit()
+}
+
+// nextSyncRevision увеличивает ревизию синхронизации пользователя и возвращает её новое значение.
+// Строка пользователя остаётся заблокированной до конца транзакции q, поэтому изменения записей
+// с меньшей ревизией фиксируются раньше изменений с большей и не пропускаются FindSync
+func nextSyncRevision(ctx context.Context, q querier, userID int) (revision int64, err error) {
+	err = q.QueryRowContext(ctx,
+		`UPDATE users SET sync_revision = sync_revision + 1 WHERE user_id = $1 RETURNING sync_revision`,
+		userID).Scan(&revision)
+
+	return revision, err
+}
+
+// touchItems назначает новую ревизию синхронизации записям пользователя, которые соответствуют
+// условию condition с аргументом arg — $3, размещение которых изменяется без сохранения записей
+func touchItems(ctx context.Context, q querier, userID int, condition string, arg interface{}) error {
+	revision, err := nextSyncRevision(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx,
+		`UPDATE items SET sync_revision = $1 WHERE user_id = $2 and `+condition,
+		revision,
+		userID,
+		arg)
+
+	return err
+}
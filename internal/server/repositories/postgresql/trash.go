@@ -0,0 +1,163 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/vukit/gophkeeper/internal/server/model"
+)
+
+// FindTrash возвращает записи пользователя всех типов в корзине, начиная с последней удалённой
+func (repo RepoPostgreSQL) FindTrash(ctx context.Context, user model.User) (items []model.TrashItem, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
+	}
+
+	rows, err := repo.db.QueryContext(ctx,
+		`SELECT i.item_id, i.uid, i.type, CASE WHEN i.type = $2 THEN i.data - 'path' ELSE i.data END, i.deleted_at, `+
+			placementColumns+` FROM items i
+		WHERE i.user_id = $1 and i.deleted_at IS NOT NULL ORDER BY i.deleted_at DESC, i.item_id DESC`,
+		user.ID,
+		model.ItemTypeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items = make([]model.TrashItem, 0)
+
+	for rows.Next() {
+		item := model.TrashItem{}
+
+		var data []byte
+
+		if err = scanPlacement(rows, &item.Placement, &item.ID, &item.UID, &item.Type, &data, &item.DeletedAt); err != nil {
+			return nil, err
+		}
+
+		item.Data = data
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// RestoreTrash возвращает запись itemID пользователя из корзины с новой ревизией синхронизации
+func (repo RepoPostgreSQL) RestoreTrash(ctx context.Context, userID, itemID int) (err error) {
+	if repo.db == nil {
+		return ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	revision, err := nextSyncRevision(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE items SET deleted_at = NULL, sync_revision = $1 WHERE item_id = $2 and user_id = $3 and deleted_at IS NOT NULL`,
+		revision,
+		itemID,
+		userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrDBItemNotFound
+	}
+
+	return tx.Commit()
+}
+
+// PurgeTrash окончательно удаляет записи всех пользователей, удалённые в корзину раньше before,
+// и возвращает пути к содержимому удалённых файлов в файловом репозитории
+func (repo RepoPostgreSQL) PurgeTrash(ctx context.Context, before time.Time) (paths []string, err error) {
+	if repo.db == nil {
+		return nil, ErrDBNoDBConn
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	paths, err = purgeTrash(ctx, tx, `deleted_at < $1`, before)
+	if err != nil {
+		return nil, err
+	}
+
+	return paths, tx.Commit()
+}
+
+// purgeTrash окончательно удаляет записи в корзине, которые соответствуют условию condition с аргументами args,
+// ключи одноразовых паролей удалённых логинов отвязываются от них с новой ревизией синхронизации.
+// Ревизии синхронизации, после которых удалённые записи уже не передаются как удалённые, запоминаются
+// у пользователей в sync_purged. Возвращает пути к содержимому удалённых файлов
+func purgeTrash(ctx context.Context, tx *sql.Tx, condition string, args ...interface{}) (paths []string, err error) {
+	_, err = tx.ExecContext(ctx,
+		`WITH unlinked AS (
+			SELECT o.item_id, o.user_id FROM items o
+			WHERE o.type = '`+model.ItemTypeOTP+`' and (o.data->>'login_id')::int IN
+				(SELECT item_id FROM items WHERE user_id = o.user_id and type = '`+model.ItemTypeLogin+`' and `+condition+`)
+		), bumped AS (
+			UPDATE users u SET sync_revision = u.sync_revision + 1
+			WHERE u.user_id IN (SELECT user_id FROM unlinked) RETURNING u.user_id, u.sync_revision
+		)
+		UPDATE items o SET data = jsonb_set(o.data, '{login_id}', '0'), sync_revision = b.sync_revision
+		FROM unlinked l JOIN bumped b ON b.user_id = l.user_id WHERE o.item_id = l.item_id`,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`WITH purged AS (
+			DELETE FROM items WHERE deleted_at IS NOT NULL and `+condition+`
+			RETURNING user_id, sync_revision, CASE WHEN type = '`+model.ItemTypeFile+`' THEN data->>'path' ELSE '' END AS path
+		), marked AS (
+			UPDATE users u SET sync_purged = GREATEST(u.sync_purged, p.sync_revision)
+			FROM (SELECT user_id, max(sync_revision) AS sync_revision FROM purged GROUP BY user_id) p
+			WHERE u.user_id = p.user_id
+		)
+		SELECT path FROM purged`,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	paths = make([]string, 0)
+
+	for rows.Next() {
+		var path string
+
+		if err = rows.Scan(&path); err != nil {
+			return nil, err
+		}
+
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, rows.Err()
+}
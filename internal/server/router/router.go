@@ -2,69 +2,122 @@ package router
 
 import (
 	"context"
-	"crypto/rand"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	httpSwagger "github.com/swaggo/http-swagger"
 
-	"github.com/go-chi/jwtauth"
+	"github.com/vukit/gophkeeper/internal/server/events"
 	"github.com/vukit/gophkeeper/internal/server/handlers"
 	handler "github.com/vukit/gophkeeper/internal/server/handlers/http"
+	"github.com/vukit/gophkeeper/internal/server/jwtkeys"
+	"github.com/vukit/gophkeeper/internal/server/limiter"
 	"github.com/vukit/gophkeeper/internal/server/logger"
+	"github.com/vukit/gophkeeper/internal/server/model"
 
 	// Подключение Swagger
 	_ "github.com/vukit/gophkeeper/internal/server/swagger"
 )
 
 // NewRouter возвращает маршрутизатор для HTTP запросов
-func NewRouter(ctx context.Context, repoDB handlers.RepoDB, repoFile handlers.RepoFile, mLogger *logger.Logger) (r chi.Router, err error) {
-	secret, err := generateSecret(32)
-	if err != nil {
-		return nil, err
-	}
-
-	tokenAuth := jwtauth.New("HS256", secret, nil)
-
+func NewRouter(
+	ctx context.Context,
+	tokenAuth *jwtkeys.KeySet,
+	repoDB handlers.RepoDB,
+	repoFile handlers.RepoFile,
+	authLimiter *limiter.Limiter,
+	broker *events.Broker,
+	mLogger *logger.Logger,
+) (r chi.Router, err error) {
 	r = chi.NewRouter()
 
 	r.Use(middleware.Compress(5))
 	r.Mount("/swagger", httpSwagger.WrapHandler)
 
-	h := handler.NewHandler(tokenAuth, repoDB, repoFile, mLogger)
+	h := handler.NewHandler(tokenAuth, repoDB, repoFile, authLimiter, broker, mLogger)
 
 	r.Handle("/", http.FileServer(http.Dir("./internal/server/static")))
 
 	r.Post("/api/signup", h.SignUp(ctx))
 
+	r.Post("/api/prelogin", h.PreLogin(ctx))
+
 	r.Post("/api/signin", h.SignIn(ctx))
 
-	r.Group(func(r chi.Router) {
-		r.Use(jwtauth.Verifier(tokenAuth))
-		r.Use(jwtauth.Authenticator)
-		r.Post("/api/logins", h.SaveLogin(ctx))
-		r.Delete("/api/logins/{id}", h.DeleteLogin(ctx))
-		r.Get("/api/logins", h.FindLogins(ctx))
-		r.Post("/api/cards", h.SaveCard(ctx))
-		r.Delete("/api/cards/{id}", h.DeleteCard(ctx))
-		r.Get("/api/cards", h.FindCards(ctx))
-		r.Post("/api/files", h.SaveFile(ctx))
-		r.Delete("/api/files/{id}", h.DeleteFile(ctx))
-		r.Get("/api/files", h.FindFiles(ctx))
-		r.Get("/api/files/{id}", h.FindFile(ctx))
-	})
+	r.Post("/api/signin/totp", h.SignInTOTP(ctx))
 
-	return r, nil
-}
+	r.Post("/api/recovery", h.FindRecoveryKey(ctx))
 
-func generateSecret(n int) ([]byte, error) {
-	b := make([]byte, n)
+	r.Post("/api/recovery/password", h.Recover(ctx))
 
-	_, err := rand.Read(b)
-	if err != nil {
-		return nil, err
-	}
+	r.Post("/api/refresh", h.Refresh(ctx))
 
-	return b, nil
+	// управление учётной записью доступно только в сеансе пользователя
+	r.Group(func(r chi.Router) {
+		r.Use(jwtkeys.Verifier(tokenAuth))
+		r.Use(jwtkeys.Authenticator)
+		r.Use(h.Session(ctx))
+		r.Use(h.ClientCertificate(ctx))
+		r.Post("/api/logout", h.Logout(ctx))
+		r.Post("/api/logout/all", h.LogoutAll(ctx))
+		r.Post("/api/totp", h.EnrollTOTP(ctx))
+		r.Post("/api/totp/confirm", h.ConfirmTOTP(ctx))
+		r.Delete("/api/totp", h.DisableTOTP(ctx))
+		r.Post("/api/credential", h.UpdateCredential(ctx))
+		r.Delete("/api/account", h.DeleteAccount(ctx))
+		r.Get("/api/account/export", h.ExportAccount(ctx))
+		r.Post("/api/tokens", h.CreateAPIToken(ctx))
+		r.Get("/api/tokens", h.FindAPITokens(ctx))
+		r.Delete("/api/tokens/{id}", h.DeleteAPIToken(ctx))
+		r.Put("/api/recovery/keys", h.SaveRecoveryKeys(ctx))
+		r.With(h.Notify(ctx)).Post("/api/password", h.ChangePassword(ctx))
+		r.Put("/api/password/files/{id}", h.StageFile(ctx))
+		r.Delete("/api/password/files", h.DiscardStagedFiles(ctx))
+	})
+
+	// данные пользователя доступны в сеансе и по токену API с нужной областью доступа
+	r.Group(func(r chi.Router) {
+		r.Use(h.APIToken(ctx))
+		r.Use(jwtkeys.Verifier(tokenAuth))
+		r.Use(jwtkeys.Authenticator)
+		r.Use(h.Session(ctx))
+		r.Use(h.ClientCertificate(ctx))
+		r.With(h.Scope(model.ScopeLoginsWrite), h.Notify(ctx)).Post("/api/logins", h.SaveLogin(ctx))
+		r.With(h.Scope(model.ScopeLoginsWrite), h.Notify(ctx)).Delete("/api/logins/{id}", h.DeleteLogin(ctx))
+		r.With(h.Scope(model.ScopeLoginsRead)).Get("/api/logins", h.FindLogins(ctx))
+		r.With(h.Scope(model.ScopeCardsWrite), h.Notify(ctx)).Post("/api/cards", h.SaveCard(ctx))
+		r.With(h.Scope(model.ScopeCardsWrite), h.Notify(ctx)).Delete("/api/cards/{id}", h.DeleteCard(ctx))
+		r.With(h.Scope(model.ScopeCardsRead)).Get("/api/cards", h.FindCards(ctx))
+		r.With(h.Scope(model.ScopeNotesWrite), h.Notify(ctx)).Post("/api/notes", h.SaveNote(ctx))
+		r.With(h.Scope(model.ScopeNotesWrite), h.Notify(ctx)).Delete("/api/notes/{id}", h.DeleteNote(ctx))
+		r.With(h.Scope(model.ScopeNotesRead)).Get("/api/notes", h.FindNotes(ctx))
+		r.With(h.Scope(model.ScopeOTPsWrite), h.Notify(ctx)).Post("/api/otps", h.SaveOTP(ctx))
+		r.With(h.Scope(model.ScopeOTPsWrite), h.Notify(ctx)).Delete("/api/otps/{id}", h.DeleteOTP(ctx))
+		r.With(h.Scope(model.ScopeOTPsRead)).Get("/api/otps", h.FindOTPs(ctx))
+		r.With(h.Scope(model.ScopeSSHKeysWrite), h.Notify(ctx)).Post("/api/sshkeys", h.SaveSSHKey(ctx))
+		r.With(h.Scope(model.ScopeSSHKeysWrite), h.Notify(ctx)).Delete("/api/sshkeys/{id}", h.DeleteSSHKey(ctx))
+		r.With(h.Scope(model.ScopeSSHKeysRead)).Get("/api/sshkeys", h.FindSSHKeys(ctx))
+		r.With(h.Scope(model.ScopeItemsWrite), h.Notify(ctx)).Post("/api/items", h.SaveItem(ctx))
+		r.With(h.Scope(model.ScopeItemsWrite), h.Notify(ctx)).Delete("/api/items/{id}", h.DeleteItem(ctx))
+		r.With(h.Scope(model.ScopeItemsRead)).Get("/api/items", h.FindItems(ctx))
+		r.With(h.Scope(model.ScopeHistoryRead)).Get("/api/revisions/{id}", h.FindRevisions(ctx))
+		r.With(h.Scope(model.ScopeHistoryWrite), h.Notify(ctx)).Post("/api/revisions/{id}/{revision}", h.RestoreRevision(ctx))
+		r.With(h.Scope(model.ScopeTrashRead)).Get("/api/trash", h.FindTrash(ctx))
+		r.With(h.Scope(model.ScopeTrashWrite), h.Notify(ctx)).Post("/api/trash/{id}", h.RestoreTrash(ctx))
+		r.With(h.Scope(model.ScopeSyncRead)).Get("/api/sync", h.Sync(ctx))
+		r.With(h.Scope(model.ScopeSyncRead)).Get("/api/events", h.Events(ctx))
+		r.With(h.Scope(model.ScopeFoldersWrite), h.Notify(ctx)).Post("/api/folders", h.SaveFolder(ctx))
+		r.With(h.Scope(model.ScopeFoldersWrite), h.Notify(ctx)).Delete("/api/folders/{id}", h.DeleteFolder(ctx))
+		r.With(h.Scope(model.ScopeFoldersRead)).Get("/api/folders", h.FindFolders(ctx))
+		r.With(h.Scope(model.ScopeTagsWrite), h.Notify(ctx)).Post("/api/tags", h.SaveTag(ctx))
+		r.With(h.Scope(model.ScopeTagsWrite), h.Notify(ctx)).Delete("/api/tags/{id}", h.DeleteTag(ctx))
+		r.With(h.Scope(model.ScopeTagsRead)).Get("/api/tags", h.FindTags(ctx))
+		r.With(h.Scope(model.ScopeFilesWrite), h.Notify(ctx)).Post("/api/files", h.SaveFile(ctx))
+		r.With(h.Scope(model.ScopeFilesWrite), h.Notify(ctx)).Delete("/api/files/{id}", h.DeleteFile(ctx))
+		r.With(h.Scope(model.ScopeFilesRead)).Get("/api/files", h.FindFiles(ctx))
+		r.With(h.Scope(model.ScopeFilesRead)).Get("/api/files/{id}", h.FindFile(ctx))
+	})
+
+	return r, nil
 }
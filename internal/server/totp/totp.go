@@ -0,0 +1,117 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Параметры одноразовых паролей
+const (
+	Digits = 6
+	Period = 30
+	Skew   = 1
+)
+
+const secretLength = 20
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+var ErrTOTPInvalidSecret = errors.New("invalid totp secret")
+
+// NewSecret возвращает новый случайный секрет в кодировке base32 без выравнивания
+func NewSecret() (string, error) {
+	b := make([]byte, secretLength)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// URI возвращает ссылку otpauth для добавления секрета secret учётной записи account в приложение-аутентификатор
+func URI(issuer, account, secret string) string {
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprint(Digits))
+	values.Set("period", fmt.Sprint(Period))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+
+	return "otpauth://totp/" + label + "?" + values.Encode()
+}
+
+// HOTP возвращает одноразовый пароль RFC 4226 длиной digits для ключа key и счётчика counter
+func HOTP(key []byte, counter uint64, digits int) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+// Step возвращает номер интервала RFC 6238 для момента t
+func Step(t time.Time) uint64 {
+	return uint64(t.Unix()) / Period
+}
+
+// Code возвращает одноразовый пароль для секрета secret в момент t
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return HOTP(key, Step(t), Digits), nil
+}
+
+// Validate проверяет одноразовый пароль code для секрета secret в момент t с допуском Skew интервалов
+// и возвращает номер совпавшего интервала, по которому вызывающая сторона отклоняет повторное использование пароля
+func Validate(secret, code string, t time.Time) (step uint64, ok bool) {
+	key, err := decodeSecret(secret)
+	if err != nil || len(code) != Digits {
+		return 0, false
+	}
+
+	current := Step(t)
+
+	for i := -Skew; i <= Skew; i++ {
+		counter := current + uint64(i)
+		if subtle.ConstantTimeCompare([]byte(HOTP(key, counter, Digits)), []byte(code)) == 1 {
+			step, ok = counter, true
+		}
+	}
+
+	return step, ok
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(key) == 0 {
+		return nil, ErrTOTPInvalidSecret
+	}
+
+	return key, nil
+}
@@ -0,0 +1,147 @@
+package totp_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vukit/gophkeeper/internal/server/totp"
+)
+
+// rfcKey ключ тестовых векторов RFC 4226 и RFC 6238
+var rfcKey = []byte("12345678901234567890")
+
+func TestHOTP(t *testing.T) {
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, code := range want {
+		assert.Equal(t, code, totp.HOTP(rfcKey, uint64(counter), 6))
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	tests := []struct {
+		name string
+		unix int64
+		want string
+	}{
+		{
+			name: "case 1",
+			unix: 59,
+			want: "94287082",
+		},
+		{
+			name: "case 2",
+			unix: 1111111109,
+			want: "07081804",
+		},
+		{
+			name: "case 3",
+			unix: 1111111111,
+			want: "14050471",
+		},
+		{
+			name: "case 4",
+			unix: 1234567890,
+			want: "89005924",
+		},
+		{
+			name: "case 5",
+			unix: 2000000000,
+			want: "69279037",
+		},
+		{
+			name: "case 6",
+			unix: 20000000000,
+			want: "65353130",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, totp.HOTP(rfcKey, totp.Step(time.Unix(tt.unix, 0)), 8))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := totp.NewSecret()
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+
+	code, err := totp.Code(secret, now)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		at       time.Time
+		wantStep uint64
+		wantOK   bool
+	}{
+		{
+			name:     "case 1",
+			secret:   secret,
+			code:     code,
+			at:       now,
+			wantStep: totp.Step(now),
+			wantOK:   true,
+		},
+		{
+			name:     "case 2",
+			secret:   strings.ToLower(secret),
+			code:     code,
+			at:       now.Add(totp.Period * time.Second),
+			wantStep: totp.Step(now),
+			wantOK:   true,
+		},
+		{
+			name:     "case 3",
+			secret:   secret,
+			code:     code,
+			at:       now.Add(3 * totp.Period * time.Second),
+			wantStep: 0,
+			wantOK:   false,
+		},
+		{
+			name:     "case 4",
+			secret:   secret,
+			code:     "12345",
+			at:       now,
+			wantStep: 0,
+			wantOK:   false,
+		},
+		{
+			name:     "case 5",
+			secret:   "not base32!",
+			code:     code,
+			at:       now,
+			wantStep: 0,
+			wantOK:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := totp.Validate(tt.secret, tt.code, tt.at)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantStep, step)
+		})
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("GophKeeper", "john doe", "JBSWY3DPEHPK3PXP")
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/GophKeeper:john%20doe?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=GophKeeper")
+	assert.Contains(t, uri, "digits=6")
+}